@@ -20,11 +20,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/big"
 	"reflect"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 )
 
 // The ABI holds information about a contract's context and available
@@ -77,117 +77,96 @@ func (abi ABI) Pack(name string, args ...interface{}) ([]byte, error) {
 	return append(method.Id(), arguments...), nil
 }
 
-// toGoSliceType prses the input and casts it to the proper slice defined by the ABI
-// argument in T.
-func toGoSlice(i int, t Argument, output []byte) (interface{}, error) {
-	index := i * 32
-	// The slice must, at very least be large enough for the index+32 which is exactly the size required
-	// for the [offset in output, size of offset].
-	if index+32 > len(output) {
-		return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), index+32)
-	}
-	elem := t.Type.Elem
-
-	// first we need to create a slice of the type
-	var refSlice reflect.Value
-	switch elem.T {
-	case IntTy, UintTy, BoolTy: // int, uint, bool can all be of type big int.
-		refSlice = reflect.ValueOf([]*big.Int(nil))
-	case AddressTy: // address must be of slice Address
-		refSlice = reflect.ValueOf([]common.Address(nil))
-	case HashTy: // hash must be of slice hash
-		refSlice = reflect.ValueOf([]common.Hash(nil))
-	case FixedBytesTy:
-		refSlice = reflect.ValueOf([]byte(nil))
-	default: // no other types are supported
-		return nil, fmt.Errorf("abi: unsupported slice type %v", elem.T)
-	}
-	// get the offset which determines the start of this array ...
-	offset := int(common.BytesToBig(output[index : index+32]).Uint64())
-	if offset+32 > len(output) {
-		return nil, fmt.Errorf("abi: cannot marshal in to go slice: offset %d would go over slice boundary (len=%d)", len(output), offset+32)
+// readOffset reads the offset stored at index in the given tuple output and
+// makes sure that at least the 32 bytes following it are within bounds.
+func readOffset(output []byte, index int) (int, error) {
+	offset := common.BytesToBig(output[index : index+32])
+	if offset.BitLen() > 31 || int(offset.Int64()) > len(output)-32 {
+		return 0, fmt.Errorf("abi: cannot marshal in to go type: offset %v would go over slice boundary (len=%d)", offset, len(output))
 	}
+	return int(offset.Int64()), nil
+}
 
-	slice := output[offset:]
-	// ... starting with the size of the array in elements ...
-	size := int(common.BytesToBig(slice[:32]).Uint64())
-	slice = slice[32:]
-	// ... and make sure that we've at the very least the amount of bytes
-	// available in the buffer.
-	if size*32 > len(slice) {
-		return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), offset+32+size*32)
+// readLength reads the length prefix of dynamic data and makes sure that the
+// given number of bytes per element are available after it.
+func readLength(data []byte, elemSize int) (int, error) {
+	size := common.BytesToBig(data[:32])
+	if size.BitLen() > 31 || int(size.Int64())*elemSize > len(data)-32 {
+		return 0, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d for %v elements of %d bytes", len(data)-32, size, elemSize)
 	}
+	return int(size.Int64()), nil
+}
 
-	// reslice to match the required size
-	slice = slice[:(size * 32)]
+// toGoSlice unpacks size consecutively packed elements of the array type t
+// from the given output into a Go slice.
+func toGoSlice(t Type, output []byte, size int) (interface{}, error) {
+	elem := t.Elem
+	if size*elem.headSize() > len(output) {
+		return nil, fmt.Errorf("abi: cannot marshal in to go slice: insufficient size output %d require %d", len(output), size*elem.headSize())
+	}
+	refSlice := reflect.MakeSlice(t.goType(), 0, size)
 	for i := 0; i < size; i++ {
-		var (
-			inter        interface{}             // interface type
-			returnOutput = slice[i*32 : i*32+32] // the return output
-		)
-
-		// set inter to the correct type (cast)
-		switch elem.T {
-		case IntTy, UintTy:
-			inter = common.BytesToBig(returnOutput)
-		case BoolTy:
-			inter = common.BytesToBig(returnOutput).Uint64() > 0
-		case AddressTy:
-			inter = common.BytesToAddress(returnOutput)
-		case HashTy:
-			inter = common.BytesToHash(returnOutput)
+		inter, err := toGoType(i*elem.headSize(), *elem, output)
+		if err != nil {
+			return nil, err
 		}
 		// append the item to our reflect slice
 		refSlice = reflect.Append(refSlice, reflect.ValueOf(inter))
 	}
-
 	// return the interface
 	return refSlice.Interface(), nil
 }
 
-// toGoType parses the input and casts it to the proper type defined by the ABI
-// argument in T.
-func toGoType(i int, t Argument, output []byte) (interface{}, error) {
-	// we need to treat slices differently
-	if (t.Type.IsSlice || t.Type.IsArray) && t.Type.T != BytesTy && t.Type.T != StringTy && t.Type.T != FixedBytesTy {
-		return toGoSlice(i, t, output)
-	}
-
-	index := i * 32
+// toGoType parses the value found at the given head index of a packed tuple and
+// casts it to the proper type defined by the ABI type t.
+func toGoType(index int, t Type, output []byte) (interface{}, error) {
 	if index+32 > len(output) {
 		return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), index+32)
 	}
+	// Dynamic types store an offset to their data, relative to the tuple
+	var data []byte
+	if isDynamicType(t) {
+		offset, err := readOffset(output, index)
+		if err != nil {
+			return nil, err
+		}
+		data = output[offset:]
+	}
 
-	// Parse the given index output and check whether we need to read
-	// a different offset and length based on the type (i.e. string, bytes)
-	var returnOutput []byte
-	switch t.Type.T {
+	switch t.T {
+	case SliceTy:
+		switch {
+		case t.IsSlice:
+			size, err := readLength(data, t.Elem.headSize())
+			if err != nil {
+				return nil, err
+			}
+			return toGoSlice(t, data[32:], size)
+		case isDynamicType(t):
+			return toGoSlice(t, data, t.SliceSize)
+		default:
+			return toGoSlice(t, output[index:], t.SliceSize)
+		}
 	case StringTy, BytesTy: // variable arrays are written at the end of the return bytes
-		// parse offset from which we should start reading
-		offset := int(common.BytesToBig(output[index : index+32]).Uint64())
-		if offset+32 > len(output) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32)
+		size, err := readLength(data, 1)
+		if err != nil {
+			return nil, err
 		}
-		// parse the size up until we should be reading
-		size := int(common.BytesToBig(output[offset : offset+32]).Uint64())
-		if offset+32+size > len(output) {
-			return nil, fmt.Errorf("abi: cannot marshal in to go type: length insufficient %d require %d", len(output), offset+32+size)
+		if t.T == StringTy {
+			return string(data[32 : 32+size]), nil
 		}
-
-		// get the bytes for this return value
-		returnOutput = output[offset+32 : offset+32+size]
-	default:
-		returnOutput = output[index : index+32]
+		return data[32 : 32+size], nil
 	}
+	returnOutput := output[index : index+32]
 
 	// convert the bytes to whatever is specified by the ABI.
-	switch t.Type.T {
+	switch t.T {
 	case IntTy, UintTy:
 		bigNum := common.BytesToBig(returnOutput)
 
 		// If the type is a integer convert to the integer type
 		// specified by the ABI.
-		switch t.Type.Kind {
+		switch t.Kind {
 		case reflect.Uint8:
 			return uint8(bigNum.Uint64()), nil
 		case reflect.Uint16:
@@ -207,18 +186,36 @@ func toGoType(i int, t Argument, output []byte) (interface{}, error) {
 		case reflect.Ptr:
 			return bigNum, nil
 		}
+	case FixedPointTy:
+		return common.S256(common.BytesToBig(returnOutput)), nil
+	case UfixedPointTy:
+		return common.BytesToBig(returnOutput), nil
 	case BoolTy:
 		return common.BytesToBig(returnOutput).Uint64() > 0, nil
 	case AddressTy:
 		return common.BytesToAddress(returnOutput), nil
 	case HashTy:
 		return common.BytesToHash(returnOutput), nil
-	case BytesTy, FixedBytesTy:
+	case FixedBytesTy:
 		return returnOutput, nil
-	case StringTy:
-		return string(returnOutput), nil
 	}
-	return nil, fmt.Errorf("abi: unknown type %v", t.Type.T)
+	return nil, fmt.Errorf("abi: unknown type %v", t.T)
+}
+
+// unpackTuple unpacks the values of the given arguments from the packed tuple.
+func unpackTuple(args []Argument, output []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(args))
+
+	index := 0
+	for i, arg := range args {
+		value, err := toGoType(index, arg.Type, output)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+		index += arg.Type.headSize()
+	}
+	return values, nil
 }
 
 // these variable are used to determine certain types during type assertion for
@@ -237,27 +234,53 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
 	if len(output) == 0 {
 		return fmt.Errorf("abi: unmarshalling empty output")
 	}
+	values, err := unpackTuple(method.Outputs, output)
+	if err != nil {
+		return err
+	}
+	return assign(v, method.Outputs, values)
+}
 
+// UnpackLog unpacks the topics and data of a log emitted by the named event in
+// v according to the abi specification. Indexed arguments are read from the
+// topics; as the EVM only stores the Keccak256 hash of indexed dynamic types,
+// those are unpacked as common.Hash. The remaining arguments are read from the
+// log data.
+func (abi ABI) UnpackLog(v interface{}, name string, log *vm.Log) error {
+	event, exist := abi.Events[name]
+	if !exist {
+		return fmt.Errorf("abi: event '%s' not found", name)
+	}
+	values, err := event.unpack(log)
+	if err != nil {
+		return err
+	}
+	return assign(v, event.Inputs, values)
+}
+
+// assign sets the unpacked values of the given arguments in v. Multiple values
+// can be assigned to a struct, matching the argument names to the field names,
+// or to a []interface{}.
+func assign(v interface{}, args []Argument, values []interface{}) error {
 	value := reflect.ValueOf(v).Elem()
 	typ := value.Type()
 
-	if len(method.Outputs) > 1 {
+	if len(args) > 1 {
 		switch value.Kind() {
 		// struct will match named return values to the struct's field
 		// names
 		case reflect.Struct:
-			for i := 0; i < len(method.Outputs); i++ {
-				marshalledValue, err := toGoType(i, method.Outputs[i], output)
-				if err != nil {
-					return err
+			for i, arg := range args {
+				if arg.Name == "" {
+					continue
 				}
-				reflectValue := reflect.ValueOf(marshalledValue)
+				reflectValue := reflect.ValueOf(values[i])
 
 				for j := 0; j < typ.NumField(); j++ {
 					field := typ.Field(j)
 					// TODO read tags: `abi:"fieldName"`
-					if field.Name == strings.ToUpper(method.Outputs[i].Name[:1])+method.Outputs[i].Name[1:] {
-						if err := set(value.Field(j), reflectValue, method.Outputs[i]); err != nil {
+					if field.Name == strings.ToUpper(arg.Name[:1])+arg.Name[1:] {
+						if err := set(value.Field(j), reflectValue, arg); err != nil {
 							return err
 						}
 					}
@@ -270,25 +293,17 @@ func (abi ABI) Unpack(v interface{}, name string, output []byte) error {
 
 			// create a new slice and start appending the unmarshalled
 			// values to the new interface slice.
-			z := reflect.MakeSlice(typ, 0, len(method.Outputs))
-			for i := 0; i < len(method.Outputs); i++ {
-				marshalledValue, err := toGoType(i, method.Outputs[i], output)
-				if err != nil {
-					return err
-				}
-				z = reflect.Append(z, reflect.ValueOf(marshalledValue))
+			z := reflect.MakeSlice(typ, 0, len(args))
+			for _, value := range values {
+				z = reflect.Append(z, reflect.ValueOf(value))
 			}
 			value.Set(z)
 		default:
 			return fmt.Errorf("abi: cannot unmarshal tuple in to %v", typ)
 		}
 
-	} else {
-		marshalledValue, err := toGoType(0, method.Outputs[0], output)
-		if err != nil {
-			return err
-		}
-		if err := set(value, reflect.ValueOf(marshalledValue), method.Outputs[0]); err != nil {
+	} else if len(args) == 1 {
+		if err := set(value, reflect.ValueOf(values[0]), args[0]); err != nil {
 			return err
 		}
 	}
@@ -326,12 +341,12 @@ func set(dst, src reflect.Value, output Argument) error {
 
 func (abi *ABI) UnmarshalJSON(data []byte) error {
 	var fields []struct {
-		Type     string
-		Name     string
-		Constant bool
-		Indexed  bool
-		Inputs   []Argument
-		Outputs  []Argument
+		Type      string
+		Name      string
+		Constant  bool
+		Anonymous bool
+		Inputs    []Argument
+		Outputs   []Argument
 	}
 
 	if err := json.Unmarshal(data, &fields); err != nil {
@@ -356,8 +371,9 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 			}
 		case "event":
 			abi.Events[field.Name] = Event{
-				Name:   field.Name,
-				Inputs: field.Inputs,
+				Name:      field.Name,
+				Anonymous: field.Anonymous,
+				Inputs:    field.Inputs,
 			}
 		}
 	}
@@ -18,9 +18,12 @@ package abi
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"math/big"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -325,9 +328,8 @@ func TestMethodPack(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	// static arrays are packed in place, without offset and length
 	sig := abi.Methods["slice"].Id()
-	sig = append(sig, common.LeftPadBytes([]byte{32}, 32)...)
-	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{1}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 
@@ -374,9 +376,8 @@ func TestMethodPack(t *testing.T) {
 		t.Errorf("expected %x got %x", sig, packed)
 	}
 
+	// static arrays are packed in place, without offset and length
 	sig = abi.Methods["slice256"].Id()
-	sig = append(sig, common.LeftPadBytes([]byte{32}, 32)...)
-	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{1}, 32)...)
 	sig = append(sig, common.LeftPadBytes([]byte{2}, 32)...)
 
@@ -1119,3 +1120,234 @@ func TestUnmarshal(t *testing.T) {
 		t.Fatal("expected error:", err)
 	}
 }
+
+// abiTestValue converts a json decoded argument of the ABITests vectors into the
+// Go value expected by the given abi type.
+func abiTestValue(typ Type, arg interface{}) interface{} {
+	switch typ.T {
+	case SliceTy:
+		args := arg.([]interface{})
+		value := reflect.MakeSlice(typ.goType(), len(args), len(args))
+		for i, elem := range args {
+			value.Index(i).Set(reflect.ValueOf(abiTestValue(*typ.Elem, elem)))
+		}
+		return value.Interface()
+	case IntTy, UintTy:
+		n := int64(arg.(float64))
+		if typ.Kind == reflect.Ptr {
+			return big.NewInt(n)
+		}
+		return reflect.ValueOf(n).Convert(typ.goType()).Interface()
+	case AddressTy:
+		return common.HexToAddress(arg.(string))
+	case FixedBytesTy:
+		value := reflect.New(reflect.ArrayOf(typ.SliceSize, byte_t)).Elem()
+		reflect.Copy(value, reflect.ValueOf([]byte(arg.(string))))
+		return value.Interface()
+	case BytesTy:
+		return []byte(arg.(string))
+	}
+	return arg
+}
+
+// Tests that the packing and unpacking of values conforms to the json test
+// vectors of the ABITests suite.
+func TestABITests(t *testing.T) {
+	blob, err := ioutil.ReadFile(filepath.Join("..", "..", "tests", "files", "ABITests", "basic_abi_tests.json"))
+	if err != nil {
+		t.Fatalf("failed to read test vectors: %v", err)
+	}
+	var tests map[string]struct {
+		Args   []interface{}
+		Result string
+		Types  []string
+	}
+	if err := json.Unmarshal(blob, &tests); err != nil {
+		t.Fatalf("failed to parse test vectors: %v", err)
+	}
+	for name, test := range tests {
+		method := Method{Name: name}
+		args := make([]interface{}, len(test.Types))
+		for i, kind := range test.Types {
+			typ, err := NewType(kind)
+			if err != nil {
+				t.Fatalf("%s: failed to parse type %s: %v", name, kind, err)
+			}
+			method.Inputs = append(method.Inputs, Argument{Type: typ})
+			args[i] = abiTestValue(typ, test.Args[i])
+		}
+		packed, err := method.pack(method, args...)
+		if err != nil {
+			t.Errorf("%s: failed to pack: %v", name, err)
+			continue
+		}
+		if result := common.Hex2Bytes(test.Result); !bytes.Equal(packed, result) {
+			t.Errorf("%s: packed mismatch:\nhave %x\nwant %x", name, packed, result)
+		}
+		values, err := unpackTuple(method.Inputs, packed)
+		if err != nil {
+			t.Errorf("%s: failed to unpack: %v", name, err)
+			continue
+		}
+		for i, value := range values {
+			want := args[i]
+			if method.Inputs[i].Type.T == FixedBytesTy {
+				// fixed size bytes are unpacked into their full word
+				arr := reflect.ValueOf(want)
+				want = common.RightPadBytes(mustArrayToByteSlice(arr).Bytes(), 32)
+			}
+			if !reflect.DeepEqual(value, want) {
+				t.Errorf("%s: arg %d unpack mismatch: have %v, want %v", name, i, value, want)
+			}
+		}
+	}
+}
+
+// Tests that nested and dynamic arrays are packed according to the abi spec and
+// can be unpacked again.
+func TestNestedArrays(t *testing.T) {
+	const definition = `[
+	{ "name" : "g", "inputs": [ { "name": "a", "type": "uint[][]" }, { "name": "b", "type": "string[]" } ], "outputs": [ { "name": "a", "type": "uint[][]" }, { "name": "b", "type": "string[]" } ] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		ints    = [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}}
+		strs    = []string{"one", "two", "three"}
+		encoded = common.Hex2Bytes("" +
+			"0000000000000000000000000000000000000000000000000000000000000040" + // offset of [[1, 2], [3]]
+			"0000000000000000000000000000000000000000000000000000000000000140" + // offset of ["one", "two", "three"]
+			"0000000000000000000000000000000000000000000000000000000000000002" + // count of [[1, 2], [3]]
+			"0000000000000000000000000000000000000000000000000000000000000040" + // offset of [1, 2]
+			"00000000000000000000000000000000000000000000000000000000000000a0" + // offset of [3]
+			"0000000000000000000000000000000000000000000000000000000000000002" + // count of [1, 2]
+			"0000000000000000000000000000000000000000000000000000000000000001" + // 1
+			"0000000000000000000000000000000000000000000000000000000000000002" + // 2
+			"0000000000000000000000000000000000000000000000000000000000000001" + // count of [3]
+			"0000000000000000000000000000000000000000000000000000000000000003" + // 3
+			"0000000000000000000000000000000000000000000000000000000000000003" + // count of ["one", "two", "three"]
+			"0000000000000000000000000000000000000000000000000000000000000060" + // offset of "one"
+			"00000000000000000000000000000000000000000000000000000000000000a0" + // offset of "two"
+			"00000000000000000000000000000000000000000000000000000000000000e0" + // offset of "three"
+			"0000000000000000000000000000000000000000000000000000000000000003" + // length of "one"
+			"6f6e650000000000000000000000000000000000000000000000000000000000" + // "one"
+			"0000000000000000000000000000000000000000000000000000000000000003" + // length of "two"
+			"74776f0000000000000000000000000000000000000000000000000000000000" + // "two"
+			"0000000000000000000000000000000000000000000000000000000000000005" + // length of "three"
+			"7468726565000000000000000000000000000000000000000000000000000000") // "three"
+	)
+	packed, err := abi.Pack("g", ints, strs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed[4:], encoded) {
+		t.Errorf("packed mismatch:\nhave %x\nwant %x", packed[4:], encoded)
+	}
+	var out struct {
+		A [][]*big.Int
+		B []string
+	}
+	if err := abi.Unpack(&out, "g", encoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out.A, ints) || !reflect.DeepEqual(out.B, strs) {
+		t.Errorf("unpack mismatch: have %v %v, want %v %v", out.A, out.B, ints, strs)
+	}
+}
+
+// Tests that arrays of any type survive a pack/unpack round trip.
+func TestArrayRoundTrip(t *testing.T) {
+	for i, test := range []struct {
+		typ   string
+		input interface{}
+	}{
+		{"uint32[]", []uint32{1, 2, 3}},
+		{"uint32[]", []uint32{}},
+		{"bool[2]", []bool{true, false}},
+		{"bytes[]", [][]byte{[]byte("hello"), bytes.Repeat([]byte{1}, 33), []byte{}}},
+		{"string[2]", []string{"hello", "world"}},
+		{"uint8[2][]", [][]uint8{{1, 2}, {3, 4}, {5, 6}}},
+		{"uint8[][2]", [][]uint8{{1}, {2, 3}}},
+		{"address[][]", [][]common.Address{{common.Address{1}}, {}, {common.Address{2}, common.Address{3}}}},
+		{"string[][2][]", [][][]string{{{"a"}, {"b", "c"}}, {{}, {"d"}}}},
+	} {
+		typ, err := NewType(test.typ)
+		if err != nil {
+			t.Fatalf("%d: unexpected parse error: %v", i, err)
+		}
+		if typ.String() != test.typ {
+			t.Errorf("%d: type string mismatch: have %s, want %s", i, typ, test.typ)
+		}
+		args := []Argument{{Type: typ}}
+		packed, err := packTuple([]Type{typ}, []reflect.Value{reflect.ValueOf(test.input)})
+		if err != nil {
+			t.Errorf("%d: pack failed: %v", i, err)
+			continue
+		}
+		if isDynamicType(typ) != (len(packed) > typ.headSize()) || !isDynamicType(typ) && len(packed) != typ.headSize() {
+			t.Errorf("%d: packed size %d inconsistent with head size %d", i, len(packed), typ.headSize())
+		}
+		values, err := unpackTuple(args, packed)
+		if err != nil {
+			t.Errorf("%d: unpack failed: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(values[0], test.input) {
+			t.Errorf("%d: round trip mismatch: have %v, want %v", i, values[0], test.input)
+		}
+	}
+}
+
+// Tests parsing, packing and unpacking of fixed point types.
+func TestFixedPoint(t *testing.T) {
+	for i, test := range []struct {
+		typ    string
+		str    string
+		size   int
+		fail   bool
+		signed bool
+	}{
+		{typ: "fixed", str: "fixed128x128", size: 256, signed: true},
+		{typ: "ufixed", str: "ufixed128x128", size: 256},
+		{typ: "fixed64x8", str: "fixed64x8", size: 72, signed: true},
+		{typ: "ufixed0x8", str: "ufixed0x8", size: 8},
+		{typ: "fixed7x8", fail: true},
+		{typ: "fixed128x136", fail: true},
+		{typ: "ufixed0x0", fail: true},
+	} {
+		typ, err := NewType(test.typ)
+		if test.fail {
+			if err == nil {
+				t.Errorf("%d: expected parse error for %s", i, test.typ)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: unexpected parse error: %v", i, err)
+		}
+		if typ.String() != test.str || typ.Size != test.size {
+			t.Errorf("%d: type mismatch: have %s/%d, want %s/%d", i, typ, typ.Size, test.str, test.size)
+		}
+		// 1.5 scaled by 2**8, negated for signed types
+		value := big.NewInt(384)
+		if test.signed {
+			value.Neg(value)
+		}
+		packed, err := typ.pack(reflect.ValueOf(value))
+		if err != nil {
+			t.Fatalf("%d: pack failed: %v", i, err)
+		}
+		if test.signed && packed[0] != 0xff {
+			t.Errorf("%d: negative value not packed in two's complement: %x", i, packed)
+		}
+		out, err := toGoType(0, typ, packed)
+		if err != nil {
+			t.Fatalf("%d: unpack failed: %v", i, err)
+		}
+		if out.(*big.Int).Cmp(value) != 0 {
+			t.Errorf("%d: round trip mismatch: have %v, want %v", i, out, value)
+		}
+	}
+}
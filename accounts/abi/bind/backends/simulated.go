@@ -78,6 +78,23 @@ func (b *SimulatedBackend) Rollback() {
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.database)
 }
 
+// CodeAt returns the code of the contract at the given address in the pending
+// state.
+func (b *SimulatedBackend) CodeAt(contract common.Address) []byte {
+	return b.pendingState.GetCode(contract)
+}
+
+// BalanceAt returns the balance of the account in the pending state.
+func (b *SimulatedBackend) BalanceAt(account common.Address) *big.Int {
+	return new(big.Int).Set(b.pendingState.GetBalance(account))
+}
+
+// TransactionReceipt returns the receipt of a committed transaction, or nil if
+// the transaction is unknown or still pending.
+func (b *SimulatedBackend) TransactionReceipt(txhash common.Hash) *types.Receipt {
+	return core.GetReceipt(b.database, txhash)
+}
+
 // ContractCall implements ContractCaller.ContractCall, executing the specified
 // contract with the given input data.
 func (b *SimulatedBackend) ContractCall(contract common.Address, data []byte, pending bool) ([]byte, error) {
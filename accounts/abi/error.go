@@ -45,7 +45,9 @@ func sliceTypeCheck(t Type, val reflect.Value) error {
 			return sliceTypeCheck(*t.Elem, val.Index(0))
 		}
 	} else if t.Elem.IsArray {
-		return sliceTypeCheck(*t.Elem, val.Index(0))
+		if val.Len() > 0 {
+			return sliceTypeCheck(*t.Elem, val.Index(0))
+		}
 	}
 
 	if elemKind := val.Type().Elem().Kind(); elemKind != t.Elem.Kind {
@@ -21,14 +21,16 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // Event is an event potentially triggered by the EVM's LOG mechanism. The Event
 // holds type information (inputs) about the yielded output
 type Event struct {
-	Name   string
-	Inputs []Argument
+	Name      string
+	Anonymous bool
+	Inputs    []Argument
 }
 
 // Id returns the canonical representation of the event's signature used by the
@@ -42,3 +44,44 @@ func (e Event) Id() common.Hash {
 	}
 	return common.BytesToHash(crypto.Keccak256([]byte(fmt.Sprintf("%v(%v)", e.Name, strings.Join(types, ",")))))
 }
+
+// unpack unpacks the argument values of the event from a log it emitted.
+func (e Event) unpack(log *vm.Log) ([]interface{}, error) {
+	topics := log.Topics
+	if !e.Anonymous {
+		if len(topics) == 0 || topics[0] != e.Id() {
+			return nil, fmt.Errorf("abi: log is not a %s event", e.Name)
+		}
+		topics = topics[1:]
+	}
+	// Split the arguments into the indexed ones stored in the topics and the
+	// remaining ones packed into the log data
+	var nonIndexed []Argument
+	for _, input := range e.Inputs {
+		if !input.Indexed {
+			nonIndexed = append(nonIndexed, input)
+		}
+	}
+	data, err := unpackTuple(nonIndexed, log.Data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(e.Inputs))
+	for i, input := range e.Inputs {
+		if !input.Indexed {
+			values[i], data = data[0], data[1:]
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("abi: insufficient topics for indexed argument %d of %s event", i, e.Name)
+		}
+		// Dynamic types are hashed into the topic, the value itself is lost
+		if isDynamicType(input.Type) || input.Type.headSize() != 32 {
+			values[i] = topics[0]
+		} else if values[i], err = toGoType(0, input.Type, topics[0][:]); err != nil {
+			return nil, err
+		}
+		topics = topics[1:]
+	}
+	return values, nil
+}
@@ -17,10 +17,14 @@
 package abi
 
 import (
+	"bytes"
+	"math/big"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -54,3 +58,81 @@ func TestEventId(t *testing.T) {
 		}
 	}
 }
+
+// Tests that logs are unpacked from both their topics and data, with dynamic
+// indexed arguments yielding their hash.
+func TestUnpackLog(t *testing.T) {
+	const definition = `[
+	{ "type" : "event", "name" : "received", "inputs": [
+		{ "name" : "sender", "type": "address", "indexed": true },
+		{ "name" : "memo", "type": "string", "indexed": true },
+		{ "name" : "amount", "type": "uint256" },
+		{ "name" : "ids", "type": "uint8[]" },
+		{ "name" : "ok", "type": "bool", "indexed": true }
+	] },
+	{ "type" : "event", "name" : "anon", "anonymous": true, "inputs": [
+		{ "name" : "value", "type": "uint32", "indexed": true }
+	] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := abi.Events["received"]
+	data, err := packTuple([]Type{event.Inputs[2].Type, event.Inputs[3].Type}, []reflect.Value{reflect.ValueOf(big.NewInt(1000)), reflect.ValueOf([]uint8{1, 2})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := common.HexToAddress("0x1234")
+	log := &vm.Log{
+		Topics: []common.Hash{
+			event.Id(),
+			common.BytesToHash(sender[:]),
+			crypto.Keccak256Hash([]byte("hello")),
+			common.BigToHash(common.Big1),
+		},
+		Data: data,
+	}
+	var received struct {
+		Sender common.Address
+		Memo   common.Hash
+		Amount *big.Int
+		Ids    []uint8
+		Ok     bool
+	}
+	if err := abi.UnpackLog(&received, "received", log); err != nil {
+		t.Fatal(err)
+	}
+	if received.Sender != sender {
+		t.Errorf("sender mismatch: have %x, want %x", received.Sender, sender)
+	}
+	if received.Memo != crypto.Keccak256Hash([]byte("hello")) {
+		t.Errorf("memo hash mismatch: have %x", received.Memo)
+	}
+	if received.Amount == nil || received.Amount.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("amount mismatch: have %v, want 1000", received.Amount)
+	}
+	if !bytes.Equal(received.Ids, []uint8{1, 2}) {
+		t.Errorf("ids mismatch: have %v, want [1 2]", received.Ids)
+	}
+	if !received.Ok {
+		t.Errorf("ok mismatch: have false, want true")
+	}
+	// Logs of other events and truncated logs must be rejected
+	other := &vm.Log{Topics: []common.Hash{common.HexToHash("0x01")}, Data: data}
+	if err := abi.UnpackLog(&received, "received", other); err == nil {
+		t.Errorf("expected error for log of a different event")
+	}
+	truncated := &vm.Log{Topics: log.Topics[:2], Data: data}
+	if err := abi.UnpackLog(&received, "received", truncated); err == nil {
+		t.Errorf("expected error for log with missing topics")
+	}
+	// Anonymous events carry no signature topic
+	var value uint32
+	if err := abi.UnpackLog(&value, "anon", &vm.Log{Topics: []common.Hash{common.BigToHash(big.NewInt(7))}}); err != nil {
+		t.Fatal(err)
+	}
+	if value != 7 {
+		t.Errorf("anonymous value mismatch: have %d, want 7", value)
+	}
+}
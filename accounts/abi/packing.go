@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"math/big"
 	"reflect"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -29,12 +30,39 @@ func packBytesSlice(bytes []byte, l int) []byte {
 	return append(len, common.RightPadBytes(bytes, (l+31)/32*32)...)
 }
 
+// packTuple packs the given values according to the abi specification of a
+// tuple with the given types: static values are packed in place into the head,
+// dynamic values are appended to the tail and referenced by their offset.
+func packTuple(types []Type, values []reflect.Value) ([]byte, error) {
+	var headSize int
+	for _, t := range types {
+		headSize += t.headSize()
+	}
+	var head, tail []byte
+	for i, t := range types {
+		packed, err := t.pack(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if isDynamicType(t) {
+			head = append(head, packNum(reflect.ValueOf(headSize+len(tail)), UintTy)...)
+			tail = append(tail, packed...)
+		} else {
+			head = append(head, packed...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
 // packElement packs the given reflect value according to the abi specification in
 // t.
 func packElement(t Type, reflectValue reflect.Value) []byte {
 	switch t.T {
 	case IntTy, UintTy:
 		return packNum(reflectValue, t.T)
+	case FixedPointTy, UfixedPointTy:
+		// two's complement of the scaled value, common.U256 modifies in place
+		return U256(new(big.Int).Set(reflectValue.Interface().(*big.Int)))
 	case StringTy:
 		return packBytesSlice([]byte(reflectValue.String()), reflectValue.Len())
 	case AddressTy:
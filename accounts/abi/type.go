@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -34,6 +35,8 @@ const (
 	BytesTy
 	HashTy
 	RealTy
+	FixedPointTy
+	UfixedPointTy
 )
 
 // Type is the reflection of the supported argument type
@@ -52,49 +55,62 @@ type Type struct {
 }
 
 var (
-	// fullTypeRegex parses the abi types
+	// typeRegex parses the abi sub types
 	//
 	// Types can be in the format of:
 	//
-	// 	Input  = Type [ "[" [ Number ] "]" ] Name .
+	// 	Input  = Type { "[" [ Number ] "]" } Name .
 	// 	Type   = [ "u" ] "int" [ Number ] .
 	//
 	// Examples:
 	//
-	//      string     int       uint       real
-	//      string32   int8      uint8      uint[]
-	//      address    int256    uint256    real[2]
-	fullTypeRegex = regexp.MustCompile("([a-zA-Z0-9]+)(\\[([0-9]*)?\\])?")
-	// typeRegex parses the abi sub types
+	//      string     int       uint       fixed128x128
+	//      string[]   int8      uint8      uint[]
+	//      address    int256    uint256    bytes[2][]
 	typeRegex = regexp.MustCompile("([a-zA-Z]+)([0-9]*)?")
+	// arrayRegex parses the outermost dimension of an array type
+	arrayRegex = regexp.MustCompile("^\\[([0-9]*)\\]$")
+	// fixedRegex parses the bit sizes of a fixed point type
+	fixedRegex = regexp.MustCompile("^(u?fixed)([0-9]+)x([0-9]+)$")
 )
 
 // NewType creates a new reflection type of abi type given in t.
 func NewType(t string) (typ Type, err error) {
-	res := fullTypeRegex.FindAllStringSubmatch(t, -1)[0]
-	// check if type is slice and parse type.
-	switch {
-	case res[3] != "":
-		// err is ignored. Already checked for number through the regexp
-		typ.SliceSize, _ = strconv.Atoi(res[3])
-		typ.IsArray = true
-	case res[2] != "":
-		typ.IsSlice, typ.SliceSize = true, -1
-	case res[0] == "":
+	if strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
 	}
-	if typ.IsArray || typ.IsSlice {
-		sliceType, err := NewType(res[1])
+	// Arrays are parsed from their outermost dimension inwards, so that
+	// uint[2][] is a slice of uint[2] elements.
+	if i := strings.LastIndex(t, "["); i >= 0 {
+		res := arrayRegex.FindStringSubmatch(t[i:])
+		if res == nil {
+			return Type{}, fmt.Errorf("abi: type parse error: %s", t)
+		}
+		if res[1] == "" {
+			typ.IsSlice, typ.SliceSize = true, -1
+		} else {
+			// err is ignored. Already checked for number through the regexp
+			typ.SliceSize, _ = strconv.Atoi(res[1])
+			typ.IsArray = true
+		}
+		elemType, err := NewType(t[:i])
 		if err != nil {
 			return Type{}, err
 		}
-		typ.Elem = &sliceType
-		typ.stringKind = sliceType.stringKind + t[len(res[1]):]
+		typ.Elem = &elemType
+		typ.T = SliceTy
+		typ.stringKind = elemType.stringKind + t[i:]
 		return typ, nil
 	}
-
+	if t == "fixed" || t == "ufixed" || fixedRegex.MatchString(t) {
+		return newFixedPointType(t)
+	}
 	// parse the type and size of the abi-type.
-	parsedType := typeRegex.FindAllStringSubmatch(res[1], -1)[0]
+	parsed := typeRegex.FindAllStringSubmatch(t, -1)
+	if len(parsed) == 0 {
+		return Type{}, fmt.Errorf("abi: type parse error: %s", t)
+	}
+	parsedType := parsed[0]
 	// varSize is the size of the variable
 	var varSize int
 	if len(parsedType[2]) > 0 {
@@ -155,11 +171,41 @@ func NewType(t string) (typ Type, err error) {
 	return
 }
 
+// newFixedPointType creates the reflection type of a fixed<M>x<N> or ufixed<M>x<N>
+// abi type, M being the number of integral and N the number of fractional bits.
+// Fixed point values are represented by the *big.Int X * 2**N.
+func newFixedPointType(t string) (typ Type, err error) {
+	switch t {
+	case "fixed", "ufixed":
+		t += "128x128"
+	}
+	res := fixedRegex.FindStringSubmatch(t)
+	// errs are ignored. Already checked for numbers through the regexp
+	integral, _ := strconv.Atoi(res[2])
+	fractional, _ := strconv.Atoi(res[3])
+	if integral%8 != 0 || fractional%8 != 0 || integral+fractional == 0 || integral+fractional > 256 {
+		return Type{}, fmt.Errorf("abi: invalid fixed point type: %s", t)
+	}
+	typ.Kind = reflect.Ptr
+	typ.Type = big_t
+	typ.Size = integral + fractional
+	typ.stringKind = t
+	if res[1] == "fixed" {
+		typ.T = FixedPointTy
+	} else {
+		typ.T = UfixedPointTy
+	}
+	return typ, nil
+}
+
 // String implements Stringer
 func (t Type) String() (out string) {
 	return t.stringKind
 }
 
+// pack packs the given value according to the abi specification in t. Dynamic
+// types are packed into their tail data; placing them behind an offset is up to
+// the enclosing tuple.
 func (t Type) pack(v reflect.Value) ([]byte, error) {
 	// dereference pointer first if it's a pointer
 	v = indirect(v)
@@ -168,23 +214,82 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if (t.IsSlice || t.IsArray) && t.T != BytesTy && t.T != FixedBytesTy {
-		var packed []byte
-		for i := 0; i < v.Len(); i++ {
-			val, err := t.Elem.pack(v.Index(i))
-			if err != nil {
-				return nil, err
-			}
-			packed = append(packed, val...)
+	if t.T == SliceTy {
+		types := make([]Type, v.Len())
+		values := make([]reflect.Value, v.Len())
+		for i := range types {
+			types[i], values[i] = *t.Elem, v.Index(i)
 		}
-		return packBytesSlice(packed, v.Len()), nil
+		packed, err := packTuple(types, values)
+		if err != nil {
+			return nil, err
+		}
+		if t.IsSlice {
+			return append(packNum(reflect.ValueOf(v.Len()), UintTy), packed...), nil
+		}
+		return packed, nil
 	}
 
 	return packElement(t, v), nil
 }
 
-// requireLengthPrefix returns whether the type requires any sort of length
-// prefixing.
-func (t Type) requiresLengthPrefix() bool {
-	return t.T != FixedBytesTy && (t.T == StringTy || t.T == BytesTy || t.IsSlice || t.IsArray)
+// isDynamicType returns whether the type is dynamically sized, i.e. whether
+// its data is placed in the tail of a tuple behind an offset.
+func isDynamicType(t Type) bool {
+	switch t.T {
+	case StringTy, BytesTy:
+		return true
+	case SliceTy:
+		return t.IsSlice || isDynamicType(*t.Elem)
+	}
+	return false
+}
+
+// headSize returns the number of bytes the type occupies in the head of a
+// tuple. Static arrays are packed in place, everything else takes up a single
+// word (either the value itself or the offset of its data).
+func (t Type) headSize() int {
+	if t.T == SliceTy && t.IsArray && !isDynamicType(t) {
+		return t.SliceSize * t.Elem.headSize()
+	}
+	return 32
+}
+
+// goType returns the Go type a value of the abi type is unpacked into.
+func (t Type) goType() reflect.Type {
+	switch t.T {
+	case IntTy, UintTy:
+		switch t.Kind {
+		case reflect.Uint8:
+			return uint8_t
+		case reflect.Uint16:
+			return uint16_t
+		case reflect.Uint32:
+			return uint32_t
+		case reflect.Uint64:
+			return uint64_t
+		case reflect.Int8:
+			return int8_t
+		case reflect.Int16:
+			return int16_t
+		case reflect.Int32:
+			return int32_t
+		case reflect.Int64:
+			return int64_t
+		}
+		return reflect.PtrTo(big_t)
+	case FixedPointTy, UfixedPointTy:
+		return reflect.PtrTo(big_t)
+	case BoolTy:
+		return reflect.TypeOf(false)
+	case StringTy:
+		return reflect.TypeOf("")
+	case AddressTy:
+		return address_t
+	case HashTy:
+		return hash_t
+	case SliceTy:
+		return reflect.SliceOf(t.Elem.goType())
+	}
+	return byte_ts
 }
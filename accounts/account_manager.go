@@ -26,14 +26,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/hd"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
 )
 
 var (
@@ -50,7 +54,8 @@ type Account struct {
 	// File contains the key file name.
 	// When Acccount is used as an argument to select a key, File can be left blank to
 	// select just by address or set to the basename or absolute path of a file in the key
-	// directory. Accounts returned by Manager will always contain an absolute path.
+	// directory. Accounts returned by Manager will always contain an absolute path, or
+	// for accounts of an external backend, a location identifying the backend.
 	File string
 }
 
@@ -62,12 +67,24 @@ func (acc *Account) UnmarshalJSON(raw []byte) error {
 	return json.Unmarshal(raw, &acc.Address)
 }
 
-// Manager manages a key storage directory on disk.
+// Manager manages a key storage directory on disk, aggregating the accounts of
+// any additional backends registered with AddBackend.
 type Manager struct {
 	cache    *addrCache
 	keyStore keyStore
 	mu       sync.RWMutex
 	unlocked map[common.Address]*unlocked
+
+	backends   []registeredBackend // Additional account sources, e.g. external signers
+	backendsMu sync.RWMutex        // Protects the backends list
+	mux        event.TypeMux       // Posts the account events of the key directory and backends
+}
+
+// registeredBackend is a backend along with the subscription its account events
+// are forwarded through.
+type registeredBackend struct {
+	Backend
+	sub event.Subscription
 }
 
 type unlocked struct {
@@ -95,6 +112,7 @@ func NewPlaintextManager(keydir string) *Manager {
 func (am *Manager) init(keydir string) {
 	am.unlocked = make(map[common.Address]*unlocked)
 	am.cache = newAddrCache(keydir)
+	am.cache.mux = &am.mux
 	// TODO: In order for this finalizer to work, there must be no references
 	// to am. addrCache doesn't keep a reference but unlocked keys do,
 	// so the finalizer will not trigger until all timed unlocks have expired.
@@ -103,18 +121,93 @@ func (am *Manager) init(keydir string) {
 	})
 }
 
+// AddBackend registers an additional source of accounts with the manager. Its
+// accounts are listed alongside the keys in the key directory and requests to
+// sign with them are forwarded to the backend.
+func (am *Manager) AddBackend(b Backend) {
+	sub := b.Subscribe()
+
+	am.backendsMu.Lock()
+	am.backends = append(am.backends, registeredBackend{b, sub})
+	am.backendsMu.Unlock()
+
+	go func() {
+		for ev := range sub.Chan() {
+			am.mux.Post(ev.Data)
+		}
+	}()
+	for _, a := range b.Accounts() {
+		am.mux.Post(AccountArrivedEvent{a})
+	}
+}
+
+// RemoveBackend unregisters a backend added with AddBackend, posting the departure
+// of its accounts. Its events are no longer forwarded. It returns false if the
+// backend was not registered.
+func (am *Manager) RemoveBackend(b Backend) bool {
+	am.backendsMu.Lock()
+	var removed *registeredBackend
+	for i, rb := range am.backends {
+		if rb.Backend == b {
+			removed = &rb
+			am.backends = append(am.backends[:i:i], am.backends[i+1:]...)
+			break
+		}
+	}
+	am.backendsMu.Unlock()
+
+	if removed == nil {
+		return false
+	}
+	removed.sub.Unsubscribe()
+	for _, a := range b.Accounts() {
+		am.mux.Post(AccountDepartedEvent{a})
+	}
+	return true
+}
+
+// Subscribe creates a subscription for AccountArrivedEvent and AccountDepartedEvent,
+// posted whenever an account appears in or disappears from the key directory or
+// one of the registered backends.
+func (am *Manager) Subscribe() event.Subscription {
+	return am.mux.Subscribe(AccountArrivedEvent{}, AccountDepartedEvent{})
+}
+
+// backend returns the registered backend holding the given address, if any.
+func (am *Manager) backend(addr common.Address) Backend {
+	am.backendsMu.RLock()
+	defer am.backendsMu.RUnlock()
+
+	for _, b := range am.backends {
+		if b.HasAddress(addr) {
+			return b.Backend
+		}
+	}
+	return nil
+}
+
 // HasAddress reports whether a key with the given address is present.
 func (am *Manager) HasAddress(addr common.Address) bool {
-	return am.cache.hasAddress(addr)
+	return am.cache.hasAddress(addr) || am.backend(addr) != nil
 }
 
-// Accounts returns all key files present in the directory.
+// Accounts returns all key files present in the directory, followed by the
+// accounts of the registered backends.
 func (am *Manager) Accounts() []Account {
-	return am.cache.accounts()
+	accounts := am.cache.accounts()
+
+	am.backendsMu.RLock()
+	defer am.backendsMu.RUnlock()
+	for _, b := range am.backends {
+		accounts = append(accounts, b.Accounts()...)
+	}
+	return accounts
 }
 
 // DeleteAccount deletes the key matched by account if the passphrase is correct.
-// If a contains no filename, the address must match a unique key.
+// If a contains no filename, the address must match a unique key. Accounts of
+// an HD wallet can't be deleted, as removing the wallet file would destroy the
+// seed and all other accounts derived from it.
 func (am *Manager) DeleteAccount(a Account, passphrase string) error {
 	// Decrypting the key isn't really necessary, but we do
 	// it anyway to check the password and zero out the key
@@ -126,25 +219,65 @@ func (am *Manager) DeleteAccount(a Account, passphrase string) error {
 	if err != nil {
 		return err
 	}
+	if keyjson, err := ioutil.ReadFile(a.File); err != nil {
+		return err
+	} else if isHDWallet(keyjson) {
+		return ErrHDDelete
+	}
 	// The order is crucial here. The key is dropped from the
 	// cache after the file is gone so that a reload happening in
 	// between won't insert it into the cache again.
 	err = os.Remove(a.File)
 	if err == nil {
-		am.cache.delete(a)
+		am.cache.deleteFile(a.File)
 	}
 	return err
 }
 
-// Sign signs hash with an unlocked private key matching the given address.
+// Sign signs hash with an unlocked private key matching the given address. If
+// the key directory holds no such key, signing is delegated to the registered
+// backend holding the address.
 func (am *Manager) Sign(addr common.Address, hash []byte) (signature []byte, err error) {
 	am.mu.RLock()
-	defer am.mu.RUnlock()
-	unlockedKey, found := am.unlocked[addr]
-	if !found {
-		return nil, ErrLocked
+	if unlockedKey, found := am.unlocked[addr]; found {
+		defer am.mu.RUnlock()
+		return crypto.Sign(hash, unlockedKey.PrivateKey)
+	}
+	am.mu.RUnlock()
+
+	if !am.cache.hasAddress(addr) {
+		if b := am.backend(addr); b != nil {
+			return b.Sign(addr, hash)
+		}
 	}
-	return crypto.Sign(hash, unlockedKey.PrivateKey)
+	return nil, ErrLocked
+}
+
+// SignWithPassphrase signs hash with the key of the given account, decrypting it
+// with passphrase just for this signature. The account doesn't need to be unlocked
+// and the decrypted key is wiped right after use.
+func (am *Manager) SignWithPassphrase(a Account, passphrase string, hash []byte) (signature []byte, err error) {
+	_, key, err := am.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key.PrivateKey)
+	return crypto.Sign(hash, key.PrivateKey)
+}
+
+// SignTx signs a transaction with the account of the given address. Backends
+// implementing TxSigner receive the whole transaction, others only its hash.
+func (am *Manager) SignTx(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	if !am.cache.hasAddress(addr) {
+		if b, ok := am.backend(addr).(TxSigner); ok {
+			return b.SignTx(addr, tx)
+		}
+	}
+	signature, err := am.Sign(addr, tx.SigHash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signature)
 }
 
 func (am *Manager) GetUnlocked(addr common.Address) (prvkey *ecdsa.PrivateKey, err error) {
@@ -256,6 +389,83 @@ func (am *Manager) NewAccount(passphrase string) (Account, error) {
 	return account, nil
 }
 
+// NewHDWallet generates a new mnemonic sentence and stores the seed derived from
+// it as an HD wallet in the key directory, encrypted with the passphrase. The
+// mnemonic is returned together with the first account of the wallet. It is the
+// only means of restoring the wallet and is not stored anywhere.
+func (am *Manager) NewHDWallet(passphrase string) (string, Account, error) {
+	entropy, err := hd.NewEntropy(hdEntropyBits)
+	if err != nil {
+		return "", Account{}, err
+	}
+	mnemonic, err := hd.NewMnemonic(entropy)
+	if err != nil {
+		return "", Account{}, err
+	}
+	account, err := am.ImportHDWallet(mnemonic, passphrase)
+	if err != nil {
+		return "", Account{}, err
+	}
+	return mnemonic, account, nil
+}
+
+// ImportHDWallet restores the HD wallet of a BIP39 mnemonic sentence created
+// without a mnemonic passphrase, encrypting its seed with passphrase. Accounts
+// are derived along the standard Ethereum path m/44'/60'/0'/0, the first of
+// which is returned. Further accounts can be added with DeriveAccount.
+func (am *Manager) ImportHDWallet(mnemonic, passphrase string) (Account, error) {
+	ks, ok := am.keyStore.(*keyStorePassphrase)
+	if !ok {
+		return Account{}, ErrHDUnsupported
+	}
+	if err := hd.ValidateMnemonic(mnemonic); err != nil {
+		return Account{}, err
+	}
+	seed := hd.NewSeed(mnemonic, "")
+	defer zeroBytes(seed)
+
+	master, err := hd.NewMaster(seed)
+	if err != nil {
+		return Account{}, err
+	}
+	base, err := master.Derive(hd.DefaultBaseDerivationPath)
+	if err != nil {
+		return Account{}, err
+	}
+	if first, err := deriveHDAccount(base, 0); err == nil && am.cache.hasAddress(first.Address) {
+		return Account{}, fmt.Errorf("account already exists")
+	}
+	account, err := ks.storeHDWallet(seed, passphrase)
+	if err != nil {
+		return Account{}, err
+	}
+	am.cache.add(account)
+	return account, nil
+}
+
+// DeriveAccount derives the next account of the HD wallet that a belongs to and
+// adds it to the wallet. The wallet need not be unlocked, but the new account
+// has to be unlocked before it can sign.
+func (am *Manager) DeriveAccount(a Account) (Account, error) {
+	ks, ok := am.keyStore.(*keyStorePassphrase)
+	if !ok {
+		return Account{}, ErrHDUnsupported
+	}
+	am.cache.maybeReload()
+	am.cache.mu.Lock()
+	a, err := am.cache.find(a)
+	am.cache.mu.Unlock()
+	if err != nil {
+		return Account{}, err
+	}
+	account, err := ks.appendHDAccount(a.File)
+	if err != nil {
+		return Account{}, err
+	}
+	am.cache.add(account)
+	return account, nil
+}
+
 // AccountByIndex returns the ith account.
 func (am *Manager) AccountByIndex(i int) (Account, error) {
 	accounts := am.Accounts()
@@ -311,12 +521,19 @@ func (am *Manager) importKey(key *Key, passphrase string) (Account, error) {
 	return a, nil
 }
 
-// Update changes the passphrase of an existing account.
+// Update changes the passphrase of an existing account. For accounts of an HD
+// wallet the passphrase of the whole wallet is changed.
 func (am *Manager) Update(a Account, passphrase, newPassphrase string) error {
 	a, key, err := am.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return err
 	}
+	if ks, ok := am.keyStore.(*keyStorePassphrase); ok {
+		if keyjson, err := ioutil.ReadFile(a.File); err == nil && isHDWallet(keyjson) {
+			zeroKey(key.PrivateKey)
+			return ks.updateHDWallet(a.File, passphrase, newPassphrase)
+		}
+	}
 	return am.keyStore.StoreKey(a.File, key, newPassphrase)
 }
 
@@ -19,12 +19,15 @@ package accounts
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
 )
 
 var testSigData = make([]byte, 32)
@@ -64,6 +67,197 @@ func TestManager(t *testing.T) {
 	}
 }
 
+func TestHDWallet(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	// Import a wallet with a well known first address
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	a1, err := am.ImportHDWallet(mnemonic, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"); a1.Address != want {
+		t.Fatalf("first account mismatch: have %x, want %x", a1.Address, want)
+	}
+	if _, err := am.ImportHDWallet(mnemonic, "foo"); err == nil {
+		t.Errorf("duplicate wallet import succeeded")
+	}
+	// Derive a second account without the passphrase and check both are listed
+	a2, err := am.DeriveAccount(a1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a2.File != a1.File || a2.Address == a1.Address {
+		t.Fatalf("derived account mismatch: have %v, first %v", a2, a1)
+	}
+	if accounts := am.Accounts(); len(accounts) != 2 {
+		t.Fatalf("account count mismatch: have %d, want 2", len(accounts))
+	}
+	// Both accounts must unlock and sign, also after a passphrase change
+	if err := am.Update(a2, "foo", "bar"); err != nil {
+		t.Fatalf("Update error: %v", err)
+	}
+	if err := am.Unlock(a1, "foo"); err != ErrDecrypt {
+		t.Fatalf("unlock with old passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	for _, a := range []Account{a1, a2} {
+		if err := am.Unlock(a, "bar"); err != nil {
+			t.Fatalf("failed to unlock %x: %v", a.Address, err)
+		}
+		sig, err := am.Sign(a.Address, testSigData)
+		if err != nil {
+			t.Fatalf("failed to sign with %x: %v", a.Address, err)
+		}
+		pub, err := crypto.SigToPub(testSigData, sig)
+		if err != nil || crypto.PubkeyToAddress(*pub) != a.Address {
+			t.Fatalf("signature of %x doesn't recover to signer", a.Address)
+		}
+	}
+	// Deleting an account must not destroy the wallet and its other accounts
+	for _, a := range []Account{a1, a2} {
+		if err := am.DeleteAccount(a, "bar"); err != ErrHDDelete {
+			t.Fatalf("DeleteAccount error mismatch: have %v, want %v", err, ErrHDDelete)
+		}
+	}
+	if !common.FileExist(a1.File) || !am.HasAddress(a1.Address) || !am.HasAddress(a2.Address) {
+		t.Errorf("HD wallet should be kept after DeleteAccount")
+	}
+}
+
+func TestHDWalletNew(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	mnemonic, a, err := am.NewHDWallet("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := len(strings.Fields(mnemonic)); words != 24 {
+		t.Errorf("mnemonic length mismatch: have %d words, want 24", words)
+	}
+	// Restoring the mnemonic into another key store must yield the same account
+	dir2, am2 := tmpManager(t, true)
+	defer os.RemoveAll(dir2)
+
+	restored, err := am2.ImportHDWallet(mnemonic, "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Address != a.Address {
+		t.Errorf("restored account mismatch: have %x, want %x", restored.Address, a.Address)
+	}
+	// Plain key stores and single keys don't support HD wallets
+	single, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.DeriveAccount(single); err != ErrNotHDWallet {
+		t.Errorf("derivation from single key error mismatch: have %v, want %v", err, ErrNotHDWallet)
+	}
+	dir3, plain := tmpManager(t, false)
+	defer os.RemoveAll(dir3)
+
+	if _, _, err := plain.NewHDWallet("foo"); err != ErrHDUnsupported {
+		t.Errorf("plain key store error mismatch: have %v, want %v", err, ErrHDUnsupported)
+	}
+}
+
+func TestAccountEvents(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	sub := am.Subscribe()
+	defer sub.Unsubscribe()
+
+	events := make(chan interface{}, 10)
+	go func() {
+		for ev := range sub.Chan() {
+			events <- ev.Data
+		}
+	}()
+	a, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.DeleteAccount(a, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []interface{}{AccountArrivedEvent{a}, AccountDepartedEvent{a}} {
+		select {
+		case ev := <-events:
+			if ev != want {
+				t.Fatalf("event %d mismatch: have %+v, want %+v", i, ev, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timeout waiting for %+v", i, want)
+		}
+	}
+}
+
+// testBackend is a backend holding a fixed account.
+type testBackend struct {
+	account Account
+	mux     event.TypeMux
+}
+
+func (b *testBackend) Accounts() []Account                         { return []Account{b.account} }
+func (b *testBackend) HasAddress(addr common.Address) bool         { return addr == b.account.Address }
+func (b *testBackend) Sign(common.Address, []byte) ([]byte, error) { return nil, ErrLocked }
+func (b *testBackend) Subscribe() event.Subscription {
+	return b.mux.Subscribe(AccountArrivedEvent{}, AccountDepartedEvent{})
+}
+
+func TestRemoveBackend(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	sub := am.Subscribe()
+	defer sub.Unsubscribe()
+
+	events := make(chan interface{}, 10)
+	go func() {
+		for ev := range sub.Chan() {
+			events <- ev.Data
+		}
+	}()
+	expect := func(want interface{}) {
+		select {
+		case ev := <-events:
+			if ev != want {
+				t.Fatalf("event mismatch: have %+v, want %+v", ev, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %+v", want)
+		}
+	}
+	b := &testBackend{account: Account{Address: common.Address{1}}}
+	am.AddBackend(b)
+	expect(AccountArrivedEvent{b.account})
+
+	other := Account{Address: common.Address{2}}
+	b.mux.Post(AccountArrivedEvent{other})
+	expect(AccountArrivedEvent{other})
+
+	if !am.RemoveBackend(b) {
+		t.Fatal("registered backend not removed")
+	}
+	expect(AccountDepartedEvent{b.account})
+	if am.HasAddress(b.account.Address) || len(am.Accounts()) != 0 {
+		t.Fatalf("accounts of removed backend still listed: %v", am.Accounts())
+	}
+	// Events of a removed backend are no longer forwarded
+	b.mux.Post(AccountDepartedEvent{other})
+	select {
+	case ev := <-events:
+		t.Fatalf("event of removed backend forwarded: %+v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if am.RemoveBackend(b) {
+		t.Fatal("backend removed twice")
+	}
+}
+
 func TestSign(t *testing.T) {
 	dir, am := tmpManager(t, true)
 	defer os.RemoveAll(dir)
@@ -81,6 +275,83 @@ func TestSign(t *testing.T) {
 	}
 }
 
+func TestSignWithPassphrase(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	a1, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.SignWithPassphrase(a1, "bar", testSigData); err != ErrDecrypt {
+		t.Fatalf("wrong passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	sig, err := am.SignWithPassphrase(a1, "foo", testSigData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := crypto.SigToPub(testSigData, sig); err != nil || crypto.PubkeyToAddress(*pub) != a1.Address {
+		t.Fatalf("signature doesn't recover to signer")
+	}
+	// One-shot signing must leave the account locked
+	if _, err := am.Sign(a1.Address, testSigData); err != ErrLocked {
+		t.Fatalf("signing after one-shot signature error mismatch: have %v, want %v", err, ErrLocked)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, plain := tmpManager(t, false)
+	defer os.RemoveAll(dir)
+
+	a, err := plain.NewAccount("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := InspectKeyFile(a.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != FormatPlain || info.Address != a.Address {
+		t.Fatalf("plain key file info mismatch: have %s %x, want %s %x", info.Format, info.Address, FormatPlain, a.Address)
+	}
+	if !info.Outdated(veryLightScryptN, veryLightScryptP) {
+		t.Errorf("plain key file not reported outdated")
+	}
+	// Migrating into an encrypted key store must encrypt the key and back up the original
+	am := NewManager(dir, veryLightScryptN, veryLightScryptP)
+	backup := filepath.Join(dir, ".backup")
+
+	if err := am.Migrate(a, "foo", backup); err != nil {
+		t.Fatal(err)
+	}
+	if info, err = InspectKeyFile(a.File); err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != FormatV3 || info.KDF != "scrypt" || info.ScryptN != veryLightScryptN || info.ScryptP != veryLightScryptP {
+		t.Errorf("migrated key file info mismatch: have %s", info)
+	}
+	if info.Outdated(veryLightScryptN, veryLightScryptP) {
+		t.Errorf("migrated key file reported outdated")
+	}
+	if _, err := InspectKeyFile(filepath.Join(backup, filepath.Base(a.File))); err != nil {
+		t.Errorf("backup missing: %v", err)
+	}
+	if err := am.Unlock(a, "foo"); err != nil {
+		t.Errorf("migrated key can't be unlocked: %v", err)
+	}
+	// A wrong passphrase must fail before anything is written
+	if err := am.Migrate(a, "bar", filepath.Join(dir, ".other")); err != ErrDecrypt {
+		t.Errorf("wrong passphrase error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".other")); !os.IsNotExist(err) {
+		t.Errorf("backup created despite failed migration")
+	}
+	// Earlier backups must never be overwritten
+	if err := am.Migrate(a, "foo", backup); err == nil {
+		t.Errorf("migration overwrote existing backup")
+	}
+}
+
 func TestTimedUnlock(t *testing.T) {
 	dir, am := tmpManager(t, true)
 	defer os.RemoveAll(dir)
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 )
@@ -70,6 +71,10 @@ type addrCache struct {
 	all      accountsByFile
 	byAddr   map[common.Address][]Account
 	throttle *time.Timer
+
+	mux      *event.TypeMux // Optional mux to post account arrivals and departures to
+	pending  []interface{}  // Account events queued while holding mu
+	notifyMu sync.Mutex     // Ensures queued events are posted in order
 }
 
 func newAddrCache(keydir string) *addrCache {
@@ -98,6 +103,7 @@ func (ac *addrCache) hasAddress(addr common.Address) bool {
 }
 
 func (ac *addrCache) add(newAccount Account) {
+	defer ac.notify()
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 
@@ -110,18 +116,54 @@ func (ac *addrCache) add(newAccount Account) {
 	copy(ac.all[i+1:], ac.all[i:])
 	ac.all[i] = newAccount
 	ac.byAddr[newAccount.Address] = append(ac.byAddr[newAccount.Address], newAccount)
+	ac.queue(AccountArrivedEvent{newAccount})
 }
 
 // note: removed needs to be unique here (i.e. both File and Address must be set).
 func (ac *addrCache) delete(removed Account) {
+	defer ac.notify()
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
+	if !containsAccount(ac.byAddr[removed.Address], removed) {
+		return
+	}
 	ac.all = removeAccount(ac.all, removed)
 	if ba := removeAccount(ac.byAddr[removed.Address], removed); len(ba) == 0 {
 		delete(ac.byAddr, removed.Address)
 	} else {
 		ac.byAddr[removed.Address] = ba
 	}
+	ac.queue(AccountDepartedEvent{removed})
+}
+
+// deleteFile drops all accounts stored in the given file, which may be more
+// than one in case of an HD wallet.
+func (ac *addrCache) deleteFile(path string) {
+	defer ac.notify()
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	for i := 0; i < len(ac.all); {
+		if removed := ac.all[i]; removed.File == path {
+			ac.all = append(ac.all[:i], ac.all[i+1:]...)
+			if ba := removeAccount(ac.byAddr[removed.Address], removed); len(ba) == 0 {
+				delete(ac.byAddr, removed.Address)
+			} else {
+				ac.byAddr[removed.Address] = ba
+			}
+			ac.queue(AccountDepartedEvent{removed})
+			continue
+		}
+		i++
+	}
+}
+
+func containsAccount(slice []Account, elem Account) bool {
+	for i := range slice {
+		if slice[i] == elem {
+			return true
+		}
+	}
+	return false
 }
 
 func removeAccount(slice []Account, elem Account) []Account {
@@ -169,6 +211,7 @@ func (ac *addrCache) find(a Account) (Account, error) {
 }
 
 func (ac *addrCache) maybeReload() {
+	defer ac.notify()
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 	if ac.watcher.running {
@@ -204,6 +247,22 @@ func (ac *addrCache) reload() {
 	if err != nil && glog.V(logger.Debug) {
 		glog.Errorf("can't load keys: %v", err)
 	}
+	// Queue events for the accounts that changed since the last reload
+	if ac.mux != nil {
+		previous := make(map[Account]bool, len(ac.all))
+		for _, a := range ac.all {
+			previous[a] = true
+		}
+		for _, a := range accounts {
+			if !previous[a] {
+				ac.queue(AccountArrivedEvent{a})
+			}
+			delete(previous, a)
+		}
+		for a := range previous {
+			ac.queue(AccountDepartedEvent{a})
+		}
+	}
 	ac.all = accounts
 	sort.Sort(ac.all)
 	for k := range ac.byAddr {
@@ -215,6 +274,30 @@ func (ac *addrCache) reload() {
 	glog.V(logger.Debug).Infof("reloaded keys, cache has %d accounts", len(ac.all))
 }
 
+// queue schedules an account event to be posted by the next notify call.
+// Callers must hold ac.mu.
+func (ac *addrCache) queue(ev interface{}) {
+	if ac.mux != nil {
+		ac.pending = append(ac.pending, ev)
+	}
+}
+
+// notify posts all queued account events. Posting blocks until the subscribers
+// receive the events, so it must not be called while holding ac.mu.
+func (ac *addrCache) notify() {
+	ac.notifyMu.Lock()
+	defer ac.notifyMu.Unlock()
+
+	ac.mu.Lock()
+	events := ac.pending
+	ac.pending = nil
+	ac.mu.Unlock()
+
+	for _, ev := range events {
+		ac.mux.Post(ev)
+	}
+}
+
 func (ac *addrCache) scan() ([]Account, error) {
 	files, err := ioutil.ReadDir(ac.keydir)
 	if err != nil {
@@ -225,7 +308,10 @@ func (ac *addrCache) scan() ([]Account, error) {
 		buf     = new(bufio.Reader)
 		addrs   []Account
 		keyJSON struct {
-			Address common.Address `json:"address"`
+			Address  common.Address `json:"address"`
+			Accounts []struct {
+				Address common.Address `json:"address"`
+			} `json:"accounts"`
 		}
 	)
 	for _, fi := range files {
@@ -241,11 +327,16 @@ func (ac *addrCache) scan() ([]Account, error) {
 		}
 		buf.Reset(fd)
 		// Parse the address.
-		keyJSON.Address = common.Address{}
+		keyJSON.Address, keyJSON.Accounts = common.Address{}, nil
 		err = json.NewDecoder(buf).Decode(&keyJSON)
 		switch {
 		case err != nil:
 			glog.V(logger.Debug).Infof("can't decode key %s: %v", path, err)
+		case len(keyJSON.Accounts) > 0:
+			// HD wallet, all derived accounts share the same file
+			for _, account := range keyJSON.Accounts {
+				addrs = append(addrs, Account{Address: account.Address, File: path})
+			}
 		case (keyJSON.Address == common.Address{}):
 			glog.V(logger.Debug).Infof("can't decode key %s: missing or zero address", path)
 		default:
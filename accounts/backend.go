@@ -0,0 +1,61 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Wallet is a source of accounts that is able to sign on their behalf.
+type Wallet interface {
+	// Accounts returns all accounts currently held by the wallet.
+	Accounts() []Account
+
+	// HasAddress reports whether the wallet holds the account with the given address.
+	HasAddress(addr common.Address) bool
+
+	// Sign signs hash with the key of the given address. Wallets that need
+	// authorization to do so, e.g. a passphrase or user confirmation, either
+	// obtain it themselves or fail with an error such as ErrLocked.
+	Sign(addr common.Address, hash []byte) ([]byte, error)
+}
+
+// Backend is a Wallet that notifies about accounts arriving and departing, so
+// a Manager can aggregate the accounts of several backends.
+type Backend interface {
+	Wallet
+
+	// Subscribe creates a subscription for the AccountArrivedEvent and
+	// AccountDepartedEvent posted by the backend.
+	Subscribe() event.Subscription
+}
+
+// TxSigner is implemented by wallets that sign whole transactions instead of
+// their bare hashes, e.g. to apply signing policies based on their contents.
+type TxSigner interface {
+	// SignTx returns tx signed by the key of the given address.
+	SignTx(addr common.Address, tx *types.Transaction) (*types.Transaction, error)
+}
+
+// AccountArrivedEvent is posted when an account becomes available, e.g. when a
+// key file appears in the key directory or an external signer reports it.
+type AccountArrivedEvent struct{ Account Account }
+
+// AccountDepartedEvent is posted when an account is no longer available.
+type AccountDepartedEvent struct{ Account Account }
@@ -0,0 +1,243 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an accounts backend whose keys are held by a
+// separate signer process, reached over IPC.
+//
+// The signer is expected to serve the following JSON-RPC methods:
+//
+//	account_list()                      -> [address, ...]
+//	account_signHash(addr, hash)        -> signature
+//	account_signTransaction(addr, tx)   -> signed tx
+//
+// with addresses, hashes and signatures encoded as 0x prefixed hex strings and
+// transactions as 0x prefixed hex strings of their RLP encoding. The
+// signer is free to ask a user for confirmation or apply its own policies before
+// answering a signing request, and to reject it with an error.
+package external
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// refreshInterval is the time between two polls of the signer's account list.
+const refreshInterval = 3 * time.Second
+
+// Backend is an accounts.Backend forwarding all signing requests to an external
+// signer process.
+type Backend struct {
+	endpoint string     // IPC endpoint of the signer
+	client   rpc.Client // Connection to the signer
+	reqId    uint64     // Id of the last request sent to the signer
+	reqMu    sync.Mutex // Serializes request/response pairs on the connection
+
+	accounts []accounts.Account // Accounts reported by the last refresh
+	accMu    sync.RWMutex       // Protects the account list
+
+	mux  event.TypeMux
+	quit chan struct{}
+}
+
+// NewBackend connects to the signer listening on the given IPC endpoint and
+// retrieves its accounts. The account list is refreshed periodically until the
+// backend is closed.
+func NewBackend(endpoint string) (*Backend, error) {
+	client, err := rpc.NewIPCClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	b := &Backend{
+		endpoint: endpoint,
+		client:   client,
+		quit:     make(chan struct{}),
+	}
+	if err := b.refresh(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	go b.loop()
+	return b, nil
+}
+
+// Close stops refreshing the account list and disconnects from the signer.
+func (b *Backend) Close() {
+	close(b.quit)
+	b.mux.Stop()
+
+	b.reqMu.Lock()
+	b.client.Close()
+	b.reqMu.Unlock()
+}
+
+// Accounts implements accounts.Wallet, returning the accounts of the signer.
+func (b *Backend) Accounts() []accounts.Account {
+	b.accMu.RLock()
+	defer b.accMu.RUnlock()
+
+	cpy := make([]accounts.Account, len(b.accounts))
+	copy(cpy, b.accounts)
+	return cpy
+}
+
+// HasAddress implements accounts.Wallet.
+func (b *Backend) HasAddress(addr common.Address) bool {
+	b.accMu.RLock()
+	defer b.accMu.RUnlock()
+
+	for _, a := range b.accounts {
+		if a.Address == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign implements accounts.Wallet, requesting a signature from the signer. The
+// call blocks until the signer answers, which may involve user confirmation.
+func (b *Backend) Sign(addr common.Address, hash []byte) ([]byte, error) {
+	var signature string
+	if err := b.call(&signature, "account_signHash", addr, common.ToHex(hash)); err != nil {
+		return nil, err
+	}
+	return common.FromHex(signature), nil
+}
+
+// SignTx implements accounts.TxSigner, handing the whole transaction to the
+// signer so that it can judge the request by its contents.
+func (b *Backend) SignTx(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var signed string
+	if err := b.call(&signed, "account_signTransaction", addr, common.ToHex(data)); err != nil {
+		return nil, err
+	}
+	result := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(signed), result); err != nil {
+		return nil, err
+	}
+	// Make sure the signer didn't swap the transaction or the sender
+	if result.SigHash() != tx.SigHash() {
+		return nil, errors.New("signer returned a different transaction")
+	}
+	if from, err := result.From(); err != nil || from != addr {
+		return nil, errors.New("signer returned a transaction from a different account")
+	}
+	return result, nil
+}
+
+// Subscribe implements accounts.Backend.
+func (b *Backend) Subscribe() event.Subscription {
+	return b.mux.Subscribe(accounts.AccountArrivedEvent{}, accounts.AccountDepartedEvent{})
+}
+
+// loop periodically refreshes the account list of the signer.
+func (b *Backend) loop() {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.quit:
+			return
+		case <-ticker.C:
+			if err := b.refresh(); err != nil {
+				glog.V(logger.Debug).Infof("can't list accounts of signer %s: %v", b.endpoint, err)
+			}
+		}
+	}
+}
+
+// refresh retrieves the account list of the signer and posts events for the
+// accounts that arrived or departed since the last refresh.
+func (b *Backend) refresh() error {
+	var addrs []common.Address
+	if err := b.call(&addrs, "account_list"); err != nil {
+		return err
+	}
+	fresh := make([]accounts.Account, len(addrs))
+	for i, addr := range addrs {
+		fresh[i] = accounts.Account{Address: addr, File: "ipc:" + b.endpoint}
+	}
+	b.accMu.Lock()
+	previous := b.accounts
+	b.accounts = fresh
+	b.accMu.Unlock()
+
+	known := make(map[accounts.Account]bool, len(previous))
+	for _, a := range previous {
+		known[a] = true
+	}
+	for _, a := range fresh {
+		if !known[a] {
+			b.mux.Post(accounts.AccountArrivedEvent{Account: a})
+		}
+		delete(known, a)
+	}
+	for a := range known {
+		b.mux.Post(accounts.AccountDepartedEvent{Account: a})
+	}
+	return nil
+}
+
+// call sends a request to the signer and decodes the result into result.
+func (b *Backend) call(result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	b.reqMu.Lock()
+	defer b.reqMu.Unlock()
+
+	b.reqId++
+	req := rpc.JSONRequest{
+		Id:      []byte(strconv.FormatUint(b.reqId, 10)),
+		Version: "2.0",
+		Method:  method,
+		Payload: payload,
+	}
+	if err := b.client.Send(req); err != nil {
+		return err
+	}
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpc.JSONError  `json:"error"`
+	}
+	if err := b.client.Recv(&res); err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return errors.New(res.Error.Message)
+	}
+	return json.Unmarshal(res.Result, result)
+}
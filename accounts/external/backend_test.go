@@ -0,0 +1,132 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// FakeSigner is a minimal signer serving a single key and refusing to sign
+// while locked.
+type FakeSigner struct {
+	key    []byte
+	locked bool
+}
+
+func (s *FakeSigner) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(crypto.ToECDSA(s.key).PublicKey)}
+}
+
+func (s *FakeSigner) SignHash(addr common.Address, hash string) (string, error) {
+	if s.locked {
+		return "", errors.New("request denied")
+	}
+	sig, err := crypto.Sign(common.FromHex(hash), crypto.ToECDSA(s.key))
+	if err != nil {
+		return "", err
+	}
+	return common.ToHex(sig), nil
+}
+
+func startSigner(t *testing.T, signer *FakeSigner) (string, func()) {
+	dir, err := ioutil.TempDir("", "external-signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := filepath.Join(dir, "signer.ipc")
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", signer); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := rpc.CreateIPCListener(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeCodec(rpc.NewJSONCodec(conn), rpc.OptionMethodInvocation)
+		}
+	}()
+	return endpoint, func() {
+		listener.Close()
+		server.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestBackend(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := &FakeSigner{key: crypto.FromECDSA(key)}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	endpoint, stop := startSigner(t, signer)
+	defer stop()
+
+	backend, err := NewBackend(endpoint)
+	if err != nil {
+		t.Fatalf("failed to connect to signer: %v", err)
+	}
+	defer backend.Close()
+
+	// Aggregate the signer with an empty key directory and sign through the manager
+	dir, err := ioutil.TempDir("", "external-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP)
+	am.AddBackend(backend)
+
+	if accs := am.Accounts(); len(accs) != 1 || accs[0].Address != addr {
+		t.Fatalf("account list mismatch: have %v, want [%x]", accs, addr)
+	}
+	if !am.HasAddress(addr) {
+		t.Fatalf("manager doesn't report signer account %x", addr)
+	}
+	hash := crypto.Keccak256([]byte("foo"))
+	sig, err := am.Sign(addr, hash)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if pub, err := crypto.SigToPub(hash, sig); err != nil || crypto.PubkeyToAddress(*pub) != addr {
+		t.Fatalf("signature doesn't recover to signer account")
+	}
+	// Rejections by the signer must be reported to the caller
+	signer.locked = true
+	if _, err := am.Sign(addr, hash); err == nil || err.Error() != "request denied" {
+		t.Fatalf("rejected signing error mismatch: have %v, want request denied", err)
+	}
+	// Unknown accounts aren't routed to the signer
+	if _, err := am.Sign(common.Address{1}, hash); err != accounts.ErrLocked {
+		t.Fatalf("unknown account error mismatch: have %v, want %v", err, accounts.ErrLocked)
+	}
+}
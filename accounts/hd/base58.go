@@ -0,0 +1,92 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// base58Alphabet is the Bitcoin base58 alphabet used by serialized extended keys.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var (
+	errBase58Char     = errors.New("hd: invalid base58 character")
+	errBase58Checksum = errors.New("hd: base58 checksum mismatch")
+)
+
+// base58CheckEncode appends a four byte double SHA256 checksum to data and
+// encodes the result in base58.
+func base58CheckEncode(data []byte) string {
+	checksum := crypto.Sha256(crypto.Sha256(data))
+	data = append(append([]byte{}, data...), checksum[:4]...)
+
+	var (
+		num    = new(big.Int).SetBytes(data)
+		radix  = big.NewInt(58)
+		mod    = new(big.Int)
+		result []byte
+	)
+	for num.Sign() > 0 {
+		num.DivMod(num, radix, mod)
+		result = append(result, base58Alphabet[mod.Int64()])
+	}
+	// Leading zero bytes are encoded as leading '1' characters
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		result = append(result, base58Alphabet[0])
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return string(result)
+}
+
+// base58CheckDecode decodes a base58 string and verifies and strips its
+// trailing checksum.
+func base58CheckDecode(s string) ([]byte, error) {
+	var (
+		num   = new(big.Int)
+		radix = big.NewInt(58)
+	)
+	for i := 0; i < len(s); i++ {
+		digit := bytes.IndexByte([]byte(base58Alphabet), s[i])
+		if digit < 0 {
+			return nil, errBase58Char
+		}
+		num.Mul(num, radix)
+		num.Add(num, big.NewInt(int64(digit)))
+	}
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	data := append(make([]byte, zeros), num.Bytes()...)
+	if len(data) < 4 {
+		return nil, errBase58Checksum
+	}
+	payload, checksum := data[:len(data)-4], data[len(data)-4:]
+	if !bytes.Equal(crypto.Sha256(crypto.Sha256(payload))[:4], checksum) {
+		return nil, errBase58Checksum
+	}
+	return payload, nil
+}
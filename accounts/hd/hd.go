@@ -0,0 +1,324 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package hd implements hierarchical deterministic wallets: BIP39 mnemonic
+// sentences, BIP32 extended keys on the secp256k1 curve and the BIP44 paths
+// used to derive Ethereum accounts from them.
+package hd
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+)
+
+var (
+	// masterKeySalt is the HMAC key used to derive a master key from a seed.
+	masterKeySalt = []byte("Bitcoin seed")
+
+	// Version bytes prefixing serialized mainnet extended keys.
+	privateVersion = []byte{0x04, 0x88, 0xad, 0xe4} // xprv
+	publicVersion  = []byte{0x04, 0x88, 0xb2, 0x1e} // xpub
+)
+
+const (
+	minSeedLength = 16 // Minimum seed length in bytes allowed by BIP32
+	maxSeedLength = 64 // Maximum seed length in bytes allowed by BIP32
+
+	serializedKeyLength = 78 // Length in bytes of a serialized extended key
+)
+
+var (
+	ErrInvalidSeed        = errors.New("hd: seed must be 16 to 64 bytes long")
+	ErrInvalidChild       = errors.New("hd: derived key is invalid, use the next index")
+	ErrDeriveHardened     = errors.New("hd: cannot derive a hardened key from a public key")
+	ErrNotPrivate         = errors.New("hd: extended key is not private")
+	ErrInvalidExtendedKey = errors.New("hd: invalid serialized extended key")
+)
+
+// ExtendedKey is a BIP32 extended key: a private or public secp256k1 key
+// together with the chain code needed to derive its children.
+type ExtendedKey struct {
+	key       []byte // 32 byte private scalar or 33 byte compressed public key
+	chainCode []byte // 32 byte chain code
+	depth     byte   // Number of derivations since the master key
+	parentFP  []byte // First 4 bytes of the parent's key identifier
+	childNum  uint32 // Index of this key below its parent
+	private   bool   // Whether key is a private scalar
+}
+
+// NewMaster creates the master private key for a seed, usually obtained from a
+// mnemonic sentence via NewSeed.
+func NewMaster(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < minSeedLength || len(seed) > maxSeedLength {
+		return nil, ErrInvalidSeed
+	}
+	mac := hmac.New(sha512.New, masterKeySalt)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	if k := new(big.Int).SetBytes(sum[:32]); k.Sign() == 0 || k.Cmp(secp256k1.S256().N) >= 0 {
+		return nil, ErrInvalidSeed
+	}
+	return &ExtendedKey{
+		key:       sum[:32],
+		chainCode: sum[32:],
+		parentFP:  []byte{0, 0, 0, 0},
+		private:   true,
+	}, nil
+}
+
+// IsPrivate reports whether the extended key holds a private key.
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.private
+}
+
+// Depth returns the number of derivation steps between the master key and k.
+func (k *ExtendedKey) Depth() int {
+	return int(k.depth)
+}
+
+// Child derives the child key with the given index. Indexes starting from
+// HardenedKeyStart derive hardened children, which requires a private key.
+// In the astronomically unlikely case that the index yields an invalid key,
+// ErrInvalidChild is returned and the caller should proceed to the next index.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	var data []byte
+	if index >= HardenedKeyStart {
+		if !k.private {
+			return nil, ErrDeriveHardened
+		}
+		data = append([]byte{0x00}, k.key...)
+	} else {
+		data = k.pubKeyBytes()
+	}
+	var num [4]byte
+	binary.BigEndian.PutUint32(num[:], index)
+	data = append(data, num[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	curve := secp256k1.S256()
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(curve.N) >= 0 {
+		return nil, ErrInvalidChild
+	}
+	child := &ExtendedKey{
+		chainCode: sum[32:],
+		depth:     k.depth + 1,
+		parentFP:  k.fingerprint(),
+		childNum:  index,
+		private:   k.private,
+	}
+	if k.private {
+		// Child private key is (tweak + parent) mod N
+		key := tweak.Add(tweak, new(big.Int).SetBytes(k.key))
+		key.Mod(key, curve.N)
+		if key.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+		child.key = paddedBytes(key, 32)
+	} else {
+		// Child public key is tweak*G + parent
+		tx, ty := curve.ScalarBaseMult(sum[:32])
+		px, py := decompressPubkey(k.key)
+		if tx == nil || px == nil {
+			return nil, ErrInvalidChild
+		}
+		x, y := curve.Add(tx, ty, px, py)
+		if x.Sign() == 0 && y.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+		child.key = compressPubkey(x, y)
+	}
+	return child, nil
+}
+
+// Derive walks down the given path starting from k and returns the final key.
+func (k *ExtendedKey) Derive(path DerivationPath) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		if key, err = key.Child(index); err != nil {
+			return nil, fmt.Errorf("%v at %v", err, path)
+		}
+	}
+	return key, nil
+}
+
+// Neuter returns the public extended key corresponding to k. Public keys can
+// derive the public halves of all non-hardened descendants.
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	if !k.private {
+		return k
+	}
+	return &ExtendedKey{
+		key:       k.pubKeyBytes(),
+		chainCode: k.chainCode,
+		depth:     k.depth,
+		parentFP:  k.parentFP,
+		childNum:  k.childNum,
+	}
+}
+
+// ToECDSA returns the private key held by k.
+func (k *ExtendedKey) ToECDSA() (*ecdsa.PrivateKey, error) {
+	if !k.private {
+		return nil, ErrNotPrivate
+	}
+	return crypto.ToECDSA(k.key), nil
+}
+
+// ToECDSAPub returns the public key held by k.
+func (k *ExtendedKey) ToECDSAPub() *ecdsa.PublicKey {
+	if k.private {
+		return &crypto.ToECDSA(k.key).PublicKey
+	}
+	x, y := decompressPubkey(k.key)
+	return &ecdsa.PublicKey{Curve: secp256k1.S256(), X: x, Y: y}
+}
+
+// String implements fmt.Stringer, returning the base58 serialization of the key
+// (the familiar xprv... and xpub... strings).
+func (k *ExtendedKey) String() string {
+	data := make([]byte, 0, serializedKeyLength)
+	if k.private {
+		data = append(data, privateVersion...)
+	} else {
+		data = append(data, publicVersion...)
+	}
+	var num [4]byte
+	binary.BigEndian.PutUint32(num[:], k.childNum)
+
+	data = append(data, k.depth)
+	data = append(data, k.parentFP...)
+	data = append(data, num[:]...)
+	data = append(data, k.chainCode...)
+	if k.private {
+		data = append(data, 0x00)
+	}
+	data = append(data, k.key...)
+	return base58CheckEncode(data)
+}
+
+// ParseExtendedKey decodes a base58 serialized extended key as produced by
+// ExtendedKey.String.
+func ParseExtendedKey(s string) (*ExtendedKey, error) {
+	data, err := base58CheckDecode(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != serializedKeyLength {
+		return nil, ErrInvalidExtendedKey
+	}
+	k := &ExtendedKey{
+		depth:     data[4],
+		parentFP:  data[5:9],
+		childNum:  binary.BigEndian.Uint32(data[9:13]),
+		chainCode: data[13:45],
+	}
+	switch version := data[:4]; {
+	case bytes.Equal(version, privateVersion):
+		if data[45] != 0x00 {
+			return nil, ErrInvalidExtendedKey
+		}
+		if n := new(big.Int).SetBytes(data[46:]); n.Sign() == 0 || n.Cmp(secp256k1.S256().N) >= 0 {
+			return nil, ErrInvalidExtendedKey
+		}
+		k.key, k.private = data[46:], true
+	case bytes.Equal(version, publicVersion):
+		if x, _ := decompressPubkey(data[45:]); x == nil {
+			return nil, ErrInvalidExtendedKey
+		}
+		k.key = data[45:]
+	default:
+		return nil, ErrInvalidExtendedKey
+	}
+	return k, nil
+}
+
+// pubKeyBytes returns the compressed public key of k.
+func (k *ExtendedKey) pubKeyBytes() []byte {
+	if !k.private {
+		return k.key
+	}
+	x, y := secp256k1.S256().ScalarBaseMult(k.key)
+	return compressPubkey(x, y)
+}
+
+// fingerprint returns the first four bytes of the hash160 of the public key,
+// identifying k as the parent of its children.
+func (k *ExtendedKey) fingerprint() []byte {
+	return crypto.Ripemd160(crypto.Sha256(k.pubKeyBytes()))[:4]
+}
+
+// compressPubkey encodes a curve point in the 33 byte compressed form.
+func compressPubkey(x, y *big.Int) []byte {
+	key := make([]byte, 33)
+	key[0] = 0x02 | byte(y.Bit(0))
+	copy(key[1:], paddedBytes(x, 32))
+	return key
+}
+
+// decompressPubkey decodes a 33 byte compressed public key, returning nil
+// coordinates if the encoding is not a valid curve point.
+func decompressPubkey(key []byte) (*big.Int, *big.Int) {
+	if len(key) != 33 || (key[0] != 0x02 && key[0] != 0x03) {
+		return nil, nil
+	}
+	curve := secp256k1.S256()
+	x := new(big.Int).SetBytes(key[1:])
+	if x.Cmp(curve.P) >= 0 {
+		return nil, nil
+	}
+	// y^2 = x^3 + 7, and P = 3 mod 4 so y = (y^2)^((P+1)/4)
+	y := new(big.Int).Exp(x, big.NewInt(3), curve.P)
+	y.Add(y, curve.B)
+	y.Mod(y, curve.P)
+
+	exp := new(big.Int).Add(curve.P, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y.Exp(y, exp, curve.P)
+
+	if y.Bit(0) != uint(key[0]&1) {
+		y.Sub(curve.P, y)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil
+	}
+	return x, y
+}
+
+// paddedBytes returns the big-endian bytes of n left padded to size.
+func paddedBytes(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
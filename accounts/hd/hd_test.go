@@ -0,0 +1,166 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Official BIP32 test vectors 1 and 2, listing the serialized private and
+// public key at every step of the derivation path.
+var extendedKeyTests = []struct {
+	seed string
+	path string
+	keys [][2]string
+}{
+	{
+		seed: "000102030405060708090a0b0c0d0e0f",
+		path: "m/0'/1/2'/2/1000000000",
+		keys: [][2]string{
+			{"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi", "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"},
+			{"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7", "xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw"},
+			{"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs", "xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ"},
+			{"xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM", "xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5"},
+			{"xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334", "xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV"},
+			{"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76", "xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy"},
+		},
+	},
+	{
+		seed: "fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+		path: "m/0/2147483647'/1/2147483646'/2",
+		keys: [][2]string{
+			{"xprv9s21ZrQH143K31xYSDQpPDxsXRTUcvj2iNHm5NUtrGiGG5e2DtALGdso3pGz6ssrdK4PFmM8NSpSBHNqPqm55Qn3LqFtT2emdEXVYsCzC2U", "xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB"},
+			{"xprv9vHkqa6EV4sPZHYqZznhT2NPtPCjKuDKGY38FBWLvgaDx45zo9WQRUT3dKYnjwih2yJD9mkrocEZXo1ex8G81dwSM1fwqWpWkeS3v86pgKt", "xpub69H7F5d8KSRgmmdJg2KhpAK8SR3DjMwAdkxj3ZuxV27CprR9LgpeyGmXUbC6wb7ERfvrnKZjXoUmmDznezpbZb7ap6r1D3tgFxHmwMkQTPH"},
+			{"xprv9wSp6B7kry3Vj9m1zSnLvN3xH8RdsPP1Mh7fAaR7aRLcQMKTR2vidYEeEg2mUCTAwCd6vnxVrcjfy2kRgVsFawNzmjuHc2YmYRmagcEPdU9", "xpub6ASAVgeehLbnwdqV6UKMHVzgqAG8Gr6riv3Fxxpj8ksbH9ebxaEyBLZ85ySDhKiLDBrQSARLq1uNRts8RuJiHjaDMBU4Zn9h8LZNnBC5y4a"},
+			{"xprv9zFnWC6h2cLgpmSA46vutJzBcfJ8yaJGg8cX1e5StJh45BBciYTRXSd25UEPVuesF9yog62tGAQtHjXajPPdbRCHuWS6T8XA2ECKADdw4Ef", "xpub6DF8uhdarytz3FWdA8TvFSvvAh8dP3283MY7p2V4SeE2wyWmG5mg5EwVvmdMVCQcoNJxGoWaU9DCWh89LojfZ537wTfunKau47EL2dhHKon"},
+			{"xprvA1RpRA33e1JQ7ifknakTFpgNXPmW2YvmhqLQYMmrj4xJXXWYpDPS3xz7iAxn8L39njGVyuoseXzU6rcxFLJ8HFsTjSyQbLYnMpCqE2VbFWc", "xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL"},
+			{"xprvA2nrNbFZABcdryreWet9Ea4LvTJcGsqrMzxHx98MMrotbir7yrKCEXw7nadnHM8Dq38EGfSh6dqA9QWTyefMLEcBYJUuekgW4BYPJcr9E7j", "xpub6FnCn6nSzZAw5Tw7cgR9bi15UV96gLZhjDstkXXxvCLsUXBGXPdSnLFbdpq8p9HmGsApME5hQTZ3emM2rnY5agb9rXpVGyy3bdW6EEgAtqt"},
+		},
+	},
+}
+
+func TestExtendedKeyVectors(t *testing.T) {
+	for i, tt := range extendedKeyTests {
+		seed, _ := hex.DecodeString(tt.seed)
+		path, err := ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatalf("test %d: failed to parse path: %v", i, err)
+		}
+		key, err := NewMaster(seed)
+		if err != nil {
+			t.Fatalf("test %d: failed to create master key: %v", i, err)
+		}
+		for j, want := range tt.keys {
+			if j > 0 {
+				if key, err = key.Child(path[j-1]); err != nil {
+					t.Fatalf("test %d, depth %d: failed to derive child: %v", i, j, err)
+				}
+			}
+			if have := key.String(); have != want[0] {
+				t.Errorf("test %d, depth %d: private key mismatch:\nhave %s\nwant %s", i, j, have, want[0])
+			}
+			if have := key.Neuter().String(); have != want[1] {
+				t.Errorf("test %d, depth %d: public key mismatch:\nhave %s\nwant %s", i, j, have, want[1])
+			}
+			// Both serializations must parse back into the same keys
+			for k, s := range want {
+				parsed, err := ParseExtendedKey(s)
+				if err != nil {
+					t.Errorf("test %d, depth %d: failed to parse %s: %v", i, j, s, err)
+				} else if parsed.String() != s {
+					t.Errorf("test %d, depth %d: round trip mismatch: have %s, want %s", i, j, parsed, s)
+				} else if parsed.IsPrivate() != (k == 0) {
+					t.Errorf("test %d, depth %d: privacy mismatch for %s", i, j, s)
+				}
+			}
+		}
+	}
+}
+
+// Tests that public derivation yields the same keys as private derivation
+// followed by neutering, and that hardened derivation is refused.
+func TestPublicDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString(extendedKeyTests[0].seed)
+	master, _ := NewMaster(seed)
+
+	account, err := master.Derive(DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatalf("failed to derive account root: %v", err)
+	}
+	public := account.Neuter()
+	for i := uint32(0); i < 5; i++ {
+		private, err := account.Child(i)
+		if err != nil {
+			t.Fatalf("index %d: failed to derive private child: %v", i, err)
+		}
+		derived, err := public.Child(i)
+		if err != nil {
+			t.Fatalf("index %d: failed to derive public child: %v", i, err)
+		}
+		if have, want := derived.String(), private.Neuter().String(); have != want {
+			t.Errorf("index %d: public child mismatch:\nhave %s\nwant %s", i, have, want)
+		}
+		key, err := private.ToECDSA()
+		if err != nil {
+			t.Fatalf("index %d: failed to export private key: %v", i, err)
+		}
+		if have, want := crypto.PubkeyToAddress(*derived.ToECDSAPub()), crypto.PubkeyToAddress(key.PublicKey); have != want {
+			t.Errorf("index %d: address mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	if _, err := public.Child(HardenedKeyStart); err != ErrDeriveHardened {
+		t.Errorf("hardened public derivation error mismatch: have %v, want %v", err, ErrDeriveHardened)
+	}
+	if _, err := public.ToECDSA(); err != ErrNotPrivate {
+		t.Errorf("public key export error mismatch: have %v, want %v", err, ErrNotPrivate)
+	}
+}
+
+func TestDerivationPath(t *testing.T) {
+	tests := []struct {
+		input  string
+		output DerivationPath
+		format string
+	}{
+		{"m", DerivationPath{}, "m"},
+		{"m/44'/60'/0'/0", DefaultBaseDerivationPath, "m/44'/60'/0'/0"},
+		{"44h/60h/0h/0/7", DefaultBaseDerivationPath.Child(7), "m/44'/60'/0'/0/7"},
+		{"m/2147483647'/1", DerivationPath{0xffffffff, 1}, "m/2147483647'/1"},
+	}
+	for i, tt := range tests {
+		path, err := ParseDerivationPath(tt.input)
+		if err != nil {
+			t.Errorf("test %d: failed to parse %q: %v", i, tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(path, tt.output) {
+			t.Errorf("test %d: path mismatch: have %v, want %v", i, []uint32(path), []uint32(tt.output))
+		}
+		if path.String() != tt.format {
+			t.Errorf("test %d: format mismatch: have %s, want %s", i, path, tt.format)
+		}
+	}
+	for _, input := range []string{"m//0", "m/-1", "m/2147483648", "m/0''", "m/x"} {
+		if _, err := ParseDerivationPath(input); err == nil {
+			t.Errorf("invalid path %q accepted", input)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// MinEntropyBits is the minimum number of entropy bits encoded by a mnemonic.
+	MinEntropyBits = 128
+
+	// MaxEntropyBits is the maximum number of entropy bits encoded by a mnemonic.
+	MaxEntropyBits = 256
+
+	seedIterations = 2048 // PBKDF2 rounds used to stretch a mnemonic into a seed
+	seedLength     = 64   // Length in bytes of the seed derived from a mnemonic
+)
+
+var (
+	ErrInvalidEntropy   = errors.New("hd: entropy must be 128 to 256 bits in multiples of 32")
+	ErrMnemonicChecksum = errors.New("hd: mnemonic checksum mismatch")
+)
+
+// wordIndex maps the words of the word list to their index.
+var wordIndex = make(map[string]int)
+
+func init() {
+	for i, word := range englishWords {
+		wordIndex[word] = i
+	}
+}
+
+// checkEntropyBits ensures the given number of bits is valid BIP39 entropy.
+func checkEntropyBits(bits int) error {
+	if bits < MinEntropyBits || bits > MaxEntropyBits || bits%32 != 0 {
+		return ErrInvalidEntropy
+	}
+	return nil
+}
+
+// NewEntropy generates the given number of bits of cryptographically secure
+// random entropy to create a mnemonic from.
+func NewEntropy(bits int) ([]byte, error) {
+	if err := checkEntropyBits(bits); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+// NewMnemonic encodes the given entropy as a BIP39 mnemonic sentence. Every
+// word of the sentence encodes 11 bits of the entropy followed by a checksum
+// of one bit per 32 bits of entropy.
+func NewMnemonic(entropy []byte) (string, error) {
+	if err := checkEntropyBits(len(entropy) * 8); err != nil {
+		return "", err
+	}
+	// The checksum is at most 8 bits long, so the first hash byte suffices
+	hash := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), hash[0])
+
+	words := make([]string, (len(entropy)*8+len(entropy)/4)/11)
+	for i := range words {
+		index := 0
+		for j := 0; j < 11; j++ {
+			bit := i*11 + j
+			index = index<<1 | int(data[bit/8]>>uint(7-bit%8)&1)
+		}
+		words[i] = englishWords[index]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy validates a BIP39 mnemonic sentence and returns the entropy
+// encoded by it.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if bits := len(words) * 11 * 32 / 33; len(words)%3 != 0 || checkEntropyBits(bits) != nil {
+		return nil, fmt.Errorf("hd: invalid mnemonic length %d, want 12 to 24 words in multiples of 3", len(words))
+	}
+	data := make([]byte, (len(words)*11+7)/8)
+	for i, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("hd: unknown mnemonic word %q", word)
+		}
+		for j := 0; j < 11; j++ {
+			if index&(1<<uint(10-j)) != 0 {
+				bit := i*11 + j
+				data[bit/8] |= 1 << uint(7-bit%8)
+			}
+		}
+	}
+	// Split off the entropy and verify the checksum trailing it
+	size := len(words) * 11 * 32 / 33 / 8
+	entropy, checksum := data[:size], data[size]
+
+	hash := sha256.Sum256(entropy)
+	mask := byte(0xff) << uint(8-size/4)
+	if hash[0]&mask != checksum&mask {
+		return nil, ErrMnemonicChecksum
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic checks that the given sentence is a valid BIP39 mnemonic.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// NewSeed stretches a mnemonic sentence and an optional passphrase into the 64
+// byte BIP39 seed used to create a master key. The mnemonic is not validated.
+// Both the mnemonic and the passphrase are expected in Unicode NFKD form, which
+// is only of concern for passphrases outside of the ASCII range.
+func NewSeed(mnemonic, passphrase string) []byte {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), seedIterations, seedLength, sha512.New)
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+// Official BIP39 test vectors, all using the passphrase "TREZOR".
+var mnemonicTests = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		entropy:  "00000000000000000000000000000000",
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffff",
+		mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		seed:     "ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		entropy:  "808080808080808080808080808080808080808080808080",
+		mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter always",
+		seed:     "107d7c02a5aa6f38c58083ff74f04c607c2d2c0ecc55501dadd72d025b751bc27fe913ffb796f841c49b1d33b610cf0e91d3aa239027f5e99fe4ce9e5088cd65",
+	},
+	{
+		entropy:  "0000000000000000000000000000000000000000000000000000000000000000",
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		seed:     "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+	{
+		entropy:  "b63a9c59a6e641f288ebc103017f1da9f8290b3da6bdef7b",
+		mnemonic: "renew stay biology evidence goat welcome casual join adapt armor shuffle fault little machine walk stumble urge swap",
+		seed:     "9248d83e06f4cd98debf5b6f010542760df925ce46cf38a1bdb4e4de7d21f5c39366941c69e1bdbf2966e0f6e6dbece898a0e2f0a4c2b3e640953dfe8b7bbdc5",
+	},
+	{
+		entropy:  "3e141609b97933b66a060dcddc71fad1d91677db872031e85f4c015c5e7e8982",
+		mnemonic: "dignity pass list indicate nasty swamp pool script soccer toe leaf photo multiply desk host tomato cradle drill spread actor shine dismiss champion exotic",
+		seed:     "ff7f3184df8696d8bef94b6c03114dbee0ef89ff938712301d27ed8336ca89ef9635da20af07d4175f2bf5f3de130f39c9d9e8dd0472489c19b1a020a940da67",
+	},
+	{
+		entropy:  "18ab19a9f54a9274f03e5209a2ac8a91",
+		mnemonic: "board flee heavy tunnel powder denial science ski answer betray cargo cat",
+		seed:     "6eff1bb21562918509c73cb990260db07c0ce34ff0e3cc4a8cb3276129fbcb300bddfe005831350efd633909f476c45c88253276d9fd0df6ef48609e8bb7dca8",
+	},
+}
+
+func TestWordlist(t *testing.T) {
+	if len(englishWords) != 2048 {
+		t.Fatalf("word count mismatch: have %d, want 2048", len(englishWords))
+	}
+	if sum := crc32.ChecksumIEEE([]byte(english)); sum != 0xc1dbd296 {
+		t.Fatalf("word list checksum mismatch: have %08x, want c1dbd296", sum)
+	}
+}
+
+func TestMnemonicVectors(t *testing.T) {
+	for i, tt := range mnemonicTests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Errorf("test %d: failed to create mnemonic: %v", i, err)
+			continue
+		}
+		if mnemonic != tt.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch:\nhave %q\nwant %q", i, mnemonic, tt.mnemonic)
+		}
+		decoded, err := MnemonicToEntropy(tt.mnemonic)
+		if err != nil {
+			t.Errorf("test %d: failed to decode mnemonic: %v", i, err)
+		} else if !bytes.Equal(decoded, entropy) {
+			t.Errorf("test %d: entropy mismatch: have %x, want %x", i, decoded, entropy)
+		}
+		if seed := hex.EncodeToString(NewSeed(tt.mnemonic, "TREZOR")); seed != tt.seed {
+			t.Errorf("test %d: seed mismatch:\nhave %s\nwant %s", i, seed, tt.seed)
+		}
+	}
+}
+
+func TestMnemonicInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow yellow",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice caged above",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo, wrong",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+	}
+	for i, mnemonic := range tests {
+		if err := ValidateMnemonic(mnemonic); err == nil {
+			t.Errorf("test %d: invalid mnemonic %q accepted", i, mnemonic)
+		}
+	}
+	if _, err := NewMnemonic(make([]byte, 15)); err != ErrInvalidEntropy {
+		t.Errorf("short entropy error mismatch: have %v, want %v", err, ErrInvalidEntropy)
+	}
+}
+
+func TestMnemonicRoundTrip(t *testing.T) {
+	for bits := MinEntropyBits; bits <= MaxEntropyBits; bits += 32 {
+		entropy, err := NewEntropy(bits)
+		if err != nil {
+			t.Fatalf("%d bits: failed to generate entropy: %v", bits, err)
+		}
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("%d bits: failed to create mnemonic: %v", bits, err)
+		}
+		if words := len(strings.Fields(mnemonic)); words != bits*33/32/11 {
+			t.Errorf("%d bits: word count mismatch: have %d, want %d", bits, words, bits*33/32/11)
+		}
+		// Extra whitespace must not affect validation nor the seed
+		spaced := "  " + strings.Replace(mnemonic, " ", "\t ", -1) + "\n"
+		decoded, err := MnemonicToEntropy(spaced)
+		if err != nil {
+			t.Fatalf("%d bits: failed to decode mnemonic: %v", bits, err)
+		}
+		if !bytes.Equal(decoded, entropy) {
+			t.Errorf("%d bits: entropy mismatch: have %x, want %x", bits, decoded, entropy)
+		}
+		if !bytes.Equal(NewSeed(spaced, ""), NewSeed(mnemonic, "")) {
+			t.Errorf("%d bits: whitespace changed the seed", bits)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HardenedKeyStart is the index of the first hardened child key. Hardened keys
+// can only be derived from a private parent key.
+const HardenedKeyStart = 0x80000000
+
+// DefaultBaseDerivationPath is the BIP44 path (purpose 44', coin type 60') below
+// which Ethereum accounts are derived. Account i is child i of this path.
+var DefaultBaseDerivationPath = DerivationPath{HardenedKeyStart + 44, HardenedKeyStart + 60, HardenedKeyStart + 0, 0}
+
+// DerivationPath is a list of child indexes leading from a master key down to
+// one of its descendant keys.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path in the usual textual notation, for example
+// "m/44'/60'/0'/0". Hardened indexes are marked by a trailing ' or h, and the
+// leading "m" for the master key is optional.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	components := strings.Split(strings.TrimSpace(path), "/")
+	if len(components) > 0 && components[0] == "m" {
+		components = components[1:]
+	}
+	if len(components) == 0 || (len(components) == 1 && components[0] == "") {
+		return DerivationPath{}, nil
+	}
+	result := make(DerivationPath, 0, len(components))
+	for _, component := range components {
+		component = strings.TrimSpace(component)
+		if component == "" {
+			return nil, errors.New("hd: empty derivation path component")
+		}
+		var offset uint32
+		if strings.HasSuffix(component, "'") || strings.HasSuffix(component, "h") {
+			offset = HardenedKeyStart
+			component = component[:len(component)-1]
+		}
+		index, err := strconv.ParseUint(component, 10, 32)
+		if err != nil || index >= HardenedKeyStart {
+			return nil, fmt.Errorf("hd: invalid derivation path component %q", component)
+		}
+		result = append(result, uint32(index)+offset)
+	}
+	return result, nil
+}
+
+// Child returns a copy of the path extended with the given child index.
+func (path DerivationPath) Child(index uint32) DerivationPath {
+	child := make(DerivationPath, len(path), len(path)+1)
+	copy(child, path)
+	return append(child, index)
+}
+
+// String implements fmt.Stringer, returning the path in the same notation that
+// ParseDerivationPath accepts.
+func (path DerivationPath) String() string {
+	result := "m"
+	for _, index := range path {
+		result += "/"
+		if index >= HardenedKeyStart {
+			result += strconv.FormatUint(uint64(index-HardenedKeyStart), 10) + "'"
+		} else {
+			result += strconv.FormatUint(uint64(index), 10)
+		}
+	}
+	return result
+}
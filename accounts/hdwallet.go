@@ -0,0 +1,248 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/hd"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pborman/uuid"
+)
+
+// hdEntropyBits is the entropy of the mnemonics generated for new HD wallets,
+// resulting in 24 word sentences.
+const hdEntropyBits = 256
+
+var (
+	ErrHDUnsupported = errors.New("HD wallets require an encrypted key store")
+	ErrNotHDWallet   = errors.New("account is not part of an HD wallet")
+	ErrHDDelete      = errors.New("accounts of an HD wallet can't be deleted, they share the wallet file with its seed")
+)
+
+// hdWalletJSON is the on-disk format of an HD wallet. A single file holds the
+// encrypted seed and all accounts derived from it so far. Accounts are children
+// of the base derivation path, whose public extended key is stored in the clear
+// so that new accounts can be derived without the passphrase.
+type hdWalletJSON struct {
+	Accounts []hdAccountJSON `json:"accounts"`
+	Path     string          `json:"path"`
+	XPub     string          `json:"xpub"`
+	Crypto   cryptoJSON      `json:"crypto"`
+	Id       string          `json:"id"`
+	Version  int             `json:"version"`
+}
+
+type hdAccountJSON struct {
+	Address common.Address `json:"address"`
+	Index   uint32         `json:"index"`
+}
+
+// isHDWallet reports whether keyjson holds an HD wallet rather than a single key.
+func isHDWallet(keyjson []byte) bool {
+	var w struct {
+		XPub string `json:"xpub"`
+	}
+	return json.Unmarshal(keyjson, &w) == nil && w.XPub != ""
+}
+
+// hdWalletFileName implements the naming convention for HD wallet files:
+// UTC--<created_at UTC ISO8601>--hd-<first address hex>
+func hdWalletFileName(addr common.Address) string {
+	ts := time.Now().UTC()
+	return fmt.Sprintf("UTC--%s--hd-%s", toISO8601(ts), hex.EncodeToString(addr[:]))
+}
+
+// deriveHDAccount derives the first valid account at or after index below the
+// given base key. The private key is not needed, base may be public.
+func deriveHDAccount(base *hd.ExtendedKey, index uint32) (hdAccountJSON, error) {
+	for ; index < hd.HardenedKeyStart; index++ {
+		child, err := base.Child(index)
+		if err == hd.ErrInvalidChild {
+			continue
+		}
+		if err != nil {
+			return hdAccountJSON{}, err
+		}
+		return hdAccountJSON{Address: crypto.PubkeyToAddress(*child.ToECDSAPub()), Index: index}, nil
+	}
+	return hdAccountJSON{}, errors.New("HD wallet has no more accounts to derive")
+}
+
+// storeHDWallet creates an HD wallet from seed with its first account derived,
+// encrypts it with auth and writes it to the key directory.
+func (ks keyStorePassphrase) storeHDWallet(seed []byte, auth string) (Account, error) {
+	master, err := hd.NewMaster(seed)
+	if err != nil {
+		return Account{}, err
+	}
+	base, err := master.Derive(hd.DefaultBaseDerivationPath)
+	if err != nil {
+		return Account{}, err
+	}
+	account, err := deriveHDAccount(base, 0)
+	if err != nil {
+		return Account{}, err
+	}
+	cryptoStruct, err := encryptData(seed, auth, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return Account{}, err
+	}
+	keyjson, err := json.Marshal(hdWalletJSON{
+		Accounts: []hdAccountJSON{account},
+		Path:     hd.DefaultBaseDerivationPath.String(),
+		XPub:     base.Neuter().String(),
+		Crypto:   cryptoStruct,
+		Id:       uuid.NewRandom().String(),
+		Version:  version,
+	})
+	if err != nil {
+		return Account{}, err
+	}
+	a := Account{Address: account.Address, File: ks.JoinPath(hdWalletFileName(account.Address))}
+	return a, writeKeyFile(a.File, keyjson)
+}
+
+// appendHDAccount derives the next account of the HD wallet stored in filename
+// and adds it to the file. Only the public extended key is used, so no
+// passphrase is needed.
+func (ks keyStorePassphrase) appendHDAccount(filename string) (Account, error) {
+	keyjson, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Account{}, err
+	}
+	if !isHDWallet(keyjson) {
+		return Account{}, ErrNotHDWallet
+	}
+	w := new(hdWalletJSON)
+	if err := json.Unmarshal(keyjson, w); err != nil {
+		return Account{}, err
+	}
+	base, err := hd.ParseExtendedKey(w.XPub)
+	if err != nil {
+		return Account{}, err
+	}
+	var next uint32
+	for _, account := range w.Accounts {
+		if account.Index >= next {
+			next = account.Index + 1
+		}
+	}
+	account, err := deriveHDAccount(base, next)
+	if err != nil {
+		return Account{}, err
+	}
+	w.Accounts = append(w.Accounts, account)
+	if keyjson, err = json.Marshal(w); err != nil {
+		return Account{}, err
+	}
+	return Account{Address: account.Address, File: filename}, writeKeyFile(filename, keyjson)
+}
+
+// updateHDWallet re-encrypts the seed of the HD wallet stored in filename with
+// a new passphrase, keeping all derived accounts.
+func (ks keyStorePassphrase) updateHDWallet(filename, auth, newAuth string) error {
+	keyjson, err := ks.reencryptHDWallet(filename, auth, newAuth)
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(filename, keyjson)
+}
+
+// reencryptHDWallet decrypts the seed of the HD wallet stored in filename and
+// returns the wallet JSON with the seed encrypted using newAuth and the scrypt
+// parameters of the key store.
+func (ks keyStorePassphrase) reencryptHDWallet(filename, auth, newAuth string) ([]byte, error) {
+	keyjson, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	w := new(hdWalletJSON)
+	if err := json.Unmarshal(keyjson, w); err != nil {
+		return nil, err
+	}
+	seed, err := decryptData(w.Crypto, auth)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	if w.Crypto, err = encryptData(seed, newAuth, ks.scryptN, ks.scryptP); err != nil {
+		return nil, err
+	}
+	return json.Marshal(w)
+}
+
+// decryptHDKey decrypts the seed of an HD wallet and derives the private key
+// of the account with the given address.
+func decryptHDKey(keyjson []byte, addr common.Address, auth string) (*Key, error) {
+	w := new(hdWalletJSON)
+	if err := json.Unmarshal(keyjson, w); err != nil {
+		return nil, err
+	}
+	var account *hdAccountJSON
+	for i := range w.Accounts {
+		if w.Accounts[i].Address == addr {
+			account = &w.Accounts[i]
+			break
+		}
+	}
+	if account == nil {
+		return nil, fmt.Errorf("key content mismatch: HD wallet has no account %x", addr)
+	}
+	path, err := hd.ParseDerivationPath(w.Path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := decryptData(w.Crypto, auth)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	master, err := hd.NewMaster(seed)
+	if err != nil {
+		return nil, err
+	}
+	child, err := master.Derive(path.Child(account.Index))
+	if err != nil {
+		return nil, err
+	}
+	key, err := child.ToECDSA()
+	if err != nil {
+		return nil, err
+	}
+	// Make sure we're really operating on the requested key (no swap attacks)
+	if derived := crypto.PubkeyToAddress(key.PublicKey); derived != addr {
+		zeroKey(key)
+		return nil, fmt.Errorf("key content mismatch: have account %x, want %x", derived, addr)
+	}
+	return &Key{Id: uuid.Parse(w.Id), Address: addr, PrivateKey: key}, nil
+}
+
+// zeroBytes zeroes a byte slice holding secret data.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Key file formats reported by InspectKeyFile.
+const (
+	FormatPlain = "plain" // Unencrypted key, as written by NewPlaintextManager
+	FormatV1    = "v1"    // Encrypted key of the deprecated version 1 format
+	FormatV3    = "v3"    // Encrypted key of the current Web3 Secret Storage format
+	FormatHD    = "hd"    // Encrypted seed of an HD wallet
+)
+
+// KeyFileInfo describes the format and encryption parameters of a key file.
+type KeyFileInfo struct {
+	File    string
+	Address common.Address // Address of the key, or of the first account of an HD wallet
+	Format  string         // One of the Format* constants
+	Cipher  string         // Symmetric cipher, empty for plain keys
+	KDF     string         // Key derivation function, empty for plain keys
+	ScryptN int            // Scrypt CPU/memory cost, zero unless the KDF is scrypt
+	ScryptP int            // Scrypt parallelization, zero unless the KDF is scrypt
+}
+
+// Outdated reports whether the key file would benefit from being migrated to
+// the current format encrypted with the given scrypt parameters, i.e. whether
+// it is unencrypted, of a deprecated format or protected by a weaker KDF.
+func (info *KeyFileInfo) Outdated(scryptN, scryptP int) bool {
+	if info.Format != FormatV3 && info.Format != FormatHD {
+		return true
+	}
+	if info.KDF != keyHeaderKDF || info.Cipher != "aes-128-ctr" {
+		return true
+	}
+	return info.ScryptN*info.ScryptP < scryptN*scryptP
+}
+
+// String implements fmt.Stringer.
+func (info *KeyFileInfo) String() string {
+	switch {
+	case info.Format == FormatPlain:
+		return "plain, unencrypted"
+	case info.KDF == keyHeaderKDF:
+		return fmt.Sprintf("%s, %s, %s n=%d p=%d", info.Format, info.Cipher, info.KDF, info.ScryptN, info.ScryptP)
+	default:
+		return fmt.Sprintf("%s, %s, %s", info.Format, info.Cipher, info.KDF)
+	}
+}
+
+// InspectKeyFile determines the format and encryption parameters of a key file
+// without decrypting it.
+func InspectKeyFile(file string) (*KeyFileInfo, error) {
+	keyjson, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var k struct {
+		Address    string          `json:"address"`
+		PrivateKey string          `json:"privatekey"`
+		Accounts   []hdAccountJSON `json:"accounts"`
+		XPub       string          `json:"xpub"`
+		Crypto     *cryptoJSON     `json:"crypto"`
+		Version    interface{}     `json:"version"`
+	}
+	if err := json.Unmarshal(keyjson, &k); err != nil {
+		return nil, err
+	}
+	info := &KeyFileInfo{File: file, Address: common.HexToAddress(k.Address)}
+	switch {
+	case k.XPub != "":
+		info.Format = FormatHD
+		if len(k.Accounts) > 0 {
+			info.Address = k.Accounts[0].Address
+		}
+	case k.PrivateKey != "":
+		info.Format = FormatPlain
+		return info, nil
+	case k.Version == "1":
+		info.Format = FormatV1
+	case k.Version == float64(version):
+		info.Format = FormatV3
+	default:
+		return nil, fmt.Errorf("unknown key file version %v", k.Version)
+	}
+	if k.Crypto == nil {
+		return nil, errors.New("key file lacks crypto section")
+	}
+	info.Cipher, info.KDF = k.Crypto.Cipher, k.Crypto.KDF
+	if info.Format == FormatV1 {
+		// Version 1 files name the cipher but encrypt with CBC, see decryptKeyV1
+		info.Cipher = "aes-128-cbc"
+	}
+	if info.KDF == keyHeaderKDF {
+		if n, ok := k.Crypto.KDFParams["n"].(float64); ok {
+			info.ScryptN = int(n)
+		}
+		if p, ok := k.Crypto.KDFParams["p"].(float64); ok {
+			info.ScryptP = int(p)
+		}
+	}
+	return info, nil
+}
+
+// Migrate re-encrypts the key file holding the given account in the current
+// format, using the scrypt parameters of the manager and the given passphrase.
+// Unencrypted keys ignore the passphrase when being read, it only protects the
+// migrated file. The original file is copied into backupDir before it is
+// replaced, and the replacement itself is atomic.
+func (am *Manager) Migrate(a Account, passphrase, backupDir string) error {
+	ks, ok := am.keyStore.(*keyStorePassphrase)
+	if !ok {
+		return errors.New("key files can only be migrated into an encrypted key store")
+	}
+	am.cache.maybeReload()
+	am.cache.mu.Lock()
+	a, err := am.cache.find(a)
+	am.cache.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	info, err := InspectKeyFile(a.File)
+	if err != nil {
+		return err
+	}
+	// Decrypt the secret and encrypt it with the new parameters
+	var keyjson []byte
+	switch info.Format {
+	case FormatHD:
+		if keyjson, err = ks.reencryptHDWallet(a.File, passphrase, passphrase); err != nil {
+			return err
+		}
+	default:
+		var key *Key
+		if info.Format == FormatPlain {
+			key, err = keyStorePlain{}.GetKey(a.Address, a.File, "")
+		} else {
+			key, err = ks.GetKey(a.Address, a.File, passphrase)
+		}
+		if err != nil {
+			return err
+		}
+		keyjson, err = EncryptKey(key, passphrase, ks.scryptN, ks.scryptP)
+		zeroKey(key.PrivateKey)
+		if err != nil {
+			return err
+		}
+	}
+	if err := backupKeyFile(a.File, backupDir); err != nil {
+		return fmt.Errorf("backup failed: %v", err)
+	}
+	return writeKeyFile(a.File, keyjson)
+}
+
+// backupKeyFile copies a key file into the backup directory, refusing to
+// overwrite an earlier backup of the same name.
+func backupKeyFile(file, backupDir string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return err
+	}
+	backup, err := os.OpenFile(filepath.Join(backupDir, filepath.Base(file)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := backup.Write(content); err != nil {
+		backup.Close()
+		return err
+	}
+	return backup.Close()
+}
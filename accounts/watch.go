@@ -102,6 +102,7 @@ func (w *watcher) loop() {
 			w.ac.mu.Lock()
 			w.ac.reload()
 			w.ac.mu.Unlock()
+			w.ac.notify()
 			if hadEvent {
 				debounce.Reset(debounceDuration)
 				inCycle, hadEvent = true, false
@@ -0,0 +1,53 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// asm assembles EVM mnemonics into hex encoded bytecode. The output can be fed
+// back into disasm or passed to evm --code.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/asm"
+)
+
+func main() {
+	var (
+		src []byte
+		err error
+	)
+	switch len(os.Args) {
+	case 1:
+		src, err = ioutil.ReadAll(os.Stdin)
+	case 2:
+		src, err = ioutil.ReadFile(os.Args[1])
+	default:
+		fmt.Fprintln(os.Stderr, "usage: asm [file]")
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code, err := asm.Compile(src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%x\n", code)
+}
@@ -19,6 +19,8 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"time"
 
 	"github.com/codegangsta/cli"
 	"github.com/ethereum/go-ethereum/accounts"
@@ -29,6 +31,22 @@ import (
 )
 
 var (
+	accountMigrateAllFlag = cli.BoolFlag{
+		Name:  "all",
+		Usage: "Migrate all outdated key files",
+	}
+	accountMigrateBackupFlag = cli.StringFlag{
+		Name:  "backup",
+		Usage: "Directory to back up the original key files into (default: timestamped directory in <keydir>/.backup)",
+	}
+	accountMigrateScryptNFlag = cli.IntFlag{
+		Name:  "scryptn",
+		Usage: "Scrypt CPU/memory cost of the migrated key files (default: set by --lightkdf)",
+	}
+	accountMigrateScryptPFlag = cli.IntFlag{
+		Name:  "scryptp",
+		Usage: "Scrypt parallelization of the migrated key files (default: set by --lightkdf)",
+	}
 	walletCommand = cli.Command{
 		Name:  "wallet",
 		Usage: "ethereum presale wallet",
@@ -162,6 +180,42 @@ this import mechanism is not needed when you transfer an account between
 nodes.
 					`,
 			},
+			{
+				Action: accountMigrate,
+				Name:   "migrate",
+				Usage:  "inspect key files and re-encrypt them with new parameters",
+				Description: `
+
+    ethereum account migrate [--all] [<address|index> ...]
+
+Reports the format, cipher and key derivation parameters of every key file in
+the keystore, marking the ones which are unencrypted, of a deprecated format or
+protected by weaker scrypt parameters than requested as outdated.
+
+The key files of the given accounts, or of all outdated accounts if --all is
+set, are then re-encrypted in the newest format. You are prompted for the
+passphrase of each key file, which also protects the migrated file. Unencrypted
+key files are protected with a new passphrase instead.
+
+The scrypt parameters default to the ones used for new accounts, honoring the
+--lightkdf flag, and can be overridden with --scryptn and --scryptp.
+
+Every key file is copied into the backup directory before being replaced, and
+the replacement itself is atomic, so an interrupted migration never loses a key.
+Once you verified that the migrated accounts can be unlocked, delete the backup:
+it holds your keys protected by their old parameters.
+
+For non-interactive use the passphrases can be specified with the --password flag:
+
+    ethereum --password <passwordfile> account migrate --all
+					`,
+				Flags: []cli.Flag{
+					accountMigrateAllFlag,
+					accountMigrateBackupFlag,
+					accountMigrateScryptNFlag,
+					accountMigrateScryptPFlag,
+				},
+			},
 		},
 	}
 )
@@ -284,6 +338,96 @@ func accountUpdate(ctx *cli.Context) {
 	}
 }
 
+// accountMigrate reports the format of all key files in the keystore and
+// re-encrypts the requested ones with the configured scrypt parameters.
+func accountMigrate(ctx *cli.Context) {
+	scryptN, scryptP := accounts.StandardScryptN, accounts.StandardScryptP
+	if ctx.GlobalBool(utils.LightKDFFlag.Name) {
+		scryptN, scryptP = accounts.LightScryptN, accounts.LightScryptP
+	}
+	if n := ctx.Int(accountMigrateScryptNFlag.Name); n != 0 {
+		scryptN = n
+	}
+	if p := ctx.Int(accountMigrateScryptPFlag.Name); p != 0 {
+		scryptP = p
+	}
+	keydir := utils.MakeKeyStoreDir(utils.MustMakeDataDir(ctx), ctx)
+	accman := accounts.NewManager(keydir, scryptN, scryptP)
+
+	// Report every key file, gathering the outdated ones along the way
+	var (
+		all      = accman.Accounts()
+		outdated []accounts.Account
+	)
+	for i, acct := range all {
+		info, err := accounts.InspectKeyFile(acct.File)
+		if err != nil {
+			fmt.Printf("Account #%d: {%x} %s: %v\n", i, acct.Address, acct.File, err)
+			continue
+		}
+		status := ""
+		if info.Outdated(scryptN, scryptP) {
+			outdated = append(outdated, acct)
+			status = " (outdated)"
+		}
+		fmt.Printf("Account #%d: {%x} %s: %s%s\n", i, acct.Address, acct.File, info, status)
+	}
+	// Resolve the accounts to migrate, skipping repeated files of HD wallets
+	var selected []accounts.Account
+	if ctx.Bool(accountMigrateAllFlag.Name) {
+		selected = outdated
+	}
+	for _, arg := range ctx.Args() {
+		acct, err := utils.MakeAddress(accman, arg)
+		if err != nil {
+			utils.Fatalf("Could not resolve account %s: %v", arg, err)
+		}
+		if acct.File == "" {
+			for _, known := range all {
+				if known.Address == acct.Address {
+					acct = known
+					break
+				}
+			}
+		}
+		if acct.File == "" {
+			utils.Fatalf("Unknown account %x", acct.Address)
+		}
+		selected = append(selected, acct)
+	}
+	if len(selected) == 0 {
+		return
+	}
+	backup := ctx.String(accountMigrateBackupFlag.Name)
+	if backup == "" {
+		backup = filepath.Join(keydir, ".backup", time.Now().UTC().Format("2006-01-02T15-04-05"))
+	}
+	passwords := utils.MakePasswordList(ctx)
+	migrated := make(map[string]bool)
+
+	for i, acct := range selected {
+		if migrated[acct.File] {
+			continue
+		}
+		info, err := accounts.InspectKeyFile(acct.File)
+		if err != nil {
+			utils.Fatalf("Could not inspect key file %s: %v", acct.File, err)
+		}
+		var password string
+		if info.Format == accounts.FormatPlain {
+			password = getPassPhrase(fmt.Sprintf("Account %x is unencrypted. Please give a password. Do not forget this password.", acct.Address), true, i, passwords)
+		} else {
+			password = getPassPhrase(fmt.Sprintf("Migrating account %x", acct.Address), false, i, passwords)
+		}
+		if err := accman.Migrate(acct, password, backup); err != nil {
+			utils.Fatalf("Could not migrate account %x: %v", acct.Address, err)
+		}
+		migrated[acct.File] = true
+		fmt.Printf("Migrated account {%x} %s\n", acct.Address, acct.File)
+	}
+	fmt.Printf("Original key files backed up to %s\n", backup)
+}
+
 func importWallet(ctx *cli.Context) {
 	keyfile := ctx.Args().First()
 	if len(keyfile) == 0 {
@@ -167,6 +167,7 @@ JavaScript API. See https://github.com/ethereum/go-ethereum/wiki/Javascipt-Conso
 		utils.FastSyncFlag,
 		utils.CacheFlag,
 		utils.LightKDFFlag,
+		utils.SignerFlag,
 		utils.JSpathFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
@@ -176,6 +177,8 @@ JavaScript API. See https://github.com/ethereum/go-ethereum/wiki/Javascipt-Conso
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
 		utils.MiningGPUFlag,
+		utils.StratumAddrFlag,
+		utils.StratumDifficultyFlag,
 		utils.AutoDAGFlag,
 		utils.TargetGasLimitFlag,
 		utils.NATFlag,
@@ -198,17 +201,24 @@ JavaScript API. See https://github.com/ethereum/go-ethereum/wiki/Javascipt-Conso
 		utils.ExecFlag,
 		utils.PreLoadJSFlag,
 		utils.WhisperEnabledFlag,
+		utils.WhisperVersionFlag,
+		utils.WhisperMinPoWFlag,
+		utils.WhisperTopicFilteringFlag,
+		utils.WhisperMailServerFlag,
+		utils.WhisperMailServerPasswordFileFlag,
 		utils.SwarmConfigPathFlag,
 		utils.SwarmSwapDisabled,
 		utils.SwarmSyncDisabled,
 		utils.SwarmPortFlag,
 		utils.SwarmAccountAddrFlag,
 		utils.ChequebookAddrFlag,
+		utils.EnsRootFlag,
 		utils.DevModeFlag,
 		utils.TestNetFlag,
 		utils.VMForceJitFlag,
 		utils.VMJitCacheFlag,
 		utils.VMEnableJitFlag,
+		utils.VMProfileFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
 		utils.MetricsEnabledFlag,
@@ -440,6 +450,11 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		if err := ethereum.StartMining(ctx.GlobalInt(utils.MinerThreadsFlag.Name), ctx.GlobalString(utils.MiningGPUFlag.Name)); err != nil {
 			utils.Fatalf("Failed to start mining: %v", err)
 		}
+	} else if ctx.GlobalString(utils.StratumAddrFlag.Name) != "" {
+		// Mining rigs connected over stratum need work even without local miners
+		if err := ethereum.StartMining(0, ""); err != nil {
+			utils.Fatalf("Failed to start mining for stratum: %v", err)
+		}
 	}
 }
 
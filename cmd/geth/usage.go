@@ -72,6 +72,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IdentityFlag,
 			utils.FastSyncFlag,
 			utils.LightKDFFlag,
+			utils.SignerFlag,
 			utils.CacheFlag,
 			utils.BlockchainVersionFlag,
 		},
@@ -128,6 +129,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.StratumAddrFlag,
+			utils.StratumDifficultyFlag,
 		},
 	},
 	{
@@ -147,6 +150,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.VMEnableJitFlag,
 			utils.VMForceJitFlag,
 			utils.VMJitCacheFlag,
+			utils.VMProfileFlag,
 		},
 	},
 	{
@@ -160,6 +164,11 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "EXPERIMENTAL",
 		Flags: []cli.Flag{
 			utils.WhisperEnabledFlag,
+			utils.WhisperVersionFlag,
+			utils.WhisperMinPoWFlag,
+			utils.WhisperTopicFilteringFlag,
+			utils.WhisperMailServerFlag,
+			utils.WhisperMailServerPasswordFileFlag,
 			utils.NatspecEnabledFlag,
 		},
 	},
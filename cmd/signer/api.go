@@ -0,0 +1,134 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// SignerAPI is the "account" RPC namespace served by the signer, as expected by
+// the external accounts backend of geth.
+type SignerAPI struct {
+	am    *accounts.Manager
+	rules *rules
+	audit *auditLog
+	now   func() time.Time // Clock of the rate limiter, replaceable in tests
+}
+
+// newSignerAPI creates the signing API on top of an account manager whose
+// accounts were unlocked at startup.
+func newSignerAPI(am *accounts.Manager, rules *rules, audit *auditLog) *SignerAPI {
+	return &SignerAPI{am: am, rules: rules, audit: audit, now: time.Now}
+}
+
+// List returns the addresses of all accounts in the keystore of the signer.
+func (api *SignerAPI) List() []common.Address {
+	accounts := api.am.Accounts()
+	addrs := make([]common.Address, len(accounts))
+	for i, a := range accounts {
+		addrs[i] = a.Address
+	}
+	return addrs
+}
+
+// SignHash signs an arbitrary hash with the given account, if the rules allow
+// the signing of opaque data.
+func (api *SignerAPI) SignHash(addr common.Address, hash string) (string, error) {
+	entry := &auditEntry{
+		Time:   api.now(),
+		Method: "account_signHash",
+		From:   addr,
+		Hash:   common.BytesToHash(common.FromHex(hash)),
+	}
+	if len(common.FromHex(hash)) != common.HashLength {
+		return "", api.reject(entry, errors.New("invalid hash length"))
+	}
+	if err := api.rules.approveHash(addr, entry.Time); err != nil {
+		return "", api.reject(entry, err)
+	}
+	signature, err := api.am.Sign(addr, entry.Hash[:])
+	if err != nil {
+		return "", api.reject(entry, err)
+	}
+	if err := api.approve(entry); err != nil {
+		return "", err
+	}
+	return common.ToHex(signature), nil
+}
+
+// SignTransaction signs an RLP encoded transaction with the given account if it
+// satisfies the rules, returning the signed transaction in RLP encoding.
+func (api *SignerAPI) SignTransaction(addr common.Address, rawTx string) (string, error) {
+	entry := &auditEntry{
+		Time:   api.now(),
+		Method: "account_signTransaction",
+		From:   addr,
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(rawTx), tx); err != nil {
+		return "", api.reject(entry, fmt.Errorf("invalid transaction: %v", err))
+	}
+	nonce := tx.Nonce()
+	entry.To, entry.Value, entry.Nonce, entry.Hash = tx.To(), tx.Value(), &nonce, tx.SigHash()
+
+	if err := api.rules.approveTx(addr, tx, entry.Time); err != nil {
+		return "", api.reject(entry, err)
+	}
+	signature, err := api.am.Sign(addr, entry.Hash[:])
+	if err != nil {
+		return "", api.reject(entry, err)
+	}
+	signed, err := tx.WithSignature(signature)
+	if err != nil {
+		return "", api.reject(entry, err)
+	}
+	data, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return "", api.reject(entry, err)
+	}
+	if err := api.approve(entry); err != nil {
+		return "", err
+	}
+	return common.ToHex(data), nil
+}
+
+// approve records a successfully signed request in the audit log. If logging
+// fails, the signature is withheld.
+func (api *SignerAPI) approve(entry *auditEntry) error {
+	entry.Approved = true
+	if err := api.audit.record(entry); err != nil {
+		return fmt.Errorf("request denied: audit log failed: %v", err)
+	}
+	return nil
+}
+
+// reject records a refused request in the audit log and returns the reason to
+// be reported to the requester.
+func (api *SignerAPI) reject(entry *auditEntry, reason error) error {
+	entry.Reason = reason.Error()
+	if err := api.audit.record(entry); err != nil {
+		return fmt.Errorf("request denied: audit log failed: %v", err)
+	}
+	return fmt.Errorf("request denied: %v", reason)
+}
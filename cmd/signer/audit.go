@@ -0,0 +1,78 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// auditEntry is a single line of the audit log, recording one signing request
+// and the decision taken on it.
+type auditEntry struct {
+	Time     time.Time       `json:"time"`
+	Method   string          `json:"method"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to,omitempty"`
+	Value    *big.Int        `json:"value,omitempty"`
+	Nonce    *uint64         `json:"nonce,omitempty"`
+	Hash     common.Hash     `json:"hash"`
+	Approved bool            `json:"approved"`
+	Reason   string          `json:"reason,omitempty"`
+}
+
+// auditLog appends an entry for every request received by the signer to a file,
+// one JSON object per line.
+type auditLog struct {
+	out  io.WriteCloser
+	lock sync.Mutex
+}
+
+// openAuditLog opens the audit log at the given path, appending to it if it
+// already exists.
+func openAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{out: file}, nil
+}
+
+// record writes an entry to the log. Requests are never answered unless they
+// were logged, so any error is returned to refuse the request.
+func (l *auditLog) record(entry *auditEntry) error {
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	_, err = l.out.Write(append(blob, '\n'))
+	return err
+}
+
+// close closes the underlying log file.
+func (l *auditLog) close() error {
+	return l.out.Close()
+}
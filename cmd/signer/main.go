@@ -0,0 +1,165 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// signer is a standalone transaction signer. It owns a keystore and answers the
+// signing requests of geth (started with --signer) according to configurable
+// rules, so that no decrypted key ever resides in the node process. Every
+// request and the decision taken on it is recorded in an audit log.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func main() {
+	var (
+		keydir    = flag.String("keystore", "", "directory of the keystore to sign with")
+		lightKDF  = flag.Bool("lightkdf", false, "keystore uses the light scrypt parameters")
+		unlock    = flag.String("unlock", "", "comma separated list of accounts (addresses or indexes) to unlock, all if empty")
+		passfile  = flag.String("password", "", "password file, one password per line for the unlocked accounts")
+		rulesFile = flag.String("rules", "", "JSON file with the signing rules")
+		auditFile = flag.String("audit", "signer-audit.log", "file to append the audit log to")
+		ipcPath   = flag.String("ipcpath", "signer.ipc", "IPC endpoint to serve the signing API on")
+		httpAddr  = flag.String("http", "", "additionally serve the signing API over HTTP on this address (e.g. 127.0.0.1:8550)")
+	)
+	flag.Var(glog.GetVerbosity(), "verbosity", "log verbosity (0-9)")
+	flag.Var(glog.GetVModule(), "vmodule", "log verbosity pattern")
+	glog.SetToStderr(true)
+	flag.Parse()
+
+	if *keydir == "" {
+		utils.Fatalf("Use -keystore to specify the keystore directory")
+	}
+	if *rulesFile == "" {
+		utils.Fatalf("Use -rules to specify the signing rules")
+	}
+	rules, err := loadRules(*rulesFile)
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	audit, err := openAuditLog(*auditFile)
+	if err != nil {
+		utils.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer audit.close()
+
+	// Open the keystore and unlock the accounts to sign with
+	scryptN, scryptP := accounts.StandardScryptN, accounts.StandardScryptP
+	if *lightKDF {
+		scryptN, scryptP = accounts.LightScryptN, accounts.LightScryptP
+	}
+	am := accounts.NewManager(*keydir, scryptN, scryptP)
+	if err := unlockAccounts(am, *unlock, *passfile); err != nil {
+		utils.Fatalf("%v", err)
+	}
+	// Serve the signing API until interrupted
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", newSignerAPI(am, rules, audit)); err != nil {
+		utils.Fatalf("Failed to register signing API: %v", err)
+	}
+	listener, err := rpc.CreateIPCListener(*ipcPath)
+	if err != nil {
+		utils.Fatalf("Failed to open IPC endpoint: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeCodec(rpc.NewJSONCodec(conn), rpc.OptionMethodInvocation)
+		}
+	}()
+	glog.V(logger.Info).Infof("IPC endpoint opened: %s", *ipcPath)
+
+	if *httpAddr != "" {
+		listener, err := net.Listen("tcp", *httpAddr)
+		if err != nil {
+			utils.Fatalf("Failed to open HTTP endpoint: %v", err)
+		}
+		defer listener.Close()
+		go rpc.NewHTTPServer("", server).Serve(listener)
+		glog.V(logger.Info).Infof("HTTP endpoint opened: http://%s", *httpAddr)
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	<-sigc
+	glog.V(logger.Info).Infoln("Got interrupt, shutting down...")
+	server.Stop()
+}
+
+// unlockAccounts unlocks the requested accounts of the keystore, reading their
+// passwords from the password file or prompting for them.
+func unlockAccounts(am *accounts.Manager, unlock, passfile string) error {
+	var passwords []string
+	if passfile != "" {
+		blob, err := ioutil.ReadFile(passfile)
+		if err != nil {
+			return fmt.Errorf("Failed to read password file: %v", err)
+		}
+		for _, line := range strings.Split(string(blob), "\n") {
+			passwords = append(passwords, strings.TrimRight(line, "\r"))
+		}
+	}
+	var selected []accounts.Account
+	if unlock == "" {
+		selected = am.Accounts()
+	} else {
+		for _, id := range strings.Split(unlock, ",") {
+			account, err := utils.MakeAddress(am, strings.TrimSpace(id))
+			if err != nil {
+				return err
+			}
+			selected = append(selected, account)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("No accounts to sign with in the keystore")
+	}
+	for i, account := range selected {
+		var password string
+		switch {
+		case len(passwords) == 0:
+			prompt := fmt.Sprintf("Passphrase for account %s: ", account.Address.Hex())
+			var err error
+			if password, err = utils.Stdin.PasswordPrompt(prompt); err != nil {
+				return fmt.Errorf("Failed to read passphrase: %v", err)
+			}
+		case i < len(passwords):
+			password = passwords[i]
+		default:
+			password = passwords[len(passwords)-1]
+		}
+		if err := am.Unlock(account, password); err != nil {
+			return fmt.Errorf("Failed to unlock account %s: %v", account.Address.Hex(), err)
+		}
+		glog.V(logger.Info).Infof("Unlocked account %s", account.Address.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultRatePeriod is the rate limiting window if the rules don't specify one.
+const defaultRatePeriod = time.Hour
+
+// rules is the signing policy of the signer, loaded from a JSON file such as
+//
+//	{
+//	  "recipients": ["0x8a3d...", "0x19f2..."],
+//	  "allowContractCreation": false,
+//	  "maxValue": 1000000000000000000,
+//	  "rateLimit": 10,
+//	  "ratePeriod": "1h",
+//	  "allowHashSigning": false
+//	}
+//
+// A transaction is only signed if it satisfies all of the configured rules.
+type rules struct {
+	Recipients    []common.Address `json:"recipients"`            // Allowed recipients, any if empty
+	AllowCreation bool             `json:"allowContractCreation"` // Whether contracts may be created
+	MaxValue      *big.Int         `json:"maxValue"`              // Maximum value per transaction in wei, unlimited if nil
+	RateLimit     int              `json:"rateLimit"`             // Maximum transactions per account and period, unlimited if 0
+	RatePeriod    string           `json:"ratePeriod"`            // Window of the rate limit, defaults to one hour
+	AllowHash     bool             `json:"allowHashSigning"`      // Whether arbitrary hashes may be signed
+
+	period  time.Duration                  // Parsed rate limiting window
+	history map[common.Address][]time.Time // Times of the recently approved transactions
+	lock    sync.Mutex                     // Protects the rate limiting history
+}
+
+// loadRules reads the signing rules from a JSON file.
+func loadRules(file string) (*rules, error) {
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	r := new(rules)
+	if err := json.Unmarshal(blob, r); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %v", file, err)
+	}
+	if err := r.init(); err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %v", file, err)
+	}
+	return r, nil
+}
+
+// init validates the rules and prepares the rate limiter.
+func (r *rules) init() error {
+	r.period = defaultRatePeriod
+	if r.RatePeriod != "" {
+		period, err := time.ParseDuration(r.RatePeriod)
+		if err != nil {
+			return fmt.Errorf("ratePeriod: %v", err)
+		}
+		if period <= 0 {
+			return fmt.Errorf("ratePeriod: must be positive")
+		}
+		r.period = period
+	}
+	if r.RateLimit < 0 {
+		return fmt.Errorf("rateLimit: must not be negative")
+	}
+	if r.MaxValue != nil && r.MaxValue.Sign() < 0 {
+		return fmt.Errorf("maxValue: must not be negative")
+	}
+	r.history = make(map[common.Address][]time.Time)
+	return nil
+}
+
+// approveTx checks a transaction to be sent from the given account against the
+// rules. If it is approved, it counts towards the rate limit of the account even
+// if it's not signed in the end.
+func (r *rules) approveTx(from common.Address, tx *types.Transaction, now time.Time) error {
+	if to := tx.To(); to == nil {
+		if !r.AllowCreation {
+			return fmt.Errorf("contract creation not allowed")
+		}
+	} else if len(r.Recipients) > 0 {
+		allowed := false
+		for _, recipient := range r.Recipients {
+			if recipient == *to {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("recipient %x not allowed", *to)
+		}
+	}
+	if r.MaxValue != nil && tx.Value().Cmp(r.MaxValue) > 0 {
+		return fmt.Errorf("value %v exceeds limit of %v wei", tx.Value(), r.MaxValue)
+	}
+	return r.limit(from, now)
+}
+
+// approveHash checks whether an arbitrary hash may be signed.
+func (r *rules) approveHash(from common.Address, now time.Time) error {
+	if !r.AllowHash {
+		return fmt.Errorf("hash signing not allowed")
+	}
+	return r.limit(from, now)
+}
+
+// limit enforces the rate limit of an account, recording the request if the
+// limit is not yet reached.
+func (r *rules) limit(from common.Address, now time.Time) error {
+	if r.RateLimit == 0 {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Drop all requests that left the rate limiting window
+	recent := r.history[from]
+	for len(recent) > 0 && now.Sub(recent[0]) >= r.period {
+		recent = recent[1:]
+	}
+	if len(recent) >= r.RateLimit {
+		r.history[from] = recent
+		return fmt.Errorf("rate limit of %d requests per %v reached", r.RateLimit, r.period)
+	}
+	r.history[from] = append(recent, now)
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	testRecipient = common.HexToAddress("0x8a3d7e5a8b4d9e6fd1f04cb6d3a3c3e3e7a1c0ff")
+	testStranger  = common.HexToAddress("0x19f2a1f1a5a6a7a8a9aaabacadaeafb0b1b2b3b4")
+)
+
+func newTestRules(t *testing.T, blob string) *rules {
+	r := new(rules)
+	if err := json.Unmarshal([]byte(blob), r); err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	if err := r.init(); err != nil {
+		t.Fatalf("failed to init rules: %v", err)
+	}
+	return r
+}
+
+func TestRules(t *testing.T) {
+	r := newTestRules(t, `{
+		"recipients": ["`+testRecipient.Hex()+`"],
+		"maxValue": 1000,
+		"rateLimit": 2,
+		"ratePeriod": "1m"
+	}`)
+	var (
+		from = common.Address{1}
+		now  = time.Now()
+	)
+	tests := []struct {
+		tx   *types.Transaction
+		time time.Time
+		ok   bool
+	}{
+		{types.NewTransaction(0, testStranger, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), now, false},     // unknown recipient
+		{types.NewContractCreation(0, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil), now, false},              // creation not allowed
+		{types.NewTransaction(0, testRecipient, big.NewInt(1001), big.NewInt(21000), big.NewInt(1), nil), now, false}, // value too high
+		{types.NewTransaction(0, testRecipient, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil), now, true},
+		{types.NewTransaction(1, testRecipient, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), now, true},
+		{types.NewTransaction(2, testRecipient, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), now, false}, // rate limited
+		{types.NewTransaction(2, testRecipient, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil), now.Add(time.Minute), true},
+	}
+	for i, tt := range tests {
+		if err := r.approveTx(from, tt.tx, tt.time); (err == nil) != tt.ok {
+			t.Errorf("test %d: approval mismatch: have %v, want ok %v", i, err, tt.ok)
+		}
+	}
+	// Rate limits are tracked per account, hashes are refused by default
+	if err := r.approveTx(common.Address{2}, tests[3].tx, now); err != nil {
+		t.Errorf("other account rate limited: %v", err)
+	}
+	if err := r.approveHash(from, now); err == nil {
+		t.Errorf("hash signing allowed without permission")
+	}
+	// Invalid rules must be refused
+	for i, blob := range []string{`{"ratePeriod": "soon"}`, `{"ratePeriod": "-1h"}`, `{"rateLimit": -1}`, `{"maxValue": -1}`} {
+		r := new(rules)
+		if err := json.Unmarshal([]byte(blob), r); err != nil {
+			t.Fatalf("invalid rules %d: failed to parse: %v", i, err)
+		}
+		if err := r.init(); err == nil {
+			t.Errorf("invalid rules %d accepted: %s", i, blob)
+		}
+	}
+}
+
+func TestSignerAPI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am := accounts.NewManager(filepath.Join(dir, "keystore"), accounts.LightScryptN, accounts.LightScryptP)
+	account, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.Unlock(account, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	audit, err := openAuditLog(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.close()
+
+	rules := newTestRules(t, `{"recipients": ["`+testRecipient.Hex()+`"], "maxValue": 1000}`)
+	api := newSignerAPI(am, rules, audit)
+
+	if addrs := api.List(); len(addrs) != 1 || addrs[0] != account.Address {
+		t.Fatalf("account list mismatch: have %x, want [%x]", addrs, account.Address)
+	}
+	// Sign an allowed transaction and ensure the result is valid
+	tx := types.NewTransaction(3, testRecipient, big.NewInt(1000), big.NewInt(21000), big.NewInt(1), nil)
+	raw, _ := rlp.EncodeToBytes(tx)
+
+	signedRaw, err := api.SignTransaction(account.Address, common.ToHex(raw))
+	if err != nil {
+		t.Fatalf("failed to sign allowed transaction: %v", err)
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(signedRaw), signed); err != nil {
+		t.Fatalf("failed to decode signed transaction: %v", err)
+	}
+	if from, err := signed.From(); err != nil || from != account.Address {
+		t.Fatalf("signed transaction sender mismatch: have %x (%v), want %x", from, err, account.Address)
+	}
+	// Refuse a disallowed transaction and a raw hash
+	tx = types.NewTransaction(4, testStranger, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	raw, _ = rlp.EncodeToBytes(tx)
+	if _, err := api.SignTransaction(account.Address, common.ToHex(raw)); err == nil {
+		t.Fatalf("disallowed transaction signed")
+	}
+	if _, err := api.SignHash(account.Address, common.ToHex(crypto.Keccak256(nil))); err == nil {
+		t.Fatalf("hash signed without permission")
+	}
+	// Every request must have made it into the audit log
+	audit.close()
+	file, err := os.Open(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var entries []auditEntry
+	for scanner := bufio.NewScanner(file); scanner.Scan(); {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid audit entry %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("audit entry count mismatch: have %d, want 3", len(entries))
+	}
+	if !entries[0].Approved || entries[0].Method != "account_signTransaction" || *entries[0].Nonce != 3 || *entries[0].To != testRecipient {
+		t.Errorf("approved transaction entry mismatch: %+v", entries[0])
+	}
+	if entries[1].Approved || !strings.Contains(entries[1].Reason, "not allowed") {
+		t.Errorf("rejected transaction entry mismatch: %+v", entries[1])
+	}
+	if entries[2].Approved || entries[2].Method != "account_signHash" {
+		t.Errorf("rejected hash entry mismatch: %+v", entries[2])
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// manifestType is the content type marking an entry as a nested manifest.
+const manifestType = "application/bzz-manifest+json"
+
+// manifest is the JSON representation of a swarm manifest.
+type manifest struct {
+	Entries []manifestEntry `json:"entries"`
+}
+
+// manifestEntry maps a path to content stored in swarm.
+type manifestEntry struct {
+	Hash        string `json:"hash"`
+	Path        string `json:"path"`
+	ContentType string `json:"contentType"`
+	Status      int    `json:"status,omitempty"`
+}
+
+// client talks to the HTTP API of a swarm node.
+type client struct {
+	api     string // base URL of the API, e.g. http://127.0.0.1:8500
+	encrypt bool   // upload content encrypted
+}
+
+func newClient(api string) *client {
+	return &client{api: strings.TrimRight(api, "/")}
+}
+
+// uploadRaw stores size bytes read from r and returns their content hash,
+// followed by the secret if the client encrypts.
+func (c *client) uploadRaw(r io.Reader, size int64) (string, error) {
+	url := c.api + "/bzzr:/"
+	if c.encrypt {
+		url += "?encrypt"
+	}
+	req, err := http.NewRequest("POST", url, r)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("upload failed: %s: %s", resp.Status, bytes.TrimSpace(content))
+	}
+	return string(bytes.TrimSpace(content)), nil
+}
+
+// downloadRaw retrieves the content stored under hash.
+func (c *client) downloadRaw(hash string) ([]byte, error) {
+	resp, err := http.Get(c.api + "/bzzr:/" + hash)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download of %s failed: %s: %s", hash, resp.Status, bytes.TrimSpace(content))
+	}
+	return content, nil
+}
+
+// uploadManifest stores the manifest and returns its hash.
+func (c *client) uploadManifest(m *manifest) (string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return c.uploadRaw(bytes.NewReader(data), int64(len(data)))
+}
+
+// downloadManifest retrieves and decodes the manifest stored under hash.
+func (c *client) downloadManifest(hash string) (*manifest, error) {
+	data, err := c.downloadRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+	m := new(manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("manifest %s is malformed: %v", hash, err)
+	}
+	return m, nil
+}
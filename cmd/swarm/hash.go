@@ -0,0 +1,51 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+func hash(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Need the file to hash")
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		utils.Fatalf("Error opening file %s: %v", args[0], err)
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	chunker := storage.NewTreeChunker(storage.NewChunkerParams())
+	key := make([]byte, chunker.KeySize())
+	errC := chunker.Split(key, io.NewSectionReader(f, 0, stat.Size()), nil, nil)
+	if err := <-errC; err != nil {
+		utils.Fatalf("%v", err)
+	}
+	fmt.Printf("%064x\n", key)
+}
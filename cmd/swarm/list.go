@@ -0,0 +1,70 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+)
+
+func list(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 1 || len(args) > 2 {
+		utils.Fatalf("Need the manifest hash and an optional path prefix")
+	}
+	var prefix string
+	if len(args) == 2 {
+		prefix = args[1]
+	}
+	c := newClient(ctx.GlobalString(SwarmAPIFlag.Name))
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "HASH\tCONTENT TYPE\tPATH")
+	err := c.walkManifest(args[0], "", func(entry manifestEntry) {
+		if strings.HasPrefix(entry.Path, prefix) {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Hash, entry.ContentType, entry.Path)
+		}
+	})
+	w.Flush()
+	if err != nil {
+		utils.Fatalf("Failed to list manifest: %v", err)
+	}
+}
+
+// walkManifest calls fn for every entry of the manifest, descending into the
+// nested manifests. The entry paths passed to fn are relative to the root.
+func (c *client) walkManifest(hash, prefix string, fn func(entry manifestEntry)) error {
+	m, err := c.downloadManifest(hash)
+	if err != nil {
+		return err
+	}
+	for _, entry := range m.Entries {
+		entry.Path = prefix + entry.Path
+		if entry.ContentType == manifestType {
+			if err := c.walkManifest(entry.Hash, entry.Path, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		fn(entry)
+	}
+	return nil
+}
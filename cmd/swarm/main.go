@@ -0,0 +1,279 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// swarm is the standalone command-line client for the Swarm distributed
+// storage network. Without a command it runs a swarm node, the subcommands
+// talk to the HTTP API of a running one.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/chequebook"
+	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/swarm"
+	bzzapi "github.com/ethereum/go-ethereum/swarm/api"
+)
+
+const (
+	ClientIdentifier = "bzzd"
+	Version          = "0.1"
+)
+
+var (
+	gitCommit string // set via linker flag
+	app       *cli.App
+)
+
+var (
+	SwarmSwapEnabledFlag = cli.BoolFlag{
+		Name:  "swap",
+		Usage: "Swarm SWAP enabled (requires --ethapi)",
+	}
+	EthAPIFlag = cli.StringFlag{
+		Name:  "ethapi",
+		Usage: "IPC endpoint of the Ethereum node used as chequebook backend for SWAP and for ENS name resolution",
+	}
+	SwarmAPIFlag = cli.StringFlag{
+		Name:  "bzzapi",
+		Usage: "HTTP API endpoint of the swarm node the commands talk to",
+		Value: "http://127.0.0.1:8500",
+	}
+	EncryptFlag = cli.BoolFlag{
+		Name:  "encrypt",
+		Usage: "store the uploaded content encrypted, only readable through the printed reference",
+	}
+)
+
+func init() {
+	// Override the flag defaults clashing with a geth running next to swarm
+	utils.ListenPortFlag.Value = 30399
+	utils.IPCPathFlag.Value = utils.DirectoryString{Value: "bzzd.ipc"}
+
+	version := Version
+	if gitCommit != "" {
+		version += "-" + gitCommit[:8]
+	}
+	app = utils.NewApp(version, "Ethereum Swarm server and client")
+	app.Action = bzzd
+	app.Commands = []cli.Command{
+		{
+			Action:    upload,
+			Name:      "up",
+			Usage:     "upload a file or directory to swarm using the HTTP API",
+			ArgsUsage: "<file or directory>",
+			Flags:     []cli.Flag{EncryptFlag},
+			Description: `
+Uploads a file or all files of a directory and prints the hash of the manifest
+mapping the paths to the uploaded content. The index.html of a directory is
+served at the path of the directory itself.
+
+With --encrypt the content and the manifest are encrypted, the printed reference
+is the hash followed by the secret needed to read them.
+`,
+		},
+		{
+			Action:    hash,
+			Name:      "hash",
+			Usage:     "print the swarm hash of a file",
+			ArgsUsage: "<file>",
+			Description: `
+Calculates the swarm content hash of a file locally, without uploading it.
+`,
+		},
+		{
+			Action:    list,
+			Name:      "ls",
+			Usage:     "list the files of a manifest",
+			ArgsUsage: "<manifest hash> [prefix]",
+			Description: `
+Lists the entries of a manifest, descending into nested manifests. If a prefix
+is given, only the paths starting with it are listed.
+`,
+		},
+		{
+			Name:  "manifest",
+			Usage: "update a manifest",
+			Subcommands: []cli.Command{
+				{
+					Action:    manifestAdd,
+					Name:      "add",
+					Usage:     "add a new path to the manifest",
+					ArgsUsage: "<manifest hash> <path> <hash> [content type]",
+					Description: `
+Adds the content hash under the path and prints the hash of the new manifest.
+`,
+				},
+				{
+					Action:    manifestUpdate,
+					Name:      "update",
+					Usage:     "update the hash of an existing path in the manifest",
+					ArgsUsage: "<manifest hash> <path> <hash>",
+					Description: `
+Replaces the content hash of the path and prints the hash of the new manifest.
+`,
+				},
+				{
+					Action:    manifestRemove,
+					Name:      "remove",
+					Usage:     "remove a path from the manifest",
+					ArgsUsage: "<manifest hash> <path>",
+					Description: `
+Removes the path and prints the hash of the new manifest.
+`,
+				},
+			},
+		},
+	}
+	app.Flags = []cli.Flag{
+		utils.IdentityFlag,
+		utils.DataDirFlag,
+		utils.KeyStoreDirFlag,
+		utils.LightKDFFlag,
+		utils.PasswordFileFlag,
+		utils.BootnodesFlag,
+		utils.ListenPortFlag,
+		utils.MaxPeersFlag,
+		utils.MaxPendingPeersFlag,
+		utils.NATFlag,
+		utils.NoDiscoverFlag,
+		utils.NodeKeyFileFlag,
+		utils.NodeKeyHexFlag,
+		utils.IPCDisabledFlag,
+		utils.IPCPathFlag,
+		// swarm flags
+		utils.SwarmAccountAddrFlag,
+		utils.SwarmConfigPathFlag,
+		utils.SwarmPortFlag,
+		utils.ChequebookAddrFlag,
+		utils.EnsRootFlag,
+		SwarmSwapEnabledFlag,
+		utils.SwarmSyncDisabled,
+		EthAPIFlag,
+		SwarmAPIFlag,
+	}
+	app.Flags = append(app.Flags, debug.Flags...)
+	app.Before = func(ctx *cli.Context) error {
+		runtime.GOMAXPROCS(runtime.NumCPU())
+		return debug.Setup(ctx)
+	}
+	app.After = func(ctx *cli.Context) error {
+		logger.Flush()
+		debug.Exit()
+		utils.Stdin.Close() // Resets terminal mode.
+		return nil
+	}
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// bzzd runs a standalone swarm node until interrupted.
+func bzzd(ctx *cli.Context) {
+	stack := makeSwarmNode(ctx)
+	utils.StartNode(stack)
+	stack.Wait()
+}
+
+// makeSwarmNode assembles a protocol stack running swarm as its only service.
+func makeSwarmNode(ctx *cli.Context) *node.Node {
+	datadir := utils.MustMakeDataDir(ctx)
+	stackConf := &node.Config{
+		DataDir:         datadir,
+		PrivateKey:      utils.MakeNodeKey(ctx),
+		Name:            utils.MakeNodeName(ClientIdentifier, Version, ctx),
+		NoDiscovery:     ctx.GlobalBool(utils.NoDiscoverFlag.Name),
+		BootstrapNodes:  utils.MakeBootstrapNodes(ctx),
+		ListenAddr:      utils.MakeListenAddress(ctx),
+		NAT:             utils.MakeNAT(ctx),
+		MaxPeers:        ctx.GlobalInt(utils.MaxPeersFlag.Name),
+		MaxPendingPeers: ctx.GlobalInt(utils.MaxPendingPeersFlag.Name),
+		IPCPath:         utils.MakeIPCPath(ctx),
+	}
+	stack, err := node.New(stackConf)
+	if err != nil {
+		utils.Fatalf("Failed to create the protocol stack: %v", err)
+	}
+	bzzconfig := makeSwarmConfig(ctx, datadir)
+
+	swapEnabled := ctx.GlobalBool(SwarmSwapEnabledFlag.Name)
+	syncEnabled := !ctx.GlobalBool(utils.SwarmSyncDisabled.Name)
+
+	var backend chequebook.Backend
+	if endpoint := ctx.GlobalString(EthAPIFlag.Name); endpoint != "" {
+		client, err := rpc.NewIPCClient(endpoint)
+		if err != nil {
+			utils.Fatalf("Unable to connect to the Ethereum API at %s: %v", endpoint, err)
+		}
+		backend = bzzapi.NewRpcEthApi(client)
+	} else if swapEnabled {
+		utils.Fatalf("SWAP requires an Ethereum API endpoint (--%s)", EthAPIFlag.Name)
+	}
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		return swarm.NewSwarm(ctx, backend, bzzconfig, swapEnabled, syncEnabled)
+	}); err != nil {
+		utils.Fatalf("Failed to register the Swarm service: %v", err)
+	}
+	return stack
+}
+
+// makeSwarmConfig unlocks the swarm account and loads (or creates) the swarm
+// configuration belonging to it.
+func makeSwarmConfig(ctx *cli.Context, datadir string) *bzzapi.Config {
+	hexaddr := ctx.GlobalString(utils.SwarmAccountAddrFlag.Name)
+	if hexaddr == "" {
+		utils.Fatalf("No swarm account specified (--%s)", utils.SwarmAccountAddrFlag.Name)
+	}
+	accman := utils.MakeAccountManager(ctx)
+	account, _ := utils.UnlockAccount(ctx, accman, strings.TrimSpace(hexaddr), 0, utils.MakePasswordList(ctx))
+
+	prvkey, err := accman.GetUnlocked(account.Address)
+	if err != nil {
+		utils.Fatalf("Unable to unlock swarm account: %v", err)
+	}
+	chbookaddr := common.HexToAddress(ctx.GlobalString(utils.ChequebookAddrFlag.Name))
+	bzzdir := ctx.GlobalString(utils.SwarmConfigPathFlag.Name)
+	if bzzdir == "" {
+		bzzdir = filepath.Join(datadir, "bzz")
+	}
+	bzzconfig, err := bzzapi.NewConfig(bzzdir, chbookaddr, prvkey)
+	if err != nil {
+		utils.Fatalf("Unable to configure swarm: %v", err)
+	}
+	if bzzport := ctx.GlobalString(utils.SwarmPortFlag.Name); bzzport != "" {
+		bzzconfig.Port = bzzport
+	}
+	if ensroot := ctx.GlobalString(utils.EnsRootFlag.Name); ensroot != "" {
+		bzzconfig.EnsRoot = common.HexToAddress(ensroot)
+	}
+	glog.V(logger.Info).Infof("Swarm account %s, config at %s", account.Address.Hex(), bzzconfig.Path)
+	return bzzconfig
+}
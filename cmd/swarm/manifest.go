@@ -0,0 +1,124 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+)
+
+func manifestAdd(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) < 3 || len(args) > 4 {
+		utils.Fatalf("Need the manifest hash, the path, the content hash and an optional content type")
+	}
+	entry := manifestEntry{Hash: args[2], ContentType: "application/octet-stream"}
+	if len(args) == 4 {
+		entry.ContentType = args[3]
+	}
+	c := newClient(ctx.GlobalString(SwarmAPIFlag.Name))
+	hash, err := c.modifyManifest(args[0], args[1], func(m *manifest, path string) error {
+		if m.find(path) >= 0 {
+			return fmt.Errorf("path %q already exists", args[1])
+		}
+		entry.Path = path
+		m.Entries = append(m.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		utils.Fatalf("Failed to add manifest entry: %v", err)
+	}
+	fmt.Println(hash)
+}
+
+func manifestUpdate(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 3 {
+		utils.Fatalf("Need the manifest hash, the path and the new content hash")
+	}
+	c := newClient(ctx.GlobalString(SwarmAPIFlag.Name))
+	hash, err := c.modifyManifest(args[0], args[1], func(m *manifest, path string) error {
+		i := m.find(path)
+		if i < 0 {
+			return fmt.Errorf("path %q not found", args[1])
+		}
+		m.Entries[i].Hash = args[2]
+		return nil
+	})
+	if err != nil {
+		utils.Fatalf("Failed to update manifest entry: %v", err)
+	}
+	fmt.Println(hash)
+}
+
+func manifestRemove(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 2 {
+		utils.Fatalf("Need the manifest hash and the path to remove")
+	}
+	c := newClient(ctx.GlobalString(SwarmAPIFlag.Name))
+	hash, err := c.modifyManifest(args[0], args[1], func(m *manifest, path string) error {
+		i := m.find(path)
+		if i < 0 {
+			return fmt.Errorf("path %q not found", args[1])
+		}
+		m.Entries = append(m.Entries[:i], m.Entries[i+1:]...)
+		return nil
+	})
+	if err != nil {
+		utils.Fatalf("Failed to remove manifest entry: %v", err)
+	}
+	fmt.Println(hash)
+}
+
+// find returns the index of the entry with the given path, or -1.
+func (m *manifest) find(path string) int {
+	for i, entry := range m.Entries {
+		if entry.Path == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// modifyManifest applies fn to the manifest holding path, which is either the
+// manifest under hash or one nested into it, and stores the changes up to the
+// root. The path passed to fn is relative to the manifest it is called on.
+// The hash of the new root manifest is returned.
+func (c *client) modifyManifest(hash, path string, fn func(m *manifest, path string) error) (string, error) {
+	m, err := c.downloadManifest(hash)
+	if err != nil {
+		return "", err
+	}
+	for i, entry := range m.Entries {
+		if entry.ContentType == manifestType && entry.Path != path && strings.HasPrefix(path, entry.Path) {
+			sub, err := c.modifyManifest(entry.Hash, path[len(entry.Path):], fn)
+			if err != nil {
+				return "", err
+			}
+			m.Entries[i].Hash = sub
+			return c.uploadManifest(m)
+		}
+	}
+	if err := fn(m, path); err != nil {
+		return "", err
+	}
+	return c.uploadManifest(m)
+}
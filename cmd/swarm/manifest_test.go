@@ -0,0 +1,182 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testStore is a fake swarm HTTP API serving the raw bzzr: scheme from memory.
+type testStore struct {
+	mu      sync.Mutex
+	content map[string][]byte
+}
+
+func newTestClient() (*client, *httptest.Server) {
+	store := &testStore{content: make(map[string][]byte)}
+	server := httptest.NewServer(store)
+	return newClient(server.URL), server
+}
+
+func (s *testStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case "POST":
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			http.Error(w, "bad upload", http.StatusBadRequest)
+			return
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		s.content[hash] = data
+		w.Write([]byte(hash))
+	case "GET":
+		data, ok := s.content[strings.TrimPrefix(r.URL.Path, "/bzzr:/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+func listManifest(t *testing.T, c *client, hash string) map[string]string {
+	paths := make(map[string]string)
+	if err := c.walkManifest(hash, "", func(entry manifestEntry) {
+		paths[entry.Path] = entry.Hash
+	}); err != nil {
+		t.Fatalf("failed to list manifest: %v", err)
+	}
+	return paths
+}
+
+func TestUploadDirectory(t *testing.T) {
+	c, server := newTestClient()
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "swarm-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"index.html":     "<html></html>",
+		"css/style.css":  "body {}",
+		"img/index.html": "images",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash, err := c.uploadPath(dir)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	m, err := c.downloadManifest(hash)
+	if err != nil {
+		t.Fatalf("failed to retrieve manifest: %v", err)
+	}
+	want := map[string]string{
+		"":              "text/html; charset=utf-8",
+		"css/style.css": "text/css; charset=utf-8",
+		"img":           "text/html; charset=utf-8",
+	}
+	if len(m.Entries) != len(want) {
+		t.Fatalf("manifest entry count mismatch: have %d, want %d", len(m.Entries), len(want))
+	}
+	for _, entry := range m.Entries {
+		if contentType, ok := want[entry.Path]; !ok || contentType != entry.ContentType {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+	}
+}
+
+func TestManifestModify(t *testing.T) {
+	c, server := newTestClient()
+	defer server.Close()
+
+	// Build a root manifest with a nested one under "sub/"
+	nested, err := c.uploadManifest(&manifest{Entries: []manifestEntry{
+		{Hash: "aa", Path: "a.txt", ContentType: "text/plain"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := c.uploadManifest(&manifest{Entries: []manifestEntry{
+		{Hash: "bb", Path: "b.txt", ContentType: "text/plain"},
+		{Hash: nested, Path: "sub/", ContentType: manifestType},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := listManifest(t, c, root), map[string]string{"b.txt": "bb", "sub/a.txt": "aa"}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("listing mismatch: have %v, want %v", have, want)
+	}
+	// Add into the nested manifest, update and remove entries
+	add := func(m *manifest, path string) error {
+		m.Entries = append(m.Entries, manifestEntry{Hash: "cc", Path: path})
+		return nil
+	}
+	if root, err = c.modifyManifest(root, "sub/c.txt", add); err != nil {
+		t.Fatalf("failed to add entry: %v", err)
+	}
+	update := func(m *manifest, path string) error {
+		m.Entries[m.find(path)].Hash = "dd"
+		return nil
+	}
+	if root, err = c.modifyManifest(root, "b.txt", update); err != nil {
+		t.Fatalf("failed to update entry: %v", err)
+	}
+	remove := func(m *manifest, path string) error {
+		i := m.find(path)
+		m.Entries = append(m.Entries[:i], m.Entries[i+1:]...)
+		return nil
+	}
+	if root, err = c.modifyManifest(root, "sub/a.txt", remove); err != nil {
+		t.Fatalf("failed to remove entry: %v", err)
+	}
+	if have, want := listManifest(t, c, root), map[string]string{"b.txt": "dd", "sub/c.txt": "cc"}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("listing mismatch: have %v, want %v", have, want)
+	}
+	// The nested manifest must have been stored under a new hash
+	m, err := c.downloadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(m)
+	if strings.Contains(string(data), nested) {
+		t.Errorf("root manifest still refers to the original nested manifest: %s", data)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+)
+
+// indexFile is served at the path of the directory containing it.
+const indexFile = "index.html"
+
+func upload(ctx *cli.Context) {
+	args := ctx.Args()
+	if len(args) != 1 {
+		utils.Fatalf("Need the file or directory to upload")
+	}
+	c := newClient(ctx.GlobalString(SwarmAPIFlag.Name))
+	c.encrypt = ctx.Bool(EncryptFlag.Name)
+	hash, err := c.uploadPath(args[0])
+	if err != nil {
+		utils.Fatalf("Upload failed: %v", err)
+	}
+	fmt.Println(hash)
+}
+
+// uploadPath uploads a file or the files of a directory and returns the hash
+// of the manifest built from them.
+func (c *client) uploadPath(root string) (string, error) {
+	stat, err := os.Stat(root)
+	if err != nil {
+		return "", err
+	}
+	if !stat.IsDir() {
+		entry, err := c.uploadFile(root)
+		if err != nil {
+			return "", err
+		}
+		return c.uploadManifest(&manifest{Entries: []manifestEntry{entry}})
+	}
+	m := new(manifest)
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		entry, err := c.uploadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		entry.Path = filepath.ToSlash(rel)
+		if filepath.Base(rel) == indexFile {
+			entry.Path = filepath.ToSlash(filepath.Dir(rel))
+			if entry.Path == "." {
+				entry.Path = ""
+			}
+		}
+		m.Entries = append(m.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return c.uploadManifest(m)
+}
+
+// uploadFile stores the contents of a file and returns the manifest entry
+// referring to it, without the path set.
+func (c *client) uploadFile(path string) (manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	contentType, err := detectContentType(f)
+	if err != nil {
+		return manifestEntry{}, err
+	}
+	hash, err := c.uploadRaw(f, stat.Size())
+	if err != nil {
+		return manifestEntry{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return manifestEntry{Hash: hash, ContentType: contentType}, nil
+}
+
+// detectContentType guesses the MIME type of a file from its extension,
+// falling back to sniffing its first bytes. The file is rewound afterwards.
+func detectContentType(f *os.File) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(f.Name())); contentType != "" {
+		return contentType, nil
+	}
+	buf := make([]byte, 512)
+	n, err := f.Read(buf)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/codegangsta/cli"
 	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -43,6 +44,7 @@ import (
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/nat"
@@ -52,6 +54,8 @@ import (
 	"github.com/ethereum/go-ethereum/swarm"
 	bzzapi "github.com/ethereum/go-ethereum/swarm/api"
 	"github.com/ethereum/go-ethereum/whisper"
+	"github.com/ethereum/go-ethereum/whisper/mailserver"
+	"github.com/ethereum/go-ethereum/whisper/whisperv5"
 )
 
 func init() {
@@ -162,6 +166,10 @@ var (
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	SignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "IPC endpoint of an external signer providing additional accounts",
+	}
 	// Miner settings
 	// TODO: refactor CPU vs GPU mining flags
 	MiningEnabledFlag = cli.BoolFlag{
@@ -200,6 +208,15 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	StratumAddrFlag = cli.StringFlag{
+		Name:  "stratum",
+		Usage: "Listening address of the stratum mining server pushing work to mining rigs (e.g. ':8008')",
+	}
+	StratumDifficultyFlag = cli.StringFlag{
+		Name:  "stratum.difficulty",
+		Usage: "Default and minimum share difficulty of the stratum mining workers",
+		Value: miner.DefaultStratumDifficulty.String(),
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -225,6 +242,10 @@ var (
 		Name:  "jitvm",
 		Usage: "Enable the JIT VM",
 	}
+	VMProfileFlag = cli.BoolFlag{
+		Name:  "vmprofile",
+		Usage: "Collect per opcode and per contract execution statistics (see debug.vmProfile)",
+	}
 
 	// logging and debug settings
 	MetricsEnabledFlag = cli.BoolFlag{
@@ -350,10 +371,36 @@ var (
 		Name:  "shh",
 		Usage: "Enable Whisper",
 	}
+	WhisperVersionFlag = cli.IntFlag{
+		Name:  "shh.version",
+		Usage: "Whisper protocol version to run (2 or 5)",
+		Value: 2,
+	}
+	WhisperMinPoWFlag = cli.StringFlag{
+		Name:  "shh.pow",
+		Usage: "Minimum PoW of the envelopes accepted from peers (Whisper v5)",
+		Value: fmt.Sprint(whisperv5.DefaultMinimumPoW),
+	}
+	WhisperTopicFilteringFlag = cli.BoolFlag{
+		Name:  "shh.filtertopics",
+		Usage: "Only request envelopes of the locally watched topics from peers (Whisper v5)",
+	}
+	WhisperMailServerFlag = cli.BoolFlag{
+		Name:  "shh.mailserver",
+		Usage: "Archive envelopes and deliver historic ones to requesting peers (Whisper v5)",
+	}
+	WhisperMailServerPasswordFileFlag = cli.StringFlag{
+		Name:  "shh.mailserver.passwordfile",
+		Usage: "File containing the password the mail server requests are encrypted with (prompted for if missing)",
+	}
 	ChequebookAddrFlag = cli.StringFlag{
 		Name:  "chequebook",
 		Usage: "chequebook contract address",
 	}
+	EnsRootFlag = cli.StringFlag{
+		Name:  "ensroot",
+		Usage: "ENS registry contract address used by swarm for name resolution",
+	}
 	SwarmAccountAddrFlag = cli.StringFlag{
 		Name:  "bzzaccount",
 		Usage: "Swarm account address (swarm disabled if empty)",
@@ -600,7 +647,17 @@ func MakeAccountManager(ctx *cli.Context) *accounts.Manager {
 	}
 	datadir := MustMakeDataDir(ctx)
 	keydir := MakeKeyStoreDir(datadir, ctx)
-	return accounts.NewManager(keydir, scryptN, scryptP)
+	accman := accounts.NewManager(keydir, scryptN, scryptP)
+
+	// Aggregate the accounts of an external signer if requested
+	if endpoint := ctx.GlobalString(SignerFlag.Name); endpoint != "" {
+		signer, err := external.NewBackend(endpoint)
+		if err != nil {
+			Fatalf("Could not connect to signer %s: %v", endpoint, err)
+		}
+		accman.AddBackend(signer)
+	}
+	return accman
 }
 
 // MakeAddress converts an account specified directly as a hex encoded string or
@@ -665,7 +722,7 @@ func MakePasswordList(ctx *cli.Context) []string {
 	return lines
 }
 
-func UnlockAccount(ctx *cli.Context, accman *accounts.Manager, address string, i int, passwords []string) (common.Address, string) {
+func UnlockAccount(ctx *cli.Context, accman *accounts.Manager, address string, i int, passwords []string) (accounts.Account, string) {
 	// Try to unlock the specified account a few times
 	account, err := MakeAddress(accman, address)
 	if err != nil {
@@ -681,7 +738,7 @@ func UnlockAccount(ctx *cli.Context, accman *accounts.Manager, address string, i
 	}
 	// All trials expended to unlock account, bail out
 	Fatalf("Failed to unlock account: %s", address)
-	return common.Address{}, ""
+	return accounts.Account{}, ""
 }
 
 // getPassPhrase retrieves the passwor associated with an account, either fetched
@@ -696,12 +753,12 @@ func GetPassPhrase(prompt string, confirmation bool, i int, passwords []string)
 	}
 	// Otherwise prompt the user for the password
 	fmt.Println(prompt)
-	password, err := PromptPassword("Passphrase: ", true)
+	password, err := Stdin.PasswordPrompt("Passphrase: ")
 	if err != nil {
 		Fatalf("Failed to read passphrase: %v", err)
 	}
 	if confirmation {
-		confirm, err := PromptPassword("Repeat passphrase: ", false)
+		confirm, err := Stdin.PasswordPrompt("Repeat passphrase: ")
 		if err != nil {
 			Fatalf("Failed to read passphrase confirmation: %v", err)
 		}
@@ -785,6 +842,7 @@ func MakeSystemNode(name, version string, extra []byte, ctx *cli.Context) *node.
 		DocRoot:                 ctx.GlobalString(DocRootFlag.Name),
 		EnableJit:               jitEnabled,
 		ForceJit:                ctx.GlobalBool(VMForceJitFlag.Name),
+		VmProfile:               ctx.GlobalBool(VMProfileFlag.Name),
 		GasPrice:                common.String2Big(ctx.GlobalString(GasPriceFlag.Name)),
 		GpoMinGasPrice:          common.String2Big(ctx.GlobalString(GpoMinGasPriceFlag.Name)),
 		GpoMaxGasPrice:          common.String2Big(ctx.GlobalString(GpoMaxGasPriceFlag.Name)),
@@ -794,6 +852,8 @@ func MakeSystemNode(name, version string, extra []byte, ctx *cli.Context) *node.
 		GpobaseCorrectionFactor: ctx.GlobalInt(GpobaseCorrectionFactorFlag.Name),
 		SolcPath:                ctx.GlobalString(SolcPathFlag.Name),
 		AutoDAG:                 ctx.GlobalBool(AutoDAGFlag.Name) || ctx.GlobalBool(MiningEnabledFlag.Name),
+		StratumAddr:             ctx.GlobalString(StratumAddrFlag.Name),
+		StratumDifficulty:       common.String2Big(ctx.GlobalString(StratumDifficultyFlag.Name)),
 	}
 	// Configure the Whisper service
 	shhEnable := ctx.GlobalBool(WhisperEnabledFlag.Name)
@@ -855,17 +915,68 @@ func MakeSystemNode(name, version string, extra []byte, ctx *cli.Context) *node.
 
 	// Whisper
 	if shhEnable {
-		if err := stack.Register(func(*node.ServiceContext) (node.Service, error) { return whisper.New(), nil }); err != nil {
+		constructor := func(*node.ServiceContext) (node.Service, error) { return whisper.New(), nil }
+		switch version := ctx.GlobalInt(WhisperVersionFlag.Name); version {
+		case 2:
+			// PoC-1 protocol of the whisper package, the default
+		case 5:
+			pow, err := strconv.ParseFloat(ctx.GlobalString(WhisperMinPoWFlag.Name), 64)
+			if err != nil {
+				Fatalf("Invalid minimum Whisper PoW: %v", err)
+			}
+			config := &whisperv5.Config{
+				MaxMessageSize: whisperv5.DefaultMaxMessageSize,
+				MinimumPoW:     pow,
+				TopicFiltering: ctx.GlobalBool(WhisperTopicFilteringFlag.Name),
+			}
+			constructor = func(*node.ServiceContext) (node.Service, error) { return whisperv5.New(config), nil }
+		default:
+			Fatalf("Unsupported Whisper version %d", version)
+		}
+		if err := stack.Register(constructor); err != nil {
 			Fatalf("Failed to register the Whisper service: %v", err)
 		}
 	}
+	if ctx.GlobalBool(WhisperMailServerFlag.Name) {
+		if !shhEnable || ctx.GlobalInt(WhisperVersionFlag.Name) != 5 {
+			Fatalf("The Whisper mail server requires --%s and --%s=5", WhisperEnabledFlag.Name, WhisperVersionFlag.Name)
+		}
+		var passwords []string
+		if path := ctx.GlobalString(WhisperMailServerPasswordFileFlag.Name); path != "" {
+			text, err := ioutil.ReadFile(path)
+			if err != nil {
+				Fatalf("Failed to read the Whisper mail server password file: %v", err)
+			}
+			passwords = []string{strings.TrimRight(strings.SplitN(string(text), "\n", 2)[0], "\r")}
+		}
+		password := GetPassPhrase("Please give the password the Whisper mail server requests are encrypted with.", false, 0, passwords)
+		pow, _ := strconv.ParseFloat(ctx.GlobalString(WhisperMinPoWFlag.Name), 64)
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			var shh *whisperv5.Whisper
+			if err := ctx.Service(&shh); err != nil {
+				return nil, err
+			}
+			db, err := ctx.OpenDatabase("shhmail", 16, 16)
+			if err != nil {
+				return nil, err
+			}
+			ldb, ok := db.(*ethdb.LDBDatabase)
+			if !ok {
+				db.Close()
+				return nil, fmt.Errorf("the Whisper mail server requires a data directory")
+			}
+			return mailserver.New(shh, ldb, password, pow)
+		}); err != nil {
+			Fatalf("Failed to register the Whisper mail server: %v", err)
+		}
+	}
 
 	// bzz.	Swarm
 	var bzzconfig *bzzapi.Config
 	hexaddr := ctx.GlobalString(SwarmAccountAddrFlag.Name)
 	if hexaddr != "" {
 		swarmaccount := common.HexToAddress(hexaddr)
-		if !accman.HasAccount(swarmaccount) {
+		if !accman.HasAddress(swarmaccount) {
 			Fatalf("swarm account '%v' does not exist: %v", hexaddr, err)
 		}
 		prvkey, err := accman.GetUnlocked(swarmaccount)
@@ -885,10 +996,13 @@ func MakeSystemNode(name, version string, extra []byte, ctx *cli.Context) *node.
 		if len(bzzport) > 0 {
 			bzzconfig.Port = bzzport
 		}
+		if ensroot := ctx.GlobalString(EnsRootFlag.Name); ensroot != "" {
+			bzzconfig.EnsRoot = common.HexToAddress(ensroot)
+		}
 		swapEnabled := !ctx.GlobalBool(SwarmSwapDisabled.Name)
 		syncEnabled := !ctx.GlobalBool(SwarmSyncDisabled.Name)
 		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-			return swarm.NewSwarm(ctx, bzzconfig, swapEnabled, syncEnabled)
+			return swarm.NewSwarm(ctx, nil, bzzconfig, swapEnabled, syncEnabled)
 		}); err != nil {
 			Fatalf("Failed to register the Swarm service: %v", err)
 		}
@@ -43,6 +43,15 @@ func (self *Api) Cash(cheque *Cheque) (txhash string, err error) {
 	return ch.Cash(cheque)
 }
 
+// Outstanding lists the amounts issued to and cashed by each beneficiary
+func (self *Api) Outstanding() ([]*ChequeStatus, error) {
+	ch := self.chequebookf()
+	if ch == nil {
+		return nil, errNoChequebook
+	}
+	return ch.Outstanding()
+}
+
 func (self *Api) Deposit(amount *big.Int) (txhash string, err error) {
 	ch := self.chequebookf()
 	if ch == nil {
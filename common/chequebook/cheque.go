@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -319,6 +320,45 @@ func (self *Chequebook) Issue(beneficiary common.Address, amount *big.Int) (ch *
 	return
 }
 
+// ChequeStatus is the account of the cheques issued to a beneficiary
+type ChequeStatus struct {
+	Beneficiary common.Address
+	Issued      *big.Int // cumulative amount of the cheques issued
+	Cashed      *big.Int // cumulative amount cashed by the beneficiary
+	Outstanding *big.Int // amount issued but not yet cashed
+}
+
+// Outstanding lists the cheques issued to each beneficiary and how much of
+// them is not cashed yet, the amounts cashed are read from the contract
+func (self *Chequebook) Outstanding() ([]*ChequeStatus, error) {
+	defer self.lock.Unlock()
+	self.lock.Lock()
+	var statuses []*ChequeStatus
+	for beneficiary, sent := range self.sent {
+		tallyhex, _, err := self.backend.Call(self.owner.Hex(), self.contract.Hex(), "", "", "", getSentAbiEncode(beneficiary))
+		if err != nil {
+			return nil, fmt.Errorf("unable to get amount cashed by %v: %v", beneficiary.Hex(), err)
+		}
+		cashed := new(big.Int).SetBytes(common.FromHex(tallyhex))
+		statuses = append(statuses, &ChequeStatus{
+			Beneficiary: beneficiary,
+			Issued:      new(big.Int).Set(sent),
+			Cashed:      cashed,
+			Outstanding: new(big.Int).Sub(sent, cashed),
+		})
+	}
+	sort.Sort(byBeneficiary(statuses))
+	return statuses, nil
+}
+
+type byBeneficiary []*ChequeStatus
+
+func (s byBeneficiary) Len() int      { return len(s) }
+func (s byBeneficiary) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byBeneficiary) Less(i, j int) bool {
+	return bytes.Compare(s[i].Beneficiary[:], s[j].Beneficiary[:]) < 0
+}
+
 // convenience method to cash any cheque
 func (self *Chequebook) Cash(ch *Cheque) (txhash string, err error) {
 	return ch.Cash(self.owner, self.backend)
@@ -515,12 +555,27 @@ func (self *Inbox) Stop() {
 func (self *Inbox) Cash() (txhash string, err error) {
 	if self.cheque != nil {
 		txhash, err = self.cheque.Cash(self.sender, self.backend)
+		if err != nil {
+			glog.V(logger.Warn).Infof("[CHEQUEBOOK] error cashing cheque (total: %v) on chequebook (%s): %v", self.cheque.Amount, self.contract.Hex(), err)
+			return
+		}
 		glog.V(logger.Detail).Infof("[CHEQUEBOOK] cashing cheque (total: %v) on chequebook (%s) sending to %v", self.cheque.Amount, self.contract.Hex(), self.beneficiary.Hex())
 		self.cashed = self.cheque.Amount
+		self.txhash = txhash
 	}
 	return
 }
 
+// Uncashed returns the amount received in cheques that is not cashed yet
+func (self *Inbox) Uncashed() *big.Int {
+	defer self.lock.Unlock()
+	self.lock.Lock()
+	if self.cheque == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Sub(self.cheque.Amount, self.cashed)
+}
+
 // AutoCash(cashInterval, maxUncashed) (re)sets maximum time and amount which
 // triggers cashing of the last uncashed cheque
 // if maxUncashed is set to 0, then autocash on receipt
@@ -556,10 +611,7 @@ func (self *Inbox) autoCash(cashInterval time.Duration) {
 			case <-ticker.C:
 				self.lock.Lock()
 				if self.cheque != nil && self.cheque.Amount.Cmp(self.cashed) != 0 {
-					txhash, err := self.Cash()
-					if err == nil {
-						self.txhash = txhash
-					}
+					self.Cash()
 				}
 				self.lock.Unlock()
 			}
@@ -577,7 +629,7 @@ func (self *Inbox) Receive(promise swap.Promise) (*big.Int, error) {
 	var sum *big.Int
 	if self.cheque == nil {
 		// the sum is checked against the blockchain once a check is received
-		tallyhex, _, err := self.backend.Call(self.beneficiary.Hex(), self.contract.Hex(), "", "", "", getSentAbiEncode(ch.Contract))
+		tallyhex, _, err := self.backend.Call(self.beneficiary.Hex(), self.contract.Hex(), "", "", "", getSentAbiEncode(self.beneficiary))
 		if err != nil {
 			return nil, fmt.Errorf("inbox: error calling backend to set amount: %v", err)
 		}
@@ -589,6 +641,8 @@ func (self *Inbox) Receive(promise swap.Promise) (*big.Int, error) {
 		// 	return nil, fmt.Errorf("inbox: cannot convert amount '%s' (%v) to integer", tallyhex, tally)
 		// }
 		sum = new(big.Int).SetBytes(tally)
+		// what was sent so far is cashed already
+		self.cashed = new(big.Int).Set(sum)
 	} else {
 		sum = self.cheque.Amount
 	}
@@ -601,6 +655,7 @@ func (self *Inbox) Receive(promise swap.Promise) (*big.Int, error) {
 		if self.maxUncashed != nil {
 			uncashed = new(big.Int).Sub(ch.Amount, self.cashed)
 			if self.maxUncashed.Cmp(uncashed) < 0 {
+				// errors are logged, cashing is reattempted with the next cheque
 				self.Cash()
 			}
 		}
@@ -676,5 +731,5 @@ func (self *Cheque) Verify(signerKey *ecdsa.PublicKey, contract, beneficiary com
 // Cash(backend) will cash the check using xeth backend to send a transaction
 // Beneficiary address should be unlocked
 func (self *Cheque) Cash(sender common.Address, backend Backend) (string, error) {
-	return backend.Transact(sender.Hex(), self.Contract.Hex(), "", "", "", gasToCash, self.cashAbiEncode())
+	return backend.Transact(sender.Hex(), self.Contract.Hex(), "", "", gasToCash, "", self.cashAbiEncode())
 }
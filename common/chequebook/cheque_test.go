@@ -1,11 +1,18 @@
 package chequebook
 
 import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
@@ -500,3 +507,157 @@ func TestCash(t *testing.T) {
 	}
 
 }
+
+// simBackend implements Backend on a simulated blockchain, transactions are
+// signed with the keys of the accounts and mined right away
+type simBackend struct {
+	*backends.SimulatedBackend
+	keys map[common.Address]*ecdsa.PrivateKey
+}
+
+func newSimBackend(keys ...*ecdsa.PrivateKey) *simBackend {
+	b := &simBackend{keys: make(map[common.Address]*ecdsa.PrivateKey)}
+	var accounts []core.GenesisAccount
+	for _, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		b.keys[addr] = key
+		accounts = append(accounts, core.GenesisAccount{Address: addr, Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)})
+	}
+	b.SimulatedBackend = backends.NewSimulatedBackend(accounts...)
+	return b
+}
+
+func (b *simBackend) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error) {
+	from := common.HexToAddress(fromStr)
+	key, ok := b.keys[from]
+	if !ok {
+		return "", fmt.Errorf("unknown account %v", fromStr)
+	}
+	nonce, _ := b.PendingAccountNonce(from)
+	value, gas := new(big.Int), big.NewInt(90000)
+	if valueStr != "" {
+		value.SetString(valueStr, 10)
+	}
+	if gasStr != "" {
+		gas.SetString(gasStr, 10)
+	}
+	gasPrice, _ := b.SuggestGasPrice()
+	if gasPriceStr != "" {
+		gasPrice.SetString(gasPriceStr, 10)
+	}
+	var tx *types.Transaction
+	if toStr == "" {
+		tx = types.NewContractCreation(nonce, value, gas, gasPrice, common.FromHex(codeStr))
+	} else {
+		tx = types.NewTransaction(nonce, common.HexToAddress(toStr), value, gas, gasPrice, common.FromHex(codeStr))
+	}
+	tx, err := tx.SignECDSA(key)
+	if err != nil {
+		return "", err
+	}
+	if err := b.SendTransaction(tx); err != nil {
+		return "", err
+	}
+	b.Commit()
+	return tx.Hash().Hex(), nil
+}
+
+func (b *simBackend) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, string, error) {
+	out, err := b.ContractCall(common.HexToAddress(toStr), common.FromHex(codeStr), false)
+	return common.ToHex(out), "", err
+}
+
+func (b *simBackend) GetTxReceipt(txhash common.Hash) *types.Receipt {
+	return b.TransactionReceipt(txhash)
+}
+
+func (b *simBackend) CodeAt(address string) string {
+	return common.ToHex(b.SimulatedBackend.CodeAt(common.HexToAddress(address)))
+}
+
+func (b *simBackend) BalanceAt(address common.Address) string {
+	return b.SimulatedBackend.BalanceAt(address).String()
+}
+
+func TestAutoCashOnChain(t *testing.T) {
+	ownerKey, _ := crypto.GenerateKey()
+	beneficiaryKey, _ := crypto.GenerateKey()
+	owner := crypto.PubkeyToAddress(ownerKey.PublicKey)
+	beneficiary := crypto.PubkeyToAddress(beneficiaryKey.PublicKey)
+	backend := newSimBackend(ownerKey, beneficiaryKey)
+
+	// deploy the chequebook contract with a deposit
+	txhash, err := backend.Transact(owner.Hex(), "", "", "1000000", deployGas, "", ContractCode)
+	if err != nil {
+		t.Fatalf("deployment failed: %v", err)
+	}
+	receipt := backend.GetTxReceipt(common.HexToHash(txhash))
+	if receipt == nil {
+		t.Fatalf("no receipt for deployment")
+	}
+	contract := receipt.ContractAddress
+	if err := Validate(contract, backend); err != nil {
+		t.Fatalf("invalid contract: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "chequebook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	chbook, err := NewChequebook(filepath.Join(dir, "chequebook.json"), contract, ownerKey, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chbook.Balance().Cmp(big.NewInt(1000000)) != 0 {
+		t.Fatalf("expected balance 1000000, got %v", chbook.Balance())
+	}
+	inbox, err := NewInbox(contract, beneficiary, beneficiary, &ownerKey.PublicKey, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// cash as soon as more than 30 wei are uncashed
+	inbox.AutoCash(0, big.NewInt(30))
+	defer inbox.Stop()
+
+	cashed := func() *big.Int {
+		tallyhex, _, err := backend.Call(owner.Hex(), contract.Hex(), "", "", "", getSentAbiEncode(beneficiary))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return new(big.Int).SetBytes(common.FromHex(tallyhex))
+	}
+	receive := func(amount int64) {
+		ch, err := chbook.Issue(beneficiary, big.NewInt(amount))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := inbox.Receive(ch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	receive(20)
+	if cashed().Sign() != 0 || inbox.Uncashed().Cmp(big.NewInt(20)) != 0 {
+		t.Fatalf("cheque below threshold cashed (cashed %v, uncashed %v)", cashed(), inbox.Uncashed())
+	}
+	receive(20)
+	if cashed().Cmp(big.NewInt(40)) != 0 || inbox.Uncashed().Sign() != 0 {
+		t.Fatalf("cheques above threshold not cashed (cashed %v, uncashed %v)", cashed(), inbox.Uncashed())
+	}
+	if balance := backend.SimulatedBackend.BalanceAt(contract); balance.Cmp(big.NewInt(1000000-40)) != 0 {
+		t.Errorf("expected contract balance %v, got %v", 1000000-40, balance)
+	}
+
+	// cheques issued but not cashed yet are outstanding
+	if _, err := chbook.Issue(beneficiary, big.NewInt(5)); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := chbook.Outstanding()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].Beneficiary != beneficiary || statuses[0].Issued.Cmp(big.NewInt(45)) != 0 || statuses[0].Cashed.Cmp(big.NewInt(40)) != 0 || statuses[0].Outstanding.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("unexpected outstanding cheques: %+v", statuses[0])
+	}
+}
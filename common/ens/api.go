@@ -0,0 +1,88 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ens
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const Version = "1.0"
+
+// PublicApi exposes the lookups of the registry under the ens namespace
+type PublicApi struct {
+	ens *ENS
+}
+
+func NewPublicApi(ens *ENS) *PublicApi {
+	return &PublicApi{ens}
+}
+
+// Resolve returns the content hash of name
+func (self *PublicApi) Resolve(name string) (common.Hash, error) {
+	return self.ens.Resolve(name)
+}
+
+// Owner returns the owner of name
+func (self *PublicApi) Owner(name string) (common.Address, error) {
+	return self.ens.Owner(name)
+}
+
+// PrivateApi exposes the transactions of the registry under the ens
+// namespace, they are sent from the account of the node so the api must not
+// be public
+type PrivateApi struct {
+	ens *ENS
+}
+
+func NewPrivateApi(ens *ENS) *PrivateApi {
+	return &PrivateApi{ens}
+}
+
+// Register assigns name to the account of the node, returns the transaction
+// hash
+func (self *PrivateApi) Register(name string) (common.Hash, error) {
+	tx, err := self.ens.Register(name)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// SetResolver sets the resolver of name, returns the transaction hash
+func (self *PrivateApi) SetResolver(name string, resolverAddr common.Address) (common.Hash, error) {
+	tx, err := self.ens.SetResolver(name, resolverAddr)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// SetContentHash sets the content hash of name, returns the transaction hash
+func (self *PrivateApi) SetContentHash(name string, hash common.Hash) (common.Hash, error) {
+	tx, err := self.ens.SetContentHash(name, hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// DeployResolver deploys a public resolver for the registry, returns its
+// address
+func (self *PrivateApi) DeployResolver() (common.Address, error) {
+	resolverAddr, _, err := self.ens.DeployResolver()
+	return resolverAddr, err
+}
@@ -0,0 +1,188 @@
+; ENS registry: owner and resolver records of the namehash nodes
+;
+; storage:
+;   sha3(node . 0) owner of node
+;   sha3(node . 1) resolver of node
+;
+; calls throw by jumping to offset 0, which is not a JUMPDEST
+
+; dispatch on the function selector
+    PUSH1 0
+    CALLDATALOAD
+    PUSH29 0x100000000000000000000000000000000000000000000000000000000
+    SWAP1
+    DIV
+    DUP1
+    PUSH4 0x02571be3 ; owner(bytes32)
+    EQ
+    JUMPI @owner
+    DUP1
+    PUSH4 0x0178b8bf ; resolver(bytes32)
+    EQ
+    JUMPI @resolver
+    DUP1
+    PUSH4 0x5b0fc9c3 ; setOwner(bytes32,address)
+    EQ
+    JUMPI @setOwner
+    DUP1
+    PUSH4 0x06ab5923 ; setSubnodeOwner(bytes32,bytes32,address)
+    EQ
+    JUMPI @setSubnodeOwner
+    DUP1
+    PUSH4 0x1896f70a ; setResolver(bytes32,address)
+    EQ
+    JUMPI @setResolver
+    PUSH1 0
+    JUMP
+
+owner:
+    PUSH1 0          ; owner record
+    JUMP @record
+
+resolver:
+    PUSH1 1          ; resolver record
+
+; returns the record of the node in the first argument
+record:
+    PUSH1 32
+    MSTORE
+    PUSH1 4
+    CALLDATALOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 64
+    PUSH1 0
+    SHA3
+    SLOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 32
+    PUSH1 0
+    RETURN
+
+setOwner:
+    PUSH1 4
+    CALLDATALOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 0
+    PUSH1 32
+    MSTORE
+    PUSH1 64
+    PUSH1 0
+    SHA3             ; owner slot of node
+    DUP1
+    SLOAD
+    CALLER
+    EQ
+    ISZERO
+    PUSH1 0
+    JUMPI            ; only the owner of the node
+    PUSH20 0xffffffffffffffffffffffffffffffffffffffff
+    PUSH1 36
+    CALLDATALOAD
+    AND              ; new owner
+    DUP1
+    SWAP2
+    SSTORE
+    ; Transfer(node, owner)
+    PUSH1 0
+    MSTORE
+    PUSH1 4
+    CALLDATALOAD
+    PUSH32 0xd4735d920b0f87494915f556dd9b54c8f309026070caea5c737245152564d266
+    PUSH1 32
+    PUSH1 0
+    LOG2
+    STOP
+
+setSubnodeOwner:
+    PUSH1 4
+    CALLDATALOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 0
+    PUSH1 32
+    MSTORE
+    PUSH1 64
+    PUSH1 0
+    SHA3
+    SLOAD
+    CALLER
+    EQ
+    ISZERO
+    PUSH1 0
+    JUMPI            ; only the owner of the parent node
+    PUSH1 36
+    CALLDATALOAD
+    PUSH1 32
+    MSTORE
+    PUSH1 64
+    PUSH1 0
+    SHA3             ; subnode = sha3(node . label)
+    PUSH1 0
+    MSTORE
+    PUSH1 0
+    PUSH1 32
+    MSTORE
+    PUSH20 0xffffffffffffffffffffffffffffffffffffffff
+    PUSH1 68
+    CALLDATALOAD
+    AND              ; new owner
+    DUP1
+    PUSH1 64
+    PUSH1 0
+    SHA3             ; owner slot of subnode
+    SSTORE
+    ; NewOwner(node, label, owner)
+    PUSH1 0
+    MSTORE
+    PUSH1 36
+    CALLDATALOAD
+    PUSH1 4
+    CALLDATALOAD
+    PUSH32 0xce0457fe73731f824cc272376169235128c118b49d344817417c6d108d155e82
+    PUSH1 32
+    PUSH1 0
+    LOG3
+    STOP
+
+setResolver:
+    PUSH1 4
+    CALLDATALOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 0
+    PUSH1 32
+    MSTORE
+    PUSH1 64
+    PUSH1 0
+    SHA3
+    SLOAD
+    CALLER
+    EQ
+    ISZERO
+    PUSH1 0
+    JUMPI            ; only the owner of the node
+    PUSH1 1
+    PUSH1 32
+    MSTORE
+    PUSH20 0xffffffffffffffffffffffffffffffffffffffff
+    PUSH1 36
+    CALLDATALOAD
+    AND              ; new resolver
+    DUP1
+    PUSH1 64
+    PUSH1 0
+    SHA3             ; resolver slot of node
+    SSTORE
+    ; NewResolver(node, resolver)
+    PUSH1 0
+    MSTORE
+    PUSH1 4
+    CALLDATALOAD
+    PUSH32 0x335721b01866dc23fbee8b6b2c7b1e14d6f05c28cd35a2c934239f94095602a0
+    PUSH1 32
+    PUSH1 0
+    LOG2
+    STOP
@@ -0,0 +1,6 @@
+; the creator owns the root node
+    CALLER
+    PUSH1 64
+    PUSH1 0
+    SHA3             ; owner slot of node 0, memory is zero
+    SSTORE
@@ -0,0 +1,99 @@
+; resolver of content hash records, set by the owners of the nodes in the
+; ENS registry
+;
+; storage:
+;   0              address of the ENS registry
+;   sha3(node . 0) content hash of node
+;
+; calls throw by jumping to offset 0, which is not a JUMPDEST
+
+; dispatch on the function selector
+    PUSH1 0
+    CALLDATALOAD
+    PUSH29 0x100000000000000000000000000000000000000000000000000000000
+    SWAP1
+    DIV
+    DUP1
+    PUSH4 0x2dff6941 ; content(bytes32)
+    EQ
+    JUMPI @content
+    DUP1
+    PUSH4 0xc3d014d6 ; setContent(bytes32,bytes32)
+    EQ
+    JUMPI @setContent
+    PUSH1 0
+    JUMP
+
+content:
+    PUSH1 4
+    CALLDATALOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 0
+    PUSH1 32
+    MSTORE
+    PUSH1 64
+    PUSH1 0
+    SHA3
+    SLOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 32
+    PUSH1 0
+    RETURN
+
+setContent:
+    ; ens.owner(node)
+    PUSH32 0x02571be300000000000000000000000000000000000000000000000000000000
+    PUSH1 0
+    MSTORE
+    PUSH1 4
+    CALLDATALOAD
+    PUSH1 4
+    MSTORE
+    PUSH1 32         ; output size
+    PUSH1 0          ; output offset
+    PUSH1 36         ; input size
+    PUSH1 0          ; input offset
+    PUSH1 0          ; value
+    PUSH1 0
+    SLOAD            ; registry
+    PUSH1 50         ; the call itself costs 40
+    GAS
+    SUB
+    CALL
+    ISZERO
+    PUSH1 0
+    JUMPI            ; the registry must answer
+    PUSH1 0
+    MLOAD
+    CALLER
+    EQ
+    ISZERO
+    PUSH1 0
+    JUMPI            ; only the owner of the node
+    PUSH1 4
+    CALLDATALOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 0
+    PUSH1 32
+    MSTORE
+    PUSH1 36
+    CALLDATALOAD     ; content hash
+    PUSH1 64
+    PUSH1 0
+    SHA3
+    SSTORE
+    ; ContentChanged(node, hash)
+    PUSH1 36
+    CALLDATALOAD
+    PUSH1 0
+    MSTORE
+    PUSH1 4
+    CALLDATALOAD
+    PUSH32 0x0424b6fe0d9c3bdbece0e7879dc241bb0c22e900be8b6c168b4ee08bd9bf83bc
+    PUSH1 32
+    PUSH1 0
+    LOG2
+    STOP
@@ -0,0 +1,11 @@
+; the registry is the constructor argument appended to the code
+    PUSH1 32
+    DUP1
+    CODESIZE
+    SUB
+    PUSH1 0
+    CODECOPY
+    PUSH1 0
+    MLOAD
+    PUSH1 0
+    SSTORE
@@ -0,0 +1,68 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build none
+
+// build assembles the deploy code of the named contracts into <name>.bin: the
+// constructor in <name>.init.asm followed by the code returning the runtime
+// code in <name>.asm, which is appended.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/asm"
+)
+
+// the code returning the runtime code appended to the deploy code is always
+// 13 bytes long
+const returnSize = 13
+
+func main() {
+	for _, name := range os.Args[1:] {
+		if err := build(name); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func build(name string) error {
+	runtime, err := compile(name + ".asm")
+	if err != nil {
+		return err
+	}
+	init, err := compile(name + ".init.asm")
+	if err != nil {
+		return err
+	}
+	ret, err := asm.Compile([]byte(fmt.Sprintf("PUSH2 %d\nDUP1\nPUSH2 %d\nPUSH1 0\nCODECOPY\nPUSH1 0\nRETURN", len(runtime), len(init)+returnSize)))
+	if err != nil {
+		return err
+	}
+	code := append(append(init, ret...), runtime...)
+	return ioutil.WriteFile(name+".bin", []byte(fmt.Sprintf("0x%x\n", code)), 0644)
+}
+
+func compile(path string) ([]byte, error) {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return asm.Compile(src)
+}
@@ -0,0 +1,24 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package contract contains the bindings of the ENS registry and the public
+// resolver contracts. The contracts are written in EVM assembly (see core/asm),
+// build.go assembles their deploy code and abigen generates the bindings.
+package contract
+
+//go:generate go run build.go ENS PublicResolver
+//go:generate abigen --abi ENS.abi --bin ENS.bin --pkg contract --type ENS --out ens.go
+//go:generate abigen --abi PublicResolver.abi --bin PublicResolver.bin --pkg contract --type PublicResolver --out publicresolver.go
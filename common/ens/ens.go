@@ -0,0 +1,157 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ens resolves names through an ENS style registry contract. The
+// registry maps the namehash of a name to its owner and to the resolver
+// contract holding its records, such as the content hash swarm serves under
+// the name.
+package ens
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/ens/contract"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var errNoResolver = errors.New("no resolver set for name")
+
+// ENS is a registry contract bound to an account sending its transactions
+type ENS struct {
+	registry        *contract.ENSSession
+	contractAddr    common.Address
+	contractBackend bind.ContractBackend
+}
+
+// NewENS binds the registry deployed at contractAddr. Transactions are sent
+// with transactOpts, calls are made against the pending state.
+func NewENS(transactOpts *bind.TransactOpts, contractAddr common.Address, contractBackend bind.ContractBackend) (*ENS, error) {
+	registry, err := contract.NewENS(contractAddr, contractBackend)
+	if err != nil {
+		return nil, err
+	}
+	return &ENS{
+		registry: &contract.ENSSession{
+			Contract:     registry,
+			CallOpts:     bind.CallOpts{Pending: true},
+			TransactOpts: *transactOpts,
+		},
+		contractAddr:    contractAddr,
+		contractBackend: contractBackend,
+	}, nil
+}
+
+// DeployENS deploys a new registry, its root node is owned by the sender of
+// transactOpts
+func DeployENS(transactOpts *bind.TransactOpts, contractBackend bind.ContractBackend) (*ENS, error) {
+	contractAddr, _, _, err := contract.DeployENS(transactOpts, contractBackend)
+	if err != nil {
+		return nil, err
+	}
+	return NewENS(transactOpts, contractAddr, contractBackend)
+}
+
+// Address returns the address of the registry contract
+func (self *ENS) Address() common.Address {
+	return self.contractAddr
+}
+
+// ensParentNode returns the node of the parent of name and the hash of the
+// label of name within it
+func ensParentNode(name string) (common.Hash, common.Hash) {
+	parts := strings.SplitN(name, ".", 2)
+	label := crypto.Sha3Hash([]byte(parts[0]))
+	if len(parts) == 1 {
+		return common.Hash{}, label
+	}
+	return ensNode(parts[1]), label
+}
+
+// ensNode returns the namehash of name, the root node is the zero hash
+func ensNode(name string) common.Hash {
+	if name == "" {
+		return common.Hash{}
+	}
+	parentNode, label := ensParentNode(name)
+	return crypto.Sha3Hash(parentNode[:], label[:])
+}
+
+// Owner returns the owner of name
+func (self *ENS) Owner(name string) (common.Address, error) {
+	return self.registry.Owner(ensNode(name))
+}
+
+// Register assigns name to the sender, which must own the parent of name
+func (self *ENS) Register(name string) (*types.Transaction, error) {
+	parentNode, label := ensParentNode(name)
+	return self.registry.SetSubnodeOwner(parentNode, label, self.registry.TransactOpts.From)
+}
+
+// SetResolver sets the resolver contract of name, which the sender must own
+func (self *ENS) SetResolver(name string, resolverAddr common.Address) (*types.Transaction, error) {
+	return self.registry.SetResolver(ensNode(name), resolverAddr)
+}
+
+// DeployResolver deploys a new public resolver for the names of the registry
+func (self *ENS) DeployResolver() (common.Address, *types.Transaction, error) {
+	resolverAddr, tx, _, err := contract.DeployPublicResolver(&self.registry.TransactOpts, self.contractBackend, self.contractAddr)
+	return resolverAddr, tx, err
+}
+
+// resolver returns the resolver of the node
+func (self *ENS) resolver(node common.Hash) (*contract.PublicResolverSession, error) {
+	resolverAddr, err := self.registry.Resolver(node)
+	if err != nil {
+		return nil, err
+	}
+	if resolverAddr == (common.Address{}) {
+		return nil, errNoResolver
+	}
+	resolver, err := contract.NewPublicResolver(resolverAddr, self.contractBackend)
+	if err != nil {
+		return nil, err
+	}
+	return &contract.PublicResolverSession{
+		Contract:     resolver,
+		CallOpts:     self.registry.CallOpts,
+		TransactOpts: self.registry.TransactOpts,
+	}, nil
+}
+
+// Resolve returns the content hash name resolves to
+func (self *ENS) Resolve(name string) (common.Hash, error) {
+	node := ensNode(name)
+	resolver, err := self.resolver(node)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return resolver.Content(node)
+}
+
+// SetContentHash sets the content hash of name in its resolver, the sender
+// must own name
+func (self *ENS) SetContentHash(name string, hash common.Hash) (*types.Transaction, error) {
+	node := ensNode(name)
+	resolver, err := self.resolver(node)
+	if err != nil {
+		return nil, err
+	}
+	return resolver.SetContent(node, hash)
+}
@@ -0,0 +1,123 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ens
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	key, _       = crypto.GenerateKey()
+	addr         = crypto.PubkeyToAddress(key.PublicKey)
+	otherKey, _  = crypto.GenerateKey()
+	otherAddr    = crypto.PubkeyToAddress(otherKey.PublicKey)
+	testBalance  = big.NewInt(1000000000000000000)
+	contentHash  = common.HexToHash("0x2a")
+	contentHash2 = common.HexToHash("0x2b")
+)
+
+func TestENSNode(t *testing.T) {
+	// namehash test vectors of the ENS specification
+	tests := map[string]string{
+		"":        "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	}
+	for name, want := range tests {
+		if node := ensNode(name); node != common.HexToHash(want) {
+			t.Errorf("namehash of %q: have %x, want %s", name, node, want)
+		}
+	}
+}
+
+func TestENS(t *testing.T) {
+	sim := backends.NewSimulatedBackend(core.GenesisAccount{Address: addr, Balance: testBalance}, core.GenesisAccount{Address: otherAddr, Balance: testBalance})
+	ens, err := DeployENS(bind.NewKeyedTransactor(key), sim)
+	if err != nil {
+		t.Fatalf("can't deploy registry: %v", err)
+	}
+	sim.Commit()
+
+	// the deployer owns the root and registers a top level name
+	if _, err := ens.Register("swarm"); err != nil {
+		t.Fatalf("can't register name: %v", err)
+	}
+	sim.Commit()
+	if owner, err := ens.Owner("swarm"); err != nil || owner != addr {
+		t.Fatalf("owner of name: have %x (%v), want %x", owner, err, addr)
+	}
+	if _, err := ens.Resolve("swarm"); err != errNoResolver {
+		t.Fatalf("expected no resolver, got %v", err)
+	}
+
+	resolverAddr, _, err := ens.DeployResolver()
+	if err != nil {
+		t.Fatalf("can't deploy resolver: %v", err)
+	}
+	sim.Commit()
+	if _, err := ens.SetResolver("swarm", resolverAddr); err != nil {
+		t.Fatalf("can't set resolver: %v", err)
+	}
+	sim.Commit()
+	if _, err := ens.SetContentHash("swarm", contentHash); err != nil {
+		t.Fatalf("can't set content hash: %v", err)
+	}
+	sim.Commit()
+	if hash, err := ens.Resolve("swarm"); err != nil || hash != contentHash {
+		t.Fatalf("resolve name: have %x (%v), want %x", hash, err, contentHash)
+	}
+
+	// the owner of the name assigns a subname to another account
+	other, err := NewENS(bind.NewKeyedTransactor(otherKey), ens.Address(), sim)
+	if err != nil {
+		t.Fatalf("can't bind registry: %v", err)
+	}
+	if _, err := ens.registry.SetSubnodeOwner(ensNode("swarm"), crypto.Sha3Hash([]byte("docs")), otherAddr); err != nil {
+		t.Fatalf("can't assign subname: %v", err)
+	}
+	sim.Commit()
+	if _, err := other.SetResolver("docs.swarm", resolverAddr); err != nil {
+		t.Fatalf("can't set resolver of subname: %v", err)
+	}
+	sim.Commit()
+	if _, err := other.SetContentHash("docs.swarm", contentHash2); err != nil {
+		t.Fatalf("can't set content hash of subname: %v", err)
+	}
+	sim.Commit()
+	if hash, err := ens.Resolve("docs.swarm"); err != nil || hash != contentHash2 {
+		t.Fatalf("resolve subname: have %x (%v), want %x", hash, err, contentHash2)
+	}
+
+	// only the owner may change the records of a name
+	other.registry.TransactOpts.GasLimit = big.NewInt(100000)
+	other.SetContentHash("swarm", contentHash2)
+	other.Register("swarm")
+	sim.Commit()
+	if hash, err := ens.Resolve("swarm"); err != nil || hash != contentHash {
+		t.Fatalf("content hash changed by another account: have %x (%v), want %x", hash, err, contentHash)
+	}
+	if owner, err := ens.Owner("swarm"); err != nil || owner != addr {
+		t.Fatalf("name taken by another account: have %x (%v), want %x", owner, err, addr)
+	}
+}
@@ -263,11 +263,7 @@ func (be *registryAPIBackend) Transact(fromStr, toStr, nonceStr, valueStr, gasSt
 		tx = types.NewTransaction(nonce, to, value, gas, price, data)
 	}
 
-	signature, err := be.am.Sign(from, tx.SigHash().Bytes())
-	if err != nil {
-		return "", err
-	}
-	signedTx, err := tx.WithSignature(signature)
+	signedTx, err := be.am.SignTx(from, tx)
 	if err != nil {
 		return "", err
 	}
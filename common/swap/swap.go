@@ -76,9 +76,39 @@ type Swap struct {
 	local   *Params    // local peer's swap parameters
 	remote  *Profile   // remote peer's swap profile
 	proto   Protocol   // peer communication protocol
+	stats   *Stats     // totals of the exchange with the peer
 	Payment
 }
 
+// Stats are the accounting metrics of the exchange with a peer
+type Stats struct {
+	Balance          int      // units, positive if the peer owes, negative if we owe
+	Sold             uint64   // units of service provided to the peer
+	Bought           uint64   // units of service used from the peer
+	Paid             *big.Int // amount promised to the peer (wei)
+	Received         *big.Int // amount promised by the peer (wei)
+	PaymentsSent     uint64   // number of promises issued to the peer
+	PaymentsReceived uint64   // number of valid promises received from the peer
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		Paid:     new(big.Int),
+		Received: new(big.Int),
+	}
+}
+
+// Add adds the totals of other to the stats
+func (self *Stats) Add(other *Stats) {
+	self.Balance += other.Balance
+	self.Sold += other.Sold
+	self.Bought += other.Bought
+	self.Paid.Add(self.Paid, other.Paid)
+	self.Received.Add(self.Received, other.Received)
+	self.PaymentsSent += other.PaymentsSent
+	self.PaymentsReceived += other.PaymentsReceived
+}
+
 type Payment struct {
 	Out         OutPayment // outgoing payment handler
 	In          InPayment  // incoming  payment handler
@@ -92,6 +122,7 @@ func New(local *Params, pm Payment, proto Protocol) (self *Swap, err error) {
 		local:   local,
 		Payment: pm,
 		proto:   proto,
+		stats:   NewStats(),
 	}
 
 	self.SetParams(local)
@@ -150,6 +181,16 @@ func (self *Swap) setParams(local *Params) {
 func (self *Swap) Add(n int) error {
 	defer self.lock.Unlock()
 	self.lock.Lock()
+	if n > 0 {
+		self.stats.Sold += uint64(n)
+	} else {
+		self.stats.Bought += uint64(-n)
+	}
+	return self.add(n)
+}
+
+// caller holds the lock
+func (self *Swap) add(n int) error {
 	self.balance += n
 	if !self.Sells && self.balance > 0 {
 		glog.V(logger.Detail).Infof("[SWAP] <%v> remote peer cannot have debt (unable to buy)", self.proto, self.balance)
@@ -176,6 +217,16 @@ func (self *Swap) Balance() int {
 	return self.balance
 }
 
+// Stats returns a copy of the accounting metrics of the swap
+func (self *Swap) Stats() *Stats {
+	defer self.lock.Unlock()
+	self.lock.Lock()
+	stats := NewStats()
+	stats.Add(self.stats)
+	stats.Balance = self.balance
+	return stats
+}
+
 // send(units) is called when payment is due
 // In case of insolvency no promise is issued and sent, safe against fraud
 // No return value: no error = payment is opportunistic = hang in till dropped
@@ -190,6 +241,8 @@ func (self *Swap) send() {
 			glog.V(logger.Warn).Infof("[SWAP] <%v> cheque issued (amount: %v, channel: %v)", self.proto, amount, self.Out)
 			self.proto.Pay(-self.balance, promise)
 			self.balance = 0
+			self.stats.Paid.Add(self.stats.Paid, amount)
+			self.stats.PaymentsSent++
 		}
 	}
 }
@@ -218,7 +271,11 @@ func (self *Swap) Receive(units int, promise Promise) error {
 	}
 
 	// credit remote peer with units
-	self.Add(-units)
+	self.lock.Lock()
+	self.stats.Received.Add(self.stats.Received, amount)
+	self.stats.PaymentsReceived++
+	self.add(-units)
+	self.lock.Unlock()
 	glog.V(logger.Detail).Infof("[SWAP] <%v> received promise (amount: %v, channel: %v): %v", self.proto, amount, self.In, promise)
 
 	return nil
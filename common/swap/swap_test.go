@@ -159,6 +159,17 @@ func TestSwap(t *testing.T) {
 		t.Fatalf("expected payment amount %v, got %v", exp, proto.promises[0].amount)
 	}
 
+	stats := swap.Stats()
+	if stats.Sold != 10 || stats.Bought != 3 || stats.Balance != 0 {
+		t.Fatalf("unexpected units in stats: %+v", stats)
+	}
+	if stats.Received.Cmp(big.NewInt(20)) != 0 || stats.PaymentsReceived != 1 {
+		t.Fatalf("unexpected payments received in stats: %+v", stats)
+	}
+	if stats.Paid.Cmp(exp) != 0 || stats.PaymentsSent != 1 {
+		t.Fatalf("unexpected payments sent in stats: %+v", stats)
+	}
+
 	swap.SetParams(&Params{
 		Profile: &Profile{
 			PayAt:  5,
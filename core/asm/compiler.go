@@ -0,0 +1,223 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// labelSize is the number of bytes used to push a label offset. Offsets are
+// always pushed with a fixed width so that label positions can be computed
+// before all labels are known.
+const labelSize = 4
+
+// instruction is a single parsed statement of the assembly source.
+type instruction struct {
+	line    int
+	label   string    // label defined by this statement (emits a JUMPDEST)
+	op      vm.OpCode // opcode to emit, ignored for label definitions
+	size    int       // push width; 0 selects the minimal width
+	operand *Token    // optional operand of PUSH, JUMP and JUMPI
+}
+
+// Compile assembles the given source into EVM byte code.
+func Compile(src []byte) ([]byte, error) {
+	tokens, err := Lex(src)
+	if err != nil {
+		return nil, err
+	}
+	return CompileTokens(tokens)
+}
+
+// CompileTokens assembles a lexed token stream into EVM byte code.
+func CompileTokens(tokens []Token) ([]byte, error) {
+	instrs, err := parse(tokens)
+	if err != nil {
+		return nil, err
+	}
+	// First pass: resolve the location of every label
+	labels := make(map[string]uint64)
+	pc := uint64(0)
+	for _, in := range instrs {
+		if in.label != "" {
+			if _, exists := labels[in.label]; exists {
+				return nil, &SyntaxError{in.line, fmt.Sprintf("label %q redefined", in.label)}
+			}
+			labels[in.label] = pc
+			pc++
+			continue
+		}
+		size, err := in.encodedSize()
+		if err != nil {
+			return nil, err
+		}
+		pc += uint64(size)
+	}
+	// Second pass: emit the byte code
+	code := make([]byte, 0, pc)
+	for _, in := range instrs {
+		if in.label != "" {
+			code = append(code, byte(vm.JUMPDEST))
+			continue
+		}
+		if in.operand == nil {
+			code = append(code, byte(in.op))
+			continue
+		}
+		data, err := in.operandBytes(labels)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, byte(vm.PUSH1)+byte(len(data)-1))
+		code = append(code, data...)
+		if in.op == vm.JUMP || in.op == vm.JUMPI {
+			code = append(code, byte(in.op))
+		}
+	}
+	return code, nil
+}
+
+// parse groups the token stream into instructions, validating mnemonics and
+// operands along the way.
+func parse(tokens []Token) ([]instruction, error) {
+	var instrs []instruction
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch tok.Type {
+		case LineEnd:
+			continue
+
+		case LabelDef:
+			instrs = append(instrs, instruction{line: tok.Line, label: tok.Text})
+
+		case Element:
+			in := instruction{line: tok.Line}
+			switch {
+			case tok.Text == "PUSH":
+				in.op = vm.PUSH
+			case strings.HasPrefix(tok.Text, "PUSH") && vm.StringToOp(tok.Text).IsPush():
+				in.op = vm.StringToOp(tok.Text)
+				in.size = int(in.op-vm.PUSH1) + 1
+			default:
+				op := vm.StringToOp(tok.Text)
+				// Besides PUSH, the parser pseudo-ops have no meaning in bytecode
+				if (op == vm.STOP && tok.Text != "STOP") || (op >= vm.PUSH && op <= vm.SWAP) {
+					return nil, &SyntaxError{tok.Line, fmt.Sprintf("unknown instruction %q", tok.Text)}
+				}
+				in.op = op
+			}
+			// Consume the operand, if any
+			if i+1 < len(tokens) && (tokens[i+1].Type == Number || tokens[i+1].Type == Label) {
+				if in.op != vm.PUSH && !in.op.IsPush() && in.op != vm.JUMP && in.op != vm.JUMPI {
+					return nil, &SyntaxError{tok.Line, fmt.Sprintf("%s takes no operand", tok.Text)}
+				}
+				i++
+				operand := tokens[i]
+				in.operand = &operand
+			} else if in.op == vm.PUSH || in.op.IsPush() {
+				return nil, &SyntaxError{tok.Line, fmt.Sprintf("%s requires an operand", tok.Text)}
+			}
+			instrs = append(instrs, in)
+
+		default:
+			return nil, &SyntaxError{tok.Line, fmt.Sprintf("unexpected %v %q", tok.Type, tok.Text)}
+		}
+	}
+	return instrs, nil
+}
+
+// encodedSize returns the number of bytes the instruction assembles into.
+func (in *instruction) encodedSize() (int, error) {
+	if in.operand == nil {
+		return 1, nil
+	}
+	size := in.size
+	if size == 0 {
+		if in.operand.Type == Label {
+			size = labelSize
+		} else {
+			n, err := in.number()
+			if err != nil {
+				return 0, err
+			}
+			size = minPushSize(n)
+		}
+	}
+	if in.op == vm.JUMP || in.op == vm.JUMPI {
+		return size + 2, nil
+	}
+	return size + 1, nil
+}
+
+// operandBytes returns the push data of the instruction, left padded to the
+// push width.
+func (in *instruction) operandBytes(labels map[string]uint64) ([]byte, error) {
+	var n *big.Int
+	if in.operand.Type == Label {
+		pos, ok := labels[in.operand.Text]
+		if !ok {
+			return nil, &SyntaxError{in.line, fmt.Sprintf("undefined label %q", in.operand.Text)}
+		}
+		n = new(big.Int).SetUint64(pos)
+	} else {
+		var err error
+		if n, err = in.number(); err != nil {
+			return nil, err
+		}
+	}
+	size := in.size
+	if size == 0 {
+		if in.operand.Type == Label {
+			size = labelSize
+		} else {
+			size = minPushSize(n)
+		}
+	}
+	data := n.Bytes()
+	if len(data) > size {
+		return nil, &SyntaxError{in.line, fmt.Sprintf("operand %s does not fit in %d bytes", in.operand.Text, size)}
+	}
+	return append(make([]byte, size-len(data)), data...), nil
+}
+
+// number parses the numeric operand of the instruction.
+func (in *instruction) number() (*big.Int, error) {
+	text, base := in.operand.Text, 10
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X") {
+		text, base = text[2:], 16
+	}
+	n, ok := new(big.Int).SetString(text, base)
+	if !ok || n.Sign() < 0 {
+		return nil, &SyntaxError{in.line, fmt.Sprintf("invalid number %q", in.operand.Text)}
+	}
+	if n.BitLen() > 256 {
+		return nil, &SyntaxError{in.line, fmt.Sprintf("number %s exceeds 256 bits", in.operand.Text)}
+	}
+	return n, nil
+}
+
+// minPushSize returns the smallest push width able to hold n.
+func minPushSize(n *big.Int) int {
+	if size := len(n.Bytes()); size > 0 {
+		return size
+	}
+	return 1
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+func TestCompiler(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"STOP", "00"},
+		{"PUSH1 1\nPUSH1 0x02\nADD", "6001600201"},
+		{"PUSH 0", "6000"},
+		{"PUSH 256", "610100"},
+		{"PUSH3 0x01", "62000001"},
+		{"JUMP @end\nend:", "630000000656" + "5b"},
+		{"start:\nPUSH @start\nJUMPI @start", "5b" + "6300000000" + "630000000057"},
+		{"push1 0x01 ; comment\ndup1", "600180"},
+	}
+	for i, test := range tests {
+		code, err := Compile([]byte(test.input))
+		if err != nil {
+			t.Errorf("test %d: compile error: %v", i, err)
+			continue
+		}
+		if want := common.Hex2Bytes(test.output); !bytes.Equal(code, want) {
+			t.Errorf("test %d: code mismatch: have %x, want %x", i, code, want)
+		}
+	}
+}
+
+func TestCompilerErrors(t *testing.T) {
+	tests := []string{
+		"FOO",           // unknown instruction
+		"DUP",           // pseudo-op
+		"SWAP",          // pseudo-op
+		"PUSH1",         // missing operand
+		"ADD 1",         // spurious operand
+		"PUSH1 0x0100",  // operand too large
+		"JUMP @missing", // undefined label
+		"a:\na:",        // redefined label
+		"PUSH 1 2",      // dangling number
+		"PUSH32 0x1" + string(bytes.Repeat([]byte{'0'}, 64)), // over 256 bits
+	}
+	for i, input := range tests {
+		if _, err := Compile([]byte(input)); err == nil {
+			t.Errorf("test %d: expected error for %q", i, input)
+		}
+	}
+}
+
+func TestCompileAndExecute(t *testing.T) {
+	src := `
+		; count down from 10 and return the number of iterations
+		    PUSH 0          ; iterations
+		    PUSH 10         ; counter
+		loop:
+		    SWAP1
+		    PUSH1 1
+		    ADD
+		    SWAP1
+		    PUSH1 1
+		    SWAP1
+		    SUB
+		    DUP1
+		    JUMPI @loop
+		    POP
+		    PUSH 0
+		    MSTORE
+		    PUSH 32
+		    PUSH 0
+		    RETURN
+	`
+	code, err := Compile([]byte(src))
+	if err != nil {
+		t.Fatalf("compile error: %v", err)
+	}
+	ret, _, err := runtime.Execute(code, nil, nil)
+	if err != nil {
+		t.Fatalf("execution error: %v", err)
+	}
+	if want := common.LeftPadBytes([]byte{10}, 32); !bytes.Equal(ret, want) {
+		t.Errorf("return mismatch: have %x, want %x", ret, want)
+	}
+	if asm := vm.Disassemble(code); asm[len(asm)-1] != "RETURN" {
+		t.Errorf("disassembly mismatch: have %v", asm)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package asm implements an assembler for EVM mnemonics.
+
+The source consists of one statement per line. Comments start with a ';' and
+run until the end of the line.
+
+	; count down from 10
+	    PUSH 10
+	loop:                ; defines a label and emits a JUMPDEST
+	    PUSH1 0x01
+	    SWAP1
+	    SUB
+	    DUP1
+	    JUMPI @loop      ; pushes the label offset and jumps to it
+	    STOP
+
+Instructions are the mnemonics understood by vm.StringToOp. PUSH1 to PUSH32
+push a literal of the given width, while a plain PUSH selects the smallest
+width able to hold its operand. Literals are decimal or 0x prefixed
+hexadecimal numbers. A label reference (@name) may be used as the operand of
+PUSH, JUMP and JUMPI; label offsets are always pushed as 4 byte values so
+that JUMP @name and JUMPI @name assemble into a PUSH4 followed by the jump.
+*/
+package asm
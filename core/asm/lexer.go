@@ -0,0 +1,157 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TokenType is the type of a lexed token.
+type TokenType int
+
+const (
+	Element  TokenType = iota // instruction mnemonic, e.g. ADD or PUSH1
+	LabelDef                  // label definition, e.g. loop:
+	Label                     // label reference, e.g. @loop
+	Number                    // decimal or hexadecimal literal
+	LineEnd                   // end of a (non empty) source line
+)
+
+func (t TokenType) String() string {
+	switch t {
+	case Element:
+		return "element"
+	case LabelDef:
+		return "label definition"
+	case Label:
+		return "label"
+	case Number:
+		return "number"
+	case LineEnd:
+		return "end of line"
+	}
+	return fmt.Sprintf("token(%d)", int(t))
+}
+
+// Token is a single lexical element of an assembly source.
+type Token struct {
+	Type TokenType
+	Text string // literal text, without the ':' or '@' decorations
+	Line int    // 1-based source line the token was found on
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("%v %q (line %d)", t.Type, t.Text, t.Line)
+}
+
+// SyntaxError is returned by Lex and Compile when the source is malformed.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Lex splits the assembly source into tokens. Comments start with a ';' and
+// run until the end of the line. Every line that contains at least one token
+// is terminated by a LineEnd token.
+func Lex(src []byte) ([]Token, error) {
+	var tokens []Token
+	for i, line := range strings.Split(string(src), "\n") {
+		lineno := i + 1
+		if idx := strings.IndexByte(line, ';'); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		for _, field := range fields {
+			tok, err := lexField(field, lineno)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+		}
+		if len(fields) > 0 {
+			tokens = append(tokens, Token{Type: LineEnd, Line: lineno})
+		}
+	}
+	return tokens, nil
+}
+
+// lexField classifies a single whitespace separated word of the source.
+func lexField(field string, line int) (Token, error) {
+	switch {
+	case strings.HasSuffix(field, ":"):
+		name := field[:len(field)-1]
+		if !isIdent(name) {
+			return Token{}, &SyntaxError{line, fmt.Sprintf("invalid label definition %q", field)}
+		}
+		return Token{Type: LabelDef, Text: name, Line: line}, nil
+
+	case strings.HasPrefix(field, "@"):
+		name := field[1:]
+		if !isIdent(name) {
+			return Token{}, &SyntaxError{line, fmt.Sprintf("invalid label reference %q", field)}
+		}
+		return Token{Type: Label, Text: name, Line: line}, nil
+
+	case unicode.IsDigit(rune(field[0])):
+		if !isNumber(field) {
+			return Token{}, &SyntaxError{line, fmt.Sprintf("invalid number %q", field)}
+		}
+		return Token{Type: Number, Text: field, Line: line}, nil
+
+	case isIdent(field):
+		return Token{Type: Element, Text: strings.ToUpper(field), Line: line}, nil
+	}
+	return Token{}, &SyntaxError{line, fmt.Sprintf("unexpected %q", field)}
+}
+
+// isIdent reports whether s is a valid label or mnemonic name.
+func isIdent(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i, c := range s {
+		if c == '_' || unicode.IsLetter(c) || (i > 0 && unicode.IsDigit(c)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// isNumber reports whether s is a decimal or 0x prefixed hexadecimal literal.
+func isNumber(s string) bool {
+	digits, hex := s, false
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		digits, hex = s[2:], true
+	}
+	if len(digits) == 0 {
+		return false
+	}
+	for _, c := range digits {
+		if unicode.IsDigit(c) || (hex && strings.ContainsRune("abcdefABCDEF", c)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package asm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	tests := []struct {
+		input  string
+		tokens []Token
+	}{
+		{
+			input:  "; only a comment\n\n",
+			tokens: nil,
+		},
+		{
+			input: "push1 0x01 ; trailing comment",
+			tokens: []Token{
+				{Type: Element, Text: "PUSH1", Line: 1},
+				{Type: Number, Text: "0x01", Line: 1},
+				{Type: LineEnd, Line: 1},
+			},
+		},
+		{
+			input: "\nloop:\n\tJUMP @loop",
+			tokens: []Token{
+				{Type: LabelDef, Text: "loop", Line: 2},
+				{Type: LineEnd, Line: 2},
+				{Type: Element, Text: "JUMP", Line: 3},
+				{Type: Label, Text: "loop", Line: 3},
+				{Type: LineEnd, Line: 3},
+			},
+		},
+	}
+	for i, test := range tests {
+		tokens, err := Lex([]byte(test.input))
+		if err != nil {
+			t.Errorf("test %d: lex error: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(tokens, test.tokens) {
+			t.Errorf("test %d: token mismatch: have %v, want %v", i, tokens, test.tokens)
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	for i, input := range []string{"0xzz", "12ab", "@", "1abel:", "ADD $"} {
+		if _, err := Lex([]byte(input)); err == nil {
+			t.Errorf("test %d: expected error for %q", i, input)
+		}
+	}
+}
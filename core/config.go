@@ -44,3 +44,14 @@ func (c *ChainConfig) IsHomestead(num *big.Int) bool {
 
 	return num.Cmp(c.HomesteadBlock) >= 0
 }
+
+// ForkBlocks returns the hard fork switch blocks of the chain after genesis, in
+// ascending order. Nodes following the same chain must agree on the canonical
+// headers at these heights.
+func (c *ChainConfig) ForkBlocks() []*big.Int {
+	var forks []*big.Int
+	if c.HomesteadBlock != nil && c.HomesteadBlock.Sign() > 0 {
+		forks = append(forks, c.HomesteadBlock)
+	}
+	return forks
+}
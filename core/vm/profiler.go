@@ -0,0 +1,278 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// OpProfile contains the aggregated execution statistics of a single opcode.
+//
+// The wall time of the call and create family of opcodes includes the time
+// spent executing the nested contract.
+type OpProfile struct {
+	Op    string        `json:"op"`
+	Count uint64        `json:"count"`
+	Gas   *big.Int      `json:"gas"`
+	Time  time.Duration `json:"time"`
+}
+
+// ContractProfile contains the aggregated execution statistics of the code
+// running at a single contract address. Gas and wall time include the nested
+// calls made by the contract. JitCalls counts the calls which ran a JIT
+// compiled program, their opcodes are not included in Ops.
+type ContractProfile struct {
+	Address  common.Address `json:"address"`
+	Calls    uint64         `json:"calls"`
+	JitCalls uint64         `json:"jitCalls"`
+	Ops      uint64         `json:"ops"`
+	Gas      *big.Int       `json:"gas"`
+	Time     time.Duration  `json:"time"`
+}
+
+// JitProfile contains the aggregated execution statistics of the contract runs
+// which executed a JIT compiled program. These are not broken down by opcode,
+// as the JIT merges instructions into segments. Gas and wall time include the
+// nested calls.
+type JitProfile struct {
+	Calls uint64        `json:"calls"`
+	Gas   *big.Int      `json:"gas"`
+	Time  time.Duration `json:"time"`
+}
+
+// ProfileResult is a snapshot of the statistics collected by a Profiler.
+type ProfileResult struct {
+	Enabled   bool              `json:"enabled"`
+	Ops       []OpProfile       `json:"ops"`
+	Contracts []ContractProfile `json:"contracts"`
+	Jit       JitProfile        `json:"jit"`
+}
+
+// opMeters are the metrics registry counterparts of an opcode's statistics.
+type opMeters struct {
+	count gometrics.Meter
+	gas   gometrics.Meter
+	time  gometrics.Meter
+}
+
+// Profiler aggregates the number of executions, the gas used and the wall time
+// spent per opcode and per contract address by the byte code VM. Runs of JIT
+// compiled programs are counted per contract and in a separate JIT bucket, but
+// not per opcode. Profiling is opt-in: a disabled (or nil) profiler costs a
+// single check per contract run.
+//
+// Statistics are also reported to the metrics registry under vm/ops/<OPCODE>/
+// if metrics collection is enabled.
+type Profiler struct {
+	enabled int32 // atomic flag whether statistics are collected
+
+	lock      sync.Mutex
+	ops       [256]OpProfile
+	contracts map[common.Address]*ContractProfile
+	jit       JitProfile
+	meters    [256]*opMeters
+}
+
+// NewProfiler creates a new, disabled EVM profiler.
+func NewProfiler() *Profiler {
+	p := &Profiler{}
+	p.Reset()
+	return p
+}
+
+// Start enables the collection of statistics.
+func (p *Profiler) Start() {
+	atomic.StoreInt32(&p.enabled, 1)
+}
+
+// Stop disables the collection of statistics. Previously collected statistics
+// are retained until Reset is called.
+func (p *Profiler) Stop() {
+	atomic.StoreInt32(&p.enabled, 0)
+}
+
+// Enabled reports whether the profiler is collecting statistics.
+func (p *Profiler) Enabled() bool {
+	return p != nil && atomic.LoadInt32(&p.enabled) == 1
+}
+
+// Reset discards all collected statistics.
+func (p *Profiler) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i := range p.ops {
+		p.ops[i] = OpProfile{Op: OpCode(i).String(), Gas: new(big.Int)}
+	}
+	p.contracts = make(map[common.Address]*ContractProfile)
+	p.jit = JitProfile{Gas: new(big.Int)}
+}
+
+// Result returns a snapshot of the collected statistics, omitting opcodes which
+// were never executed.
+func (p *Profiler) Result() *ProfileResult {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	res := &ProfileResult{
+		Enabled:   p.Enabled(),
+		Ops:       []OpProfile{},
+		Contracts: make([]ContractProfile, 0, len(p.contracts)),
+		Jit:       p.jit,
+	}
+	res.Jit.Gas = new(big.Int).Set(p.jit.Gas)
+	for _, op := range p.ops {
+		if op.Count > 0 {
+			op.Gas = new(big.Int).Set(op.Gas)
+			res.Ops = append(res.Ops, op)
+		}
+	}
+	for _, contract := range p.contracts {
+		c := *contract
+		c.Gas = new(big.Int).Set(c.Gas)
+		res.Contracts = append(res.Contracts, c)
+	}
+	return res
+}
+
+// merge folds the statistics of a single contract run into the profiler.
+func (p *Profiler) merge(address common.Address, run *runProfile, gas *big.Int, elapsed time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var ops uint64
+	for i := range run.ops {
+		stats := &run.ops[i]
+		if stats.count == 0 {
+			continue
+		}
+		ops += stats.count
+
+		agg := &p.ops[i]
+		agg.Count += stats.count
+		agg.Gas.Add(agg.Gas, stats.gas)
+		agg.Time += stats.time
+
+		if metrics.Enabled {
+			m := p.meters[i]
+			if m == nil {
+				prefix := "vm/ops/" + OpCode(i).String() + "/"
+				m = &opMeters{
+					count: metrics.NewMeter(prefix + "count"),
+					gas:   metrics.NewMeter(prefix + "gas"),
+					time:  metrics.NewMeter(prefix + "time"),
+				}
+				p.meters[i] = m
+			}
+			m.count.Mark(int64(stats.count))
+			m.gas.Mark(stats.gas.Int64())
+			m.time.Mark(int64(stats.time))
+		}
+	}
+	contract := p.contracts[address]
+	if contract == nil {
+		contract = &ContractProfile{Address: address, Gas: new(big.Int)}
+		p.contracts[address] = contract
+	}
+	contract.Calls++
+	contract.Ops += ops
+	contract.Gas.Add(contract.Gas, gas)
+	contract.Time += elapsed
+
+	if run.jit {
+		contract.JitCalls++
+		p.jit.Calls++
+		p.jit.Gas.Add(p.jit.Gas, gas)
+		p.jit.Time += elapsed
+	}
+}
+
+// runOpStats are the statistics of a single opcode within a contract run.
+type runOpStats struct {
+	count uint64
+	gas   *big.Int
+	time  time.Duration
+}
+
+// runProfile collects the statistics of a single contract run without any
+// locking, merging them into the shared profiler once the run finishes.
+type runProfile struct {
+	profiler *Profiler
+	address  common.Address
+	start    time.Time
+	gas      *big.Int // gas available at the start of the run
+	jit      bool     // whether the run executed a JIT compiled program
+
+	ops [256]runOpStats
+
+	pending bool      // whether an opcode is being timed
+	op      OpCode    // opcode being timed
+	opStart time.Time // start time of the opcode being timed
+}
+
+// newRunProfile starts profiling a contract run, returning nil if the profiler
+// is not enabled.
+func newRunProfile(p *Profiler, contract *Contract) *runProfile {
+	if !p.Enabled() {
+		return nil
+	}
+	return &runProfile{
+		profiler: p,
+		address:  contract.Address(),
+		start:    time.Now(),
+		gas:      new(big.Int).Set(contract.Gas),
+	}
+}
+
+// enter finishes timing the previous opcode, if any, and starts timing op.
+func (r *runProfile) enter(op OpCode, cost *big.Int) {
+	now := time.Now()
+	r.finish(now)
+
+	stats := &r.ops[op]
+	if stats.gas == nil {
+		stats.gas = new(big.Int)
+	}
+	stats.count++
+	stats.gas.Add(stats.gas, cost)
+
+	r.pending, r.op, r.opStart = true, op, now
+}
+
+// finish stops timing the pending opcode.
+func (r *runProfile) finish(now time.Time) {
+	if r.pending {
+		r.ops[r.op].time += now.Sub(r.opStart)
+		r.pending = false
+	}
+}
+
+// done finishes the contract run and merges its statistics into the profiler.
+func (r *runProfile) done(contract *Contract) {
+	now := time.Now()
+	r.finish(now)
+
+	used := new(big.Int).Sub(r.gas, contract.Gas)
+	r.profiler.merge(r.address, r, used, now.Sub(r.start))
+}
@@ -15,4 +15,9 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 // Package runtime provides a basic execution model for executing EVM code.
+//
+// The package also contains a differential test harness (JitDiff) that runs
+// random programs through both the byte code interpreter and the JIT VM and
+// reports any divergence. It runs as part of the package tests and, using the
+// gofuzz build tag, as a go-fuzz target.
 package runtime
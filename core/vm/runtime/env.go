@@ -56,12 +56,13 @@ func NewEnv(cfg *Config, state *state.StateDB) vm.Environment {
 		number:     cfg.BlockNumber,
 		time:       cfg.Time,
 		difficulty: cfg.Difficulty,
-		gasLimit:   cfg.GasLimit,
+		gasLimit:   new(big.Int).Set(cfg.GasLimit), // the call consumes cfg.GasLimit in place
 	}
 	env.evm = vm.New(env, vm.Config{
 		Debug:     cfg.Debug,
 		EnableJit: !cfg.DisableJit,
 		ForceJit:  !cfg.DisableJit,
+		Profiler:  cfg.Profiler,
 
 		Logger: vm.LogConfig{
 			Collector: env,
@@ -0,0 +1,34 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build gofuzz
+
+package runtime
+
+// Fuzz is the go-fuzz entry point of the JIT differential test. The input is
+// used as the code of the entry contract, executed against a fixed random state.
+//
+//	go-fuzz-build github.com/ethereum/go-ethereum/core/vm/runtime
+//	go-fuzz -bin=runtime-fuzz.zip -workdir=jitdiff
+func Fuzz(data []byte) int {
+	if len(data) == 0 {
+		return -1
+	}
+	if err := JitDiff(data, 0); err != nil {
+		panic(err)
+	}
+	return 0
+}
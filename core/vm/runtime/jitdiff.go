@@ -0,0 +1,250 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+const (
+	diffGasLimit  = 250000 // gas available to every differential execution
+	diffAccounts  = 4      // number of contract accounts in the random state
+	diffMaxOps    = 96     // maximum number of instructions in a random program
+	diffMaxSlots  = 8      // maximum number of storage slots per random account
+	diffValueBits = 64     // maximum size in bits of random balances and values
+)
+
+// diffOrigin is the transaction origin used by the differential executions.
+var diffOrigin = common.StringToAddress("jitdiff-origin")
+
+// validOps is the list of opcodes known to the VM, excluding the parsing
+// only pseudo opcodes.
+var validOps []vm.OpCode
+
+func init() {
+	for i := 0; i < 256; i++ {
+		op := vm.OpCode(i)
+		if op == vm.PUSH || op == vm.DUP || op == vm.SWAP {
+			continue
+		}
+		if !strings.HasPrefix(op.String(), "Missing opcode") {
+			validOps = append(validOps, op)
+		}
+	}
+}
+
+// diffResult is the observable outcome of executing a call.
+type diffResult struct {
+	ret     []byte
+	failed  bool
+	gasUsed *big.Int
+	refund  *big.Int
+	logs    vm.Logs
+	root    common.Hash
+}
+
+// JitDiff executes the given code as the entry contract of a pseudo random
+// state derived from seed, once with the byte code interpreter and once with
+// the forced JIT VM. It returns an error describing the first difference in
+// return data, failure status, gas used, refunds, logs or post state, or nil
+// if both executions agree.
+func JitDiff(code []byte, seed int64) error {
+	r := rand.New(rand.NewSource(seed))
+
+	db, _ := ethdb.NewMemDatabase()
+	root, addrs, err := randomState(r, db, code)
+	if err != nil {
+		return err
+	}
+	value := randomBig(r, diffValueBits/2)
+	input := make([]byte, r.Intn(68))
+	r.Read(input)
+
+	interp, err := diffExecute(db, root, addrs[0], input, value, false)
+	if err != nil {
+		return err
+	}
+	jit, err := diffExecute(db, root, addrs[0], input, value, true)
+	if err != nil {
+		return err
+	}
+	return interp.compare(jit)
+}
+
+// diffExecute runs a single call against the state identified by root.
+func diffExecute(db ethdb.Database, root common.Hash, addr common.Address, input []byte, value *big.Int, jit bool) (*diffResult, error) {
+	statedb, err := state.New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	// The EVM consumes gas from the limit in place, leaving the remaining gas.
+	gas := big.NewInt(diffGasLimit)
+	ret, err := Call(addr, input, &Config{
+		Origin:     diffOrigin,
+		GasLimit:   gas,
+		Value:      value,
+		Time:       big.NewInt(1),
+		DisableJit: !jit,
+		State:      statedb,
+	})
+	return &diffResult{
+		ret:     common.CopyBytes(ret),
+		failed:  err != nil,
+		gasUsed: new(big.Int).Sub(big.NewInt(diffGasLimit), gas),
+		refund:  new(big.Int).Set(statedb.GetRefund()),
+		logs:    statedb.Logs(),
+		root:    statedb.IntermediateRoot(),
+	}, nil
+}
+
+// compare returns an error describing the first difference between the
+// interpreter result and the JIT result.
+func (interp *diffResult) compare(jit *diffResult) error {
+	switch {
+	case interp.failed != jit.failed:
+		return fmt.Errorf("failure mismatch: interpreter %v, jit %v", interp.failed, jit.failed)
+	case !bytes.Equal(interp.ret, jit.ret):
+		return fmt.Errorf("return data mismatch: interpreter %x, jit %x", interp.ret, jit.ret)
+	case interp.gasUsed.Cmp(jit.gasUsed) != 0:
+		return fmt.Errorf("gas used mismatch: interpreter %v, jit %v", interp.gasUsed, jit.gasUsed)
+	case interp.refund.Cmp(jit.refund) != 0:
+		return fmt.Errorf("refund mismatch: interpreter %v, jit %v", interp.refund, jit.refund)
+	case len(interp.logs) != len(jit.logs):
+		return fmt.Errorf("log count mismatch: interpreter %d, jit %d", len(interp.logs), len(jit.logs))
+	}
+	for i := range interp.logs {
+		have, want := jit.logs[i], interp.logs[i]
+		if have.Address != want.Address || !bytes.Equal(have.Data, want.Data) || fmt.Sprint(have.Topics) != fmt.Sprint(want.Topics) {
+			return fmt.Errorf("log %d mismatch: interpreter %v, jit %v", i, want, have)
+		}
+	}
+	if interp.root != jit.root {
+		return fmt.Errorf("post state mismatch: interpreter %x, jit %x", interp.root, jit.root)
+	}
+	return nil
+}
+
+// randomState creates and commits a random state with a funded origin and a
+// number of contract accounts, the first of which holds the given code. The
+// remaining contracts hold random programs and serve as call targets.
+func randomState(r *rand.Rand, db ethdb.Database, code []byte) (common.Hash, []common.Address, error) {
+	statedb, err := state.New(common.Hash{}, db)
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	statedb.AddBalance(diffOrigin, new(big.Int).Lsh(common.Big1, diffValueBits))
+
+	addrs := diffAddresses()
+	for i, addr := range addrs {
+		statedb.AddBalance(addr, randomBig(r, diffValueBits))
+		statedb.SetNonce(addr, uint64(r.Intn(3)))
+		for j := r.Intn(diffMaxSlots); j > 0; j-- {
+			statedb.SetState(addr, common.BigToHash(big.NewInt(int64(r.Intn(diffMaxSlots)))), common.BigToHash(randomBig(r, 256)))
+		}
+		if i == 0 {
+			statedb.SetCode(addr, code)
+		} else {
+			statedb.SetCode(addr, randomCode(r, addrs))
+		}
+	}
+	root, err := statedb.Commit()
+	return root, addrs, err
+}
+
+// diffAddresses returns the addresses of the contract accounts in the random
+// state, the first of which is the entry contract.
+func diffAddresses() []common.Address {
+	addrs := make([]common.Address, diffAccounts)
+	for i := range addrs {
+		addrs[i] = common.BytesToAddress([]byte{0xaa, byte(i)})
+	}
+	return addrs
+}
+
+// randomCode generates a random program. The program consists mostly of valid
+// instructions, with push operands biased towards small values and known
+// account addresses, and jumps targeting the program's own jump destinations.
+func randomCode(r *rand.Rand, addrs []common.Address) []byte {
+	var (
+		code  []byte
+		dests []int // positions of the emitted JUMPDESTs
+		jumps []int // positions of the PUSH2 operands of the emitted jumps
+	)
+	// Seed the stack with a few small operands to avoid instant underflows
+	for n := r.Intn(12) + 4; n > 0; n-- {
+		code = append(code, byte(vm.PUSH1), byte(r.Intn(64)))
+	}
+	for n := r.Intn(diffMaxOps) + 1; n > 0; n-- {
+		switch p := r.Intn(100); {
+		case p < 30:
+			// Push a small number, as most operands are offsets or sizes
+			code = append(code, byte(vm.PUSH1), byte(r.Intn(64)))
+		case p < 40:
+			// Push an arbitrary sized random number
+			size := r.Intn(32) + 1
+			code = append(code, byte(vm.PUSH1)+byte(size-1))
+			for i := 0; i < size; i++ {
+				code = append(code, byte(r.Intn(256)))
+			}
+		case p < 45:
+			// Push the address of a known account, a precompile or ourselves
+			if r.Intn(3) == 0 {
+				code = append(code, byte(vm.PUSH1), byte(r.Intn(5)))
+			} else {
+				code = append(code, byte(vm.PUSH20))
+				code = append(code, addrs[r.Intn(len(addrs))].Bytes()...)
+			}
+		case p < 50:
+			dests = append(dests, len(code))
+			code = append(code, byte(vm.JUMPDEST))
+		case p < 55:
+			// Jump to a destination patched in once the program is complete
+			jumps = append(jumps, len(code)+1)
+			code = append(code, byte(vm.PUSH2), 0, 0, byte(vm.JUMP)+byte(r.Intn(2)))
+		case p < 57:
+			// An arbitrary, possibly invalid, byte
+			code = append(code, byte(r.Intn(256)))
+		default:
+			code = append(code, byte(validOps[r.Intn(len(validOps))]))
+		}
+	}
+	for _, pos := range jumps {
+		// Mostly valid destinations, sometimes an arbitrary position
+		dest := r.Intn(len(code) + 1)
+		if len(dests) > 0 && r.Intn(4) != 0 {
+			dest = dests[r.Intn(len(dests))]
+		}
+		code[pos], code[pos+1] = byte(dest>>8), byte(dest)
+	}
+	return code
+}
+
+// randomBig returns a random number of at most the given number of bits.
+func randomBig(r *rand.Rand, bits int) *big.Int {
+	buf := make([]byte, (bits+7)/8)
+	r.Read(buf)
+	return new(big.Int).SetBytes(buf)
+}
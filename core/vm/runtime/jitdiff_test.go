@@ -0,0 +1,76 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"flag"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var (
+	jitDiffRuns = flag.Int("jitdiff.runs", 500, "number of random programs to run through the JIT differential test")
+	jitDiffSeed = flag.Int64("jitdiff.seed", 1, "first seed of the JIT differential test")
+)
+
+// Tests that the interpreter and the JIT VM agree on the outcome of executing
+// random programs against random states.
+func TestJitDifferential(t *testing.T) {
+	runs := *jitDiffRuns
+	if testing.Short() {
+		runs /= 10
+	}
+	addrs := diffAddresses()
+	for seed := *jitDiffSeed; seed < *jitDiffSeed+int64(runs); seed++ {
+		code := randomCode(rand.New(rand.NewSource(seed)), addrs)
+		if err := JitDiff(code, seed); err != nil {
+			t.Errorf("seed %d: %v\ncode: %x", seed, err, code)
+		}
+	}
+}
+
+// Tests that the differential test detects diverging executions.
+func TestJitDiffCompare(t *testing.T) {
+	code := []byte{0x60, 0x01, 0x60, 0x00, 0x55} // PUSH1 1 PUSH1 0 SSTORE
+	for seed := int64(0); seed < 4; seed++ {
+		db, _ := ethdb.NewMemDatabase()
+		root, addrs, err := randomState(rand.New(rand.NewSource(seed)), db, code)
+		if err != nil {
+			t.Fatalf("failed to create state: %v", err)
+		}
+		a, err := diffExecute(db, root, addrs[0], nil, common.Big0, false)
+		if err != nil {
+			t.Fatalf("failed to execute: %v", err)
+		}
+		b, _ := diffExecute(db, root, addrs[0], nil, common.Big0, false)
+		if err := a.compare(b); err != nil {
+			t.Fatalf("identical executions reported as diverging: %v", err)
+		}
+		b.gasUsed.Add(b.gasUsed, common.Big1)
+		if err := a.compare(b); err == nil {
+			t.Errorf("gas divergence not detected")
+		}
+		b.gasUsed.Sub(b.gasUsed, common.Big1)
+		b.root[0]++
+		if err := a.compare(b); err == nil {
+			t.Errorf("post state divergence not detected")
+		}
+	}
+}
@@ -46,6 +46,7 @@ type Config struct {
 	Value       *big.Int
 	DisableJit  bool // "disable" so it's enabled by default
 	Debug       bool
+	Profiler    *vm.Profiler
 
 	State     *state.StateDB
 	GetHashFn func(n uint64) common.Hash
@@ -117,6 +117,72 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestProfiler(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	profiler := vm.NewProfiler()
+
+	// A disabled profiler must not collect anything
+	if _, _, err := Execute(code, nil, &Config{DisableJit: true, Profiler: profiler}); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if res := profiler.Result(); len(res.Ops) != 0 || len(res.Contracts) != 0 {
+		t.Fatalf("disabled profiler collected statistics: %+v", res)
+	}
+	// Enable the profiler and make sure opcodes and contracts are accounted for
+	profiler.Start()
+	for i := 0; i < 2; i++ {
+		if _, _, err := Execute(code, nil, &Config{DisableJit: true, Profiler: profiler}); err != nil {
+			t.Fatal("didn't expect error", err)
+		}
+	}
+	res := profiler.Result()
+	if !res.Enabled {
+		t.Error("expected profiler to be enabled")
+	}
+	counts := make(map[string]uint64)
+	for _, op := range res.Ops {
+		counts[op.Op] = op.Count
+	}
+	if counts["PUSH1"] != 8 || counts["MSTORE"] != 2 || counts["RETURN"] != 2 || len(counts) != 3 {
+		t.Errorf("opcode count mismatch: %v", counts)
+	}
+	if len(res.Contracts) != 1 {
+		t.Fatalf("contract count mismatch: have %d, want 1", len(res.Contracts))
+	}
+	if contract := res.Contracts[0]; contract.Calls != 2 || contract.Ops != 12 || contract.Gas.Sign() <= 0 {
+		t.Errorf("contract statistics mismatch: %+v", contract)
+	}
+	if res.Jit.Calls != 0 {
+		t.Errorf("interpreted runs counted as JIT runs: %+v", res.Jit)
+	}
+	// JIT runs are counted per contract and in their own bucket, not per opcode
+	if _, _, err := Execute(code, nil, &Config{Profiler: profiler}); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	res = profiler.Result()
+	if len(res.Ops) != 3 || res.Ops[0].Count+res.Ops[1].Count+res.Ops[2].Count != 12 {
+		t.Errorf("JIT run counted per opcode: %+v", res.Ops)
+	}
+	if contract := res.Contracts[0]; contract.Calls != 3 || contract.JitCalls != 1 || contract.Ops != 12 {
+		t.Errorf("contract statistics mismatch after JIT run: %+v", contract)
+	}
+	if res.Jit.Calls != 1 || res.Jit.Gas.Sign() <= 0 {
+		t.Errorf("JIT statistics mismatch: %+v", res.Jit)
+	}
+	// Reset should discard everything
+	profiler.Reset()
+	if res := profiler.Result(); len(res.Ops) != 0 || len(res.Contracts) != 0 || res.Jit.Calls != 0 {
+		t.Errorf("reset profiler retained statistics: %+v", res)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
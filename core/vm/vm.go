@@ -34,6 +34,7 @@ type Config struct {
 	EnableJit bool
 	ForceJit  bool
 	Logger    LogConfig
+	Profiler  *Profiler // optional opcode and contract execution profiler
 }
 
 // EVM is used to run Ethereum based contracts and will utilise the
@@ -68,6 +69,12 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 	evm.env.SetDepth(evm.env.Depth() + 1)
 	defer evm.env.SetDepth(evm.env.Depth() - 1)
 
+	// Collect execution statistics if the profiler is enabled
+	profile := newRunProfile(evm.cfg.Profiler, contract)
+	if profile != nil {
+		defer profile.done(contract)
+	}
+
 	if contract.CodeAddr != nil {
 		if p := Precompiled[contract.CodeAddr.Str()]; p != nil {
 			return evm.RunPrecompiled(p, input, contract)
@@ -90,6 +97,9 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 		// forced.
 		switch GetProgramStatus(codehash) {
 		case progReady:
+			if profile != nil {
+				profile.jit = true
+			}
 			return RunProgram(GetProgram(codehash), evm.env, contract, input)
 		case progUnknown:
 			if evm.cfg.ForceJit {
@@ -97,6 +107,9 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 				program = NewProgram(contract.Code)
 				perr := CompileProgram(program)
 				if perr == nil {
+					if profile != nil {
+						profile.jit = true
+					}
 					return RunProgram(program, evm.env, contract, input)
 				}
 				glog.V(logger.Info).Infoln("error compiling program", err)
@@ -186,6 +199,9 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 		if !contract.UseGas(cost) {
 			return nil, OutOfGasError
 		}
+		if profile != nil {
+			profile.enter(op, cost)
+		}
 
 		// Resize the memory calculated previously
 		mem.Resize(newMemSize.Uint64())
@@ -310,6 +310,15 @@ func (s *PrivateMinerAPI) MakeDAG(blockNr rpc.BlockNumber) (bool, error) {
 	return true, nil
 }
 
+// StratumStats returns the statistics of the workers seen by the stratum mining
+// server, including their share counts and hashrates.
+func (s *PrivateMinerAPI) StratumStats() ([]miner.StratumWorkerStats, error) {
+	if s.e.Stratum() == nil {
+		return nil, errors.New("stratum server not enabled")
+	}
+	return s.e.Stratum().Stats(), nil
+}
+
 // PublicTxPoolAPI offers and API for the transaction pool. It only operates on data that is non confidential.
 type PublicTxPoolAPI struct {
 	e *Ethereum
@@ -479,6 +488,74 @@ func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 	return s.am.Lock(addr) == nil
 }
 
+// signHash calculates the hash signed by personal_sign for a message. The message
+// is prefixed with "\x19Ethereum Signed Message:\n" and its length, so that the
+// signature can't be mistaken for that of a transaction or other structured data:
+//
+//	keccak256("\x19Ethereum Signed Message:\n" + len(message) + message)
+func signHash(message []byte) []byte {
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)
+	return crypto.Keccak256([]byte(msg))
+}
+
+// decodeHex decodes the hex encoded (optionally 0x prefixed) argument of the
+// signing methods, rejecting anything that isn't valid hex
+func decodeHex(input string) ([]byte, error) {
+	if strings.HasPrefix(input, "0x") || strings.HasPrefix(input, "0X") {
+		input = input[2:]
+	}
+	data, err := hex.DecodeString(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %v", err)
+	}
+	return data, nil
+}
+
+// Sign calculates an Ethereum specific signature of the given message with the
+// account of addr, decrypting its key with password for this signature only.
+// The account doesn't need to be unlocked. See signHash for the signed digest.
+//
+// The returned signature is in [R || S || V] format where V is 27 or 28.
+func (s *PrivateAccountAPI) Sign(message string, addr common.Address, password string) (string, error) {
+	data, err := decodeHex(message)
+	if err != nil {
+		return "", err
+	}
+	signature, err := s.am.SignWithPassphrase(accounts.Account{Address: addr}, password, signHash(data))
+	if err != nil {
+		return "", err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28
+	return common.ToHex(signature), nil
+}
+
+// EcRecover returns the address of the account that created the given signature
+// of a message with personal_sign.
+func (s *PrivateAccountAPI) EcRecover(message string, signature string) (common.Address, error) {
+	data, err := decodeHex(message)
+	if err != nil {
+		return common.Address{}, err
+	}
+	sig, err := decodeHex(signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("signature must be 65 bytes long")
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		return common.Address{}, fmt.Errorf("invalid Ethereum signature (V is not 27 or 28)")
+	}
+	sig = append([]byte{}, sig...)
+	sig[64] -= 27 // Transform V from 27/28 to 0/1
+
+	pub, err := crypto.SigToPub(signHash(data), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
 // PublicBlockChainAPI provides an API to access the Ethereum blockchain.
 // It offers only methods that operate on public data that is freely available to anyone.
 type PublicBlockChainAPI struct {
@@ -1114,12 +1191,9 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
+// The account manager routes the request to whichever of its backends holds the address.
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
-	signature, err := s.am.Sign(addr, tx.SigHash().Bytes())
-	if err != nil {
-		return nil, err
-	}
-	return tx.WithSignature(signature)
+	return s.am.SignTx(addr, tx)
 }
 
 type SendTxArgs struct {
@@ -1208,8 +1282,12 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(encodedTx string) (string,
 	return tx.Hash().Hex(), nil
 }
 
-// Sign signs the given hash using the key that matches the address. The key must be
-// unlocked in order to sign the hash.
+// Sign signs the given hash using the key that matches the address. Keys in the key
+// store must be unlocked in order to sign the hash, while external backends decide
+// on the request themselves.
+//
+// Note, the hash is signed as is, so it may just as well be the hash of a transaction.
+// Use personal_sign to sign messages with a prefix that can't be confused with one.
 func (s *PublicTransactionPoolAPI) Sign(addr common.Address, hash common.Hash) (string, error) {
 	signature, error := s.am.Sign(addr, hash[:])
 	return common.ToHex(signature), error
@@ -1610,6 +1688,31 @@ func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
 	return ldb.LDB().GetProperty(property)
 }
 
+// VmProfile returns the per opcode and per contract execution statistics
+// collected by the EVM profiler.
+func (api *PrivateDebugAPI) VmProfile() *vm.ProfileResult {
+	return api.eth.vmProfiler.Result()
+}
+
+// StartVmProfile turns on the collection of EVM execution statistics.
+func (api *PrivateDebugAPI) StartVmProfile() bool {
+	api.eth.vmProfiler.Start()
+	return true
+}
+
+// StopVmProfile turns off the collection of EVM execution statistics. The
+// statistics collected so far are retained.
+func (api *PrivateDebugAPI) StopVmProfile() bool {
+	api.eth.vmProfiler.Stop()
+	return true
+}
+
+// ResetVmProfile discards all collected EVM execution statistics.
+func (api *PrivateDebugAPI) ResetVmProfile() bool {
+	api.eth.vmProfiler.Reset()
+	return true
+}
+
 // BlockTraceResults is the returned value when replaying a block to check for
 // consensus results and full VM trace logs for all included transactions.
 type BlockTraceResult struct {
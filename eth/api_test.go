@@ -0,0 +1,115 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that personal_sign produces prefixed signatures that personal_ecRecover
+// attributes to the signer, without the account having to be unlocked.
+func TestPersonalSign(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-personal-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP)
+	account, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewPrivateAccountAPI(am)
+	message := common.ToHex([]byte("Hello Ethereum"))
+
+	if _, err := api.Sign(message, account.Address, "bar"); err == nil {
+		t.Fatalf("signed with wrong password")
+	}
+	signature, err := api.Sign(message, account.Address, "foo")
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	sig := common.FromHex(signature)
+	if len(sig) != 65 || (sig[64] != 27 && sig[64] != 28) {
+		t.Fatalf("invalid signature format: %s", signature)
+	}
+	signer, err := api.EcRecover(message, signature)
+	if err != nil {
+		t.Fatalf("failed to recover signer: %v", err)
+	}
+	if signer != account.Address {
+		t.Fatalf("recovered signer mismatch: have %x, want %x", signer, account.Address)
+	}
+	// The signature must not be valid for the bare message hash nor other messages
+	sig[64] -= 27
+	if pub, err := crypto.SigToPub(crypto.Keccak256([]byte("Hello Ethereum")), sig); err == nil && crypto.PubkeyToAddress(*pub) == account.Address {
+		t.Fatalf("signature valid for unprefixed message")
+	}
+	if signer, err := api.EcRecover(common.ToHex([]byte("Hello Ethereum!")), signature); err == nil && signer == account.Address {
+		t.Fatalf("signature valid for different message")
+	}
+	if _, err := am.Sign(account.Address, make([]byte, 32)); err != accounts.ErrLocked {
+		t.Fatalf("account unlocked by personal_sign: %v", err)
+	}
+}
+
+// Tests that personal_sign and personal_ecRecover reject messages that aren't
+// valid hex instead of signing whatever part of them decodes.
+func TestPersonalSignInvalidHex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-personal-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	am := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP)
+	account, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewPrivateAccountAPI(am)
+	signature, err := api.Sign("0x00", account.Address, "foo")
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	for _, message := range []string{"Hello Ethereum", "0xabc", "0xzz"} {
+		if _, err := api.Sign(message, account.Address, "foo"); err == nil {
+			t.Errorf("signed invalid hex message %q", message)
+		}
+		if _, err := api.EcRecover(message, signature); err == nil {
+			t.Errorf("recovered signer of invalid hex message %q", message)
+		}
+	}
+	if _, err := api.EcRecover("0x00", signature[:len(signature)-1]); err == nil {
+		t.Errorf("recovered signer of odd length signature")
+	}
+}
+
+func TestPersonalSignHash(t *testing.T) {
+	have := signHash([]byte("abc"))
+	want := crypto.Keccak256([]byte("\x19Ethereum Signed Message:\n3abc"))
+	if common.ToHex(have) != common.ToHex(want) {
+		t.Fatalf("hash mismatch: have %x, want %x", have, want)
+	}
+}
@@ -88,6 +88,9 @@ type Config struct {
 	MinerThreads   int
 	SolcPath       string
 
+	StratumAddr       string   // TCP address of the stratum mining server, disabled if empty
+	StratumDifficulty *big.Int // Default and minimum share difficulty of the stratum workers
+
 	GpoMinGasPrice          *big.Int
 	GpoMaxGasPrice          *big.Int
 	GpoFullBlockRatio       int
@@ -97,6 +100,7 @@ type Config struct {
 
 	EnableJit bool
 	ForceJit  bool
+	VmProfile bool // Enables the EVM opcode and contract profiler at startup
 
 	TestGenesisBlock *types.Block   // Genesis block to seed the chain database with (testing only!)
 	TestGenesisState ethdb.Database // Genesis state to seed the database with (testing only!)
@@ -129,8 +133,10 @@ type Ethereum struct {
 
 	httpclient *httpclient.HTTPClient
 
-	eventMux *event.TypeMux
-	miner    *miner.Miner
+	eventMux   *event.TypeMux
+	miner      *miner.Miner
+	stratum    *miner.StratumServer
+	vmProfiler *vm.Profiler
 
 	Mining        bool
 	MinerThreads  int
@@ -141,6 +147,7 @@ type Ethereum struct {
 	etherbase     common.Address
 	netVersionId  int
 	netRPCService *PublicNetAPI
+	stratumAddr   string
 }
 
 func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
@@ -248,9 +255,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		return nil, errors.New("missing chain config")
 	}
 	eth.chainConfig = config.ChainConfig
+	eth.vmProfiler = vm.NewProfiler()
+	if config.VmProfile {
+		eth.vmProfiler.Start()
+	}
 	eth.chainConfig.VmConfig = vm.Config{
 		EnableJit: config.EnableJit,
 		ForceJit:  config.ForceJit,
+		Profiler:  eth.vmProfiler,
 	}
 
 	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux())
@@ -270,6 +282,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	eth.miner.SetGasPrice(config.GasPrice)
 	eth.miner.SetExtra(config.ExtraData)
 
+	if config.StratumAddr != "" {
+		agent := miner.NewRemoteAgent()
+		eth.miner.Register(agent)
+
+		eth.stratum = miner.NewStratumServer(agent, eth.pow, config.StratumDifficulty)
+		eth.stratumAddr = config.StratumAddr
+	}
+
 	return eth, nil
 }
 
@@ -386,6 +406,9 @@ func (s *Ethereum) StopMining()         { s.miner.Stop() }
 func (s *Ethereum) IsMining() bool      { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 
+// Stratum returns the stratum mining server, or nil if it is disabled.
+func (s *Ethereum) Stratum() *miner.StratumServer { return s.stratum }
+
 func (s *Ethereum) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Ethereum) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Ethereum) TxPool() *core.TxPool               { return s.txPool }
@@ -409,14 +432,28 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	if s.AutoDAG {
 		s.StartAutoDAG()
 	}
+	// without discovery there is no node database to persist reputations in,
+	// they are kept in memory instead
+	if srvr.Discovery {
+		s.protocolManager.reputation.setStore(srvr)
+	}
 	s.protocolManager.Start()
 	s.netRPCService = NewPublicNetAPI(srvr, s.NetVersion())
+
+	if s.stratum != nil {
+		if err := s.stratum.Start(s.stratumAddr); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
+	if s.stratum != nil {
+		s.stratum.Stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	s.txPool.Stop()
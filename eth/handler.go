@@ -42,21 +42,34 @@ import (
 const (
 	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned blocks, headers or node data.
 	estHeaderRlpSize  = 500             // Approximate size of an RLP encoded block header
+
+	forkChallengeTimeout = 15 * time.Second // Time allowance for a peer to answer the fork challenge
 )
 
 // errIncompatibleConfig is returned if the requested protocols and configs are
 // not compatible (low protocol version restrictions and high requirements).
 var errIncompatibleConfig = errors.New("incompatible configuration")
 
+// protoError is an eth wire protocol violation committed by a remote peer.
+type protoError struct {
+	code errCode
+	msg  string
+}
+
+func (e *protoError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.msg)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protoError{code: code, msg: fmt.Sprintf(format, v...)}
 }
 
 type hashFetcherFn func(common.Hash) error
 type blockFetcherFn func([]common.Hash) error
 
 type ProtocolManager struct {
-	networkId int
+	networkId   int
+	chainconfig *core.ChainConfig
 
 	fastSync   bool
 	txpool     txPool
@@ -66,6 +79,7 @@ type ProtocolManager struct {
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
+	reputation *reputation
 
 	SubProtocols []p2p.Protocol
 
@@ -94,16 +108,18 @@ func NewProtocolManager(config *core.ChainConfig, fastSync bool, networkId int,
 	}
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
-		networkId:  networkId,
-		fastSync:   fastSync,
-		eventMux:   mux,
-		txpool:     txpool,
-		blockchain: blockchain,
-		chaindb:    chaindb,
-		peers:      newPeerSet(),
-		newPeerCh:  make(chan *peer, 1),
-		txsyncCh:   make(chan *txsync),
-		quitSync:   make(chan struct{}),
+		networkId:   networkId,
+		chainconfig: config,
+		fastSync:    fastSync,
+		eventMux:    mux,
+		txpool:      txpool,
+		blockchain:  blockchain,
+		chaindb:     chaindb,
+		peers:       newPeerSet(),
+		reputation:  newReputation(),
+		newPeerCh:   make(chan *peer, 1),
+		txsyncCh:    make(chan *txsync),
+		quitSync:    make(chan struct{}),
 	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
@@ -141,7 +157,7 @@ func NewProtocolManager(config *core.ChainConfig, fastSync bool, networkId int,
 	manager.downloader = downloader.New(chaindb, manager.eventMux, blockchain.HasHeader, blockchain.HasBlockAndState, blockchain.GetHeader,
 		blockchain.GetBlock, blockchain.CurrentHeader, blockchain.CurrentBlock, blockchain.CurrentFastBlock, blockchain.FastSyncCommitHead,
 		blockchain.GetTd, blockchain.InsertHeaderChain, blockchain.InsertChain, blockchain.InsertReceiptChain, blockchain.Rollback,
-		manager.removePeer)
+		manager.penalisingRemover(penaltyDownloadFailed))
 
 	validator := func(block *types.Block, parent *types.Block) error {
 		return core.ValidateHeader(config, pow, block.Header(), parent.Header(), true, false)
@@ -149,7 +165,7 @@ func NewProtocolManager(config *core.ChainConfig, fastSync bool, networkId int,
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().NumberU64()
 	}
-	manager.fetcher = fetcher.New(blockchain.GetBlock, validator, manager.BroadcastBlock, heighter, blockchain.InsertChain, manager.removePeer)
+	manager.fetcher = fetcher.New(blockchain.GetBlock, validator, manager.BroadcastBlock, heighter, blockchain.InsertChain, manager.penalisingRemover(penaltyFetchFailed))
 
 	return manager, nil
 }
@@ -173,6 +189,17 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
+// penalisingRemover returns a peer removal callback, which also lowers the
+// reputation of the removed peer by the given penalty.
+func (pm *ProtocolManager) penalisingRemover(penalty int64) func(id string) {
+	return func(id string) {
+		if peer := pm.peers.Peer(id); peer != nil {
+			pm.reputation.adjust(peer.ID(), -penalty)
+		}
+		pm.removePeer(id)
+	}
+}
+
 func (pm *ProtocolManager) Start() {
 	// broadcast transactions
 	pm.txSub = pm.eventMux.Subscribe(core.TxPreEvent{})
@@ -211,6 +238,11 @@ func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *p
 func (pm *ProtocolManager) handle(p *peer) error {
 	glog.V(logger.Debug).Infof("%v: peer connected [%s]", p, p.Name())
 
+	// Refuse peers which were banned for misbehaving earlier
+	if until, banned := pm.reputation.banned(p.ID()); banned {
+		glog.V(logger.Debug).Infof("%v: peer banned until %v", p, until)
+		return errResp(ErrSuspendedPeer, "banned until %v", until)
+	}
 	// Execute the Ethereum handshake
 	td, head, genesis := pm.blockchain.Status()
 	if err := p.Handshake(pm.networkId, td, head, genesis); err != nil {
@@ -228,10 +260,25 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 	defer pm.removePeer(p.id)
 
-	// Register the peer in the downloader. If the downloader considers it banned, we disconnect
-	if err := pm.downloader.RegisterPeer(p.id, p.version, p.Head(),
-		p.RequestHashes, p.RequestHashesFromNumber, p.RequestBlocks, p.RequestHeadersByHash,
-		p.RequestHeadersByNumber, p.RequestBodies, p.RequestReceipts, p.RequestNodeData); err != nil {
+	// If we're past a hard fork, challenge the peer to prove it's on our side of
+	// it. Until it does, the peer is kept out of sync and the downloader.
+	if fork := pm.forkChallenge(); fork != nil && p.version >= eth62 {
+		if err := p.RequestHeadersByNumber(fork.Number.Uint64(), 1, 0, false); err != nil {
+			return err
+		}
+		p.forkHeader = fork
+		p.forkDrop = time.AfterFunc(forkChallengeTimeout, func() {
+			glog.V(logger.Debug).Infof("%v: timed out fork check #%v, dropping", p, fork.Number)
+			pm.removePeer(p.id)
+		})
+		// Make sure the timer is cleaned up if the peer dies off
+		defer func() {
+			if p.forkDrop != nil {
+				p.forkDrop.Stop()
+				p.forkDrop = nil
+			}
+		}()
+	} else if err := pm.registerSyncPeer(p); err != nil {
 		return err
 	}
 	// Propagate existing transactions. new transactions appearing
@@ -242,11 +289,80 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			glog.V(logger.Debug).Infof("%v: message handling failed: %v", p, err)
+			if _, ok := err.(*protoError); ok {
+				pm.reputation.adjust(p.ID(), -penaltyProtocolError)
+			}
 			return err
 		}
 	}
 }
 
+// registerSyncPeer registers the peer in the downloader and makes it available
+// for sync. If the downloader considers it banned, we disconnect.
+func (pm *ProtocolManager) registerSyncPeer(p *peer) error {
+	if err := pm.downloader.RegisterPeer(p.id, p.version, p.Head(),
+		p.RequestHashes, p.RequestHashesFromNumber, p.RequestBlocks, p.RequestHeadersByHash,
+		p.RequestHeadersByNumber, p.RequestBodies, p.RequestReceipts, p.RequestNodeData); err != nil {
+		return err
+	}
+	p.SetSyncable()
+	return nil
+}
+
+// forkChallenge returns our canonical header at the highest hard fork block we
+// have already reached, or nil if we haven't passed any forks yet.
+func (pm *ProtocolManager) forkChallenge() *types.Header {
+	forks := pm.chainconfig.ForkBlocks()
+	head := pm.blockchain.CurrentHeader().Number
+	for i := len(forks) - 1; i >= 0; i-- {
+		if forks[i].Cmp(head) <= 0 {
+			return pm.blockchain.GetHeaderByNumber(forks[i].Uint64())
+		}
+	}
+	return nil
+}
+
+// checkForkReply checks whether a batch of headers answers a pending fork
+// challenge, and if so, verifies that the peer is on our side of the fork and
+// registers it for sync. It returns whether the headers were consumed by the
+// check. As the peer is only registered in the downloader once the check
+// passes, no other header request can be pending for an empty reply.
+func (pm *ProtocolManager) checkForkReply(p *peer, headers []*types.Header) (bool, error) {
+	fork := p.forkHeader
+	switch {
+	case len(headers) == 0:
+		// The peer may not have reached the fork yet, which is only plausible if
+		// its total difficulty is below ours at the fork block
+		if p.Td().Cmp(pm.blockchain.GetTd(fork.Hash())) >= 0 {
+			return false, nil
+		}
+		glog.V(logger.Debug).Infof("%v: not yet past fork block #%v", p, fork.Number)
+
+	case len(headers) == 1 && headers[0].Number.Cmp(fork.Number) == 0:
+		if hash := headers[0].Hash(); hash != fork.Hash() {
+			glog.V(logger.Debug).Infof("%v: on a different fork, dropping", p)
+			p.forkDrop.Stop()
+			p.forkDrop = nil
+			return true, errResp(ErrForkMismatch, "block #%v: have %x, want %x", fork.Number, hash[:4], fork.Hash().Bytes()[:4])
+		}
+		glog.V(logger.Debug).Infof("%v: verified fork block #%v", p, fork.Number)
+
+	default:
+		return false, nil
+	}
+	p.forkDrop.Stop()
+	p.forkDrop = nil
+	if err := pm.registerSyncPeer(p); err != nil {
+		return true, err
+	}
+	// Let the syncer consider the peer now that it's usable
+	select {
+	case pm.newPeerCh <- p:
+	default:
+	}
+	return true, nil
+}
+
 // handleMsg is invoked whenever an inbound message is received from a remote
 // peer. The remote connection is torn down upon returning any error.
 func (pm *ProtocolManager) handleMsg(p *peer) error {
@@ -438,6 +554,12 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&headers); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		// If a fork challenge is pending, check whether this is the reply to it
+		if p.forkDrop != nil {
+			if handled, err := pm.checkForkReply(p, headers); handled {
+				return err
+			}
+		}
 		// Filter out any explicitly requested headers, deliver the rest to the downloader
 		filter := len(headers) == 1
 		if filter {
@@ -20,6 +20,7 @@ import (
 	"math/big"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -29,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -580,3 +582,80 @@ func testGetReceipt(t *testing.T, protocol int) {
 		t.Errorf("receipts mismatch: %v", err)
 	}
 }
+
+// Tests that peers are challenged for the header at our last passed fork block
+// after the handshake, that they are only used for sync once they answered, and
+// that peers on a different fork are dropped.
+func TestForkChallenge62(t *testing.T) { testForkChallenge(t, 62) }
+func TestForkChallenge63(t *testing.T) { testForkChallenge(t, 63) }
+
+func testForkChallenge(t *testing.T, protocol int) {
+	pm := newTestProtocolManagerMust(t, false, 4, nil, nil)
+	pm.chainconfig = &core.ChainConfig{HomesteadBlock: big.NewInt(2)}
+	defer pm.Stop()
+
+	fork := pm.blockchain.GetHeaderByNumber(2)
+	forged := types.CopyHeader(fork)
+	forged.Extra = []byte("other side")
+
+	tests := []struct {
+		reply   []*types.Header // Headers sent in reply to the challenge
+		dropped bool            // Whether the peer should be dropped
+	}{
+		{[]*types.Header{fork}, false},  // Same side of the fork
+		{[]*types.Header{}, false},      // Peer not yet past the fork (lower TD)
+		{[]*types.Header{forged}, true}, // Other side of the fork
+	}
+	for i, tt := range tests {
+		// Create a peer with a lower total difficulty than ours at the fork
+		app, net := p2p.MsgPipe()
+		var id discover.NodeID
+		rand.Read(id[:])
+		peer := pm.newPeer(protocol, p2p.NewPeer(id, "challenged", nil), net)
+
+		errc := make(chan error, 1)
+		go func() { errc <- pm.handle(peer) }()
+
+		td, head, genesis := pm.blockchain.Status()
+		status := &statusData{
+			ProtocolVersion: uint32(protocol),
+			NetworkId:       uint32(NetworkId),
+			TD:              td,
+			CurrentBlock:    head,
+			GenesisBlock:    genesis,
+		}
+		if err := p2p.ExpectMsg(app, StatusMsg, status); err != nil {
+			t.Fatalf("test %d: status recv: %v", i, err)
+		}
+		status.TD = big.NewInt(1)
+		if err := p2p.Send(app, StatusMsg, status); err != nil {
+			t.Fatalf("test %d: status send: %v", i, err)
+		}
+		// Expect the fork challenge and answer it
+		query := &getBlockHeadersData{Origin: hashOrNumber{Number: 2}, Amount: 1}
+		if err := p2p.ExpectMsg(app, GetBlockHeadersMsg, query); err != nil {
+			t.Fatalf("test %d: fork challenge: %v", i, err)
+		}
+		// Until answered, the peer must not be used for sync
+		if peer.Syncable() {
+			t.Errorf("test %d: peer syncable before answering the fork challenge", i)
+		}
+		if err := p2p.Send(app, BlockHeadersMsg, tt.reply); err != nil {
+			t.Fatalf("test %d: reply send: %v", i, err)
+		}
+		select {
+		case err := <-errc:
+			if perr, ok := err.(*protoError); !tt.dropped || !ok || perr.code != ErrForkMismatch {
+				t.Errorf("test %d: wrong error: have %v, dropped %v", i, err, tt.dropped)
+			}
+		case <-time.After(250 * time.Millisecond):
+			if tt.dropped {
+				t.Errorf("test %d: peer on other fork not dropped", i)
+			}
+			if !peer.Syncable() {
+				t.Errorf("test %d: peer not syncable after passing the fork challenge", i)
+			}
+		}
+		app.Close()
+	}
+}
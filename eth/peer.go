@@ -65,6 +65,10 @@ type peer struct {
 
 	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
+
+	forkHeader *types.Header // Our canonical fork header the peer was challenged with
+	forkDrop   *time.Timer   // Timed disconnect if the peer doesn't answer the fork challenge
+	syncable   bool          // Whether the peer is registered in the downloader (passed the fork challenge)
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -122,6 +126,23 @@ func (p *peer) SetTd(td *big.Int) {
 	p.td.Set(td)
 }
 
+// Syncable reports whether the peer may be synchronised with.
+func (p *peer) Syncable() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.syncable
+}
+
+// SetSyncable marks the peer as registered in the downloader, making it
+// available for synchronisation.
+func (p *peer) SetSyncable() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.syncable = true
+}
+
 // MarkBlock marks a block as known for the peer, ensuring that the block will
 // never be propagated to this particular peer.
 func (p *peer) MarkBlock(hash common.Hash) {
@@ -450,3 +471,33 @@ func (ps *peerSet) BestPeer() *peer {
 	}
 	return bestPeer
 }
+
+// BestReputablePeer retrieves the known peer with the currently highest total
+// difficulty among the ones in good standing (non-negative reputation score),
+// falling back to the overall best peer if none are. Peers that didn't pass the
+// fork challenge yet are not considered.
+func (ps *peerSet) BestReputablePeer(rep *reputation) *peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var (
+		bestPeer, bestAny *peer
+		bestTd, bestAnyTd *big.Int
+	)
+	for _, p := range ps.peers {
+		if !p.Syncable() {
+			continue
+		}
+		td := p.Td()
+		if bestAny == nil || td.Cmp(bestAnyTd) > 0 {
+			bestAny, bestAnyTd = p, td
+		}
+		if rep.score(p.ID()) >= 0 && (bestPeer == nil || td.Cmp(bestTd) > 0) {
+			bestPeer, bestTd = p, td
+		}
+	}
+	if bestPeer == nil {
+		return bestAny
+	}
+	return bestPeer
+}
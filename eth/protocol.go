@@ -89,6 +89,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkMismatch
 )
 
 func (e errCode) String() string {
@@ -106,6 +107,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkMismatch:            "Fork block mismatch",
 }
 
 type txPool interface {
@@ -0,0 +1,140 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+const (
+	reputationMax          = 100       // Upper bound of a peer's reputation score
+	reputationMin          = -100      // Lower bound of a peer's reputation score
+	reputationBanThreshold = -50       // Score at or below which a peer gets banned
+	reputationBanDuration  = time.Hour // Time a banned peer is refused reconnection
+	reputationAfterBan     = -25       // Score of a banned peer, reset to 0 once the ban passes
+
+	rewardSync            = 10 // Reward for successfully synchronising with a peer
+	penaltyProtocolError  = 20 // Penalty for violating the eth wire protocol
+	penaltyDownloadFailed = 25 // Penalty for a peer dropped by the downloader (stalling, invalid or useless data)
+	penaltyFetchFailed    = 25 // Penalty for a peer dropped by the fetcher (DOS or invalid announcements)
+)
+
+// reputationStore persists the reputation of remote nodes across reconnects.
+// It is implemented by the p2p server on top of the discovery node database.
+type reputationStore interface {
+	NodeReputation(id discover.NodeID) discover.NodeReputation
+	SetNodeReputation(id discover.NodeID, rep discover.NodeReputation) error
+}
+
+// reputation tracks the quality of service of remote peers, rewarding useful
+// behaviour and penalising misbehaviour. Peers whose score drops too low are
+// temporarily banned, even across reconnects.
+type reputation struct {
+	store  reputationStore                             // Persistent backend, nil if not available
+	memory map[discover.NodeID]discover.NodeReputation // In-memory fallback if no store is set
+	lock   sync.Mutex
+}
+
+// newReputation creates a reputation tracker, keeping scores in memory until a
+// persistent store is set.
+func newReputation() *reputation {
+	return &reputation{
+		memory: make(map[discover.NodeID]discover.NodeReputation),
+	}
+}
+
+// setStore switches the tracker to a persistent reputation store.
+func (r *reputation) setStore(store reputationStore) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.store = store
+}
+
+// load retrieves the reputation of a node. Once a ban passes the node starts
+// over with a clean record, otherwise its negative score would keep it from
+// ever being selected for sync and earning its reputation back. The lock must
+// be held.
+func (r *reputation) load(id discover.NodeID) discover.NodeReputation {
+	var rep discover.NodeReputation
+	if r.store != nil {
+		rep = r.store.NodeReputation(id)
+	} else {
+		rep = r.memory[id]
+	}
+	if !rep.Banned.IsZero() && !time.Now().Before(rep.Banned) {
+		rep = discover.NodeReputation{}
+	}
+	return rep
+}
+
+// save stores the reputation of a node. The lock must be held.
+func (r *reputation) save(id discover.NodeID, rep discover.NodeReputation) {
+	if r.store != nil {
+		if err := r.store.SetNodeReputation(id, rep); err != nil {
+			glog.V(logger.Warn).Infof("failed to store reputation of %x: %v", id[:8], err)
+		}
+		return
+	}
+	r.memory[id] = rep
+}
+
+// score retrieves the current reputation score of a node.
+func (r *reputation) score(id discover.NodeID) int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.load(id).Score
+}
+
+// banned checks whether a node is currently banned, and if so, until when.
+func (r *reputation) banned(id discover.NodeID) (time.Time, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	until := r.load(id).Banned
+	return until, time.Now().Before(until)
+}
+
+// adjust changes the reputation score of a node by the given delta, banning it
+// if the score drops to or below the ban threshold. It returns whether the node
+// got banned by this adjustment.
+func (r *reputation) adjust(id discover.NodeID, delta int64) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rep := r.load(id)
+	rep.Score += delta
+	if rep.Score > reputationMax {
+		rep.Score = reputationMax
+	}
+	if rep.Score < reputationMin {
+		rep.Score = reputationMin
+	}
+	banned := rep.Score <= reputationBanThreshold
+	if banned {
+		glog.V(logger.Debug).Infof("peer %x: reputation %d, banning for %v", id[:8], rep.Score, reputationBanDuration)
+		rep.Score, rep.Banned = reputationAfterBan, time.Now().Add(reputationBanDuration)
+	}
+	r.save(id, rep)
+	return banned
+}
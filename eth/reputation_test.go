@@ -0,0 +1,182 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// testReputationStore is an in-memory reputation store for testing purposes.
+type testReputationStore map[discover.NodeID]discover.NodeReputation
+
+func (s testReputationStore) NodeReputation(id discover.NodeID) discover.NodeReputation {
+	return s[id]
+}
+
+func (s testReputationStore) SetNodeReputation(id discover.NodeID, rep discover.NodeReputation) error {
+	s[id] = rep
+	return nil
+}
+
+// Tests that reputation scores are bounded and that peers get banned once their
+// score drops to the ban threshold.
+func TestReputationBanning(t *testing.T) {
+	rep := newReputation()
+	id := discover.NodeID{1}
+
+	for i := 0; i < 10; i++ {
+		rep.adjust(id, rewardSync)
+	}
+	if score := rep.score(id); score != reputationMax {
+		t.Fatalf("score not capped: have %d, want %d", score, reputationMax)
+	}
+	// Penalise the peer until it gets banned
+	bans := 0
+	for i := 0; i < 20 && bans == 0; i++ {
+		if rep.adjust(id, -penaltyDownloadFailed) {
+			bans++
+		}
+	}
+	if bans == 0 {
+		t.Fatalf("peer not banned after repeated penalties")
+	}
+	if until, banned := rep.banned(id); !banned || until.Before(time.Now().Add(reputationBanDuration-time.Minute)) {
+		t.Fatalf("ban mismatch: banned %v until %v", banned, until)
+	}
+	if score := rep.score(id); score != reputationAfterBan {
+		t.Errorf("post-ban score mismatch: have %d, want %d", score, reputationAfterBan)
+	}
+	// Other peers must be unaffected
+	if _, banned := rep.banned(discover.NodeID{2}); banned {
+		t.Errorf("unrelated peer banned")
+	}
+}
+
+// Tests that a peer starts over with a clean record once its ban expires, and
+// can earn its reputation back.
+func TestReputationBanExpiry(t *testing.T) {
+	rep := newReputation()
+	id := discover.NodeID{1}
+
+	if !rep.adjust(id, reputationMin) {
+		t.Fatalf("peer not banned")
+	}
+	// Let the ban expire
+	expired := rep.memory[id]
+	expired.Banned = time.Now().Add(-time.Second)
+	rep.memory[id] = expired
+
+	if _, banned := rep.banned(id); banned {
+		t.Fatalf("peer still banned after expiry")
+	}
+	if score := rep.score(id); score != 0 {
+		t.Fatalf("score after ban mismatch: have %d, want 0", score)
+	}
+	rep.adjust(id, rewardSync)
+	if score := rep.score(id); score != rewardSync {
+		t.Errorf("recovered score mismatch: have %d, want %d", score, rewardSync)
+	}
+	if !rep.memory[id].Banned.IsZero() {
+		t.Errorf("expired ban not cleared: %v", rep.memory[id].Banned)
+	}
+}
+
+// Tests that reputations are persisted into the store once one is set, so they
+// survive reconnects and restarts.
+func TestReputationPersistence(t *testing.T) {
+	store := make(testReputationStore)
+	id := discover.NodeID{1}
+
+	rep := newReputation()
+	rep.setStore(store)
+	rep.adjust(id, -penaltyProtocolError)
+
+	if stored := store[id]; stored.Score != -penaltyProtocolError {
+		t.Fatalf("stored score mismatch: have %d, want %d", stored.Score, -penaltyProtocolError)
+	}
+	// A fresh tracker on the same store must see the previous score
+	rep = newReputation()
+	rep.setStore(store)
+	if score := rep.score(id); score != -penaltyProtocolError {
+		t.Errorf("restored score mismatch: have %d, want %d", score, -penaltyProtocolError)
+	}
+}
+
+// Tests that banned peers are refused at connection time.
+func TestBannedPeerRefused(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, false, 0, nil, nil)
+	defer pm.Stop()
+
+	id := discover.NodeID{1}
+	pm.reputation.adjust(id, reputationMin)
+
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+
+	errc := make(chan error, 1)
+	go func() { errc <- pm.handle(pm.newPeer(eth63, p2p.NewPeer(id, "banned", nil), net)) }()
+
+	select {
+	case err := <-errc:
+		if perr, ok := err.(*protoError); !ok || perr.code != ErrSuspendedPeer {
+			t.Errorf("wrong error: have %v, want %v", err, ErrSuspendedPeer)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("banned peer not disconnected within 2 seconds")
+	}
+}
+
+// Tests that the sync peer selection prefers peers in good standing.
+func TestBestReputablePeer(t *testing.T) {
+	rep := newReputation()
+	ps := newPeerSet()
+
+	good := newPeer(eth63, p2p.NewPeer(discover.NodeID{1}, "good", nil), nil)
+	good.td = big.NewInt(10)
+	bad := newPeer(eth63, p2p.NewPeer(discover.NodeID{2}, "bad", nil), nil)
+	bad.td = big.NewInt(20)
+
+	ps.Register(good)
+	ps.Register(bad)
+
+	// Peers not yet registered for sync are never selected
+	if best := ps.BestReputablePeer(rep); best != nil {
+		t.Errorf("best peer mismatch: have %v, want none", best)
+	}
+	good.SetSyncable()
+	bad.SetSyncable()
+
+	// Without any history, the peer with the highest difficulty is preferred
+	if best := ps.BestReputablePeer(rep); best != bad {
+		t.Errorf("best peer mismatch: have %v, want %v", best, bad)
+	}
+	// Once it misbehaves, a peer in good standing takes precedence
+	rep.adjust(bad.ID(), -penaltyProtocolError)
+	if best := ps.BestReputablePeer(rep); best != good {
+		t.Errorf("best peer mismatch: have %v, want %v", best, good)
+	}
+	// Unless there is no peer in good standing at all
+	rep.adjust(good.ID(), -penaltyProtocolError)
+	if best := ps.BestReputablePeer(rep); best != bad {
+		t.Errorf("best peer mismatch: have %v, want %v", best, bad)
+	}
+}
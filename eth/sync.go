@@ -142,11 +142,11 @@ func (pm *ProtocolManager) syncer() {
 			if pm.peers.Len() < minDesiredPeerCount {
 				break
 			}
-			go pm.synchronise(pm.peers.BestPeer())
+			go pm.synchronise(pm.peers.BestReputablePeer(pm.reputation))
 
 		case <-forceSync:
 			// Force a sync even if not enough peers are present
-			go pm.synchronise(pm.peers.BestPeer())
+			go pm.synchronise(pm.peers.BestReputablePeer(pm.reputation))
 
 		case <-pm.quitSync:
 			return
@@ -173,6 +173,8 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 	if err := pm.downloader.Synchronise(peer.id, peer.Head(), peer.Td(), mode); err != nil {
 		return
 	}
+	pm.reputation.adjust(peer.ID(), rewardSync)
+
 	// If fast sync was enabled, and we synced up, disable it
 	if pm.fastSync {
 		// Disable fast sync if we indeed have something in our chain
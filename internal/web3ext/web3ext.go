@@ -27,6 +27,8 @@ var Modules = map[string]string{
 	"net":        Net_JS,
 	"bzz":        Bzz_JS,
 	"chequebook": Chequebook_JS,
+	"swap":       Swap_JS,
+	"ens":        Ens_JS,
 }
 
 const Personal_JS = `
@@ -54,6 +56,17 @@ web3._extend({
 			name: 'importRawKey',
 			call: 'personal_importRawKey',
 			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'sign',
+			call: 'personal_sign',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'ecRecover',
+			call: 'personal_ecRecover',
+			params: 2
 		})
 	],
 	properties:
@@ -344,6 +357,26 @@ web3._extend({
 			call: 'debug_metrics',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'vmProfile',
+			call: 'debug_vmProfile',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'startVmProfile',
+			call: 'debug_startVmProfile',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'stopVmProfile',
+			call: 'debug_stopVmProfile',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'resetVmProfile',
+			call: 'debug_resetVmProfile',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'verbosity',
 			call: 'debug_verbosity',
@@ -484,7 +517,13 @@ web3._extend({
 			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		})
 	],
-	properties: []
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'stratumStats',
+			getter: 'miner_stratumStats'
+		})
+	]
 });
 `
 
@@ -625,6 +664,67 @@ web3._extend({
       params: 2,
       inputFormatter: [null, null]
     }),
+    new web3._extend.Method({
+      name: 'outstanding',
+      call: 'chequebook_outstanding',
+      params: 0
+    }),
   ]
 });
 `
+
+const Swap_JS = `
+web3._extend({
+  property: 'swap',
+  methods:
+  [
+    new web3._extend.Method({
+      name: 'ledger',
+      call: 'swap_ledger',
+      params: 0
+    }),
+  ]
+});
+`
+
+const Ens_JS = `
+web3._extend({
+	property: 'ens',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'resolve',
+			call: 'ens_resolve',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'owner',
+			call: 'ens_owner',
+			params: 1,
+			outputFormatter: web3._extend.utils.toAddress
+		}),
+		new web3._extend.Method({
+			name: 'register',
+			call: 'ens_register',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setResolver',
+			call: 'ens_setResolver',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'setContentHash',
+			call: 'ens_setContentHash',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'deployResolver',
+			call: 'ens_deployResolver',
+			params: 0,
+			outputFormatter: web3._extend.utils.toAddress
+		})
+	]
+});
+`
@@ -25,6 +25,7 @@ import (
 
 	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 )
@@ -47,6 +48,9 @@ type RemoteAgent struct {
 	hashrateMu sync.RWMutex
 	hashrate   map[common.Hash]hashrate
 
+	subsMu sync.Mutex
+	subs   map[chan struct{}]struct{} // Channels signalled when new work arrives
+
 	running int32 // running indicates whether the agent is active. Call atomically
 }
 
@@ -54,6 +58,7 @@ func NewRemoteAgent() *RemoteAgent {
 	return &RemoteAgent{
 		work:     make(map[common.Hash]*Work),
 		hashrate: make(map[common.Hash]hashrate),
+		subs:     make(map[chan struct{}]struct{}),
 	}
 }
 
@@ -104,28 +109,71 @@ func (a *RemoteAgent) GetHashRate() (tot int64) {
 }
 
 func (a *RemoteAgent) GetWork() ([3]string, error) {
+	if work := a.pendingWork(); work != nil {
+		return workPackage(work.Block, work.Block.Difficulty()), nil
+	}
+	return [3]string{}, errors.New("No work available yet, don't panic.")
+}
+
+// pendingWork returns the work currently being mined, if any, and registers it
+// so that solutions submitted for it are accepted.
+func (a *RemoteAgent) pendingWork() *Work {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	if a.currentWork == nil {
+		return nil
+	}
+	a.work[a.currentWork.Block.HashNoNonce()] = a.currentWork
+	return a.currentWork
+}
+
+// lookupWork returns the registered work with the given header hash, or nil if
+// it is unknown or went stale.
+func (a *RemoteAgent) lookupWork(hash common.Hash) *Work {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.work[hash]
+}
+
+// subscribeWork returns a channel that is signalled whenever a new work package
+// arrives from the worker.
+func (a *RemoteAgent) subscribeWork() chan struct{} {
+	a.subsMu.Lock()
+	defer a.subsMu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	a.subs[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribeWork stops signalling new work packages on the given channel.
+func (a *RemoteAgent) unsubscribeWork(ch chan struct{}) {
+	a.subsMu.Lock()
+	defer a.subsMu.Unlock()
+
+	delete(a.subs, ch)
+}
+
+// workPackage assembles the work package handed out to external miners for a
+// block, with the target calculated from the given difficulty. The package consists
+// of the header pow-hash, the seed hash of the DAG and the boundary condition
+// ("target"), 2^256/difficulty, each 32 bytes hex encoded.
+func workPackage(block *types.Block, difficulty *big.Int) [3]string {
 	var res [3]string
 
-	if a.currentWork != nil {
-		block := a.currentWork.Block
-
-		res[0] = block.HashNoNonce().Hex()
-		seedHash, _ := ethash.GetSeedHash(block.NumberU64())
-		res[1] = common.BytesToHash(seedHash).Hex()
-		// Calculate the "target" to be returned to the external miner
-		n := big.NewInt(1)
-		n.Lsh(n, 255)
-		n.Div(n, block.Difficulty())
-		n.Lsh(n, 1)
-		res[2] = common.BytesToHash(n.Bytes()).Hex()
-
-		a.work[block.HashNoNonce()] = a.currentWork
-		return res, nil
-	}
-	return res, errors.New("No work available yet, don't panic.")
+	res[0] = block.HashNoNonce().Hex()
+	seedHash, _ := ethash.GetSeedHash(block.NumberU64())
+	res[1] = common.BytesToHash(seedHash).Hex()
+	// Calculate the "target" to be returned to the external miner
+	n := big.NewInt(1)
+	n.Lsh(n, 255)
+	n.Div(n, difficulty)
+	n.Lsh(n, 1)
+	res[2] = common.BytesToHash(n.Bytes()).Hex()
+
+	return res
 }
 
 // Returns true or false, but does not indicate if the PoW was correct
@@ -160,6 +208,15 @@ out:
 			a.mu.Lock()
 			a.currentWork = work
 			a.mu.Unlock()
+
+			a.subsMu.Lock()
+			for ch := range a.subs {
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+			a.subsMu.Unlock()
 		case <-ticker:
 			// cleanup
 			a.mu.Lock()
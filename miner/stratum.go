@@ -0,0 +1,613 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/pow"
+)
+
+const (
+	stratumMaxRequestSize = 1024             // Maximum size of a single request line
+	stratumReadTimeout    = 10 * time.Minute // Time after which idle workers are disconnected
+	stratumWriteTimeout   = 10 * time.Second // Time allowed for a single message to be written
+	stratumStatsCycle     = 5 * time.Second  // Interval of feeding hashrates to the remote agent
+	stratumHashrateWindow = 10 * time.Minute // Window of shares to estimate worker hashrates from
+	stratumReportedExpiry = 10 * time.Second // Time after which self reported hashrates are ignored
+	stratumDefaultWorker  = "default"        // Worker name if the rig doesn't specify any
+	stratumMaxSessions    = 256              // Maximum number of concurrently connected mining rigs
+)
+
+var (
+	// DefaultStratumDifficulty is the share difficulty workers mine at if neither
+	// the server, nor the worker itself requests a different one.
+	DefaultStratumDifficulty = big.NewInt(2000000000)
+
+	errStratumUnauthorized = errors.New("worker not logged in")
+	errStratumNoWork       = errors.New("no work available yet")
+)
+
+// StratumWorkerStats contains the statistics of a single worker connected to the
+// stratum server.
+type StratumWorkerStats struct {
+	Name       string    `json:"name"`       // Login and worker name of the rig
+	Online     bool      `json:"online"`     // Whether the worker is currently connected
+	Difficulty *big.Int  `json:"difficulty"` // Share difficulty the worker mines at
+	Accepted   uint64    `json:"accepted"`   // Number of valid shares submitted
+	Rejected   uint64    `json:"rejected"`   // Number of invalid or duplicate shares submitted
+	Stale      uint64    `json:"stale"`      // Number of shares submitted for outdated work
+	Blocks     uint64    `json:"blocks"`     // Number of shares that turned out to be blocks
+	Hashrate   uint64    `json:"hashrate"`   // Hashrate estimated from the accepted shares
+	Reported   uint64    `json:"reported"`   // Hashrate last reported by the worker itself
+	LastShare  time.Time `json:"lastShare"`  // Time of the last accepted share
+}
+
+// stratumWorker tracks the statistics of a worker across reconnects.
+type stratumWorker struct {
+	id     common.Hash // Identifier the hashrate is reported to the agent with
+	stats  StratumWorkerStats
+	shares []stratumShare // Accepted shares within the hashrate window
+
+	firstSeen    time.Time // Time the worker first logged in
+	reportedPing time.Time // Time of the last self reported hashrate
+}
+
+// stratumShare is an accepted share, used for hashrate estimation.
+type stratumShare struct {
+	time       time.Time
+	difficulty *big.Int
+}
+
+// hashrate estimates the hashrate of the worker from its accepted shares.
+func (w *stratumWorker) hashrate(now time.Time) uint64 {
+	// Drop all shares outside of the estimation window
+	for len(w.shares) > 0 && now.Sub(w.shares[0].time) > stratumHashrateWindow {
+		w.shares = w.shares[1:]
+	}
+	window := stratumHashrateWindow
+	if elapsed := now.Sub(w.firstSeen); elapsed < window {
+		window = elapsed
+	}
+	if window < time.Second {
+		return 0
+	}
+	work := new(big.Int)
+	for _, share := range w.shares {
+		work.Add(work, share.difficulty)
+	}
+	return work.Div(work, big.NewInt(int64(window/time.Second))).Uint64()
+}
+
+// stratumRequest is a request message sent by a mining rig.
+type stratumRequest struct {
+	Id     *json.RawMessage  `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	Worker string            `json:"worker"`
+}
+
+// stratumResponse is a response or work notification sent to a mining rig.
+type stratumResponse struct {
+	Id      *json.RawMessage `json:"id"`
+	Version string           `json:"jsonrpc"`
+	Result  interface{}      `json:"result"`
+	Error   interface{}      `json:"error,omitempty"`
+}
+
+// stratumNotificationId is the request id work notifications are pushed with.
+var stratumNotificationId = json.RawMessage("0")
+
+// stratumSession is a single connection of a mining rig.
+type stratumSession struct {
+	conn net.Conn
+	enc  *json.Encoder
+	wmu  sync.Mutex // Lock serializing writes to the connection
+
+	worker     *stratumWorker // Worker the session is logged in as, nil before login
+	difficulty *big.Int       // Share difficulty requested by the worker
+}
+
+// send writes a single message to the mining rig.
+func (s *stratumSession) send(msg *stratumResponse) error {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	return s.enc.Encode(msg)
+}
+
+// shareKey uniquely identifies a submitted share to detect duplicates.
+type shareKey struct {
+	hash  common.Hash
+	nonce uint64
+}
+
+// shareBlock overrides the difficulty of a block to verify shares against the
+// lower difficulty of a worker.
+type shareBlock struct {
+	*types.Block
+	difficulty *big.Int
+}
+
+func (b shareBlock) Difficulty() *big.Int { return b.difficulty }
+
+// StratumServer is a Stratum style TCP mining server on top of a RemoteAgent. It
+// pushes new work packages to the connected mining rigs as soon as they are
+// available, and accepts shares mined at a per worker difficulty, relaying the
+// ones meeting the block difficulty to the agent.
+type StratumServer struct {
+	agent      *RemoteAgent
+	pow        pow.PoW
+	difficulty *big.Int // Default and minimum share difficulty of the workers
+
+	listener    net.Listener
+	maxSessions int // Maximum number of concurrent sessions, further rigs are refused
+	sessions    map[*stratumSession]struct{}
+	workers     map[string]*stratumWorker // Worker statistics, by worker name
+	shares      map[shareKey]struct{}     // Shares already submitted, to reject duplicates
+	mu          sync.Mutex
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewStratumServer creates a stratum server handing out the work of the given
+// remote agent and verifying shares with the given proof of work. Workers may
+// only raise their share difficulty above the given one, as every share costs
+// a proof of work verification. The agent must be registered with the miner
+// separately.
+func NewStratumServer(agent *RemoteAgent, pow pow.PoW, difficulty *big.Int) *StratumServer {
+	if difficulty == nil || difficulty.Sign() <= 0 {
+		difficulty = DefaultStratumDifficulty
+	}
+	return &StratumServer{
+		agent:       agent,
+		pow:         pow,
+		difficulty:  new(big.Int).Set(difficulty),
+		maxSessions: stratumMaxSessions,
+		sessions:    make(map[*stratumSession]struct{}),
+		workers:     make(map[string]*stratumWorker),
+		shares:      make(map[shareKey]struct{}),
+	}
+}
+
+// Start begins accepting mining rigs on the given TCP address.
+func (s *StratumServer) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.quit = make(chan struct{})
+
+	s.wg.Add(2)
+	go s.acceptLoop()
+	go s.updateLoop()
+
+	glog.V(logger.Info).Infof("Stratum server started on %v", listener.Addr())
+	return nil
+}
+
+// Stop disconnects all mining rigs and terminates the server.
+func (s *StratumServer) Stop() {
+	if s.listener == nil {
+		return
+	}
+	close(s.quit)
+	s.listener.Close()
+
+	s.mu.Lock()
+	for session := range s.sessions {
+		session.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	glog.V(logger.Info).Infoln("Stratum server stopped")
+}
+
+// Addr returns the address the server is listening on.
+func (s *StratumServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Stats returns the statistics of all the workers seen by the server.
+func (s *StratumServer) Stats() []StratumWorkerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]StratumWorkerStats, 0, len(s.workers))
+	for _, worker := range s.workers {
+		worker.stats.Hashrate = worker.hashrate(now)
+		stats = append(stats, worker.stats)
+	}
+	return stats
+}
+
+// acceptLoop accepts new mining rig connections until the server is stopped.
+func (s *StratumServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			glog.V(logger.Debug).Infof("Stratum accept failed: %v", err)
+			continue
+		}
+		session := &stratumSession{conn: conn, enc: json.NewEncoder(conn)}
+
+		s.mu.Lock()
+		if len(s.sessions) >= s.maxSessions {
+			s.mu.Unlock()
+			glog.V(logger.Debug).Infof("Stratum worker from %v refused: too many sessions", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		s.sessions[session] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.handleSession(session)
+	}
+}
+
+// updateLoop pushes new work to the logged in workers whenever the agent gets
+// some, and periodically feeds the worker hashrates to the agent.
+func (s *StratumServer) updateLoop() {
+	defer s.wg.Done()
+
+	workCh := s.agent.subscribeWork()
+	defer s.agent.unsubscribeWork(workCh)
+
+	stats := time.NewTicker(stratumStatsCycle)
+	defer stats.Stop()
+
+	for {
+		select {
+		case <-workCh:
+			s.broadcastWork()
+
+		case <-stats.C:
+			s.submitHashrates()
+
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// broadcastWork pushes the current work package to every logged in worker and
+// forgets the shares of work that went stale.
+func (s *StratumServer) broadcastWork() {
+	work := s.agent.pendingWork()
+	if work == nil {
+		return
+	}
+	s.mu.Lock()
+	for key := range s.shares {
+		if s.agent.lookupWork(key.hash) == nil {
+			delete(s.shares, key)
+		}
+	}
+	packages := make(map[*stratumSession][3]string)
+	for session := range s.sessions {
+		if session.worker != nil {
+			packages[session] = workPackage(work.Block, s.shareDifficulty(session, work.Block))
+		}
+	}
+	s.mu.Unlock()
+
+	for session, pkg := range packages {
+		if err := session.send(&stratumResponse{Id: &stratumNotificationId, Version: "2.0", Result: pkg}); err != nil {
+			glog.V(logger.Debug).Infof("Stratum work push to %v failed: %v", session.conn.RemoteAddr(), err)
+			session.conn.Close()
+		}
+	}
+}
+
+// submitHashrates feeds the hashrate of every online worker to the agent, using
+// the rate reported by the worker itself if recent, or the estimated one otherwise.
+func (s *StratumServer) submitHashrates() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, worker := range s.workers {
+		if !worker.stats.Online {
+			continue
+		}
+		rate := worker.hashrate(now)
+		if now.Sub(worker.reportedPing) < stratumReportedExpiry {
+			rate = worker.stats.Reported
+		}
+		s.agent.SubmitHashrate(worker.id, rate)
+	}
+}
+
+// shareDifficulty returns the difficulty the shares of a session are verified
+// at for the given block, which is never above the block's own difficulty.
+func (s *StratumServer) shareDifficulty(session *stratumSession, block *types.Block) *big.Int {
+	difficulty := s.difficulty
+	if session.difficulty != nil {
+		difficulty = session.difficulty
+	}
+	if difficulty.Cmp(block.Difficulty()) > 0 {
+		return block.Difficulty()
+	}
+	return difficulty
+}
+
+// handleSession serves the requests of a single mining rig until it disconnects.
+func (s *StratumServer) handleSession(session *stratumSession) {
+	defer s.wg.Done()
+	defer func() {
+		session.conn.Close()
+
+		s.mu.Lock()
+		delete(s.sessions, session)
+		if session.worker != nil {
+			session.worker.stats.Online = s.workerOnline(session.worker)
+		}
+		s.mu.Unlock()
+	}()
+	glog.V(logger.Debug).Infof("Stratum worker connected from %v", session.conn.RemoteAddr())
+
+	reader := bufio.NewReaderSize(session.conn, stratumMaxRequestSize)
+	for {
+		session.conn.SetReadDeadline(time.Now().Add(stratumReadTimeout))
+		line, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			glog.V(logger.Debug).Infof("Stratum worker %v disconnected: %v", session.conn.RemoteAddr(), err)
+			return
+		}
+		if isPrefix {
+			glog.V(logger.Debug).Infof("Stratum worker %v sent oversized request", session.conn.RemoteAddr())
+			return
+		}
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			glog.V(logger.Debug).Infof("Stratum worker %v sent malformed request: %v", session.conn.RemoteAddr(), err)
+			return
+		}
+		result, err := s.handleRequest(session, &req)
+
+		res := &stratumResponse{Id: req.Id, Version: "2.0", Result: result}
+		if err != nil {
+			res.Result, res.Error = nil, map[string]interface{}{"code": -1, "message": err.Error()}
+		}
+		if err := session.send(res); err != nil {
+			return
+		}
+		// Hand out the current work right after a successful login
+		if req.Method == "eth_submitLogin" && err == nil {
+			if work := s.agent.pendingWork(); work != nil {
+				pkg := workPackage(work.Block, s.shareDifficulty(session, work.Block))
+				if err := session.send(&stratumResponse{Id: &stratumNotificationId, Version: "2.0", Result: pkg}); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// workerOnline reports whether any session is logged in as the given worker. The
+// server lock must be held.
+func (s *StratumServer) workerOnline(worker *stratumWorker) bool {
+	for session := range s.sessions {
+		if session.worker == worker {
+			return true
+		}
+	}
+	return false
+}
+
+// handleRequest executes a single request of a mining rig.
+func (s *StratumServer) handleRequest(session *stratumSession, req *stratumRequest) (interface{}, error) {
+	switch req.Method {
+	case "eth_submitLogin":
+		var login, difficulty string
+		if err := stratumParams(req.Params, &login, &difficulty); err != nil {
+			return nil, err
+		}
+		if login == "" {
+			return nil, errors.New("missing login")
+		}
+		var diff *big.Int
+		if difficulty != "" {
+			var ok bool
+			if diff, ok = new(big.Int).SetString(difficulty, 0); !ok || diff.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid difficulty %q", difficulty)
+			}
+		}
+		name := req.Worker
+		if name == "" {
+			name = stratumDefaultWorker
+		}
+		s.login(session, login+"."+name, diff)
+		return true, nil
+
+	case "eth_getWork":
+		if session.worker == nil {
+			return nil, errStratumUnauthorized
+		}
+		work := s.agent.pendingWork()
+		if work == nil {
+			return nil, errStratumNoWork
+		}
+		return workPackage(work.Block, s.shareDifficulty(session, work.Block)), nil
+
+	case "eth_submitWork":
+		if session.worker == nil {
+			return nil, errStratumUnauthorized
+		}
+		var nonce, hash, digest string
+		if err := stratumParams(req.Params, &nonce, &hash, &digest); err != nil {
+			return nil, err
+		}
+		n := new(big.Int).SetBytes(common.FromHex(nonce))
+		if n.BitLen() > 64 {
+			return nil, fmt.Errorf("invalid nonce %q", nonce)
+		}
+		return s.submitShare(session, n.Uint64(), common.HexToHash(hash), common.HexToHash(digest)), nil
+
+	case "eth_submitHashrate":
+		if session.worker == nil {
+			return nil, errStratumUnauthorized
+		}
+		var rate, id string
+		if err := stratumParams(req.Params, &rate, &id); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		session.worker.stats.Reported = new(big.Int).SetBytes(common.FromHex(rate)).Uint64()
+		session.worker.reportedPing = time.Now()
+		s.mu.Unlock()
+		return true, nil
+
+	default:
+		return nil, fmt.Errorf("method %q not supported", req.Method)
+	}
+}
+
+// stratumParams decodes the positional string parameters of a request. Missing
+// trailing parameters are left empty.
+func stratumParams(params []json.RawMessage, args ...*string) error {
+	if len(params) > len(args) {
+		return fmt.Errorf("too many parameters: have %d, want at most %d", len(params), len(args))
+	}
+	for i, param := range params {
+		if err := json.Unmarshal(param, args[i]); err != nil {
+			return fmt.Errorf("invalid parameter %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// login associates a session with a named worker, creating its statistics if
+// seen for the first time. A nil difficulty, or one below the server's, selects
+// the server default.
+func (s *StratumServer) login(session *stratumSession, name string, difficulty *big.Int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if difficulty != nil && difficulty.Cmp(s.difficulty) < 0 {
+		difficulty = nil
+	}
+
+	if session.worker != nil && session.worker.stats.Name != name {
+		prev := session.worker
+		session.worker = nil
+		prev.stats.Online = s.workerOnline(prev)
+	}
+
+	worker, ok := s.workers[name]
+	if !ok {
+		worker = &stratumWorker{
+			id:        crypto.Keccak256Hash([]byte(name)),
+			stats:     StratumWorkerStats{Name: name},
+			firstSeen: time.Now(),
+		}
+		s.workers[name] = worker
+	}
+	session.worker, session.difficulty = worker, difficulty
+
+	worker.stats.Online = true
+	worker.stats.Difficulty = s.difficulty
+	if difficulty != nil {
+		worker.stats.Difficulty = difficulty
+	}
+
+	glog.V(logger.Info).Infof("Stratum worker %s logged in from %v", name, session.conn.RemoteAddr())
+}
+
+// submitShare verifies a share submitted by a worker at its share difficulty,
+// relaying it to the agent if it satisfies the block difficulty too.
+func (s *StratumServer) submitShare(session *stratumSession, nonce uint64, hash, digest common.Hash) bool {
+	worker := session.worker
+
+	// Make sure the share is for known work, and not yet submitted
+	work := s.agent.lookupWork(hash)
+	if work == nil {
+		s.mu.Lock()
+		worker.stats.Stale++
+		s.mu.Unlock()
+
+		glog.V(logger.Debug).Infof("Stratum worker %s submitted stale share for %x", worker.stats.Name, hash[:4])
+		return false
+	}
+	key := shareKey{hash, nonce}
+
+	s.mu.Lock()
+	_, duplicate := s.shares[key]
+	if !duplicate {
+		s.shares[key] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	// Verify the share against the difficulty of the worker
+	difficulty := s.shareDifficulty(session, work.Block)
+	block := work.Block.WithMiningResult(nonce, digest)
+	if duplicate || !s.pow.Verify(shareBlock{block, difficulty}) {
+		s.mu.Lock()
+		worker.stats.Rejected++
+		s.mu.Unlock()
+
+		glog.V(logger.Debug).Infof("Stratum worker %s submitted invalid share for %x", worker.stats.Name, hash[:4])
+		return false
+	}
+	s.mu.Lock()
+	worker.stats.Accepted++
+	worker.stats.LastShare = time.Now()
+	worker.shares = append(worker.shares, stratumShare{worker.stats.LastShare, difficulty})
+	s.mu.Unlock()
+
+	// If the share satisfies the block difficulty too, submit it as a solution
+	if difficulty.Cmp(work.Block.Difficulty()) < 0 && !s.pow.Verify(block) {
+		return true
+	}
+	if s.agent.SubmitWork(nonce, digest, hash) {
+		s.mu.Lock()
+		worker.stats.Blocks++
+		s.mu.Unlock()
+
+		glog.V(logger.Info).Infof("Stratum worker %s mined block #%d", worker.stats.Name, work.Block.NumberU64())
+	}
+	return true
+}
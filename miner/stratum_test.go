@@ -0,0 +1,264 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/pow"
+)
+
+// testPoW is a fake proof of work, treating nonces as the achieved difficulty.
+type testPoW struct{}
+
+func (testPoW) Search(pow.Block, <-chan struct{}, int) (uint64, []byte) { return 0, nil }
+func (testPoW) GetHashrate() int64                                      { return 0 }
+func (testPoW) Turbo(bool)                                              {}
+
+func (testPoW) Verify(block pow.Block) bool {
+	return new(big.Int).SetUint64(block.Nonce()).Cmp(block.Difficulty()) >= 0
+}
+
+// testStratumClient is a mining rig connected to a stratum server.
+type testStratumClient struct {
+	conn net.Conn
+	in   *bufio.Reader
+	id   int
+}
+
+func (c *testStratumClient) request(t *testing.T, method string, params ...interface{}) {
+	c.id++
+	req := map[string]interface{}{"id": c.id, "jsonrpc": "2.0", "method": method, "params": params, "worker": "rig"}
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		t.Fatalf("failed to send %s: %v", method, err)
+	}
+}
+
+func (c *testStratumClient) response(t *testing.T) *stratumResponse {
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := c.in.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	res := new(stratumResponse)
+	if err := json.Unmarshal(line, res); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return res
+}
+
+func (c *testStratumClient) expectWork(t *testing.T, block *types.Block, difficulty *big.Int) {
+	res := c.response(t)
+	if id := string(*res.Id); id != "0" {
+		t.Fatalf("work notification id mismatch: have %s, want 0", id)
+	}
+	want := workPackage(block, difficulty)
+	if fmt.Sprint(res.Result) != fmt.Sprint(want[:]) {
+		t.Fatalf("work package mismatch: have %v, want %v", res.Result, want)
+	}
+}
+
+func (c *testStratumClient) call(t *testing.T, method string, params ...interface{}) interface{} {
+	c.request(t, method, params...)
+	res := c.response(t)
+	if res.Error != nil {
+		t.Fatalf("%s failed: %v", method, res.Error)
+	}
+	return res.Result
+}
+
+func newTestWork(number, difficulty int64) *Work {
+	header := &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(difficulty)}
+	return &Work{Block: types.NewBlockWithHeader(header), createdAt: time.Now()}
+}
+
+func TestStratumMining(t *testing.T) {
+	// Start a remote agent with some work and a stratum server on top
+	results := make(chan *Result, 1)
+
+	agent := NewRemoteAgent()
+	agent.SetReturnCh(results)
+	agent.Start()
+	defer agent.Stop()
+
+	server := NewStratumServer(agent, testPoW{}, big.NewInt(5))
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	defer server.Stop()
+
+	work := newTestWork(1, 100)
+	agent.Work() <- work
+	for agent.pendingWork() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Connect a mining rig and make sure it gets work after logging in
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	defer conn.Close()
+	client := &testStratumClient{conn: conn, in: bufio.NewReader(conn)}
+
+	client.request(t, "eth_getWork")
+	if res := client.response(t); res.Error == nil {
+		t.Fatalf("work handed out before login: %v", res.Result)
+	}
+	if ok := client.call(t, "eth_submitLogin", "0x0102", "10"); ok != true {
+		t.Fatalf("login failed: %v", ok)
+	}
+	client.expectWork(t, work.Block, big.NewInt(10))
+
+	// Submit shares of various quality and check their acceptance
+	hash := work.Block.HashNoNonce().Hex()
+	tests := []struct {
+		nonce uint64
+		hash  string
+		ok    bool
+	}{
+		{50, hash, true},                  // valid share
+		{50, hash, false},                 // duplicate share
+		{7, hash, false},                  // share below the worker difficulty
+		{60, common.Hash{1}.Hex(), false}, // share for unknown work
+		{150, hash, true},                 // share satisfying the block difficulty
+		{200, hash, false},                // share for work already solved
+	}
+	for i, tt := range tests {
+		nonce := fmt.Sprintf("0x%016x", tt.nonce)
+		if ok := client.call(t, "eth_submitWork", nonce, tt.hash, common.Hash{}.Hex()); ok != tt.ok {
+			t.Errorf("share %d: acceptance mismatch: have %v, want %v", i, ok, tt.ok)
+		}
+	}
+	select {
+	case result := <-results:
+		if result.Block.Nonce() != 150 {
+			t.Errorf("mined block nonce mismatch: have %d, want 150", result.Block.Nonce())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("mined block not submitted to the agent")
+	}
+	if ok := client.call(t, "eth_submitHashrate", "0x1000", common.Hash{}.Hex()); ok != true {
+		t.Fatalf("hashrate submission failed: %v", ok)
+	}
+	// New work should be pushed to the rig without asking
+	next := newTestWork(2, 100)
+	agent.Work() <- next
+	client.expectWork(t, next.Block, big.NewInt(10))
+
+	// Check the statistics of the worker
+	stats := server.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("worker count mismatch: have %d, want 1", len(stats))
+	}
+	want := StratumWorkerStats{
+		Name:       "0x0102.rig",
+		Online:     true,
+		Difficulty: big.NewInt(10),
+		Accepted:   2,
+		Rejected:   2,
+		Stale:      2,
+		Blocks:     1,
+		Reported:   0x1000,
+	}
+	have := stats[0]
+	if have.Name != want.Name || have.Online != want.Online || have.Difficulty.Cmp(want.Difficulty) != 0 ||
+		have.Accepted != want.Accepted || have.Rejected != want.Rejected || have.Stale != want.Stale ||
+		have.Blocks != want.Blocks || have.Reported != want.Reported {
+		t.Errorf("worker stats mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+}
+
+func TestStratumHashrateEstimate(t *testing.T) {
+	now := time.Now()
+	worker := &stratumWorker{
+		firstSeen: now.Add(-100 * time.Second),
+		shares: []stratumShare{
+			{now.Add(-90 * time.Second), big.NewInt(400)},
+			{now.Add(-10 * time.Second), big.NewInt(600)},
+		},
+	}
+	if rate := worker.hashrate(now); rate != 10 {
+		t.Errorf("hashrate mismatch: have %d, want 10", rate)
+	}
+	// Shares outside of the estimation window should be dropped
+	worker.firstSeen = now.Add(-2 * stratumHashrateWindow)
+	worker.shares = append([]stratumShare{{now.Add(-stratumHashrateWindow - time.Second), big.NewInt(1000000)}}, worker.shares...)
+
+	want := uint64(1000 / (stratumHashrateWindow / time.Second))
+	if rate := worker.hashrate(now); rate != want {
+		t.Errorf("hashrate mismatch: have %d, want %d", rate, want)
+	}
+	if len(worker.shares) != 2 {
+		t.Errorf("share count mismatch: have %d, want 2", len(worker.shares))
+	}
+}
+
+func TestStratumLimits(t *testing.T) {
+	agent := NewRemoteAgent()
+	agent.Start()
+	defer agent.Stop()
+
+	server := NewStratumServer(agent, testPoW{}, big.NewInt(10))
+	server.maxSessions = 1
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	defer server.Stop()
+
+	work := newTestWork(1, 100)
+	agent.Work() <- work
+	for agent.pendingWork() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	defer conn.Close()
+	client := &testStratumClient{conn: conn, in: bufio.NewReader(conn)}
+
+	// Workers can't lower their share difficulty below the server's
+	if ok := client.call(t, "eth_submitLogin", "0x0102", "1"); ok != true {
+		t.Fatalf("login failed: %v", ok)
+	}
+	client.expectWork(t, work.Block, big.NewInt(10))
+	if ok := client.call(t, "eth_submitWork", fmt.Sprintf("0x%016x", 5), work.Block.HashNoNonce().Hex(), common.Hash{}.Hex()); ok != false {
+		t.Errorf("share below the server difficulty accepted")
+	}
+	if stats := server.Stats(); len(stats) != 1 || stats[0].Difficulty.Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("worker difficulty mismatch: have %v, want 10", stats)
+	}
+	// Rigs beyond the session limit are disconnected right away
+	extra, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	defer extra.Close()
+	extra.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := extra.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("session beyond the limit not refused: %v", err)
+	}
+}
@@ -65,6 +65,13 @@ type discoverTable interface {
 	ReadRandomNodes([]*discover.Node) int
 }
 
+// reputationStore is implemented by discovery tables which are able to persist
+// the reputation of remote nodes.
+type reputationStore interface {
+	Reputation(id discover.NodeID) discover.NodeReputation
+	SetReputation(id discover.NodeID, rep discover.NodeReputation) error
+}
+
 // the dial history remembers recent dials.
 type dialHistory []pastDial
 
@@ -43,6 +43,8 @@ var (
 	nodeDBNilNodeID      = NodeID{}       // Special node ID to use as a nil element.
 	nodeDBNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
 	nodeDBCleanupCycle   = time.Hour      // Time period for running the expiration task.
+
+	nodeDBReputationExpiration = 7 * 24 * time.Hour // Time after which an unchanged reputation should be dropped.
 )
 
 // nodeDB stores all nodes we know about.
@@ -62,6 +64,11 @@ var (
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
 	nodeDBDiscoverPong      = nodeDBDiscoverRoot + ":lastpong"
 	nodeDBDiscoverFindFails = nodeDBDiscoverRoot + ":findfail"
+
+	nodeDBReputationRoot    = ":reputation"
+	nodeDBReputationScore   = nodeDBReputationRoot + ":score"
+	nodeDBReputationBanned  = nodeDBReputationRoot + ":banned"
+	nodeDBReputationUpdated = nodeDBReputationRoot + ":updated"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -243,28 +250,36 @@ func (db *nodeDB) expirer() {
 }
 
 // expireNodes iterates over the database and deletes all nodes that have not
-// been seen (i.e. received a pong from) for some alloted time.
+// been seen (i.e. received a pong from) for some alloted time. Reputations are
+// also kept for peers never seen by discovery, so they expire on their own once
+// not updated for some alloted time.
 func (db *nodeDB) expireNodes() error {
 	threshold := time.Now().Add(-nodeDBNodeExpiration)
+	repThreshold := time.Now().Add(-nodeDBReputationExpiration)
 
-	// Find discovered nodes that are older than the allowance
+	// Find discovered nodes and reputations that are older than the allowance
 	it := db.lvl.NewIterator(nil, nil)
 	defer it.Release()
 
 	for it.Next() {
-		// Skip the item if not a discovery node
 		id, field := splitKey(it.Key())
-		if field != nodeDBDiscoverRoot {
-			continue
-		}
-		// Skip the node if not expired yet (and not self)
-		if bytes.Compare(id[:], db.self[:]) != 0 {
-			if seen := db.lastPong(id); seen.After(threshold) {
+		switch field {
+		case nodeDBDiscoverRoot:
+			// Skip the node if not expired yet (and not self)
+			if bytes.Compare(id[:], db.self[:]) != 0 {
+				if seen := db.lastPong(id); seen.After(threshold) {
+					continue
+				}
+			}
+			// Otherwise delete all associated information
+			db.deleteNode(id)
+
+		case nodeDBReputationUpdated:
+			if updated := time.Unix(db.fetchInt64(it.Key()), 0); updated.After(repThreshold) {
 				continue
 			}
+			db.deleteReputation(id)
 		}
-		// Otherwise delete all associated information
-		db.deleteNode(id)
 	}
 	return nil
 }
@@ -300,6 +315,41 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// reputation retrieves the reputation collected about a remote node by the
+// higher level protocols.
+func (db *nodeDB) reputation(id NodeID) NodeReputation {
+	rep := NodeReputation{Score: db.fetchInt64(makeKey(id, nodeDBReputationScore))}
+	if banned := db.fetchInt64(makeKey(id, nodeDBReputationBanned)); banned != 0 {
+		rep.Banned = time.Unix(banned, 0)
+	}
+	return rep
+}
+
+// updateReputation updates the reputation of a remote node.
+func (db *nodeDB) updateReputation(id NodeID, rep NodeReputation) error {
+	var banned int64
+	if !rep.Banned.IsZero() {
+		banned = rep.Banned.Unix()
+	}
+	if err := db.storeInt64(makeKey(id, nodeDBReputationScore), rep.Score); err != nil {
+		return err
+	}
+	if err := db.storeInt64(makeKey(id, nodeDBReputationBanned), banned); err != nil {
+		return err
+	}
+	return db.storeInt64(makeKey(id, nodeDBReputationUpdated), time.Now().Unix())
+}
+
+// deleteReputation deletes the reputation of a remote node.
+func (db *nodeDB) deleteReputation(id NodeID) error {
+	for _, field := range []string{nodeDBReputationScore, nodeDBReputationBanned, nodeDBReputationUpdated} {
+		if err := db.lvl.Delete(makeKey(id, field), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
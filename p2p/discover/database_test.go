@@ -25,6 +25,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var nodeDBKeyTests = []struct {
@@ -144,6 +146,17 @@ func TestNodeDBFetchStore(t *testing.T) {
 	if stored := db.findFails(node.ID); stored != num {
 		t.Errorf("find-node fails: value mismatch: have %v, want %v", stored, num)
 	}
+	// Check fetch/store operations on a node reputation object
+	if stored := db.reputation(node.ID); stored.Score != 0 || !stored.Banned.IsZero() {
+		t.Errorf("reputation: non-existing object: %v", stored)
+	}
+	rep := NodeReputation{Score: -int64(num), Banned: inst}
+	if err := db.updateReputation(node.ID, rep); err != nil {
+		t.Errorf("reputation: failed to update: %v", err)
+	}
+	if stored := db.reputation(node.ID); stored.Score != rep.Score || stored.Banned.Unix() != inst.Unix() {
+		t.Errorf("reputation: value mismatch: have %v, want %v", stored, rep)
+	}
 	// Check fetch/store operations on an actual node object
 	if stored := db.node(node.ID); stored != nil {
 		t.Errorf("node: non-existing object: %v", stored)
@@ -348,6 +361,37 @@ func TestNodeDBExpiration(t *testing.T) {
 	}
 }
 
+func TestNodeDBReputationExpiration(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	// Reputations of peers unknown to discovery, one of them stale
+	fresh, stale := NodeID{1}, NodeID{2}
+	for _, id := range []NodeID{fresh, stale} {
+		if err := db.updateReputation(id, NodeReputation{Score: -10}); err != nil {
+			t.Fatalf("failed to update reputation: %v", err)
+		}
+	}
+	updated := time.Now().Add(-nodeDBReputationExpiration - time.Minute)
+	if err := db.storeInt64(makeKey(stale, nodeDBReputationUpdated), updated.Unix()); err != nil {
+		t.Fatalf("failed to age reputation: %v", err)
+	}
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	if rep := db.reputation(fresh); rep.Score != -10 {
+		t.Errorf("fresh reputation expired: %v", rep)
+	}
+	if rep := db.reputation(stale); rep.Score != 0 {
+		t.Errorf("stale reputation not expired: %v", rep)
+	}
+	it := db.lvl.NewIterator(util.BytesPrefix(makeKey(stale, "")), nil)
+	defer it.Release()
+	if it.Next() {
+		t.Errorf("stale reputation left key %q", it.Key())
+	}
+}
+
 func TestNodeDBSelfExpiration(t *testing.T) {
 	// Find a node in the tests that shouldn't expire, and assign it as self
 	var self NodeID
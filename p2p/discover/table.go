@@ -123,6 +123,24 @@ func (tab *Table) Self() *Node {
 	return tab.self
 }
 
+// NodeReputation is the quality of service record of a remote node, maintained
+// by the higher level protocols and persisted in the node database so that it
+// survives reconnects and restarts.
+type NodeReputation struct {
+	Score  int64     // Protocol specific quality score of the node
+	Banned time.Time // Time until which connections from the node are refused
+}
+
+// Reputation retrieves the reputation of a node from the node database.
+func (tab *Table) Reputation(id NodeID) NodeReputation {
+	return tab.db.reputation(id)
+}
+
+// SetReputation stores the reputation of a node in the node database.
+func (tab *Table) SetReputation(id NodeID, rep NodeReputation) error {
+	return tab.db.updateReputation(id, rep)
+}
+
 // ReadRandomNodes fills the given slice with random nodes from the
 // table. It will not write the same node more than once. The nodes in
 // the slice are copies and can be modified by the caller.
@@ -50,7 +50,10 @@ const (
 	frameWriteTimeout = 20 * time.Second
 )
 
-var errServerStopped = errors.New("server stopped")
+var (
+	errServerStopped  = errors.New("server stopped")
+	errNoNodeDatabase = errors.New("no node database (discovery disabled)")
+)
 
 var srvjslog = logger.NewJsonLogger()
 
@@ -282,6 +285,32 @@ func (srv *Server) Self() *discover.Node {
 	return srv.ntab.Self()
 }
 
+// NodeReputation retrieves the reputation of a remote node from the node
+// database. If discovery is disabled there is no node database and a zero
+// reputation is returned.
+func (srv *Server) NodeReputation(id discover.NodeID) discover.NodeReputation {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if store, ok := srv.ntab.(reputationStore); ok {
+		return store.Reputation(id)
+	}
+	return discover.NodeReputation{}
+}
+
+// SetNodeReputation stores the reputation of a remote node in the node
+// database. If discovery is disabled there is no node database and an error
+// is returned, callers need to keep the reputation themselves.
+func (srv *Server) SetNodeReputation(id discover.NodeID, rep discover.NodeReputation) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if store, ok := srv.ntab.(reputationStore); ok {
+		return store.SetReputation(id, rep)
+	}
+	return errNoNodeDatabase
+}
+
 // Stop terminates the server and all active peer connections.
 // It blocks until all active connections have been closed.
 func (srv *Server) Stop() {
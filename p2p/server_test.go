@@ -436,3 +436,16 @@ func randomID() (id discover.NodeID) {
 	}
 	return id
 }
+
+func TestServerReputationWithoutDiscovery(t *testing.T) {
+	srv := startTestServer(t, randomID(), func(*Peer) {})
+	defer srv.Stop()
+
+	id := randomID()
+	if err := srv.SetNodeReputation(id, discover.NodeReputation{Score: -10}); err != errNoNodeDatabase {
+		t.Errorf("storing reputation without node database: have %v, want %v", err, errNoNodeDatabase)
+	}
+	if rep := srv.NodeReputation(id); rep.Score != 0 {
+		t.Errorf("reputation without node database: have %d, want 0", rep.Score)
+	}
+}
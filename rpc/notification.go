@@ -85,6 +85,7 @@ type Subscription interface {
 // notifications to subscribers.
 type bufferedSubscription struct {
 	id               string
+	namespace        string              // service namespace the notifications are sent with
 	unsubOnce        sync.Once           // call unsub method once
 	unsub            UnsubscribeCallback // called on Unsubscribed
 	notifier         *bufferedNotifier   // forward notifications to
@@ -167,8 +168,19 @@ func (n *bufferedNotifier) NewSubscription(callback UnsubscribeCallback) (Subscr
 
 // Remove the given subscription. If subscription is not found notificationNotFoundErr is returned.
 func (n *bufferedNotifier) Unsubscribe(subid string) error {
+	return n.unsubscribe(subid, func(*bufferedSubscription) bool { return true })
+}
+
+// unsubscribeNamespaced removes the given subscription only if it was made on the service
+// with the given namespace, otherwise notificationNotFoundErr is returned.
+func (n *bufferedNotifier) unsubscribeNamespaced(subid, namespace string) error {
+	return n.unsubscribe(subid, func(sub *bufferedSubscription) bool { return sub.namespace == namespace })
+}
+
+func (n *bufferedNotifier) unsubscribe(subid string, match func(*bufferedSubscription) bool) error {
 	n.mu.Lock()
 	sub, found := n.subscriptions[subid]
+	found = found && match(sub)
 	n.mu.Unlock()
 
 	if found {
@@ -270,7 +282,12 @@ func (n *bufferedNotifier) run() {
 				// indicates that the response for the unsubscribe can be send to the client.
 				close(notification.sub.flushed)
 			} else {
-				msg := n.codec.CreateNotification(notification.sub.id, notification.data)
+				var msg interface{}
+				if codec, ok := n.codec.(NamespacedServerCodec); ok {
+					msg = codec.CreateNamespacedNotification(notification.sub.id, notification.sub.namespace, notification.data)
+				} else {
+					msg = n.codec.CreateNotification(notification.sub.id, notification.data)
+				}
 				if err := n.codec.Write(msg); err != nil {
 					n.codec.Close()
 					// unable to send notification to client, unsubscribe all subscriptions
@@ -286,12 +303,13 @@ func (n *bufferedNotifier) run() {
 }
 
 // Marks the subscription as active. This will causes the notifications for this subscription to be
-// forwarded to the client.
-func (n *bufferedNotifier) activate(subid string) {
+// forwarded to the client, using the given service namespace.
+func (n *bufferedNotifier) activate(subid, namespace string) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
 	if sub, found := n.subscriptions[subid]; found {
+		sub.namespace = namespace
 		close(sub.pending)
 	}
 }
@@ -106,6 +106,9 @@ func TestNotifications(t *testing.T) {
 			t.Fatalf("%v", err)
 		}
 
+		if notification.Method != "eth_subscription" {
+			t.Fatalf("expected eth_subscription notification, got %s", notification.Method)
+		}
 		if int(notification.Params.Result.(float64)) != val+i {
 			t.Fatalf("expected %d, got %d", val+i, notification.Params.Result)
 		}
@@ -118,3 +121,53 @@ func TestNotifications(t *testing.T) {
 		t.Error("unsubscribe callback not called after closing connection")
 	}
 }
+
+func TestUnsubscribeNamespace(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("eth", &NotificationTestService{}); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	if err := server.RegisterName("shh", &NotificationTestService{}); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation|OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	call := func(id int, method string, params ...interface{}) map[string]interface{} {
+		request := map[string]interface{}{"id": id, "method": method, "version": "2.0", "params": params}
+		if err := out.Encode(request); err != nil {
+			t.Fatal(err)
+		}
+		var response map[string]interface{}
+		if err := in.Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	subid, ok := call(1, "shh_subscribe", "someSubscription", 1, 1)["result"].(string)
+	if !ok {
+		t.Fatal("expected subscription id")
+	}
+	var notification jsonNotification
+	if err := in.Decode(&notification); err != nil {
+		t.Fatal(err)
+	}
+	if notification.Method != "shh_subscription" {
+		t.Fatalf("expected shh_subscription notification, got %s", notification.Method)
+	}
+
+	// subscriptions can only be cancelled through the namespace they were made on
+	if response := call(2, "eth_unsubscribe", subid); response["error"] == nil {
+		t.Fatalf("expected eth_unsubscribe of shh subscription to fail, got %v", response)
+	}
+	if response := call(3, "shh_unsubscribe", subid); response["result"] != true {
+		t.Fatalf("expected shh_unsubscribe to succeed, got %v", response)
+	}
+}
@@ -122,6 +122,16 @@ type ServerCodec interface {
 	Closed() <-chan interface{}
 }
 
+// NamespacedServerCodec is implemented by server codecs able to send the
+// notifications of a subscription with the namespace of the service it was made
+// on (e.g. shh_subscription). Notifications are created with CreateNotification
+// on codecs not implementing it.
+type NamespacedServerCodec interface {
+	ServerCodec
+	// Create notification response, expects subscription id, service namespace and payload
+	CreateNamespacedNotification(string, string, interface{}) interface{}
+}
+
 // HexNumber serializes a number to hex format using the "%#x" format
 type HexNumber big.Int
 
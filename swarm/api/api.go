@@ -31,6 +31,7 @@ it is the public interface of the dpa which is included in the ethereum stack
 type Api struct {
 	dpa *storage.DPA
 	dns Resolver
+	db  *storage.DbStore // for pinning, nil if not supported
 }
 
 //the api constructor initialises
@@ -43,7 +44,7 @@ func NewApi(dpa *storage.DPA, dns Resolver) (self *Api) {
 }
 
 // DPA reader API
-func (self *Api) Retrieve(key storage.Key) storage.SectionReader {
+func (self *Api) Retrieve(key storage.Key) storage.LazySectionReader {
 	return self.dpa.Retrieve(key)
 }
 
@@ -51,6 +52,22 @@ func (self *Api) Store(data storage.SectionReader, wg *sync.WaitGroup) (key stor
 	return self.dpa.Store(data, wg)
 }
 
+// StoreEncrypted stores data encrypted, the returned key carries the secret
+// needed to read it
+func (self *Api) StoreEncrypted(data storage.SectionReader, wg *sync.WaitGroup) (key storage.Key, err error) {
+	return self.dpa.StoreEncrypted(data, wg)
+}
+
+// StoreStream stores content of unknown length read until EOF
+func (self *Api) StoreStream(data io.Reader, wg *sync.WaitGroup) (key storage.Key, err error) {
+	return self.dpa.StoreStream(data, wg)
+}
+
+// Append stores the content under key extended with data and returns its key
+func (self *Api) Append(key storage.Key, data io.Reader, wg *sync.WaitGroup) (storage.Key, error) {
+	return self.dpa.Append(key, data, wg)
+}
+
 // DNS Resolver
 func (self *Api) Resolve(hostPort string, nameresolver bool) (contentHash storage.Key, err error) {
 	if hashMatcher.MatchString(hostPort) || self.dns == nil {
@@ -63,10 +80,10 @@ func (self *Api) Resolve(hostPort string, nameresolver bool) (contentHash storag
 	}
 	contentHash, err = self.dns.Resolve(hostPort)
 	if err != nil {
-		err = ErrResolve(err)
+		err = ErrResolve{err}
 		glog.V(logger.Debug).Infof("[BZZ] DNS error : %v", err)
 	}
-	glog.V(logger.Debug).Infof("[BZZ] host lookup: %v -> %v", err)
+	glog.V(logger.Debug).Infof("[BZZ] host lookup: %v -> %v", hostPort, contentHash)
 	return
 }
 
@@ -121,10 +138,13 @@ func (self *Api) Put(content, contentType string) (string, error) {
 
 // Get uses iterative manifest retrieval and prefix matching
 // to resolve path to content using dpa retrieve
-// it returns a section reader, mimeType, status and an error
-func (self *Api) Get(uri string, nameresolver bool) (reader storage.SectionReader, mimeType string, status int, err error) {
+// it returns a section reader, mimeType, status, the key of the content and an error
+func (self *Api) Get(uri string, nameresolver bool) (reader storage.LazySectionReader, mimeType string, status int, key storage.Key, err error) {
 
 	key, _, path, err := self.parseAndResolve(uri, nameresolver)
+	if err != nil {
+		return
+	}
 
 	trie, err := loadManifest(self.dpa, key)
 	if err != nil {
@@ -141,12 +161,135 @@ func (self *Api) Get(uri string, nameresolver bool) (reader storage.SectionReade
 		glog.V(logger.Debug).Infof("[BZZ] Swarm: content lookup key: '%v' (%v)", key, mimeType)
 		reader = self.dpa.Retrieve(key)
 	} else {
+		key = nil
 		err = fmt.Errorf("manifest entry for '%s' not found", path)
 		glog.V(logger.Debug).Infof("[BZZ] Swarm: %v", err)
 	}
 	return
 }
 
+// ManifestEntry is a manifest entry as listed by the api
+type ManifestEntry struct {
+	Hash        string `json:"hash"`
+	Path        string `json:"path"`
+	ContentType string `json:"contentType"`
+	Status      int    `json:"status,omitempty"`
+}
+
+// ManifestList is the listing of a manifest path prefix: the entries directly
+// under the prefix and the common prefixes ("directories") of the deeper ones
+type ManifestList struct {
+	CommonPrefixes []string         `json:"common_prefixes,omitempty"`
+	Entries        []*ManifestEntry `json:"entries,omitempty"`
+}
+
+// List returns the listing of the manifest entries under the path of uri
+// a non-empty path is treated as a directory, i.e. 'img' lists 'img/...'
+func (self *Api) List(uri string, nameresolver bool) (list ManifestList, err error) {
+	key, _, path, err := self.parseAndResolve(uri, nameresolver)
+	if err != nil {
+		return
+	}
+	trie, err := loadManifest(self.dpa, key)
+	if err != nil {
+		return
+	}
+	prefix := RegularSlashes(path)
+	if prefix != "" {
+		prefix += "/"
+	}
+	seen := make(map[string]bool)
+	err = trie.listWithPrefix(prefix, func(entry *manifestTrieEntry, suffix string) {
+		if i := strings.Index(suffix, "/"); i >= 0 {
+			dir := prefix + suffix[:i+1]
+			if !seen[dir] {
+				seen[dir] = true
+				list.CommonPrefixes = append(list.CommonPrefixes, dir)
+			}
+			return
+		}
+		list.Entries = append(list.Entries, &ManifestEntry{
+			Hash:        entry.Hash,
+			Path:        prefix + suffix,
+			ContentType: entry.ContentType,
+			Status:      entry.Status,
+		})
+	})
+	return
+}
+
+// ManifestWriter adds entries to a new or an existing manifest
+// the entries are stored as they are added, the manifest itself when Store is called
+type ManifestWriter struct {
+	api     *Api
+	trie    *manifestTrie
+	prefix  string
+	encrypt bool
+}
+
+// NewManifestWriter creates a writer adding to the manifest of uri under its
+// path, or to a new empty manifest if uri is empty. If encrypt is set, the
+// added content and the manifest itself are stored encrypted.
+func (self *Api) NewManifestWriter(uri string, nameresolver, encrypt bool) (*ManifestWriter, error) {
+	writer := &ManifestWriter{
+		api:     self,
+		trie:    &manifestTrie{dpa: self.dpa, encrypted: encrypt},
+		encrypt: encrypt,
+	}
+	if RegularSlashes(uri) == "" {
+		return writer, nil
+	}
+	key, _, path, err := self.parseAndResolve(uri, nameresolver)
+	if err != nil {
+		return nil, err
+	}
+	if writer.trie, err = loadManifest(self.dpa, key); err != nil {
+		return nil, err
+	}
+	writer.trie.encrypted = writer.trie.encrypted || encrypt
+	if writer.prefix = RegularSlashes(path); writer.prefix != "" {
+		writer.prefix += "/"
+	}
+	return writer, nil
+}
+
+// AddEntry stores the content and adds it under path, relative to the prefix
+// of the writer
+func (self *ManifestWriter) AddEntry(data storage.SectionReader, path, contentType string) (storage.Key, error) {
+	wg := &sync.WaitGroup{}
+	var key storage.Key
+	var err error
+	if self.encrypt {
+		key, err = self.api.dpa.StoreEncrypted(data, wg)
+	} else {
+		key, err = self.api.dpa.Store(data, wg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	wg.Wait()
+	self.AddKey(key, path, contentType)
+	return key, nil
+}
+
+// AddKey adds already stored content under path, relative to the prefix of
+// the writer
+func (self *ManifestWriter) AddKey(key storage.Key, path, contentType string) {
+	self.trie.addEntry(&manifestTrieEntry{
+		Path:        RegularSlashes(self.prefix + path),
+		Hash:        key.String(),
+		ContentType: contentType,
+	})
+}
+
+// Store stores the manifest and returns its new root key
+func (self *ManifestWriter) Store() (storage.Key, error) {
+	if err := self.trie.recalcAndStore(); err != nil {
+		return nil, err
+	}
+	return self.trie.hash, nil
+}
+
 func (self *Api) Modify(uri, contentHash, contentType string, nameresolver bool) (newRootHash string, err error) {
 	root, _, path, err := self.parseAndResolve(uri, nameresolver)
 	trie, err := loadManifest(self.dpa, root)
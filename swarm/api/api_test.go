@@ -46,7 +46,7 @@ func checkResponse(t *testing.T, resp *testResponse, exp *Response) {
 		content := make([]byte, resp.Size)
 		read, _ := resp.reader.Read(content)
 		if int64(read) != exp.Size {
-			t.Errorf("incorrect content length. expected '%s...', got '%s...'", read, exp.Size)
+			t.Errorf("incorrect content length. expected %d, got %d", exp.Size, read)
 		}
 		resp.Content = string(content)
 	}
@@ -63,7 +63,7 @@ func expResponse(content string, mimeType string, status int) *Response {
 
 // func testGet(t *testing.T, api *Api, bzzhash string) *testResponse {
 func testGet(t *testing.T, api *Api, bzzhash string) *testResponse {
-	reader, mimeType, status, err := api.Get(bzzhash, true)
+	reader, mimeType, status, _, err := api.Get(bzzhash, true)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -22,6 +22,7 @@ var (
     "Hash": "SHA256",
     "JoinTimeout": 120,
     "SplitTimeout": 120,
+    "Parities": 0,
     "CallInterval": 10000000000,
     "KadDbPath": "` + filepath.Join("TMPDIR", "0d2f62485607cf38d9d795d93682a517661e513e", "bzz-peers.json") + `",
     "MaxProx": 10,
@@ -67,7 +68,8 @@ var (
     "Path": "` + filepath.Join("TMPDIR", "0d2f62485607cf38d9d795d93682a517661e513e") + `",
     "Port": "8500",
     "PublicKey": "0x045f5cfd26692e48d0017d380349bcf50982488bc11b5145f3ddf88b24924299048450542d43527fbe29a5cb32f38d62755393ac002e6bfdd71b8d7ba725ecd7a3",
-    "BzzKey": "0xe861964402c0b78e2d44098329b8545726f215afa737d803714a4338552fcb81"
+    "BzzKey": "0xe861964402c0b78e2d44098329b8545726f215afa737d803714a4338552fcb81",
+    "EnsRoot": "0x0000000000000000000000000000000000000000"
 }`
 )
 
@@ -36,7 +36,9 @@ func (self *DNS) Register(sender common.Address, domain string, hash common.Hash
 	return
 }
 
-type ErrResolve error
+// ErrResolve wraps name resolution failures, so that they can be told apart
+// from missing content
+type ErrResolve struct{ error }
 
 func (self *DNS) Resolve(hostPort string) (contentHash storage.Key, err error) {
 	host := hostPort
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/ens"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// ENSResolver resolves hostnames to the content hash records of an ENS
+// registry, it replaces the DNS registrar if the registry is configured
+type ENSResolver struct {
+	ens *ens.ENS
+}
+
+func NewENSResolver(ens *ens.ENS) *ENSResolver {
+	return &ENSResolver{ens}
+}
+
+func (self *ENSResolver) Resolve(host string) (storage.Key, error) {
+	hash, err := self.ens.Resolve(host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve '%s': %v", host, err)
+	}
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("no content hash set for '%s'", host)
+	}
+	glog.V(logger.Debug).Infof("[ENS] resolve host '%s' to contentHash: '%v'", host, hash.Hex())
+	return storage.Key(hash.Bytes()), nil
+}
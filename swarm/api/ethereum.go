@@ -8,11 +8,13 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/registrar"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/logger"
@@ -32,6 +34,9 @@ var (
 	addrReg         = regexp.MustCompile(`^(0x)?[a-fA-F0-9]{40}$`)
 )
 
+// ethApi also backs the contract bindings
+var _ bind.ContractBackend = (*ethApi)(nil)
+
 type ethApi struct {
 	eth           *eth.Ethereum
 	gpo           *eth.GasPriceOracle
@@ -83,7 +88,7 @@ func (self *ethApi) UpdateState() (wait chan *big.Int) {
 					}
 					statedb, err := state.New(event.Block.Root(), self.eth.ChainDb())
 					if err != nil {
-						glog.V(logger.Error).Infoln("Could not create new state: %v", err)
+						glog.V(logger.Error).Infof("Could not create new state: %v", err)
 						return
 					}
 					self.state = statedb
@@ -103,7 +108,8 @@ func (self *ethApi) AtStateNum(num int64) registrar.Backend {
 	var err error
 	switch num {
 	case -2:
-		st = self.eth.Miner().PendingState().Copy()
+		_, pending := self.eth.Miner().Pending()
+		st = pending.Copy()
 	default:
 		if block := self.getBlockByHeight(num); block != nil {
 			st, err = state.New(block.Root(), self.eth.ChainDb())
@@ -142,8 +148,8 @@ func (self *ethApi) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr
 	statedb := self.state.Copy()
 	var from *state.StateObject
 	if len(fromStr) == 0 {
-		accounts, err := self.eth.AccountManager().Accounts()
-		if err != nil || len(accounts) == 0 {
+		accounts := self.eth.AccountManager().Accounts()
+		if len(accounts) == 0 {
 			from = statedb.GetOrNewStateObject(common.Address{})
 		} else {
 			from = statedb.GetOrNewStateObject(accounts[0].Address)
@@ -175,7 +181,7 @@ func (self *ethApi) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr
 	}
 
 	header := self.CurrentBlock().Header()
-	vmenv := core.NewEnv(statedb, self.eth.BlockChain(), msg, header)
+	vmenv := core.NewEnv(statedb, self.eth.BlockChain().Config(), self.eth.BlockChain(), msg, header, vm.Config{})
 	gp := new(core.GasPool).AddGas(common.MaxBig)
 	res, gas, err := core.ApplyMessage(vmenv, msg, gp)
 	return common.ToHex(res), gas.String(), err
@@ -249,27 +255,78 @@ func (self *ethApi) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPric
 	return signed.Hash().Hex(), nil
 }
 
+// sign signs the transaction with the account manager, so that backends signing
+// whole transactions (external signers) get to see it rather than just its hash
 func (self *ethApi) sign(tx *types.Transaction, from common.Address, didUnlock bool) (*types.Transaction, error) {
-	hash := tx.SigHash()
-	sig, err := self.doSign(from, hash, didUnlock)
-	if err != nil {
-		return tx, err
-	}
-	return tx.WithSignature(sig)
-}
-
-func (self *ethApi) doSign(from common.Address, hash common.Hash, didUnlock bool) ([]byte, error) {
-	sig, err := self.eth.AccountManager().Sign(accounts.Account{Address: from}, hash.Bytes())
+	signed, err := self.eth.AccountManager().SignTx(from, tx)
 	if err == accounts.ErrLocked {
 		if didUnlock {
 			return nil, fmt.Errorf("signer account still locked after successful unlock")
 		}
 		// retry signing, the account should now be unlocked.
-		return self.doSign(from, hash, true)
-	} else if err != nil {
+		return self.sign(tx, from, true)
+	}
+	return signed, err
+}
+
+// ContractCall implements bind.ContractCaller for the contract bindings (ENS)
+func (self *ethApi) ContractCall(contract common.Address, data []byte, pending bool) ([]byte, error) {
+	block, statedb, err := self.callState(pending)
+	if err != nil {
+		return nil, err
+	}
+	if len(statedb.GetCode(contract)) == 0 {
+		return nil, bind.ErrNoCode
+	}
+	msg := newCallmsg(statedb, common.Address{}, &contract, new(big.Int), data)
+	vmenv := core.NewEnv(statedb, self.eth.BlockChain().Config(), self.eth.BlockChain(), msg, block.Header(), vm.Config{})
+	gp := new(core.GasPool).AddGas(common.MaxBig)
+	res, _, err := core.ApplyMessage(vmenv, msg, gp)
+	return res, err
+}
+
+// PendingAccountNonce implements bind.ContractTransactor
+func (self *ethApi) PendingAccountNonce(account common.Address) (uint64, error) {
+	return self.eth.TxPool().State().GetNonce(account), nil
+}
+
+// SuggestGasPrice implements bind.ContractTransactor
+func (self *ethApi) SuggestGasPrice() (*big.Int, error) {
+	return self.DefaultGasPrice(), nil
+}
+
+// EstimateGasLimit implements bind.ContractTransactor by executing the
+// transaction on the pending state
+func (self *ethApi) EstimateGasLimit(sender common.Address, contract *common.Address, value *big.Int, data []byte) (*big.Int, error) {
+	block, statedb, err := self.callState(true)
+	if err != nil {
 		return nil, err
 	}
-	return sig, nil
+	if contract != nil && len(statedb.GetCode(*contract)) == 0 {
+		return nil, bind.ErrNoCode
+	}
+	msg := newCallmsg(statedb, sender, contract, value, data)
+	vmenv := core.NewEnv(statedb, self.eth.BlockChain().Config(), self.eth.BlockChain(), msg, block.Header(), vm.Config{})
+	gp := new(core.GasPool).AddGas(common.MaxBig)
+	_, gas, _, err := core.NewStateTransition(vmenv, msg, gp).TransitionDb()
+	return gas, err
+}
+
+// SendTransaction implements bind.ContractTransactor
+func (self *ethApi) SendTransaction(tx *types.Transaction) error {
+	return self.eth.TxPool().Add(tx)
+}
+
+// callState returns a copy of the pending or the current state to run
+// calls on
+func (self *ethApi) callState(pending bool) (*types.Block, *state.StateDB, error) {
+	if pending {
+		block, statedb := self.eth.Miner().Pending()
+		return block, statedb.Copy(), nil
+	}
+	block := self.CurrentBlock()
+	statedb, err := state.New(block.Root(), self.eth.ChainDb())
+	return block, statedb, err
 }
 
 func DefaultGas() *big.Int { return new(big.Int).Set(defaultGas) }
@@ -287,7 +344,8 @@ func (self *ethApi) getBlockByHeight(height int64) *types.Block {
 
 	switch height {
 	case -2:
-		return self.eth.Miner().PendingBlock()
+		block, _ := self.eth.Miner().Pending()
+		return block
 	case -1:
 		return self.CurrentBlock()
 	default:
@@ -310,15 +368,31 @@ type callmsg struct {
 	data          []byte
 }
 
+// newCallmsg returns a call from sender, which is given the balance to pay for
+// it
+func newCallmsg(statedb *state.StateDB, sender common.Address, to *common.Address, value *big.Int, data []byte) callmsg {
+	from := statedb.GetOrNewStateObject(sender)
+	from.SetBalance(common.MaxBig)
+	return callmsg{
+		from:     from,
+		to:       to,
+		gas:      common.MaxBig,
+		gasPrice: new(big.Int),
+		value:    value,
+		data:     data,
+	}
+}
+
 func isAddress(addr string) bool {
 	return addrReg.MatchString(addr)
 }
 
 // accessor boilerplate to implement core.Message
-func (m callmsg) From() (common.Address, error) { return m.from.Address(), nil }
-func (m callmsg) Nonce() uint64                 { return m.from.Nonce() }
-func (m callmsg) To() *common.Address           { return m.to }
-func (m callmsg) GasPrice() *big.Int            { return m.gasPrice }
-func (m callmsg) Gas() *big.Int                 { return m.gas }
-func (m callmsg) Value() *big.Int               { return m.value }
-func (m callmsg) Data() []byte                  { return m.data }
+func (m callmsg) From() (common.Address, error)         { return m.from.Address(), nil }
+func (m callmsg) FromFrontier() (common.Address, error) { return m.from.Address(), nil }
+func (m callmsg) Nonce() uint64                         { return m.from.Nonce() }
+func (m callmsg) To() *common.Address                   { return m.to }
+func (m callmsg) GasPrice() *big.Int                    { return m.gasPrice }
+func (m callmsg) Gas() *big.Int                         { return m.gas }
+func (m callmsg) Value() *big.Int                       { return m.value }
+func (m callmsg) Data() []byte                          { return m.data }
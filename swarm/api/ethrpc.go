@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var _ bind.ContractBackend = (*rpcEthApi)(nil)
+
+// rpcEthApi implements the chequebook backend on top of the JSON-RPC API of
+// a remote Ethereum node, so that a standalone swarm node can use SWAP.
+// Transactions are sent from the swarm account, which needs to be unlocked
+// on the remote node. It also backs the contract bindings (ENS), whose
+// transactions are signed locally.
+type rpcEthApi struct {
+	client rpc.Client
+	lock   sync.Mutex // Send/Recv pairs must not interleave
+	id     int64
+}
+
+func NewRpcEthApi(client rpc.Client) *rpcEthApi {
+	return &rpcEthApi{client: client}
+}
+
+// request calls method on the remote node and decodes the result into result.
+func (self *rpcEthApi) request(result interface{}, method string, params ...interface{}) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.id++
+	req := map[string]interface{}{
+		"id":      self.id,
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+	if err := self.client.Send(req); err != nil {
+		return err
+	}
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpc.JSONError  `json:"error"`
+	}
+	if err := self.client.Recv(&res); err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return fmt.Errorf("%s: %s", method, res.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
+
+// hexBig converts the decimal string arguments of the backend interface to
+// the quantity encoding of the JSON-RPC API.
+func hexBig(s string) string {
+	return fmt.Sprintf("%#x", common.Big(s))
+}
+
+func (self *rpcEthApi) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error) {
+	args := map[string]string{"from": fromStr}
+	if len(toStr) > 0 {
+		args["to"] = toStr
+	}
+	if len(nonceStr) > 0 {
+		args["nonce"] = hexBig(nonceStr)
+	}
+	if len(valueStr) > 0 {
+		args["value"] = hexBig(valueStr)
+	}
+	if len(gasStr) > 0 {
+		args["gas"] = hexBig(gasStr)
+	}
+	if len(gasPriceStr) > 0 {
+		args["gasPrice"] = hexBig(gasPriceStr)
+	}
+	if len(codeStr) > 0 {
+		args["data"] = codeStr
+	}
+	var txhash string
+	if err := self.request(&txhash, "eth_sendTransaction", args); err != nil {
+		return "", err
+	}
+	return txhash, nil
+}
+
+func (self *rpcEthApi) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, string, error) {
+	args := map[string]string{"to": toStr}
+	if len(fromStr) > 0 {
+		args["from"] = fromStr
+	}
+	if len(valueStr) > 0 {
+		args["value"] = hexBig(valueStr)
+	}
+	if len(gasStr) > 0 {
+		args["gas"] = hexBig(gasStr)
+	}
+	if len(gasPriceStr) > 0 {
+		args["gasPrice"] = hexBig(gasPriceStr)
+	}
+	if len(dataStr) > 0 {
+		args["data"] = dataStr
+	}
+	var res string
+	if err := self.request(&res, "eth_call", args, "latest"); err != nil {
+		return "", "", err
+	}
+	return res, "", nil
+}
+
+func (self *rpcEthApi) GetTxReceipt(txhash common.Hash) *types.Receipt {
+	var res *struct {
+		ContractAddress   *common.Address `json:"contractAddress"`
+		CumulativeGasUsed *rpc.HexNumber  `json:"cumulativeGasUsed"`
+		GasUsed           *rpc.HexNumber  `json:"gasUsed"`
+	}
+	if err := self.request(&res, "eth_getTransactionReceipt", txhash.Hex()); err != nil {
+		glog.V(logger.Debug).Infof("[BZZ] receipt lookup for %v failed: %v", txhash.Hex(), err)
+		return nil
+	}
+	if res == nil {
+		return nil
+	}
+	receipt := &types.Receipt{
+		CumulativeGasUsed: new(big.Int),
+		GasUsed:           new(big.Int),
+	}
+	if res.ContractAddress != nil {
+		receipt.ContractAddress = *res.ContractAddress
+	}
+	if res.CumulativeGasUsed != nil {
+		receipt.CumulativeGasUsed = res.CumulativeGasUsed.BigInt()
+	}
+	if res.GasUsed != nil {
+		receipt.GasUsed = res.GasUsed.BigInt()
+	}
+	return receipt
+}
+
+func (self *rpcEthApi) CodeAt(address string) string {
+	var code string
+	if err := self.request(&code, "eth_getCode", address, "latest"); err != nil {
+		glog.V(logger.Debug).Infof("[BZZ] code lookup for %v failed: %v", address, err)
+		return "0x"
+	}
+	return code
+}
+
+func (self *rpcEthApi) BalanceAt(address common.Address) string {
+	var balance rpc.HexNumber
+	if err := self.request(&balance, "eth_getBalance", address.Hex(), "latest"); err != nil {
+		glog.V(logger.Debug).Infof("[BZZ] balance lookup for %v failed: %v", address.Hex(), err)
+		return "0"
+	}
+	return balance.BigInt().String()
+}
+
+// ContractCall implements bind.ContractCaller for the contract bindings (ENS)
+func (self *rpcEthApi) ContractCall(contract common.Address, data []byte, pending bool) ([]byte, error) {
+	block := "latest"
+	if pending {
+		block = "pending"
+	}
+	var code string
+	if err := self.request(&code, "eth_getCode", contract.Hex(), block); err != nil {
+		return nil, err
+	}
+	if len(common.FromHex(code)) == 0 {
+		return nil, bind.ErrNoCode
+	}
+	args := map[string]string{
+		"to":   contract.Hex(),
+		"data": common.ToHex(data),
+	}
+	var res string
+	if err := self.request(&res, "eth_call", args, block); err != nil {
+		return nil, err
+	}
+	return common.FromHex(res), nil
+}
+
+// PendingAccountNonce implements bind.ContractTransactor
+func (self *rpcEthApi) PendingAccountNonce(account common.Address) (uint64, error) {
+	var nonce rpc.HexNumber
+	if err := self.request(&nonce, "eth_getTransactionCount", account.Hex(), "pending"); err != nil {
+		return 0, err
+	}
+	return nonce.BigInt().Uint64(), nil
+}
+
+// SuggestGasPrice implements bind.ContractTransactor
+func (self *rpcEthApi) SuggestGasPrice() (*big.Int, error) {
+	var price rpc.HexNumber
+	if err := self.request(&price, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return price.BigInt(), nil
+}
+
+// EstimateGasLimit implements bind.ContractTransactor
+func (self *rpcEthApi) EstimateGasLimit(sender common.Address, contract *common.Address, value *big.Int, data []byte) (*big.Int, error) {
+	args := map[string]string{
+		"from": sender.Hex(),
+		"data": common.ToHex(data),
+	}
+	if contract != nil {
+		args["to"] = contract.Hex()
+	}
+	if value != nil {
+		args["value"] = fmt.Sprintf("%#x", value)
+	}
+	var gas rpc.HexNumber
+	if err := self.request(&gas, "eth_estimateGas", args); err != nil {
+		return nil, err
+	}
+	return gas.BigInt(), nil
+}
+
+// SendTransaction implements bind.ContractTransactor, the transaction is
+// signed locally
+func (self *rpcEthApi) SendTransaction(tx *types.Transaction) error {
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return err
+	}
+	return self.request(nil, "eth_sendRawTransaction", common.ToHex(data))
+}
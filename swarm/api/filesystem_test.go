@@ -58,7 +58,7 @@ func TestApiDirUpload0(t *testing.T) {
 		resp = testGet(t, api, bzzhash+"/img/logo.png")
 		exp = expResponse(content, "image/png", 0)
 
-		_, _, _, err = api.Get(bzzhash, true)
+		_, _, _, _, err = api.Get(bzzhash, true)
 		if err == nil {
 			t.Fatalf("expected error: %v", err)
 		}
@@ -120,7 +120,7 @@ func TestApiDirUploadModify(t *testing.T) {
 		resp = testGet(t, api, bzzhash+"/index.css")
 		exp = expResponse(content, "text/css", 0)
 
-		_, _, _, err = api.Get(bzzhash, true)
+		_, _, _, _, err = api.Get(bzzhash, true)
 		if err == nil {
 			t.Errorf("expected error: %v", err)
 		}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/api"
+)
+
+var listTemplate = template.Must(template.New("list").Funcs(template.FuncMap{
+	"base": func(path string) string {
+		path = strings.TrimSuffix(path, "/")
+		return path[strings.LastIndex(path, "/")+1:]
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Swarm index of {{.Path}}</title>
+</head>
+<body>
+<h1>Swarm index of {{.Path}}</h1>
+<table>
+<tr><th>Path</th><th>Type</th><th>Hash</th></tr>
+{{range .List.CommonPrefixes}}<tr><td><a href="{{base .}}/">{{base .}}/</a></td><td>DIR</td><td>-</td></tr>
+{{end}}{{range .List.Entries}}<tr><td><a href="{{base .Path}}">{{base .Path}}</a></td><td>{{.ContentType}}</td><td>{{.Hash}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// serveList responds with the listing of the manifest entries under path
+func serveList(w http.ResponseWriter, r *http.Request, a *api.Api, path string, nameresolver bool) {
+	list, err := a.List(path, nameresolver)
+	if err != nil {
+		status := http.StatusNotFound
+		if _, ok := err.(api.ErrResolve); ok {
+			status = http.StatusBadRequest
+		}
+		glog.V(logger.Debug).Infof("[BZZ] Swarm: error listing '%s': %v", path, err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeList(w, r, path, list)
+}
+
+// writeList writes the listing as JSON if the client accepts it, as an HTML
+// page otherwise
+func writeList(w http.ResponseWriter, r *http.Request, path string, list api.ManifestList) {
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+		return
+	}
+	// relative links resolve against the directory
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		target := r.URL.Path + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := listTemplate.Execute(w, &struct {
+		Path string
+		List api.ManifestList
+	}{"/" + path, list})
+	if err != nil {
+		glog.V(logger.Debug).Infof("[BZZ] Swarm: error rendering listing of '%s': %v", path, err)
+	}
+}
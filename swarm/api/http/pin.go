@@ -0,0 +1,74 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/api"
+)
+
+const (
+	pinPrefix   = "/pins/"
+	gcStatsPath = "/gcstats"
+)
+
+// pinHandler serves the pin list API:
+//
+//	GET    /pins/          lists the pinned content
+//	PUT    /pins/<uri>     pins the content of uri (a manifest unless ?raw)
+//	DELETE /pins/<uri>     unpins the content of uri
+func pinHandler(w http.ResponseWriter, r *http.Request, a *api.Api) {
+	uri := strings.TrimPrefix(r.URL.Path, pinPrefix)
+	switch {
+	case (r.Method == "GET" || r.Method == "HEAD") && uri == "":
+		pins, err := a.Pins()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		writeJSON(w, pins)
+	case (r.Method == "PUT" || r.Method == "POST") && uri != "":
+		_, raw := r.URL.Query()["raw"]
+		key, err := a.Pin(uri, raw, true)
+		if err != nil {
+			glog.V(logger.Debug).Infof("[BZZ] Swarm: pinning '%s' failed: %v", uri, err)
+			http.Error(w, err.Error(), pinErrorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(key.String()))
+	case r.Method == "DELETE" && uri != "":
+		if _, err := a.Unpin(uri, true); err != nil {
+			glog.V(logger.Debug).Infof("[BZZ] Swarm: unpinning '%s' failed: %v", uri, err)
+			http.Error(w, err.Error(), pinErrorStatus(err))
+			return
+		}
+	default:
+		http.Error(w, "Method "+r.Method+" not allowed on "+r.URL.Path, http.StatusMethodNotAllowed)
+	}
+}
+
+// gcStatsHandler reports the garbage collection statistics of the local store
+func gcStatsHandler(w http.ResponseWriter, r *http.Request, a *api.Api) {
+	stats, err := a.GCStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+func pinErrorStatus(err error) int {
+	if _, ok := err.(api.ErrResolve); ok {
+		return http.StatusBadRequest
+	}
+	return http.StatusNotFound
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/api"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+const resourcePrefix = "/bzz-resource:/"
+
+// resourceHandler serves mutable resources:
+//
+//	GET  /bzz-resource:/<owner>/<topic>            the data of the latest update
+//	GET  /bzz-resource:/<owner>/<topic>/<version>  the data of the given update
+//	POST /bzz-resource:/[<owner>/<topic>]          publishes the signed update in the body
+//
+// The topic is either a hash or a name it is derived from. The version and
+// time of the update served are given in the X-Swarm-Resource-Version and
+// X-Swarm-Resource-Time headers.
+func resourceHandler(w http.ResponseWriter, r *http.Request, a *api.Api) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, resourcePrefix), "/")
+	switch r.Method {
+	case "GET", "HEAD":
+		owner, topic, version, err := parseResourcePath(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update, err := a.Resource(owner, topic, version)
+		if err != nil {
+			glog.V(logger.Debug).Infof("[BZZ] Swarm: resource '%s': %v", path, err)
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", rawType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(update.Data)))
+		w.Header().Set("X-Swarm-Resource-Version", strconv.FormatUint(update.Version, 10))
+		w.Header().Set("X-Swarm-Resource-Time", strconv.FormatUint(update.Time, 10))
+		w.Header().Set("ETag", fmt.Sprintf("%q", update.Key()))
+		if r.Method == "GET" {
+			w.Write(update.Data)
+		}
+	case "POST", "PUT":
+		sdata, err := ioutil.ReadAll(io.LimitReader(r.Body, storage.MaxResourceDataSize+1024))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if path != "" {
+			// check the resource addressed before anything is stored
+			owner, topic, _, err := parseResourcePath(path)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			update, err := storage.ParseResourceUpdate(nil, sdata)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if owner != update.Owner || topic != update.Topic {
+				http.Error(w, "update does not belong to resource "+path, http.StatusBadRequest)
+				return
+			}
+		}
+		update, err := a.UpdateResource(sdata)
+		if err != nil {
+			glog.V(logger.Debug).Infof("[BZZ] Swarm: resource update failed: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(update.Key().String()))
+	default:
+		http.Error(w, "Method "+r.Method+" not allowed on "+r.URL.Path, http.StatusMethodNotAllowed)
+	}
+}
+
+// parseResourcePath splits <owner>/<topic>[/<version>], version is 0 if
+// missing
+func parseResourcePath(path string) (owner common.Address, topic common.Hash, version uint64, err error) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return owner, topic, 0, fmt.Errorf("invalid resource '%s', expected <owner>/<topic>[/<version>]", path)
+	}
+	if !common.IsHexAddress(parts[0]) {
+		return owner, topic, 0, fmt.Errorf("invalid resource owner '%s'", parts[0])
+	}
+	owner = common.HexToAddress(parts[0])
+	topic = resourceTopic(parts[1])
+	if len(parts) == 3 {
+		if version, err = strconv.ParseUint(parts[2], 10, 64); err != nil || version == 0 {
+			return owner, topic, 0, fmt.Errorf("invalid resource version '%s'", parts[2])
+		}
+	}
+	return owner, topic, version, nil
+}
+
+// resourceTopic takes a hex hash as is, anything else as the name of the
+// topic
+func resourceTopic(s string) common.Hash {
+	hex := strings.TrimPrefix(s, "0x")
+	if len(hex) == 2*common.HashLength && common.IsHex("0x"+hex) {
+		return common.HexToHash(hex)
+	}
+	return storage.ResourceTopic(s)
+}
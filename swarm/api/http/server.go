@@ -5,6 +5,7 @@ package http
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"regexp"
@@ -15,6 +16,7 @@ import (
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/swarm/api"
+	"github.com/ethereum/go-ethereum/swarm/storage"
 )
 
 const (
@@ -43,12 +45,25 @@ type sequentialReader struct {
 
 // starts up http server
 func StartHttpServer(api *api.Api, port string) {
+	go http.ListenAndServe(":"+port, newServeMux(api))
+	glog.V(logger.Info).Infof("[BZZ] Swarm HTTP proxy started on localhost:%s", port)
+}
+
+func newServeMux(a *api.Api) *http.ServeMux {
 	serveMux := http.NewServeMux()
 	serveMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		handler(w, r, api)
+		handler(w, r, a)
 	})
-	go http.ListenAndServe(":"+port, serveMux)
-	glog.V(logger.Info).Infof("[BZZ] Swarm HTTP proxy started on localhost:%s", port)
+	serveMux.HandleFunc(pinPrefix, func(w http.ResponseWriter, r *http.Request) {
+		pinHandler(w, r, a)
+	})
+	serveMux.HandleFunc(gcStatsPath, func(w http.ResponseWriter, r *http.Request) {
+		gcStatsHandler(w, r, a)
+	})
+	serveMux.HandleFunc(resourcePrefix, func(w http.ResponseWriter, r *http.Request) {
+		resourceHandler(w, r, a)
+	})
+	return serveMux
 }
 
 func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
@@ -95,11 +110,25 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 	)
 
 	switch {
+	case (r.Method == "POST" || r.Method == "PUT") && !raw && isDirectoryUpload(r):
+		// multipart form or tar stream: whole directory in one request
+		newKey, err := handleDirectoryUpload(r, a, path, nameresolver)
+		if err != nil {
+			glog.V(logger.Debug).Infof("[BZZ] Swarm: directory upload to '%s' failed: %v", path, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		glog.V(logger.Debug).Infof("[BZZ] Swarm: directory uploaded as manifest %v", newKey.Log())
+		w.Header().Set("Content-Type", "text/plain")
+		http.ServeContent(w, r, "", time.Now(), bytes.NewReader([]byte(newKey.String())))
 	case r.Method == "POST" || r.Method == "PUT":
-		key, err := a.Store(io.NewSectionReader(&sequentialReader{
-			reader: r.Body,
-			ahead:  make(map[int64]chan bool),
-		}, 0, r.ContentLength), nil)
+		// posting raw content with the append parameter to the hash of a
+		// document appends to it, otherwise the path is ignored
+		var appendTo string
+		if raw && r.Method == "POST" && isAppend(r) {
+			appendTo = trailingSlashes.ReplaceAllString(path, "")
+		}
+		key, err := storeBody(r, a, appendTo, nameresolver)
 		if err == nil {
 			glog.V(logger.Debug).Infof("[BZZ] Swarm: Content for %v stored", key.Log())
 		} else {
@@ -165,7 +194,13 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 
 			// retrieving content
 			reader := a.Retrieve(key)
-			glog.V(logger.Debug).Infof("[BZZ] Swarm: Reading %d bytes.", reader.Size())
+			size, err := reader.FetchSize()
+			if err != nil {
+				glog.V(logger.Debug).Infof("[BZZ] Swarm: content %v not found: %v", key.Log(), err)
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			glog.V(logger.Debug).Infof("[BZZ] Swarm: Reading %d bytes.", size)
 
 			// setting mime type
 			qv := requestURL.Query()
@@ -175,20 +210,33 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 			}
 
 			w.Header().Set("Content-Type", mimeType)
+			w.Header().Set("ETag", etag(key))
 			http.ServeContent(w, r, uri, forever(), reader)
-			glog.V(logger.Debug).Infof("[BZZ] Swarm: Serve raw content '%s' (%d bytes) as '%s'", uri, reader.Size(), mimeType)
+			glog.V(logger.Debug).Infof("[BZZ] Swarm: Serve raw content '%s' (%d bytes) as '%s'", uri, size, mimeType)
 
 			// retrieve path via manifest
 		} else {
 
 			glog.V(logger.Debug).Infof("[BZZ] Swarm: Structured GET request '%s' received.", uri)
 
-			reader, mimeType, status, err := a.Get(path, nameresolver)
+			if _, ok := requestURL.Query()["list"]; ok {
+				serveList(w, r, a, path, nameresolver)
+				return
+			}
+			reader, mimeType, status, key, err := a.Get(path, nameresolver)
+			if err == nil {
+				_, err = reader.FetchSize()
+			}
 			if err != nil {
 				if _, ok := err.(api.ErrResolve); ok {
 					glog.V(logger.Debug).Infof("[BZZ] Swarm: %v", err)
 					status = http.StatusBadRequest
 				} else {
+					// no entry for the path itself: list the entries under it, if any
+					if list, lerr := a.List(path, nameresolver); lerr == nil && (len(list.Entries) > 0 || len(list.CommonPrefixes) > 0) {
+						writeList(w, r, path, list)
+						return
+					}
 					glog.V(logger.Debug).Infof("[BZZ] Swarm: error retrieving '%s': %v", uri, err)
 					status = http.StatusNotFound
 				}
@@ -198,6 +246,7 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 
 			// set mime type and status headers
 			w.Header().Set("Content-Type", mimeType)
+			w.Header().Set("ETag", etag(key))
 			if status > 0 {
 				w.WriteHeader(status)
 			} else {
@@ -213,6 +262,11 @@ func handler(w http.ResponseWriter, r *http.Request, a *api.Api) {
 	}
 }
 
+// etag returns the entity tag of content, its quoted swarm hash
+func etag(key storage.Key) string {
+	return fmt.Sprintf("%q", key.String())
+}
+
 func (self *sequentialReader) ReadAt(target []byte, off int64) (n int, err error) {
 	self.lock.Lock()
 	// assert self.pos <= off
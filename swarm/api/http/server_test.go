@@ -0,0 +1,430 @@
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/swarm/api"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+func testServer(t *testing.T) (*httptest.Server, func()) {
+	datadir, err := ioutil.TempDir("", "bzz-http-test")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	dpa, err := storage.NewLocalDPA(datadir)
+	if err != nil {
+		os.RemoveAll(datadir)
+		t.Fatalf("unable to create dpa: %v", err)
+	}
+	dpa.Start()
+	a := api.NewApi(dpa, nil)
+	a.SetDbStore(dpa.ChunkStore.(*storage.LocalStore).DbStore.(*storage.DbStore))
+	server := httptest.NewServer(newServeMux(a))
+	return server, func() {
+		server.Close()
+		dpa.Stop()
+		os.RemoveAll(datadir)
+	}
+}
+
+func post(t *testing.T, url, contentType string, body []byte) string {
+	resp, err := http.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	content, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST %s failed: %s: %s", url, resp.Status, content)
+	}
+	return string(content)
+}
+
+func get(t *testing.T, url string, header map[string]string) (*http.Response, string) {
+	req, _ := http.NewRequest("GET", url, nil)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	content, _ := ioutil.ReadAll(resp.Body)
+	return resp, string(content)
+}
+
+var testFiles = map[string]string{
+	"index.html":    "<html><body>hello</body></html>",
+	"css/main.css":  "body { color: red }",
+	"img/logo.txt":  "not really a logo",
+	"img/thumb.txt": "neither a thumbnail",
+}
+
+func TestTarUpload(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range testFiles {
+		hdr := &tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+
+	hash := post(t, server.URL+"/bzz:/?index=index.html", tarType, buf.Bytes())
+	for name, content := range testFiles {
+		resp, body := get(t, server.URL+"/bzz:/"+hash+"/"+name, nil)
+		if resp.StatusCode != http.StatusOK || body != content {
+			t.Errorf("%s: have %s %q, want %q", name, resp.Status, body, content)
+		}
+	}
+	resp, body := get(t, server.URL+"/bzz:/"+hash+"/", nil)
+	if body != testFiles["index.html"] {
+		t.Errorf("index: have %q, want %q", body, testFiles["index.html"])
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("index content type mismatch: have %q", contentType)
+	}
+	resp, _ = get(t, server.URL+"/bzz:/"+hash+"/css/main.css", nil)
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/css") {
+		t.Errorf("css content type mismatch: have %q", contentType)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	// Create a manifest and add a directory to it under a prefix
+	root := post(t, server.URL+"/bzz:/", tarType, emptyTar())
+
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	for name, content := range testFiles {
+		fw, err := mw.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	mw.Close()
+
+	hash := post(t, server.URL+"/bzz:/"+root+"/site", mw.FormDataContentType(), buf.Bytes())
+	for name, content := range testFiles {
+		resp, body := get(t, server.URL+"/bzz:/"+hash+"/site/"+name, nil)
+		if resp.StatusCode != http.StatusOK || body != content {
+			t.Errorf("%s: have %s %q, want %q", name, resp.Status, body, content)
+		}
+	}
+}
+
+func TestMultipartUploadTooLarge(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+	defer func(size int64) { maxPartSize = size }(maxPartSize)
+	maxPartSize = 1024
+
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	fw, _ := mw.CreateFormFile("file", "small.txt")
+	fw.Write(bytes.Repeat([]byte("a"), 1024))
+	fw, _ = mw.CreateFormFile("file", "large.txt")
+	fw.Write(bytes.Repeat([]byte("a"), 1025))
+	mw.Close()
+
+	resp, err := http.Post(server.URL+"/bzz:/", mw.FormDataContentType(), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "large.txt") {
+		t.Errorf("expected oversized part to be refused, got %s: %s", resp.Status, body)
+	}
+}
+
+func TestRangeAndETag(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	content := strings.Repeat("0123456789", 1000)
+	key := post(t, server.URL+"/bzzr:/", "text/plain", []byte(content))
+
+	resp, body := get(t, server.URL+"/bzzr:/"+key, map[string]string{"Range": "bytes=4095-4104"})
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status mismatch: have %s, want 206", resp.Status)
+	}
+	if body != content[4095:4105] {
+		t.Errorf("range content mismatch: have %q, want %q", body, content[4095:4105])
+	}
+	etag := resp.Header.Get("ETag")
+	if etag != `"`+key+`"` {
+		t.Errorf("etag mismatch: have %s, want %q", etag, key)
+	}
+	resp, _ = get(t, server.URL+"/bzzr:/"+key, map[string]string{"If-None-Match": etag})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional request status mismatch: have %s, want 304", resp.Status)
+	}
+	resp, _ = get(t, server.URL+"/bzzr:/"+strings.Repeat("ab", 32), nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing content status mismatch: have %s, want 404", resp.Status)
+	}
+}
+
+func TestEncryptedUpload(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	content := strings.Repeat("0123456789", 1000)
+	key := post(t, server.URL+"/bzzr:/?encrypt", "text/plain", []byte(content))
+	if len(key) != 128 {
+		t.Fatalf("reference of encrypted content should carry the secret: %s", key)
+	}
+	resp, body := get(t, server.URL+"/bzzr:/"+key, nil)
+	if resp.StatusCode != http.StatusOK || body != content {
+		t.Errorf("encrypted content mismatch: have %s %q", resp.Status, body)
+	}
+	if _, body = get(t, server.URL+"/bzzr:/"+key[:64], nil); body == content {
+		t.Errorf("encrypted content readable by its hash")
+	}
+
+	// encrypted directory added to a plain manifest
+	root := post(t, server.URL+"/bzz:/", tarType, emptyTar())
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range testFiles {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	hash := post(t, server.URL+"/bzz:/"+root+"/secret?encrypt", tarType, buf.Bytes())
+	if len(hash) != 128 {
+		t.Fatalf("manifest with encrypted entries should be encrypted: %s", hash)
+	}
+	for name, content := range testFiles {
+		resp, body := get(t, server.URL+"/bzz:/"+hash+"/secret/"+name, nil)
+		if resp.StatusCode != http.StatusOK || body != content {
+			t.Errorf("%s: have %s %q, want %q", name, resp.Status, body, content)
+		}
+	}
+}
+
+func TestListing(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range testFiles {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	hash := post(t, server.URL+"/bzz:/", tarType, buf.Bytes())
+
+	list := func(path string) (prefixes, paths []string) {
+		resp, body := get(t, server.URL+"/bzz:/"+hash+path, map[string]string{"Accept": "application/json"})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("listing %s failed: %s", path, resp.Status)
+		}
+		var list api.ManifestList
+		if err := json.Unmarshal([]byte(body), &list); err != nil {
+			t.Fatalf("invalid listing %s: %v", body, err)
+		}
+		for _, entry := range list.Entries {
+			paths = append(paths, entry.Path)
+		}
+		return list.CommonPrefixes, paths
+	}
+	prefixes, paths := list("/?list")
+	if want := []string{"css/", "img/"}; !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("root prefixes mismatch: have %v, want %v", prefixes, want)
+	}
+	if want := []string{"index.html"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("root entries mismatch: have %v, want %v", paths, want)
+	}
+	// directories without an entry of their own are listed automatically
+	prefixes, paths = list("/img")
+	if want := []string{"img/logo.txt", "img/thumb.txt"}; len(prefixes) != 0 || !reflect.DeepEqual(paths, want) {
+		t.Errorf("img listing mismatch: have %v %v, want %v", prefixes, paths, want)
+	}
+	resp, body := get(t, server.URL+"/bzz:/"+hash+"/img/", nil)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || !strings.Contains(body, `href="logo.txt"`) {
+		t.Errorf("html listing mismatch: %s %q", resp.Header.Get("Content-Type"), body)
+	}
+}
+
+func TestPinning(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, content := range testFiles {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	hash := post(t, server.URL+"/bzz:/", tarType, buf.Bytes())
+
+	req, _ := http.NewRequest("PUT", server.URL+"/pins/"+hash, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("pinning failed: %v %v", err, resp.Status)
+	}
+	resp.Body.Close()
+
+	var pins []*storage.PinInfo
+	_, body := get(t, server.URL+"/pins/", nil)
+	if err := json.Unmarshal([]byte(body), &pins); err != nil {
+		t.Fatalf("invalid pin list %s: %v", body, err)
+	}
+	// the manifest trie has 3 nodes (root, css/ and img/) and every file is a chunk
+	if len(pins) != 1 || pins[0].Root.String() != hash || pins[0].Chunks != 3+len(testFiles) {
+		t.Errorf("unexpected pin list: %s", body)
+	}
+	var stats storage.GCStats
+	_, body = get(t, server.URL+"/gcstats", nil)
+	if err := json.Unmarshal([]byte(body), &stats); err != nil {
+		t.Fatalf("invalid gc stats %s: %v", body, err)
+	}
+	if stats.Pinned != uint64(3+len(testFiles)) {
+		t.Errorf("pinned chunk count mismatch: %s", body)
+	}
+
+	req, _ = http.NewRequest("DELETE", server.URL+"/pins/"+hash, nil)
+	if resp, err = http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unpinning failed: %v %v", err, resp.Status)
+	}
+	resp.Body.Close()
+	if _, body = get(t, server.URL+"/pins/", nil); strings.TrimSpace(body) != "[]" {
+		t.Errorf("content still pinned: %s", body)
+	}
+}
+
+func TestResource(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	prvkey, _ := crypto.GenerateKey()
+	owner := crypto.PubkeyToAddress(prvkey.PublicKey).Hex()
+	topic := storage.ResourceTopic("status")
+	publish := func(version, time uint64, data string) *http.Response {
+		update, err := storage.NewResourceUpdate(prvkey, topic, version, time, []byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Post(server.URL+"/bzz-resource:/", "application/octet-stream", bytes.NewReader(update.SData()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp, _ := get(t, server.URL+"/bzz-resource:/"+owner+"/status", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 before any update, got %s", resp.Status)
+	}
+	for version := uint64(1); version <= 3; version++ {
+		if resp := publish(version, 1000+version, fmt.Sprintf("update %d", version)); resp.StatusCode != http.StatusOK {
+			t.Fatalf("publishing version %d failed: %s", version, resp.Status)
+		}
+	}
+	resp, body := get(t, server.URL+"/bzz-resource:/"+owner+"/status", nil)
+	if resp.StatusCode != http.StatusOK || body != "update 3" || resp.Header.Get("X-Swarm-Resource-Version") != "3" || resp.Header.Get("X-Swarm-Resource-Time") != "1003" {
+		t.Errorf("unexpected latest update %s: %q %v", resp.Status, body, resp.Header)
+	}
+	// the topic can be given by its hash
+	if resp, body = get(t, server.URL+"/bzz-resource:/"+owner+"/"+topic.Hex()+"/2", nil); body != "update 2" {
+		t.Errorf("unexpected version 2 %s: %q", resp.Status, body)
+	}
+
+	// versions have to be consecutive and not older than the previous one
+	if resp := publish(5, 2000, "gap"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected version gap to be rejected, got %s", resp.Status)
+	}
+	if resp := publish(4, 999, "old"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected outdated update to be rejected, got %s", resp.Status)
+	}
+	if resp := publish(3, 1003, "update 3"); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected republishing to succeed, got %s", resp.Status)
+	}
+	if resp := publish(3, 1003, "changed"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected overwriting a version to be rejected, got %s", resp.Status)
+	}
+	update, _ := storage.NewResourceUpdate(prvkey, topic, 4, 2000, []byte("x"))
+	tampered := update.SData()
+	tampered[len(tampered)-65-1] ^= 1 // the data before the signature
+	if resp, err := http.Post(server.URL+"/bzz-resource:/", "application/octet-stream", bytes.NewReader(tampered)); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected tampered update to be rejected, got %v %v", err, resp.Status)
+	}
+	// an update posted to another resource is not stored
+	if resp, err := http.Post(server.URL+"/bzz-resource:/"+owner+"/other", "application/octet-stream", bytes.NewReader(update.SData())); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected update of another resource to be rejected, got %v %v", err, resp.Status)
+	}
+	if _, body = get(t, server.URL+"/bzz-resource:/"+owner+"/status", nil); body != "update 3" {
+		t.Errorf("latest update changed to %q", body)
+	}
+}
+
+func TestStreamingUpload(t *testing.T) {
+	server, cleanup := testServer(t)
+	defer cleanup()
+
+	content := make([]byte, 3*4096+100)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	want := post(t, server.URL+"/bzzr:/", "text/plain", content)
+
+	// a reader of unknown length is sent with chunked transfer encoding
+	body := ioutil.NopCloser(bytes.NewReader(content[:5000]))
+	resp, err := http.Post(server.URL+"/bzzr:/", "text/plain", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("streaming upload failed: %s: %s", resp.Status, key)
+	}
+	if _, body := get(t, server.URL+"/bzzr:/"+string(key), nil); body != string(content[:5000]) {
+		t.Fatalf("streamed content mismatch")
+	}
+
+	// without the append parameter the path is ignored
+	if stored := post(t, server.URL+"/bzzr:/"+string(key), "text/plain", content[5000:]); stored != post(t, server.URL+"/bzzr:/", "text/plain", content[5000:]) {
+		t.Errorf("plain post to %s did not store the body as new content", key)
+	}
+	// appending the rest gives the key of the entire content
+	if appended := post(t, server.URL+"/bzzr:/"+string(key)+"?append", "text/plain", content[5000:]); appended != want {
+		t.Errorf("key mismatch after append: have %s, want %s", appended, want)
+	}
+	if _, body := get(t, server.URL+"/bzzr:/"+want, nil); body != string(content) {
+		t.Errorf("appended content mismatch")
+	}
+}
+
+func emptyTar() []byte {
+	buf := new(bytes.Buffer)
+	tar.NewWriter(buf).Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,187 @@
+package http
+
+import (
+	"archive/tar"
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/swarm/api"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+const (
+	tarType       = "application/x-tar"
+	multipartType = "multipart/form-data"
+	sniffLen      = 512 // bytes looked at by http.DetectContentType
+)
+
+// maxPartSize bounds the files of a multipart form, which are read into
+// memory; larger files have to be uploaded in a tar stream
+var maxPartSize int64 = 64 * 1024 * 1024
+
+// isDirectoryUpload reports whether the request body holds several files,
+// i.e. it is a multipart form or a tar stream
+func isDirectoryUpload(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == multipartType || mediaType == tarType
+}
+
+// handleDirectoryUpload stores all files of a multipart form or tar stream and
+// adds them to the manifest at path (a new one if path is empty). The index
+// query parameter names the file that is also served at the root, the encrypt
+// parameter has the files and the manifest stored encrypted.
+// It returns the key of the new manifest.
+func handleDirectoryUpload(r *http.Request, a *api.Api, path string, nameresolver bool) (storage.Key, error) {
+	writer, err := a.NewManifestWriter(path, nameresolver, isEncrypted(r))
+	if err != nil {
+		return nil, err
+	}
+	index := r.URL.Query().Get("index")
+	add := func(data storage.SectionReader, name, contentType string) error {
+		name = api.RegularSlashes(filepath.ToSlash(name))
+		if name == "" {
+			return fmt.Errorf("file without name")
+		}
+		key, err := writer.AddEntry(data, name, contentType)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if name == index {
+			writer.AddKey(key, "", contentType)
+		}
+		return nil
+	}
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == tarType {
+		err = readTar(r.Body, add)
+	} else {
+		err = readMultipart(multipart.NewReader(r.Body, params["boundary"]), add)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return writer.Store()
+}
+
+// storeBody stores the request body as a single document. A body of unknown
+// length (sent with chunked transfer encoding) is split as a stream. If
+// appendTo is not empty, it is the content the body is appended to.
+func storeBody(r *http.Request, a *api.Api, appendTo string, nameresolver bool) (storage.Key, error) {
+	if appendTo != "" {
+		key, err := a.Resolve(appendTo, nameresolver)
+		if err != nil {
+			return nil, err
+		}
+		return a.Append(key, r.Body, nil)
+	}
+	if r.ContentLength < 0 {
+		if isEncrypted(r) {
+			return nil, fmt.Errorf("encrypted upload without content length")
+		}
+		return a.StoreStream(r.Body, nil)
+	}
+	store := a.Store
+	if isEncrypted(r) {
+		store = a.StoreEncrypted
+	}
+	return store(io.NewSectionReader(&sequentialReader{
+		reader: r.Body,
+		ahead:  make(map[int64]chan bool),
+	}, 0, r.ContentLength), nil)
+}
+
+// isEncrypted reports whether the uploaded content is to be stored encrypted
+func isEncrypted(r *http.Request) bool {
+	_, ok := r.URL.Query()["encrypt"]
+	return ok
+}
+
+// isAppend reports whether the uploaded content is to be appended to the
+// document at the path of the request
+func isAppend(r *http.Request) bool {
+	_, ok := r.URL.Query()["append"]
+	return ok
+}
+
+// readMultipart calls add for every file of the multipart form. The name of a
+// part is its file name, or the form field name if it has none. Files larger
+// than maxPartSize are refused.
+func readMultipart(mr *multipart.Reader, add func(storage.SectionReader, string, string) error) error {
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// the file name is taken verbatim, part.FileName would strip the directory
+		_, params, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		name := params["filename"]
+		if name == "" {
+			name = part.FormName()
+		}
+		// part sizes are unknown ahead, so they are read into memory
+		content, err := ioutil.ReadAll(io.LimitReader(part, maxPartSize+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(content)) > maxPartSize {
+			return fmt.Errorf("%s: multipart file larger than %d bytes, upload it in a tar stream", name, maxPartSize)
+		}
+		contentType := part.Header.Get("Content-Type")
+		if contentType == "" || contentType == rawType {
+			contentType = detectContentType(name, content)
+		}
+		if err := add(storage.NewChunkReaderFromBytes(content), name, contentType); err != nil {
+			return err
+		}
+	}
+}
+
+// readTar calls add for every regular file of the tar stream
+func readTar(r io.Reader, add func(storage.SectionReader, string, string) error) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		// sniff the content type without consuming the stream
+		br := bufio.NewReaderSize(tr, sniffLen)
+		head, _ := br.Peek(sniffLen)
+		contentType := detectContentType(hdr.Name, head)
+
+		data := io.NewSectionReader(&sequentialReader{
+			reader: br,
+			ahead:  make(map[int64]chan bool),
+		}, 0, hdr.Size)
+		if err := add(data, hdr.Name, contentType); err != nil {
+			return err
+		}
+	}
+}
+
+// detectContentType guesses the MIME type of a file from its extension,
+// falling back to sniffing its first bytes
+func detectContentType(name string, head []byte) string {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(head)
+}
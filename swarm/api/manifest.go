@@ -18,9 +18,10 @@ const (
 )
 
 type manifestTrie struct {
-	dpa     *storage.DPA
-	entries [257]*manifestTrieEntry // indexed by first character of path, entries[256] is the empty path entry
-	hash    storage.Key             // if hash != nil, it is stored
+	dpa       *storage.DPA
+	entries   [257]*manifestTrieEntry // indexed by first character of path, entries[256] is the empty path entry
+	hash      storage.Key             // if hash != nil, it is stored
+	encrypted bool                    // stored encrypted, entries may still refer to plain content
 }
 
 type manifestJSON struct {
@@ -40,7 +41,12 @@ func loadManifest(dpa *storage.DPA, hash storage.Key) (trie *manifestTrie, err e
 	glog.V(logger.Detail).Infof("[BZZ] manifest lookup key: '%v'.", hash.Log())
 	// retrieve manifest via DPA
 	manifestReader := dpa.Retrieve(hash)
-	return readManifest(manifestReader, hash, dpa)
+	trie, err = readManifest(manifestReader, hash, dpa)
+	if err == nil {
+		// modified encrypted manifests stay encrypted
+		trie.encrypted = dpa.IsEncrypted(hash)
+	}
+	return
 }
 
 func readManifest(manifestReader storage.SectionReader, hash storage.Key, dpa *storage.DPA) (trie *manifestTrie, err error) { // non-recursive, subtrees are downloaded on-demand
@@ -110,7 +116,8 @@ func (self *manifestTrie) addEntry(entry *manifestTrieEntry) {
 	commonPrefix := entry.Path[:cpl]
 
 	subtrie := &manifestTrie{
-		dpa: self.dpa,
+		dpa:       self.dpa,
+		encrypted: self.encrypted,
 	}
 	entry.Path = entry.Path[cpl:]
 	oldentry.Path = oldentry.Path[cpl:]
@@ -200,7 +207,13 @@ func (self *manifestTrie) recalcAndStore() error {
 
 	sr := io.NewSectionReader(bytes.NewReader(manifest), 0, int64(len(manifest)))
 	wg := &sync.WaitGroup{}
-	key, err2 := self.dpa.Store(sr, wg)
+	var key storage.Key
+	var err2 error
+	if self.encrypted {
+		key, err2 = self.dpa.StoreEncrypted(sr, wg)
+	} else {
+		key, err2 = self.dpa.Store(sr, wg)
+	}
 	wg.Wait()
 	self.hash = key
 	return err2
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+var errNoPinning = errors.New("pinning is not supported by the local store")
+
+// SetDbStore enables pinning content in the local db store
+func (self *Api) SetDbStore(db *storage.DbStore) {
+	self.db = db
+}
+
+// Pin exempts the content of uri from garbage collection. Unless raw, the
+// content is a manifest and everything it refers to is pinned along.
+// It returns the pinned root key.
+func (self *Api) Pin(uri string, raw, nameresolver bool) (storage.Key, error) {
+	if self.db == nil {
+		return nil, errNoPinning
+	}
+	key, _, path, err := self.parseAndResolve(uri, nameresolver)
+	if err != nil {
+		return nil, err
+	}
+	if RegularSlashes(path) != "" {
+		return nil, fmt.Errorf("only whole content can be pinned, not '%s'", path)
+	}
+	keys, err := self.chunkKeys(key, !raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := self.db.Pin(key, keys); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Unpin releases the content of uri for garbage collection
+func (self *Api) Unpin(uri string, nameresolver bool) (storage.Key, error) {
+	if self.db == nil {
+		return nil, errNoPinning
+	}
+	key, _, _, err := self.parseAndResolve(uri, nameresolver)
+	if err != nil {
+		return nil, err
+	}
+	return key, self.db.Unpin(key)
+}
+
+// Pins lists the pinned content
+func (self *Api) Pins() ([]*storage.PinInfo, error) {
+	if self.db == nil {
+		return nil, errNoPinning
+	}
+	return self.db.Pins(), nil
+}
+
+// GCStats reports the garbage collection statistics of the local store
+func (self *Api) GCStats() (*storage.GCStats, error) {
+	if self.db == nil {
+		return nil, errNoPinning
+	}
+	stats := self.db.GCStats()
+	return &stats, nil
+}
+
+// chunkKeys collects the keys of all chunks of the content under key and, if
+// it is a manifest, of all the content it refers to
+func (self *Api) chunkKeys(key storage.Key, manifest bool) ([]storage.Key, error) {
+	keys, err := self.dpa.Keys(key)
+	if err != nil {
+		return nil, fmt.Errorf("content %v: %v", key.Log(), err)
+	}
+	if !manifest {
+		return keys, nil
+	}
+	trie, err := loadManifest(self.dpa, key)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range trie.entries {
+		if entry == nil || entry.Hash == "" {
+			continue
+		}
+		glog.V(logger.Detail).Infof("[BZZ] pinning manifest entry '%s'", entry.Path)
+		sub, err := self.chunkKeys(storage.Key(common.Hex2Bytes(entry.Hash)), entry.ContentType == manifestType)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, sub...)
+	}
+	return keys, nil
+}
+
+// Pinning is the RPC service for managing pinned content
+type Pinning struct {
+	api *Api
+}
+
+func NewPinning(api *Api) *Pinning {
+	return &Pinning{api}
+}
+
+// Pin pins the content of uri, including everything referred to by it if it
+// is a manifest and raw is false
+func (self *Pinning) Pin(uri string, raw bool) (string, error) {
+	key, err := self.api.Pin(uri, raw, true)
+	if err != nil {
+		return "", err
+	}
+	return key.String(), nil
+}
+
+// Unpin releases the content of uri
+func (self *Pinning) Unpin(uri string) error {
+	_, err := self.api.Unpin(uri, true)
+	return err
+}
+
+// Pins lists the pinned content
+func (self *Pinning) Pins() ([]*storage.PinInfo, error) {
+	return self.api.Pins()
+}
+
+// GcStats reports the garbage collection statistics of the local store
+func (self *Pinning) GcStats() (*storage.GCStats, error) {
+	return self.api.GCStats()
+}
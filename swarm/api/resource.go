@@ -0,0 +1,131 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+const (
+	// maxResourceVersion bounds the search for the latest update
+	maxResourceVersion = 1 << 62
+	// resourceProbeTimeout is how long the search for the latest update
+	// waits for a version from the network before taking it as missing
+	resourceProbeTimeout = 500 * time.Millisecond
+)
+
+// ErrResourceNotFound is returned if a resource has no updates (or not the
+// requested one)
+type ErrResourceNotFound struct {
+	Owner   common.Address
+	Topic   common.Hash
+	Version uint64
+}
+
+func (self ErrResourceNotFound) Error() string {
+	if self.Version == 0 {
+		return fmt.Sprintf("resource %x/%x not found", self.Owner, self.Topic)
+	}
+	return fmt.Sprintf("version %d of resource %x/%x not found", self.Version, self.Owner, self.Topic)
+}
+
+// Resource returns the update of a mutable resource with the given version,
+// or the latest one if version is 0.
+// The latest version is looked for by doubling the version until one is
+// missing and bisecting the range between the last found and the missing
+// one, so it takes a logarithmic number of retrievals. This relies on the
+// versions of a resource being consecutive, which UpdateResource enforces.
+// Most of these retrievals miss, so they only wait resourceProbeTimeout for
+// the network; an update slower to arrive is not taken as the latest.
+func (self *Api) Resource(owner common.Address, topic common.Hash, version uint64) (*storage.ResourceUpdate, error) {
+	if version > 0 {
+		update, ok := self.resourceUpdate(owner, topic, version, 0)
+		if !ok {
+			return nil, ErrResourceNotFound{owner, topic, version}
+		}
+		return update, nil
+	}
+	var latest *storage.ResourceUpdate
+	lo, hi := uint64(0), uint64(1)
+	for hi < maxResourceVersion {
+		update, ok := self.resourceUpdate(owner, topic, hi, resourceProbeTimeout)
+		if !ok {
+			break
+		}
+		latest = update
+		lo, hi = hi, 2*hi
+	}
+	if latest == nil {
+		return nil, ErrResourceNotFound{owner, topic, 0}
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if update, ok := self.resourceUpdate(owner, topic, mid, resourceProbeTimeout); ok {
+			latest = update
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	glog.V(logger.Detail).Infof("[BZZ] latest version of resource %x/%x is %d", owner, topic, latest.Version)
+	return latest, nil
+}
+
+// resourceUpdate retrieves an update waiting at most timeout for the network
+// (0 for the default), ok is false if it is not found or is invalid
+func (self *Api) resourceUpdate(owner common.Address, topic common.Hash, version uint64, timeout time.Duration) (*storage.ResourceUpdate, bool) {
+	key := storage.ResourceKey(owner, topic, version)
+	var chunk *storage.Chunk
+	var err error
+	if timeout > 0 {
+		chunk, err = self.dpa.GetTimeout(key, timeout)
+	} else {
+		chunk, err = self.dpa.Get(key)
+	}
+	if err != nil || chunk.SData == nil {
+		return nil, false
+	}
+	update, err := storage.ParseResourceUpdate(key, chunk.SData)
+	if err != nil {
+		glog.V(logger.Warn).Infof("[BZZ] invalid update of resource %x/%x: %v", owner, topic, err)
+		return nil, false
+	}
+	return update, true
+}
+
+// UpdateResource stores a signed update given by its chunk data (see
+// storage.ResourceUpdate). An update has to follow the latest one: it must
+// have the next version and must not be older. Publishing the same content
+// and time again is not an error.
+func (self *Api) UpdateResource(sdata []byte) (*storage.ResourceUpdate, error) {
+	update, err := storage.ParseResourceUpdate(nil, sdata)
+	if err != nil {
+		return nil, err
+	}
+	if update.Version == 0 {
+		return nil, fmt.Errorf("resource versions start at 1")
+	}
+	if existing, ok := self.resourceUpdate(update.Owner, update.Topic, update.Version, 0); ok {
+		if existing.Time == update.Time && bytes.Equal(existing.Data, update.Data) {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("version %d of resource %x/%x already published", update.Version, update.Owner, update.Topic)
+	}
+	if update.Version > 1 {
+		previous, ok := self.resourceUpdate(update.Owner, update.Topic, update.Version-1, 0)
+		if !ok {
+			return nil, fmt.Errorf("version %d of resource %x/%x does not follow the latest one", update.Version, update.Owner, update.Topic)
+		}
+		if update.Time < previous.Time {
+			return nil, fmt.Errorf("update of resource %x/%x is older than version %d", update.Owner, update.Topic, previous.Version)
+		}
+	}
+	self.dpa.Put(update.Chunk())
+	glog.V(logger.Debug).Infof("[BZZ] stored version %d of resource %x/%x", update.Version, update.Owner, update.Topic)
+	return update, nil
+}
@@ -37,7 +37,7 @@ func (self *Storage) Put(content, contentType string) (string, error) {
 // the actual size of which is given in len(resp.Content), while the expected
 // size is resp.Size
 func (self *Storage) Get(bzzpath string) (*Response, error) {
-	reader, mimeType, status, err := self.api.Get(bzzpath, true)
+	reader, mimeType, status, _, err := self.api.Get(bzzpath, true)
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,6 @@
 package network
 
 import (
-	"bytes"
 	"encoding/binary"
 	"time"
 
@@ -94,9 +93,7 @@ func (self *Depo) HandleStoreRequestMsg(req *storeRequestMsgData, p *peer) {
 
 	case chunk.SData == nil:
 		// found chunk in memory store, needs the data, validate now
-		hasher := self.hashfunc()
-		hasher.Write(req.SData)
-		if !bytes.Equal(hasher.Sum(nil), req.Key) {
+		if !storage.ValidChunk(self.hashfunc, req.Key, req.SData) {
 			// data does not validate, ignore
 			// TODO: peer should be penalised/dropped?
 			glog.V(logger.Warn).Infof("[BZZ] Depo.HandleStoreRequest: chunk invalid. store request ignored: %v", req)
@@ -125,9 +122,12 @@ func (self *Depo) HandleRetrieveRequestMsg(req *retrieveRequestMsgData, p *peer)
 	req.from = p
 	// swap - record credit for 1 request
 	// note that only charge actual reqsearches
-	if err := p.swap.Add(1); err != nil {
-		glog.V(logger.Warn).Infof("[BZZ] Depo.HandleRetrieveRequest: %v - cannot process request: %v", req.Key.Log(), err)
-		return
+	// (no accounting if SWAP is disabled)
+	if p.swap != nil {
+		if err := p.swap.Add(1); err != nil {
+			glog.V(logger.Warn).Infof("[BZZ] Depo.HandleRetrieveRequest: %v - cannot process request: %v", req.Key.Log(), err)
+			return
+		}
 	}
 
 	// call storage.NetStore#Get which
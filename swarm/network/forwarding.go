@@ -57,6 +57,11 @@ OUT:
 			Key: chunk.Key,
 			Id:  generateId(),
 		}
+		if p.swap == nil {
+			// SWAP is disabled, no accounting
+			p.retrieve(req)
+			break OUT
+		}
 		if err := p.swap.Add(-1); err == nil {
 			p.retrieve(req)
 			break OUT
@@ -286,7 +286,10 @@ func (self *peer) LastActive() time.Time {
 func loadSync(record *kademlia.NodeRecord, node kademlia.Node) error {
 	if p, ok := node.(*peer); ok {
 		if record.Meta == nil {
-			glog.V(logger.Debug).Infof("no sync state for node record %v", record)
+			// first connection: without a state the sync request would
+			// disable syncing, request the entire history instead
+			glog.V(logger.Debug).Infof("no sync state for node record %v setting default", record)
+			p.syncState = defaultSyncState()
 			return nil
 		}
 		state, err := decodeSync(record.Meta)
@@ -169,7 +169,7 @@ func run(requestDb *storage.LDBDatabase, depo StorageHandler, hive *Hive, dbacce
 			self.syncer.stop() // quits request db and delivery loops, save requests
 		}
 		if self.swap != nil {
-			self.swap.Stop() // quits chequebox autocash etc
+			self.swapParams.Release(self.swap) // quits chequebox autocash etc
 		}
 	}()
 
@@ -385,13 +385,14 @@ func (self *bzz) sync(state *syncState) error {
 	// an explicitly received nil syncstate disables syncronisation
 	if state == nil {
 		self.syncEnabled = false
-	} else {
-		state.synced = make(chan bool)
-		state.SessionAt = cnt
-		if storage.IsZeroKey(state.Stop) && state.Synced {
-			state.Start = storage.Key(start[:])
-			state.Stop = storage.Key(stop[:])
-		}
+		// the syncer still needs a state to confirm deliveries
+		state = defaultSyncState()
+	}
+	state.synced = make(chan bool)
+	state.SessionAt = cnt
+	if storage.IsZeroKey(state.Stop) && state.Synced {
+		state.Start = storage.Key(start[:])
+		state.Stop = storage.Key(stop[:])
 	}
 	var err error
 	self.syncer, err = newSyncer(
@@ -1 +1,39 @@
 package network
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/kademlia"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+// a sync request without a state disables syncing, it must reach the remote
+// as nil rather than fail to decode and drop the peer
+func TestSyncRequestNilState(t *testing.T) {
+	data, err := rlp.EncodeToBytes(&syncRequestMsgData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &syncRequestMsgData{SyncState: defaultSyncState()}
+	if err := rlp.DecodeBytes(data, req); err != nil {
+		t.Fatalf("unable to decode sync request without state: %v", err)
+	}
+	if req.SyncState != nil {
+		t.Errorf("expected nil sync state, got %v", req.SyncState)
+	}
+}
+
+// a peer without a stored sync state is synced from the start of the history
+func TestLoadSyncWithoutState(t *testing.T) {
+	p := &peer{bzz: &bzz{}}
+	if err := loadSync(&kademlia.NodeRecord{}, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.syncState == nil {
+		t.Fatalf("no default sync state set")
+	}
+	if !p.syncState.Synced || !storage.IsZeroKey(p.syncState.Stop) || p.syncState.First != 0 {
+		t.Errorf("default sync state does not cover the entire history: %v", p.syncState)
+	}
+}
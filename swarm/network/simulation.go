@@ -0,0 +1,448 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	bzzswap "github.com/ethereum/go-ethereum/swarm/services/swap"
+	"github.com/ethereum/go-ethereum/swarm/storage"
+)
+
+/*
+Simulation runs a network of swarm nodes within a single process, so that the
+forwarding and syncing logic can be tested deterministically.
+
+Every node has its own chunk store, hive and bzz protocol instance set up the
+same way as the swarm service does it, only connections are in-memory message
+pipes instead of p2p.Server connections. The topology is under the control
+of the caller (Connect, Disconnect), unless the simulation is created with
+autoConnect, in which case connection requests of the hives are honoured too
+(i.e. nodes bootstrap from the peers they learn about).
+
+SWAP is disabled on all nodes.
+*/
+type Simulation struct {
+	Nodes []*SimNode
+
+	dir         string
+	autoConnect bool
+	lock        sync.Mutex
+	conns       map[[2]int]*simConn
+	closed      bool
+	wg          sync.WaitGroup // protocol loops
+}
+
+// SimNode is a swarm node of a simulation
+type SimNode struct {
+	Index      int
+	ID         discover.NodeID
+	LocalStore *storage.LocalStore
+	DPA        *storage.DPA
+
+	hive  *Hive
+	proto p2p.Protocol
+}
+
+// simulated nodes pretend to listen on consecutive ports of localhost, the
+// advertised address is how peer urls are mapped back to nodes
+const simBasePort = 30400
+
+// hives look for peers to connect to way more often than on the live network
+// so that auto connecting simulations settle fast
+const simCallInterval = 100 * time.Millisecond
+
+// NewSimulation creates n started nodes with no connections between them
+func NewSimulation(n int, autoConnect bool) (self *Simulation, err error) {
+	dir, err := ioutil.TempDir("", "bzz-simulation")
+	if err != nil {
+		return nil, err
+	}
+	self = &Simulation{
+		dir:         dir,
+		autoConnect: autoConnect,
+		conns:       make(map[[2]int]*simConn),
+	}
+	for i := 0; i < n; i++ {
+		node, err := self.newNode(i)
+		if err != nil {
+			self.Close()
+			return nil, fmt.Errorf("node %d: %v", i, err)
+		}
+		self.Nodes = append(self.Nodes, node)
+	}
+	return self, nil
+}
+
+func (self *Simulation) newNode(i int) (*SimNode, error) {
+	prvkey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(self.dir, fmt.Sprintf("node%02d", i))
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	chunkerParams := storage.NewChunkerParams()
+	hash := storage.MakeHashFunc(chunkerParams.Hash)
+	storeParams := storage.NewStoreParams(dir)
+	lstore, err := storage.NewLocalStore(hash, storeParams)
+	if err != nil {
+		return nil, err
+	}
+	node := &SimNode{
+		Index:      i,
+		ID:         discover.PubkeyID(&prvkey.PublicKey),
+		LocalStore: lstore,
+	}
+	hiveParams := NewHiveParams(dir)
+	hiveParams.CallInterval = uint64(simCallInterval)
+	node.hive = NewHive(crypto.Sha3Hash(crypto.FromECDSAPub(&prvkey.PublicKey)), hiveParams, false, true)
+	cloud := NewForwarder(node.hive)
+	netStore := storage.NewNetStore(hash, lstore, cloud, storeParams)
+	depo := NewDepo(hash, lstore, netStore)
+	node.DPA = storage.NewDPA(storage.NewDpaChunkStore(lstore, netStore), chunkerParams)
+
+	// swap is disabled, the params are only advertised in the handshake
+	swapParams := bzzswap.DefaultSwapParams(crypto.PubkeyToAddress(prvkey.PublicKey), prvkey)
+	node.proto, err = Bzz(depo, node.hive, NewDbAccess(lstore), swapParams, NewSyncParams(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	listenAddr := fmt.Sprintf("127.0.0.1:%d", simBasePort+i)
+	connectPeer := func(url string) error {
+		if !self.autoConnect {
+			return nil
+		}
+		peer, err := discover.ParseNode(url)
+		if err != nil {
+			return err
+		}
+		for _, other := range self.Nodes {
+			if other.ID == peer.ID {
+				go self.Connect(i, other.Index)
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown node %v", url)
+	}
+	if err := node.hive.Start(node.ID, func() string { return listenAddr }, connectPeer); err != nil {
+		return nil, err
+	}
+	node.DPA.Start()
+	return node, nil
+}
+
+// Close disconnects and stops all nodes and removes their data
+func (self *Simulation) Close() {
+	self.lock.Lock()
+	self.closed = true
+	for _, conn := range self.conns {
+		conn.close()
+	}
+	self.lock.Unlock()
+	self.wg.Wait()
+	for _, node := range self.Nodes {
+		node.DPA.Stop()
+		node.hive.Stop()
+	}
+	os.RemoveAll(self.dir)
+}
+
+func connKey(i, j int) [2]int {
+	if i > j {
+		i, j = j, i
+	}
+	return [2]int{i, j}
+}
+
+// Connect connects nodes i and j, it is a noop if they are connected
+// already. It does not wait for the handshake to complete.
+func (self *Simulation) Connect(i, j int) error {
+	if i == j || i < 0 || j < 0 || i >= len(self.Nodes) || j >= len(self.Nodes) {
+		return fmt.Errorf("invalid connection %d-%d", i, j)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.closed {
+		return fmt.Errorf("simulation closed")
+	}
+	key := connKey(i, j)
+	if _, ok := self.conns[key]; ok {
+		return nil
+	}
+	conn := newSimConn()
+	self.conns[key] = conn
+	glog.V(logger.Debug).Infof("[BZZ] simulation: connect %d-%d", i, j)
+	self.run(conn, key, self.Nodes[i], self.Nodes[j], conn.a)
+	self.run(conn, key, self.Nodes[j], self.Nodes[i], conn.b)
+	return nil
+}
+
+// run runs the protocol of node on the connection to remote, once it
+// terminates the whole connection is closed
+func (self *Simulation) run(conn *simConn, key [2]int, node, remote *SimNode, rw p2p.MsgReadWriter) {
+	self.wg.Add(1)
+	go func() {
+		defer self.wg.Done()
+		peer := p2p.NewPeer(remote.ID, fmt.Sprintf("node%02d", remote.Index), nil)
+		err := node.proto.Run(peer, rw)
+		glog.V(logger.Debug).Infof("[BZZ] simulation: node %d disconnected from %d: %v", node.Index, remote.Index, err)
+		conn.close()
+		self.lock.Lock()
+		if self.conns[key] == conn {
+			delete(self.conns, key)
+		}
+		self.lock.Unlock()
+	}()
+}
+
+// Disconnect closes the connection between nodes i and j
+func (self *Simulation) Disconnect(i, j int) {
+	self.lock.Lock()
+	conn, ok := self.conns[connKey(i, j)]
+	self.lock.Unlock()
+	if ok {
+		conn.close()
+	}
+}
+
+// Connected tells if nodes i and j are connected
+func (self *Simulation) Connected(i, j int) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	_, ok := self.conns[connKey(i, j)]
+	return ok
+}
+
+// ConnectChain connects each node to the next one
+func (self *Simulation) ConnectChain() error {
+	for i := 1; i < len(self.Nodes); i++ {
+		if err := self.Connect(i-1, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectAll connects every node to all the others
+func (self *Simulation) ConnectAll() error {
+	for i := range self.Nodes {
+		for j := i + 1; j < len(self.Nodes); j++ {
+			if err := self.Connect(i, j); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SyncEnabled switches history syncing on or off on all nodes, it takes
+// effect on subsequent connections. Without syncing chunks only spread by
+// forwarding.
+func (self *Simulation) SyncEnabled(on bool) {
+	for _, node := range self.Nodes {
+		node.hive.SyncEnabled(on)
+	}
+}
+
+// PeerCount is the number of peers in the kademlia table of node i, i.e. the
+// number of connections that completed the handshake
+func (self *Simulation) PeerCount(i int) int {
+	return self.Nodes[i].hive.kad.Count()
+}
+
+// Upload stores data via the DPA of node i and waits until all its chunks are
+// stored locally
+func (self *Simulation) Upload(i int, data []byte) (storage.Key, error) {
+	wg := &sync.WaitGroup{}
+	key, err := self.Nodes[i].DPA.Store(storage.NewChunkReaderFromBytes(data), wg)
+	if err != nil {
+		return nil, err
+	}
+	wg.Wait()
+	return key, nil
+}
+
+// Retrieve reads the content under key via the DPA of node i
+func (self *Simulation) Retrieve(i int, key storage.Key) ([]byte, error) {
+	reader := self.Nodes[i].DPA.Retrieve(key)
+	size, err := reader.FetchSize()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := reader.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Has tells if node i stores the chunk, pending requests do not count
+func (self *Simulation) Has(i int, key storage.Key) bool {
+	chunk, err := self.Nodes[i].LocalStore.Get(key)
+	return err == nil && chunk.SData != nil
+}
+
+// Holders lists the nodes storing the chunk
+func (self *Simulation) Holders(key storage.Key) (nodes []int) {
+	for i := range self.Nodes {
+		if self.Has(i, key) {
+			nodes = append(nodes, i)
+		}
+	}
+	return
+}
+
+// Distribution maps the keys of all chunks of the content under root to the
+// nodes storing them. The chunk keys are looked up via node i.
+func (self *Simulation) Distribution(i int, root storage.Key) (map[string][]int, error) {
+	keys, err := self.Nodes[i].DPA.Keys(root)
+	if err != nil {
+		return nil, err
+	}
+	dist := make(map[string][]int)
+	for _, key := range keys {
+		dist[string(key)] = self.Holders(key)
+	}
+	return dist, nil
+}
+
+// WaitFor polls cond until it holds or the timeout expires
+func (self *Simulation) WaitFor(timeout time.Duration, cond func() bool) error {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %v", timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+// WaitReplicated waits until every chunk of the content under root (looked
+// up via node i) is stored by at least replicas nodes
+func (self *Simulation) WaitReplicated(i int, root storage.Key, replicas int, timeout time.Duration) error {
+	keys, err := self.Nodes[i].DPA.Keys(root)
+	if err != nil {
+		return err
+	}
+	err = self.WaitFor(timeout, func() bool {
+		for _, key := range keys {
+			if len(self.Holders(key)) < replicas {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("content %v not replicated to %d nodes: %v", root.Log(), replicas, err)
+	}
+	return nil
+}
+
+// simConn is an in-memory connection between two nodes. Unlike p2p.MsgPipe
+// writes never block, messages are queued for the reader, so peers sending
+// to each other at the same time cannot deadlock.
+type simConn struct {
+	a, b *simPipe
+	ab   *msgQueue
+	ba   *msgQueue
+	once sync.Once
+}
+
+func newSimConn() *simConn {
+	ab, ba := newMsgQueue(), newMsgQueue()
+	return &simConn{
+		a:  &simPipe{in: ba, out: ab},
+		b:  &simPipe{in: ab, out: ba},
+		ab: ab,
+		ba: ba,
+	}
+}
+
+func (self *simConn) close() {
+	self.once.Do(func() {
+		self.ab.close()
+		self.ba.close()
+	})
+}
+
+// simPipe is one end of a simConn, it implements p2p.MsgReadWriter
+type simPipe struct {
+	in, out *msgQueue
+}
+
+func (self *simPipe) WriteMsg(msg p2p.Msg) error {
+	payload, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	return self.out.push(p2p.Msg{
+		Code:    msg.Code,
+		Size:    uint32(len(payload)),
+		Payload: bytes.NewReader(payload),
+	})
+}
+
+func (self *simPipe) ReadMsg() (p2p.Msg, error) {
+	return self.in.pop()
+}
+
+// msgQueue is an unbounded message queue
+type msgQueue struct {
+	lock   sync.Mutex
+	cond   *sync.Cond
+	msgs   []p2p.Msg
+	closed bool
+}
+
+func newMsgQueue() *msgQueue {
+	self := &msgQueue{}
+	self.cond = sync.NewCond(&self.lock)
+	return self
+}
+
+func (self *msgQueue) push(msg p2p.Msg) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.closed {
+		return p2p.ErrPipeClosed
+	}
+	self.msgs = append(self.msgs, msg)
+	self.cond.Signal()
+	return nil
+}
+
+func (self *msgQueue) pop() (p2p.Msg, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for len(self.msgs) == 0 && !self.closed {
+		self.cond.Wait()
+	}
+	if self.closed {
+		return p2p.Msg{}, p2p.ErrPipeClosed
+	}
+	msg := self.msgs[0]
+	self.msgs = self.msgs[1:]
+	msg.ReceivedAt = time.Now()
+	return msg, nil
+}
+
+func (self *msgQueue) close() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.closed = true
+	self.cond.Broadcast()
+}
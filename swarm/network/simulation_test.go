@@ -0,0 +1,174 @@
+package network
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func newTestSimulation(t *testing.T, n int, autoConnect bool) *Simulation {
+	sim, err := NewSimulation(n, autoConnect)
+	if err != nil {
+		t.Fatalf("unable to create simulation: %v", err)
+	}
+	return sim
+}
+
+func randomData(t *testing.T, size int) []byte {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func waitConnected(t *testing.T, sim *Simulation, i, peers int) {
+	if err := sim.WaitFor(5*time.Second, func() bool { return sim.PeerCount(i) >= peers }); err != nil {
+		t.Fatalf("node %d has %d peers, want %d: %v", i, sim.PeerCount(i), peers, err)
+	}
+}
+
+func TestSimulationForwarding(t *testing.T) {
+	sim := newTestSimulation(t, 3, false)
+	defer sim.Close()
+	sim.SyncEnabled(false)
+
+	data := randomData(t, 3*4096+100)
+	key, err := sim.Upload(0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.ConnectChain(); err != nil {
+		t.Fatal(err)
+	}
+	waitConnected(t, sim, 1, 2)
+
+	dist, err := sim.Distribution(0, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, holders := range dist {
+		if len(holders) != 1 {
+			t.Fatalf("chunk %x held by nodes %v before retrieval", k[:4], holders)
+		}
+	}
+	// node 2 only reaches the uploader via node 1
+	retrieved, err := sim.Retrieve(2, key)
+	if err != nil {
+		t.Fatalf("retrieval failed: %v", err)
+	}
+	if !bytes.Equal(retrieved, data) {
+		t.Fatalf("retrieved content mismatch")
+	}
+	// the chunks are stored along the way
+	if dist, err = sim.Distribution(0, key); err != nil {
+		t.Fatal(err)
+	}
+	for k, holders := range dist {
+		if len(holders) != 3 {
+			t.Errorf("chunk %x held by nodes %v, want all", k[:4], holders)
+		}
+	}
+}
+
+func TestSimulationSync(t *testing.T) {
+	sim := newTestSimulation(t, 3, false)
+	defer sim.Close()
+
+	data := randomData(t, 10*4096)
+	key, err := sim.Upload(0, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// content uploaded before connecting is synced from history
+	if err := sim.ConnectChain(); err != nil {
+		t.Fatal(err)
+	}
+	waitConnected(t, sim, 1, 2)
+	if err := sim.WaitReplicated(0, key, 3, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// a node with syncing disabled sends a nil sync state: the connection is kept,
+// it still syncs its history to the peer and gets chunks only by retrieval
+func TestSimulationSyncDisabledOnOneNode(t *testing.T) {
+	sim := newTestSimulation(t, 2, false)
+	defer sim.Close()
+	sim.Nodes[0].hive.SyncEnabled(false)
+
+	up, err := sim.Upload(0, randomData(t, 4096))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := randomData(t, 4096)
+	down, err := sim.Upload(1, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Connect(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	waitConnected(t, sim, 0, 1)
+	if err := sim.WaitReplicated(0, up, 2, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if holders := sim.Holders(down); len(holders) != 1 || holders[0] != 1 {
+		t.Errorf("chunk synced to node with syncing disabled, held by %v", holders)
+	}
+	retrieved, err := sim.Retrieve(0, down)
+	if err != nil {
+		t.Fatalf("retrieval failed: %v", err)
+	}
+	if !bytes.Equal(retrieved, data) {
+		t.Fatalf("retrieved content mismatch")
+	}
+}
+
+func TestSimulationDisconnect(t *testing.T) {
+	sim := newTestSimulation(t, 2, false)
+	defer sim.Close()
+
+	if err := sim.Connect(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	waitConnected(t, sim, 0, 1)
+	sim.Disconnect(0, 1)
+	err := sim.WaitFor(5*time.Second, func() bool {
+		return !sim.Connected(0, 1) && sim.PeerCount(0) == 0 && sim.PeerCount(1) == 0
+	})
+	if err != nil {
+		t.Fatalf("peers not removed after disconnect: %v", err)
+	}
+
+	key, err := sim.Upload(0, randomData(t, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holders := sim.Holders(key); len(holders) != 1 || holders[0] != 0 {
+		t.Errorf("chunk of disconnected node held by %v", holders)
+	}
+}
+
+func TestSimulationBootstrap(t *testing.T) {
+	sim := newTestSimulation(t, 4, true)
+	defer sim.Close()
+
+	// nodes only know the first one, they learn about each other from the
+	// hives and connect beyond it
+	for i := 1; i < len(sim.Nodes); i++ {
+		if err := sim.Connect(0, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range sim.Nodes {
+		node := sim.Nodes[i]
+		err := sim.WaitFor(5*time.Second, func() bool {
+			return node.hive.kad.DBCount() == len(sim.Nodes)-1 && sim.PeerCount(i) >= 2
+		})
+		if err != nil {
+			t.Fatalf("node %d knows %d nodes with %d peers: %v", i, node.hive.kad.DBCount(), sim.PeerCount(i), err)
+		}
+	}
+}
@@ -51,6 +51,12 @@ type syncState struct {
 	synced     chan bool   // signal that sync stage finished
 }
 
+// sync state of a peer never synced with, it requests the entire history
+// within the key range of the peer (the zero range is filled in at sync)
+func defaultSyncState() *syncState {
+	return &syncState{DbSyncState: &storage.DbSyncState{}, Synced: true}
+}
+
 // wrapper of db-s to provide mockable custom local chunk store access to syncer
 type DbAccess struct {
 	db  *storage.DbStore
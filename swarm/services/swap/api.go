@@ -0,0 +1,26 @@
+package swap
+
+import (
+	"errors"
+)
+
+var errNoLedger = errors.New("swap ledger not initialised")
+
+// Api exposes the accounting of the peers under the swap namespace
+type Api struct {
+	params *SwapParams
+}
+
+func NewApi(params *SwapParams) *Api {
+	return &Api{params}
+}
+
+// Ledger lists the totals exchanged with every peer, including the current
+// sessions
+func (self *Api) Ledger() ([]*LedgerEntry, error) {
+	ledger := self.params.Ledger()
+	if ledger == nil {
+		return nil, errNoLedger
+	}
+	return ledger.Entries(), nil
+}
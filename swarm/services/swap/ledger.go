@@ -0,0 +1,143 @@
+package swap
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/swap"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// LedgerEntry is the accounting of the exchange with a peer over all sessions.
+// As every session starts with a zero balance, the balance is the sum of those
+// left unsettled at the end of the past sessions and that of the current one.
+type LedgerEntry struct {
+	Beneficiary common.Address // recipient address of the peer, identifies the peer
+	Contract    common.Address // chequebook contract of the peer
+	Connected   bool           // whether there is a session with the peer
+	*swap.Stats
+}
+
+// Ledger keeps the accounting of all peers the node exchanged with. The
+// totals of past sessions are persisted as json, those of the current
+// sessions are added when listed.
+type Ledger struct {
+	path    string
+	lock    sync.Mutex
+	entries map[common.Address]*LedgerEntry // totals of the past sessions
+	swaps   map[*swap.Swap]*LedgerEntry     // current sessions
+}
+
+// NewLedger loads the ledger persisted at path or creates an empty one
+func NewLedger(path string) (*Ledger, error) {
+	self := &Ledger{
+		path:    path,
+		entries: make(map[common.Address]*LedgerEntry),
+		swaps:   make(map[*swap.Swap]*LedgerEntry),
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return self, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*LedgerEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		entry.Connected = false
+		self.entries[entry.Beneficiary] = entry
+	}
+	return self, nil
+}
+
+// open records a new session with the peer
+func (self *Ledger) open(s *swap.Swap, remote *SwapProfile) {
+	defer self.lock.Unlock()
+	self.lock.Lock()
+	entry := self.entry(remote.Beneficiary)
+	entry.Contract = remote.Contract
+	self.swaps[s] = entry
+}
+
+// close adds the totals of the session to those of the peer and saves the
+// ledger
+func (self *Ledger) close(s *swap.Swap) {
+	self.lock.Lock()
+	entry, ok := self.swaps[s]
+	if ok {
+		delete(self.swaps, s)
+		entry.Stats.Add(s.Stats())
+	}
+	self.lock.Unlock()
+	if ok {
+		if err := self.Save(); err != nil {
+			glog.V(logger.Warn).Infof("[BZZ] SWAP unable to save ledger: %v", err)
+		}
+	}
+}
+
+// entry returns the totals of the peer, caller holds the lock
+func (self *Ledger) entry(beneficiary common.Address) *LedgerEntry {
+	entry, ok := self.entries[beneficiary]
+	if !ok {
+		entry = &LedgerEntry{
+			Beneficiary: beneficiary,
+			Stats:       swap.NewStats(),
+		}
+		self.entries[beneficiary] = entry
+	}
+	return entry
+}
+
+// Entries returns the accounting of all peers including the current
+// sessions, ordered by beneficiary
+func (self *Ledger) Entries() []*LedgerEntry {
+	defer self.lock.Unlock()
+	self.lock.Lock()
+	totals := make(map[common.Address]*LedgerEntry)
+	var entries []*LedgerEntry
+	for beneficiary, entry := range self.entries {
+		total := &LedgerEntry{
+			Beneficiary: beneficiary,
+			Contract:    entry.Contract,
+			Stats:       swap.NewStats(),
+		}
+		total.Stats.Add(entry.Stats)
+		totals[beneficiary] = total
+		entries = append(entries, total)
+	}
+	for s, entry := range self.swaps {
+		total := totals[entry.Beneficiary]
+		total.Connected = true
+		total.Stats.Add(s.Stats())
+	}
+	sort.Sort(byBeneficiary(entries))
+	return entries
+}
+
+// Save persists the totals of all peers including the current sessions
+func (self *Ledger) Save() error {
+	data, err := json.MarshalIndent(self.Entries(), "", " ")
+	if err != nil {
+		return err
+	}
+	glog.V(logger.Detail).Infof("[BZZ] SWAP saving ledger to %v", self.path)
+	return ioutil.WriteFile(self.path, data, os.ModePerm)
+}
+
+type byBeneficiary []*LedgerEntry
+
+func (s byBeneficiary) Len() int      { return len(s) }
+func (s byBeneficiary) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byBeneficiary) Less(i, j int) bool {
+	return bytes.Compare(s[i].Beneficiary[:], s[j].Beneficiary[:]) < 0
+}
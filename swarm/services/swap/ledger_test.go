@@ -0,0 +1,96 @@
+package swap
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/swap"
+)
+
+type testPayment struct{}
+
+func (testPayment) Issue(*big.Int) (swap.Promise, error)          { return nil, nil }
+func (testPayment) AutoDeposit(time.Duration, *big.Int, *big.Int) {}
+func (testPayment) Receive(swap.Promise) (*big.Int, error)        { return new(big.Int), nil }
+func (testPayment) AutoCash(time.Duration, *big.Int)              {}
+func (testPayment) Stop()                                         {}
+
+type testProtocol struct{}
+
+func (testProtocol) Pay(int, swap.Promise) {}
+func (testProtocol) Drop()                 {}
+func (testProtocol) String() string        { return "test" }
+
+// newTestSession creates a swap session with a peer, with thresholds high
+// enough that no payment is due
+func newTestSession(t *testing.T) (*swap.Swap, *SwapProfile) {
+	profile := &swap.Profile{
+		BuyAt:  big.NewInt(1),
+		SellAt: big.NewInt(1),
+		PayAt:  100,
+		DropAt: 1000,
+	}
+	s, err := swap.New(&swap.Params{Profile: profile, Strategy: &swap.Strategy{}},
+		swap.Payment{Out: testPayment{}, In: testPayment{}, Buys: true, Sells: true}, testProtocol{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetRemote(profile)
+	return s, &SwapProfile{Profile: profile, PayProfile: &PayProfile{Beneficiary: common.Address{1}, Contract: common.Address{2}}}
+}
+
+func TestLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swap-ledger-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ledger.json")
+
+	ledger, err := NewLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(ledger *Ledger, connected bool, balance int, sold, bought uint64) {
+		entries := ledger.Entries()
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 entry, got %d", len(entries))
+		}
+		entry := entries[0]
+		if entry.Beneficiary != (common.Address{1}) || entry.Contract != (common.Address{2}) {
+			t.Errorf("peer mismatch: beneficiary %x, contract %x", entry.Beneficiary, entry.Contract)
+		}
+		if entry.Connected != connected || entry.Balance != balance || entry.Sold != sold || entry.Bought != bought {
+			t.Errorf("entry mismatch: have connected %v, balance %d, sold %d, bought %d, want %v, %d, %d, %d",
+				entry.Connected, entry.Balance, entry.Sold, entry.Bought, connected, balance, sold, bought)
+		}
+	}
+
+	// the current session is included in the totals
+	s, remote := newTestSession(t)
+	ledger.open(s, remote)
+	s.Add(3)
+	s.Add(-1)
+	check(ledger, true, 2, 3, 1)
+	ledger.close(s)
+	check(ledger, false, 2, 3, 1)
+
+	// the unsettled balances of the sessions add up
+	s, remote = newTestSession(t)
+	ledger.open(s, remote)
+	s.Add(4)
+	check(ledger, true, 6, 7, 1)
+	ledger.close(s)
+
+	// closing a session saves the ledger
+	ledger, err = NewLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(ledger, false, 6, 7, 1)
+}
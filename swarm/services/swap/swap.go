@@ -59,6 +59,7 @@ type PayProfile struct {
 	publicKey   *ecdsa.PublicKey  `json:"-"`
 	owner       common.Address
 	chbook      *chequebook.Chequebook `json:"-"`
+	ledger      *Ledger                `json:"-"`
 	backend     chequebook.Backend
 	lock        sync.RWMutex
 }
@@ -152,9 +153,28 @@ func NewSwap(local *SwapParams, remote *SwapProfile, proto swap.Protocol) (self
 	}
 	glog.V(logger.Warn).Infof("[BZZ] SWAP arrangement with <%v>: %v; %v)", proto, buy, sell)
 
+	if ledger := local.Ledger(); ledger != nil {
+		ledger.open(self, remote)
+	}
 	return
 }
 
+// Release stops the swap of a closed peer connection (quitting autocash etc)
+// and adds its totals to the ledger
+func (self *SwapParams) Release(s *swap.Swap) {
+	s.Stop()
+	if ledger := self.Ledger(); ledger != nil {
+		ledger.close(s)
+	}
+}
+
+// Ledger returns the accounting of the peers, nil before SetChequebook
+func (self *SwapParams) Ledger() *Ledger {
+	defer self.lock.Unlock()
+	self.lock.Lock()
+	return self.ledger
+}
+
 func (self *SwapParams) Chequebook() *chequebook.Chequebook {
 	defer self.lock.Unlock()
 	self.lock.Lock()
@@ -186,6 +206,14 @@ func (self *SwapParams) SetChequebook(path string, backend chequebook.Backend) (
 	var valid bool
 	done = make(chan bool)
 	self.backend = backend
+	if self.ledger == nil {
+		if err = os.MkdirAll(path, os.ModePerm); err != nil {
+			return nil, fmt.Errorf("unable to create directory for swap ledger: %v", err)
+		}
+		if self.ledger, err = NewLedger(filepath.Join(path, "ledger.json")); err != nil {
+			return nil, fmt.Errorf("unable to load swap ledger: %v", err)
+		}
+	}
 	err = chequebook.Validate(self.Contract, backend)
 	if err != nil {
 		owner := crypto.PubkeyToAddress(*(self.publicKey))
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -25,10 +26,12 @@ data_{i} := size(subtree_{i}) || key_{j} || key_{j+1} .... || key_{j+n-1}
 
 3 Leaf nodes encode an actual subslice of the input data.
 
-4 if data size is not more than maximum chunksize, the data is stored in a single chunk
+4 optionally each branching node is followed by parity chunks computed from its children, see erasure.go
+
+5 if data size is not more than maximum chunksize, the data is stored in a single chunk
   key = hash(int64(size) + data)
 
-5 if data size is more than chunksize*branches^l, but no more than chunksize*
+6 if data size is more than chunksize*branches^l, but no more than chunksize*
   branches^(l+1), the data vector is split into slices of chunksize*
   branches^l length (except the last one).
   key = hash(int64(size) + key(slice0) + key(slice1) + ...)
@@ -59,6 +62,7 @@ type ChunkerParams struct {
 	Hash         string
 	JoinTimeout  time.Duration
 	SplitTimeout time.Duration
+	Parities     int64 // parity chunks per branching node, 0 disables erasure coding
 }
 
 func NewChunkerParams() *ChunkerParams {
@@ -75,6 +79,7 @@ type TreeChunker struct {
 	hashFunc     Hasher
 	joinTimeout  time.Duration
 	splitTimeout time.Duration
+	parities     int64
 	// calculated
 	hashSize  int64 // self.hashFunc.New().Size()
 	chunkSize int64 // hashSize* branches
@@ -86,6 +91,10 @@ func NewTreeChunker(params *ChunkerParams) (self *TreeChunker) {
 	self.branches = params.Branches
 	self.joinTimeout = params.JoinTimeout * time.Second
 	self.splitTimeout = params.SplitTimeout * time.Second
+	self.parities = params.Parities
+	if self.branches+self.parities > maxShards {
+		panic(fmt.Sprintf("branches and parities must not exceed %d", maxShards))
+	}
 	self.hashSize = int64(self.hashFunc().Size())
 	self.chunkSize = self.hashSize * self.branches
 	return
@@ -120,8 +129,14 @@ func (self *TreeChunker) Split(key Key, data SectionReader, chunkC chan *Chunk,
 		panic("chunker must be initialised")
 	}
 
-	if int64(len(key)) != self.hashSize {
-		panic(fmt.Sprintf("root key buffer must be allocated byte slice of length %d", self.hashSize))
+	// a key twice the hash size carries the secret of an encrypted upload
+	var secret []byte
+	switch int64(len(key)) {
+	case self.hashSize:
+	case 2 * self.hashSize:
+		key, secret = key[:self.hashSize], key[self.hashSize:]
+	default:
+		panic(fmt.Sprintf("root key buffer must be allocated byte slice of length %d or %d", self.hashSize, 2*self.hashSize))
 	}
 
 	wg := &sync.WaitGroup{}
@@ -145,7 +160,7 @@ func (self *TreeChunker) Split(key Key, data SectionReader, chunkC chan *Chunk,
 		// glog.V(logger.Detail).Infof("[BZZ] split request received for data (%v bytes, depth: %v)", size, depth)
 
 		//launch actual recursive function passing the workgroup
-		self.split(depth, treeSize/self.branches, key, data, chunkC, rerrC, wg, swg)
+		self.split(depth, treeSize/self.branches, key, nil, secret, 0, data, chunkC, rerrC, wg, swg)
 	}()
 
 	// closes internal error channel if all subprocesses in the workgroup finished
@@ -171,7 +186,30 @@ func (self *TreeChunker) Split(key Key, data SectionReader, chunkC chan *Chunk,
 	return
 }
 
-func (self *TreeChunker) split(depth int, treeSize int64, key Key, data SectionReader, chunkC chan *Chunk, errc chan error, parentWg *sync.WaitGroup, swg *sync.WaitGroup) {
+// normalise finds the lowest level of the tree at which a subtree of the
+// given size is still encoded
+func (self *TreeChunker) normalise(depth int, treeSize, size int64) (int, int64) {
+	for depth > 0 && size < treeSize {
+		treeSize /= self.branches
+		depth--
+	}
+	return depth, treeSize
+}
+
+// chunkLength calculates the length of the root chunk of a subtree encoded
+// with the given number of parities
+func (self *TreeChunker) chunkLength(depth int, treeSize, size, parities int64) int64 {
+	depth, treeSize = self.normalise(depth, treeSize, size)
+	if depth == 0 {
+		return 8 + size
+	}
+	return 8 + ((size+treeSize-1)/treeSize+parities)*self.hashSize
+}
+
+// split encodes the subtree of data and copies the key of its root chunk to
+// key, and the chunk data itself to sdata if not nil (needed for the parities
+// of the parent chunk)
+func (self *TreeChunker) split(depth int, treeSize int64, key Key, sdata *[]byte, secret []byte, offset int64, data SectionReader, chunkC chan *Chunk, errc chan error, parentWg *sync.WaitGroup, swg *sync.WaitGroup) {
 
 	defer parentWg.Done()
 
@@ -180,16 +218,16 @@ func (self *TreeChunker) split(depth int, treeSize int64, key Key, data SectionR
 	var hash Key
 	// glog.V(logger.Detail).Infof("[BZZ] depth: %v, max subtree size: %v, data size: %v", depth, treeSize, size)
 
-	for depth > 0 && size < treeSize {
-		treeSize /= self.branches
-		depth--
-	}
+	depth, treeSize = self.normalise(depth, treeSize, size)
 
 	if depth == 0 {
 		// leaf nodes -> content chunks
 		chunkData := make([]byte, data.Size()+8)
 		binary.LittleEndian.PutUint64(chunkData[0:8], uint64(size))
 		data.ReadAt(chunkData[8:], 0)
+		if secret != nil {
+			encryptChunk(secret, offset, depth, chunkData)
+		}
 		hash = self.Hash(chunkData)
 		// glog.V(logger.Detail).Infof("[BZZ] content chunk: max subtree size: %v, data size: %v", treeSize, size)
 		newChunk = &Chunk{
@@ -202,8 +240,12 @@ func (self *TreeChunker) split(depth int, treeSize int64, key Key, data SectionR
 		branchCnt := int64((size + treeSize - 1) / treeSize)
 		// glog.V(logger.Detail).Infof("[BZZ] intermediate node: setting branches: %v, depth: %v, max subtree size: %v, data size: %v", branches, depth, treeSize, size)
 
-		var chunk []byte = make([]byte, branchCnt*self.hashSize+8)
+		var chunk []byte = make([]byte, (branchCnt+self.parities)*self.hashSize+8)
 		var pos, i int64
+		var children [][]byte
+		if self.parities > 0 {
+			children = make([][]byte, branchCnt)
+		}
 
 		binary.LittleEndian.PutUint64(chunk[0:8], uint64(size))
 
@@ -217,18 +259,44 @@ func (self *TreeChunker) split(depth int, treeSize int64, key Key, data SectionR
 				secSize = treeSize
 			}
 			// take the section of the data encoded in the subTree
-			subTreeData := NewChunkReader(data, pos, secSize)
+			subTreeReader := NewChunkReader(data, pos, secSize)
 			// the hash of that data
 			subTreeKey := chunk[8+i*self.hashSize : 8+(i+1)*self.hashSize]
 
+			var subTreeData *[]byte
+			if children != nil {
+				subTreeData = &children[i]
+			}
+
 			childrenWg.Add(1)
-			go self.split(depth-1, treeSize/self.branches, subTreeKey, subTreeData, chunkC, errc, childrenWg, swg)
+			go self.split(depth-1, treeSize/self.branches, subTreeKey, subTreeData, secret, offset+pos, subTreeReader, chunkC, errc, childrenWg, swg)
 
 			i++
 			pos += treeSize
 		}
 		// wait for all the children to complete calculating their hashes and copying them onto sections of the chunk
 		childrenWg.Wait()
+		// the parity chunks are stored along with the children
+		if children != nil {
+			for j, parity := range parityChunks(children, int(self.parities)) {
+				parityKey := self.Hash(parity)
+				copy(chunk[8+(branchCnt+int64(j))*self.hashSize:], parityKey)
+				if swg != nil {
+					swg.Add(1)
+				}
+				if chunkC != nil {
+					chunkC <- &Chunk{
+						Key:   parityKey,
+						SData: parity,
+						Size:  int64(len(parity) - 8),
+						wg:    swg,
+					}
+				}
+			}
+		}
+		if secret != nil {
+			encryptChunk(secret, offset, depth, chunk)
+		}
 		// now we got the hashes in the chunk, then hash the chunks
 		hash = self.Hash(chunk)
 		newChunk = &Chunk{
@@ -249,13 +317,24 @@ func (self *TreeChunker) split(depth int, treeSize int64, key Key, data SectionR
 	}
 	// report hash of this chunk one level up (keys corresponds to the proper subslice of the parent chunk)x
 	copy(key, hash)
+	if sdata != nil {
+		*sdata = newChunk.SData
+	}
 
 }
 
-func (self *TreeChunker) Join(key Key, chunkC chan *Chunk) SectionReader {
+func (self *TreeChunker) Join(key Key, chunkC chan *Chunk) LazySectionReader {
+	return self.join(key, chunkC)
+}
 
+func (self *TreeChunker) join(key Key, chunkC chan *Chunk) *LazyChunkReader {
+	var secret []byte
+	if int64(len(key)) == 2*self.hashSize {
+		key, secret = key[:self.hashSize], key[self.hashSize:]
+	}
 	return &LazyChunkReader{
 		key:     key,
+		secret:  secret,
 		chunkC:  chunkC,
 		quitC:   make(chan bool),
 		errC:    make(chan error),
@@ -263,9 +342,44 @@ func (self *TreeChunker) Join(key Key, chunkC chan *Chunk) SectionReader {
 	}
 }
 
+// Keys returns the keys of all the chunks of the content under key, parity
+// chunks included. The chunks are retrieved on chunkC as when joining.
+func (self *TreeChunker) Keys(key Key, chunkC chan *Chunk) ([]Key, error) {
+	reader := self.join(key, chunkC)
+	chunk, _ := reader.retrieve(reader.key)
+	if len(chunk.SData) == 0 {
+		return nil, notFound
+	}
+	size := int64(binary.LittleEndian.Uint64(chunk.SData[0:8]))
+	var depth int
+	treeSize := self.chunkSize
+	for ; treeSize < size; treeSize *= self.branches {
+		depth++
+	}
+
+	var keys []Key
+	lock := sync.Mutex{}
+	add := func(key Key) {
+		lock.Lock()
+		keys = append(keys, key)
+		lock.Unlock()
+	}
+	errC := make(chan error, 1)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	reader.walk(chunk, 0, depth, treeSize/self.branches, add, errC, &wg)
+	select {
+	case err := <-errC:
+		return nil, err
+	default:
+	}
+	return keys, nil
+}
+
 // LazyChunkReader implements LazySectionReader
 type LazyChunkReader struct {
 	key     Key          // root key
+	secret  []byte       // secret of encrypted content, nil if plain
 	chunkC  chan *Chunk  // chunk channel to send retrieve requests on
 	size    int64        // size of the entire subtree
 	off     int64        // offset
@@ -303,9 +417,13 @@ func (self *LazyChunkReader) ReadAt(b []byte, off int64) (read int, err error) {
 		// glog.V(logger.Detail).Infof("[BZZ] Size query for %v", chunk.Key.Log())
 		return
 	}
+	if off >= self.size {
+		return 0, io.EOF
+	}
 	want := int64(len(b))
 	if off+want > self.size {
 		want = self.size - off
+		b = b[:want]
 	}
 	var treeSize int64
 	var depth int
@@ -316,7 +434,7 @@ func (self *LazyChunkReader) ReadAt(b []byte, off int64) (read int, err error) {
 	}
 	wg := sync.WaitGroup{}
 	wg.Add(1)
-	go self.join(b, off, off+want, depth, treeSize/self.chunker.branches, chunk, &wg)
+	go self.join(b, off, off+want, 0, depth, treeSize/self.chunker.branches, chunk, &wg)
 	go func() {
 		wg.Wait()
 		close(self.errC)
@@ -324,6 +442,9 @@ func (self *LazyChunkReader) ReadAt(b []byte, off int64) (read int, err error) {
 	select {
 	case err = <-self.errC:
 		// glog.V(logger.Detail).Infof("[BZZ] ReadAt received %v", err)
+		if err != nil {
+			return 0, err
+		}
 		read = len(b)
 		if off+int64(read) == self.size {
 			err = io.EOF
@@ -335,7 +456,9 @@ func (self *LazyChunkReader) ReadAt(b []byte, off int64) (read int, err error) {
 	return
 }
 
-func (self *LazyChunkReader) join(b []byte, off int64, eoff int64, depth int, treeSize int64, chunk *Chunk, parentWg *sync.WaitGroup) {
+// join reads the section [off, eoff) of the subtree under chunk into b, base
+// is the offset of the subtree within the entire document
+func (self *LazyChunkReader) join(b []byte, off int64, eoff int64, base int64, depth int, treeSize int64, chunk *Chunk, parentWg *sync.WaitGroup) {
 	defer parentWg.Done()
 
 	// glog.V(logger.Detail).Infof("[BZZ] depth: %v, loff: %v, eoff: %v, chunk.Size: %v, treeSize: %v", depth, off, eoff, chunk.Size, treeSize)
@@ -343,9 +466,11 @@ func (self *LazyChunkReader) join(b []byte, off int64, eoff int64, depth int, tr
 	chunk.Size = int64(binary.LittleEndian.Uint64(chunk.SData[0:8]))
 
 	// find appropriate block level
-	for chunk.Size < treeSize && depth > 0 {
-		treeSize /= self.chunker.branches
-		depth--
+	depth, treeSize = self.chunker.normalise(depth, treeSize, chunk.Size)
+
+	data := chunk.SData
+	if self.secret != nil {
+		data = decryptChunk(self.secret, base, depth, data)
 	}
 
 	if depth == 0 {
@@ -355,7 +480,7 @@ func (self *LazyChunkReader) join(b []byte, off int64, eoff int64, depth int, tr
 			panic("len(b) does not match")
 		}
 
-		copy(b, chunk.SData[8+off:8+eoff])
+		copy(b, data[8+off:8+eoff])
 		return // simply give back the chunks reader for content chunks
 	}
 
@@ -363,6 +488,7 @@ func (self *LazyChunkReader) join(b []byte, off int64, eoff int64, depth int, tr
 	start := off / treeSize
 	end := (eoff + treeSize - 1) / treeSize
 	wg := sync.WaitGroup{}
+	node := &erasureNode{}
 
 	for i := start; i < end; i++ {
 
@@ -379,33 +505,150 @@ func (self *LazyChunkReader) join(b []byte, off int64, eoff int64, depth int, tr
 
 		wg.Add(1)
 		go func(j int64) {
-			childKey := chunk.SData[8+j*self.chunker.hashSize : 8+(j+1)*self.chunker.hashSize]
+			childKey := data[8+j*self.chunker.hashSize : 8+(j+1)*self.chunker.hashSize]
 			// glog.V(logger.Detail).Infof("[BZZ] subtree index: %v -> %v", j, childKey.Log())
 
-			ch := &Chunk{
-				Key: childKey,
-				C:   make(chan bool), // close channel to signal data delivery
-			}
-			// glog.V(logger.Detail).Infof("[BZZ] chunk data sent for %v (key interval in chunk %v-%v)", ch.Key.Log(), j*self.chunker.hashSize, (j+1)*self.chunker.hashSize)
-			self.chunkC <- ch // submit retrieval request, someone should be listening on the other side (or we will time out globally)
-
-			// waiting for the chunk retrieval
-			select {
-			case <-self.quitC:
+			ch, ok := self.retrieve(childKey)
+			if !ok {
 				// this is how we control process leakage (quitC is closed once join is finished (after timeout))
 				return
-			case <-ch.C: // bells are ringing, data have been delivered
-				// glog.V(logger.Detail).Infof("[BZZ] chunk data received")
 			}
 			if soff < off {
 				soff = off
 			}
 			if len(ch.SData) == 0 {
-				self.errC <- fmt.Errorf("chunk %v-%v not found", off, off+treeSize)
-				return
+				// missing children can be recovered if the chunk has parities
+				sdata, err := self.recoverChild(node, data, depth, treeSize, chunk.Size, j)
+				if err != nil {
+					self.errC <- fmt.Errorf("chunk %v-%v not found: %v", off, off+treeSize, err)
+					return
+				}
+				glog.V(logger.Detail).Infof("[BZZ] chunk %v recovered from parities", ch.Key.Log())
+				ch.SData = sdata
 			}
-			self.join(b[soff-off:seoff-off], soff-roff, seoff-roff, depth-1, treeSize/self.chunker.branches, ch, &wg)
+			self.join(b[soff-off:seoff-off], soff-roff, seoff-roff, base+roff, depth-1, treeSize/self.chunker.branches, ch, &wg)
 		}(i)
 	} //for
 	wg.Wait()
 }
+
+// walk calls fn with the key of chunk and those of all chunks in its subtree,
+// the first chunk not found is reported on the buffered errC
+func (self *LazyChunkReader) walk(chunk *Chunk, base int64, depth int, treeSize int64, fn func(Key), errC chan error, parentWg *sync.WaitGroup) {
+	defer parentWg.Done()
+
+	fn(chunk.Key)
+	size := int64(binary.LittleEndian.Uint64(chunk.SData[0:8]))
+	depth, treeSize = self.chunker.normalise(depth, treeSize, size)
+	if depth == 0 {
+		return
+	}
+	data := chunk.SData
+	if self.secret != nil {
+		data = decryptChunk(self.secret, base, depth, data)
+	}
+	hashSize := self.chunker.hashSize
+	children := (size + treeSize - 1) / treeSize
+	for k := children; k < (int64(len(data))-8)/hashSize; k++ {
+		fn(Key(data[8+k*hashSize : 8+(k+1)*hashSize]))
+	}
+
+	wg := sync.WaitGroup{}
+	for j := int64(0); j < children; j++ {
+		wg.Add(1)
+		go func(j int64) {
+			ch, ok := self.retrieve(data[8+j*hashSize : 8+(j+1)*hashSize])
+			if !ok {
+				wg.Done()
+				return
+			}
+			if len(ch.SData) == 0 {
+				select {
+				case errC <- fmt.Errorf("chunk %v not found", ch.Key.Log()):
+				default:
+				}
+				wg.Done()
+				return
+			}
+			self.walk(ch, base+j*treeSize, depth-1, treeSize/self.chunker.branches, fn, errC, &wg)
+		}(j)
+	}
+	wg.Wait()
+}
+
+// retrieve requests the chunk with the given key, ok is false if the reader
+// is aborted while waiting for it
+func (self *LazyChunkReader) retrieve(key Key) (chunk *Chunk, ok bool) {
+	chunk = &Chunk{
+		Key: key,
+		C:   make(chan bool), // close channel to signal data delivery
+	}
+	// glog.V(logger.Detail).Infof("[BZZ] chunk data sent for %v", chunk.Key.Log())
+	self.chunkC <- chunk // submit retrieval request, someone should be listening on the other side (or we will time out globally)
+
+	// waiting for the chunk retrieval
+	select {
+	case <-self.quitC:
+		return nil, false
+	case <-chunk.C: // bells are ringing, data have been delivered
+		// glog.V(logger.Detail).Infof("[BZZ] chunk data received")
+	}
+	return chunk, true
+}
+
+// erasureNode holds the children of an intermediate chunk recovered from its
+// parities, they are reconstructed only once for all children read concurrently
+type erasureNode struct {
+	once     sync.Once
+	children [][]byte
+	err      error
+}
+
+// recoverChild returns the data of the child j of the intermediate chunk
+// data, reconstructed from the other children and the parity chunks
+func (self *LazyChunkReader) recoverChild(node *erasureNode, data []byte, depth int, treeSize, size, j int64) ([]byte, error) {
+	hashSize := self.chunker.hashSize
+	key := func(k int64) Key {
+		return data[8+k*hashSize : 8+(k+1)*hashSize]
+	}
+	node.once.Do(func() {
+		children := (size + treeSize - 1) / treeSize
+		parities := (int64(len(data))-8)/hashSize - children
+		if parities == 0 {
+			node.err = fmt.Errorf("no parities")
+			return
+		}
+		chunks := make([][]byte, children+parities)
+		wg := sync.WaitGroup{}
+		for k := range chunks {
+			wg.Add(1)
+			go func(k int64) {
+				defer wg.Done()
+				if ch, ok := self.retrieve(key(k)); ok && len(ch.SData) > 0 {
+					chunks[k] = ch.SData
+				}
+			}(int64(k))
+		}
+		wg.Wait()
+
+		// the length of the children is needed to strip the padding
+		sizes := make([]int64, children)
+		for k := range sizes {
+			secSize := treeSize
+			if rest := size - int64(k)*treeSize; rest < treeSize {
+				secSize = rest
+			}
+			sizes[k] = self.chunker.chunkLength(depth-1, treeSize/self.chunker.branches, secSize, parities)
+		}
+		node.err = recoverChunks(chunks[:children], chunks[children:], sizes)
+		node.children = chunks[:children]
+	})
+	if node.err != nil {
+		return nil, node.err
+	}
+	sdata := node.children[j]
+	if !bytes.Equal(self.chunker.Hash(sdata), key(j)) {
+		return nil, fmt.Errorf("recovered chunk %v invalid", key(j).Log())
+	}
+	return sdata, nil
+}
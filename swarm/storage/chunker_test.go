@@ -16,6 +16,7 @@ type chunkerTester struct {
 	errors  []error
 	chunks  []*Chunk
 	timeout bool
+	secret  []byte // encrypts the content if set
 }
 
 func (self *chunkerTester) checkChunks(t *testing.T, want int) {
@@ -34,6 +35,9 @@ func (self *chunkerTester) Split(chunker *TreeChunker, l int) (key Key, input []
 	data, slice := testDataReader(l)
 	input = slice
 	key = make([]byte, 32)
+	if self.secret != nil {
+		key = append(key, self.secret...)
+	}
 	chunkC := make(chan *Chunk, 1000)
 	errC := chunker.Split(key, data, chunkC, nil)
 	quitC := make(chan bool)
@@ -144,6 +148,80 @@ func TestRandomData(t *testing.T) {
 	// t.Logf("chunks %v", tester.chunks)
 }
 
+func TestEncryptedRandomData(t *testing.T) {
+	chunker, tester := chunkerAndTester()
+	tester.secret = bytes.Repeat([]byte{0x42}, 32)
+	testRandomData(chunker, tester, 60, 1, t)
+	testRandomData(chunker, tester, 179, 5, t)
+	testRandomData(chunker, tester, 253, 7, t)
+
+	// chunks are addressed by their ciphertext which reveals nothing of the
+	// content but its size
+	key, input := tester.Split(chunker, 253)
+	for _, chunk := range tester.chunks {
+		if !bytes.Equal(chunk.Key, chunker.Hash(chunk.SData)) {
+			t.Errorf("chunk %v not addressed by its content", chunk.Key.Log())
+		}
+		if chunk.Size <= chunker.chunkSize && bytes.Contains(input, chunk.SData[8:]) {
+			t.Errorf("chunk %v holds plain content", chunk.Key.Log())
+		}
+	}
+	// sections across chunk boundaries are decrypted with the right keys
+	reader := tester.Join(chunker, key, 0)
+	output := make([]byte, 100)
+	if _, err := reader.ReadAt(output, 77); err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+	if !bytes.Equal(output, input[77:177]) {
+		t.Errorf("section mismatch\n IN: %x\nOUT: %x\n", input[77:177], output)
+	}
+	// the root hash alone does not give access
+	reader = tester.Join(chunker, key[:32], 0)
+	output = make([]byte, 100)
+	reader.ReadAt(output, 77)
+	if bytes.Equal(output, input[77:177]) {
+		t.Errorf("content readable without the secret")
+	}
+}
+
+func TestErasureCodedRandomData(t *testing.T) {
+	for _, secret := range [][]byte{nil, bytes.Repeat([]byte{0x42}, 32)} {
+		chunker, tester := chunkerAndTester()
+		chunker.parities = 2
+		tester.secret = secret
+		key, input := tester.Split(chunker, 253)
+		// 4 leaves, 3 branching nodes with 2 parities each
+		tester.checkChunks(t, 13)
+		chunks := tester.chunks
+		// every chunk but the root one can be lost
+		for i, lost := range chunks {
+			if bytes.Equal(lost.Key, key[:32]) {
+				continue
+			}
+			tester.chunks = append(append([]*Chunk{}, chunks[:i]...), chunks[i+1:]...)
+			reader := tester.Join(chunker, key, 0)
+			output := make([]byte, len(input))
+			if _, err := reader.ReadAt(output, 0); err != io.EOF {
+				t.Fatalf("read error without chunk %v: %v", lost.Key.Log(), err)
+			}
+			if !bytes.Equal(output, input) {
+				t.Fatalf("input and output mismatch without chunk %v", lost.Key.Log())
+			}
+		}
+		// but not all of them
+		tester.chunks = nil
+		for _, chunk := range chunks {
+			if bytes.Equal(chunk.Key, key[:32]) {
+				tester.chunks = append(tester.chunks, chunk)
+			}
+		}
+		reader := tester.Join(chunker, key, 0)
+		if _, err := reader.ReadAt(make([]byte, len(input)), 0); err == nil || err == io.EOF {
+			t.Errorf("expected error reading without children, got %v", err)
+		}
+	}
+}
+
 func chunkerAndTester() (chunker *TreeChunker, tester *chunkerTester) {
 	chunker = NewTreeChunker(&ChunkerParams{
 		Branches:     2,
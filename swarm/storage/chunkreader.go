@@ -22,6 +22,15 @@ type SectionReader interface {
 	io.ReaderAt
 }
 
+// LazySectionReader is a SectionReader over content retrieved on demand, the
+// size of which is only known once the root chunk has been retrieved.
+// FetchSize retrieves the root chunk and reports whether the content is
+// available, whereas Size silently returns 0 for missing content.
+type LazySectionReader interface {
+	SectionReader
+	FetchSize() (int64, error)
+}
+
 // ChunkReader implements SectionReader on a section
 // of an underlying ReaderAt.
 type ChunkReader struct {
@@ -165,10 +174,18 @@ func (r *ChunkReader) WriteTo(w io.Writer) (n int64, err error) {
 }
 
 func (self *LazyChunkReader) Size() (n int64) {
-	self.ReadAt(nil, 0)
+	self.FetchSize()
 	return self.size
 }
 
+// FetchSize retrieves the root chunk and returns the size of the content.
+func (self *LazyChunkReader) FetchSize() (int64, error) {
+	if _, err := self.ReadAt(nil, 0); err != nil {
+		return 0, err
+	}
+	return self.size, nil
+}
+
 func (self *LazyChunkReader) Read(b []byte) (read int, err error) {
 	read, err = self.ReadAt(b, self.off)
 	self.off += int64(read)
@@ -184,7 +201,11 @@ func (s *LazyChunkReader) Seek(offset int64, whence int) (int64, error) {
 	case 1:
 		offset += s.off
 	case 2:
-		offset += s.size
+		size, err := s.FetchSize()
+		if err != nil {
+			return 0, err
+		}
+		offset += size
 	}
 	if offset < 0 {
 		return 0, errOffset
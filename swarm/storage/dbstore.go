@@ -3,6 +3,7 @@
 // persistent storage of chunks
 // it implements purging based on access count allowing for external control of
 // max capacity
+// pinned chunks are exempt from purging, see pin.go
 
 package storage
 
@@ -11,6 +12,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -36,6 +38,7 @@ var (
 	keyEntryCnt  = []byte{3}
 	keyDataIdx   = []byte{4}
 	keyGCPos     = []byte{5}
+	keyPinCnt    = []byte{6}
 )
 
 type gcItem struct {
@@ -52,6 +55,8 @@ type DbStore struct {
 
 	gcPos, gcStartPos []byte
 	gcArray           []*gcItem
+	gcStats           GCStats
+	pinCnt            uint64
 
 	hashfunc Hasher
 
@@ -80,6 +85,8 @@ func NewDbStore(path string, hash Hasher, capacity uint64, radius int) (s *DbSto
 	s.accessCnt = BytesToU64(data)
 	data, _ = s.db.Get(keyDataIdx)
 	s.dataIdx = BytesToU64(data)
+	data, _ = s.db.Get(keyPinCnt)
+	s.pinCnt = BytesToU64(data)
 	s.gcPos, _ = s.db.Get(keyGCPos)
 	if s.gcPos == nil {
 		s.gcPos = s.gcStartPos
@@ -185,7 +192,9 @@ func gcListSelect(list []*gcItem, left int, right int, n int) int {
 	}
 }
 
-func (s *DbStore) collectGarbage(ratio float32) {
+// collectGarbage removes the ratio of least accessed chunks among the next
+// gcArraySize ones that are not pinned, and returns the number of chunks removed
+func (s *DbStore) collectGarbage(ratio float32) int {
 	it := s.db.NewIterator()
 	it.Seek(s.gcPos)
 	if it.Valid() {
@@ -194,8 +203,9 @@ func (s *DbStore) collectGarbage(ratio float32) {
 		s.gcPos = nil
 	}
 	gcnt := 0
+	visited := uint64(0)
 
-	for (gcnt < gcArraySize) && (uint64(gcnt) < s.entryCnt) {
+	for (gcnt < gcArraySize) && (visited < s.entryCnt) {
 
 		if (s.gcPos == nil) || (s.gcPos[0] != kpIndex) {
 			it.Seek(s.gcStartPos)
@@ -209,9 +219,21 @@ func (s *DbStore) collectGarbage(ratio float32) {
 		if (s.gcPos == nil) || (s.gcPos[0] != kpIndex) {
 			break
 		}
+		visited++
+
+		if s.pinned(s.gcPos[1:]) {
+			it.Next()
+			if it.Valid() {
+				s.gcPos = it.Key()
+			} else {
+				s.gcPos = nil
+			}
+			continue
+		}
 
 		gci := new(gcItem)
-		gci.idxKey = s.gcPos
+		// the iterator reuses the buffer of the key
+		gci.idxKey = append([]byte(nil), s.gcPos...)
 		var index dpaDBIndex
 		decodeIndex(it.Value(), &index)
 		gci.idx = index.Idx
@@ -228,14 +250,24 @@ func (s *DbStore) collectGarbage(ratio float32) {
 	}
 	it.Release()
 
+	s.gcStats.Runs++
+	s.gcStats.LastRun = time.Now()
+	if gcnt == 0 {
+		// everything is pinned
+		s.db.Put(keyGCPos, s.gcPos)
+		return 0
+	}
+
 	cutidx := gcListSelect(s.gcArray, 0, gcnt-1, int(float32(gcnt)*ratio))
 	cutval := s.gcArray[cutidx].value
 
 	// fmt.Print(gcnt, " ", s.entryCnt, " ")
 
 	// actual gc
+	collected := 0
 	for i := 0; i < gcnt; i++ {
 		if s.gcArray[i].value <= cutval {
+			collected++
 			batch := new(leveldb.Batch)
 			batch.Delete(s.gcArray[i].idxKey)
 			batch.Delete(getDataKey(s.gcArray[i].idx))
@@ -247,7 +279,9 @@ func (s *DbStore) collectGarbage(ratio float32) {
 
 	// fmt.Println(s.entryCnt)
 
+	s.gcStats.Collected += uint64(collected)
 	s.db.Put(keyGCPos, s.gcPos)
+	return collected
 }
 
 func (s *DbStore) Counter() uint64 {
@@ -335,12 +369,9 @@ func (s *DbStore) Get(key Key) (chunk *Chunk, err error) {
 			return
 		}
 
-		hasher := s.hashfunc()
-		hasher.Write(data)
-		hash := hasher.Sum(nil)
-		if bytes.Compare(hash, key) != 0 {
+		if !ValidChunk(s.hashfunc, key, data) {
 			s.db.Delete(getDataKey(index.Idx))
-			err = fmt.Errorf("invalid chunk. hash=%x, key=%v", hash, key[:])
+			err = fmt.Errorf("invalid chunk. key=%v", key[:])
 			return
 		}
 
@@ -383,7 +414,9 @@ func (s *DbStore) setCapacity(c uint64) {
 			ratio = 1
 		}
 		for s.entryCnt > c {
-			s.collectGarbage(ratio)
+			if s.collectGarbage(ratio) == 0 {
+				break // the rest is pinned
+			}
 		}
 	}
 }
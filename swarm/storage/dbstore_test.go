@@ -2,6 +2,8 @@ package storage
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"os"
 	"testing"
 
@@ -170,3 +172,62 @@ func TestDbStoreSyncIterator(t *testing.T) {
 		t.Fatalf("Expected %v chunk, got %v", keys[3], res[0])
 	}
 }
+
+func TestDbStorePinning(t *testing.T) {
+	m := initDbStore()
+	defer m.close()
+	m.setCapacity(10)
+
+	hash := MakeHashFunc(defaultHash)
+	newChunk := func() *Chunk {
+		data := make([]byte, 8+32)
+		binary.LittleEndian.PutUint64(data, 32)
+		rand.Read(data[8:])
+		hasher := hash()
+		hasher.Write(data)
+		return &Chunk{Key: hasher.Sum(nil), SData: data, Size: 32}
+	}
+	var pinned []Key
+	for i := 0; i < 5; i++ {
+		chunk := newChunk()
+		m.Put(chunk)
+		pinned = append(pinned, chunk.Key)
+	}
+	if err := m.Pin(pinned[0], pinned); err != nil {
+		t.Fatalf("pin failed: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(newChunk())
+	}
+	for _, key := range pinned {
+		if _, err := m.Get(key); err != nil {
+			t.Errorf("pinned chunk %v collected: %v", key.Log(), err)
+		}
+	}
+	stats := m.GCStats()
+	if stats.Runs == 0 || stats.Collected == 0 || stats.Pinned != 5 || stats.Entries > 10 {
+		t.Errorf("unexpected gc stats: %+v", stats)
+	}
+	if pins := m.Pins(); len(pins) != 1 || !bytes.Equal(pins[0].Root, pinned[0]) || pins[0].Chunks != 5 {
+		t.Errorf("unexpected pin list: %v", pins)
+	}
+	// collection stops short of pinned chunks
+	m.setCapacity(2)
+	if entries := m.GCStats().Entries; entries != 5 {
+		t.Errorf("expected the 5 pinned chunks to remain, have %d", entries)
+	}
+
+	if err := m.Unpin(pinned[0]); err != nil {
+		t.Fatalf("unpin failed: %v", err)
+	}
+	if err := m.Unpin(pinned[0]); err == nil {
+		t.Errorf("expected error unpinning twice")
+	}
+	if pins, stats := m.Pins(), m.GCStats(); len(pins) != 0 || stats.Pinned != 0 {
+		t.Errorf("content still pinned: %v %+v", pins, stats)
+	}
+	m.setCapacity(2)
+	if entries := m.GCStats().Entries; entries > 2 {
+		t.Errorf("unpinned chunks not collected, %d entries", entries)
+	}
+}
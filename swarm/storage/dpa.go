@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"io"
 	"sync"
 	"time"
 
@@ -37,6 +38,7 @@ type DPA struct {
 	storeC    chan *Chunk
 	retrieveC chan *Chunk
 	Chunker   Chunker
+	pyramid   *PyramidChunker
 
 	lock    sync.Mutex
 	running bool
@@ -65,6 +67,7 @@ func NewDPA(store ChunkStore, params *ChunkerParams) *DPA {
 	return &DPA{
 		Chunker:    chunker,
 		ChunkStore: store,
+		pyramid:    NewPyramidChunker(params),
 	}
 }
 
@@ -72,14 +75,69 @@ func NewDPA(store ChunkStore, params *ChunkerParams) *DPA {
 // FS-aware API and httpaccess
 // Chunk retrieval blocks on netStore requests with a timeout so reader will
 // report error if retrieval of chunks within requested range time out.
-func (self *DPA) Retrieve(key Key) SectionReader {
+func (self *DPA) Retrieve(key Key) LazySectionReader {
 	return self.Chunker.Join(key, self.retrieveC)
 }
 
+// Keys returns the keys of all chunks of the document, retrieving them
+// as needed. Used to pin content.
+func (self *DPA) Keys(key Key) ([]Key, error) {
+	return self.Chunker.Keys(key, self.retrieveC)
+}
+
 // Public API. Main entry point for document storage directly. Used by the
 // FS-aware API and httpaccess
 func (self *DPA) Store(data SectionReader, wg *sync.WaitGroup) (key Key, err error) {
 	key = make([]byte, self.Chunker.KeySize())
+	self.split(key, data, wg)
+	return
+}
+
+// StoreEncrypted stores data encrypted with a fresh random secret. The
+// returned key is the root hash followed by the secret, so only those who
+// are given the key can read the content. Retrieve decrypts it transparently.
+func (self *DPA) StoreEncrypted(data SectionReader, wg *sync.WaitGroup) (key Key, err error) {
+	key, err = newEncryptedKey(self.Chunker.KeySize())
+	if err != nil {
+		return nil, err
+	}
+	self.split(key, data, wg)
+	return
+}
+
+// StoreStream stores data read until EOF, for content of unknown length.
+// The key is the same as if it was stored with Store.
+func (self *DPA) StoreStream(data io.Reader, wg *sync.WaitGroup) (key Key, err error) {
+	key = make([]byte, self.pyramid.KeySize())
+	if err = self.pyramid.StreamSplit(key, data, self.storeC, wg); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Append stores the content under key extended with data and returns its
+// key. Only the end of the existing content is retrieved and rewritten,
+// encrypted content can't be appended to.
+func (self *DPA) Append(key Key, data io.Reader, wg *sync.WaitGroup) (Key, error) {
+	return self.pyramid.Append(key, data, self.retrieveC, self.storeC, wg)
+}
+
+// GetTimeout retrieves a chunk like Get but waits at most timeout for the
+// network to deliver it. It is meant for probing chunks that may well not
+// exist, where a miss should not cost the full search timeout.
+func (self *DPA) GetTimeout(key Key, timeout time.Duration) (*Chunk, error) {
+	if store, ok := self.ChunkStore.(*dpaChunkStore); ok {
+		return store.getTimeout(key, timeout)
+	}
+	return self.Get(key)
+}
+
+// IsEncrypted tells if key refers to encrypted content
+func (self *DPA) IsEncrypted(key Key) bool {
+	return int64(len(key)) == 2*self.Chunker.KeySize()
+}
+
+func (self *DPA) split(key Key, data SectionReader, wg *sync.WaitGroup) {
 	errC := self.Chunker.Split(key, data, self.storeC, wg)
 
 SPLIT:
@@ -97,8 +155,6 @@ SPLIT:
 			break SPLIT
 		}
 	}
-	return
-
 }
 
 func (self *DPA) Start() {
@@ -195,14 +251,17 @@ func NewDpaChunkStore(localStore, netStore ChunkStore) *dpaChunkStore {
 // Get is the entrypoint for local retrieve requests
 // waits for response or times out
 func (self *dpaChunkStore) Get(key Key) (chunk *Chunk, err error) {
+	return self.getTimeout(key, searchTimeout)
+}
+
+func (self *dpaChunkStore) getTimeout(key Key, timeout time.Duration) (chunk *Chunk, err error) {
 	chunk, err = self.netStore.Get(key)
-	// timeout := time.Now().Add(searchTimeout)
 	if chunk.SData != nil {
 		glog.V(logger.Detail).Infof("[BZZ] DPA.Get: %v found locally, %d bytes", key.Log(), len(chunk.SData))
 		return
 	}
 	// TODO: use self.timer time.Timer and reset with defer disableTimer
-	timer := time.After(searchTimeout)
+	timer := time.After(timeout)
 	select {
 	case <-timer:
 		glog.V(logger.Detail).Infof("[BZZ] DPA.Get: %v request time out ", key.Log())
@@ -7,6 +7,7 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 )
 
 const testDataSize = 0x1000000
@@ -131,3 +132,42 @@ func TestDPA_capacity(t *testing.T) {
 		t.Errorf("Comparison error after clearing memStore.")
 	}
 }
+
+// silentCloud never finds anything on the network
+type silentCloud struct{}
+
+func (silentCloud) Store(*Chunk)    {}
+func (silentCloud) Deliver(*Chunk)  {}
+func (silentCloud) Retrieve(*Chunk) {}
+
+func TestDPAGetTimeout(t *testing.T) {
+	datadir, err := ioutil.TempDir("", "bzz-dpa-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(datadir)
+	hash := MakeHashFunc(defaultHash)
+	dbStore, err := NewDbStore(datadir, hash, defaultDbCapacity, defaultRadius)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localStore := &LocalStore{NewMemStore(dbStore, defaultCacheCapacity), dbStore}
+	netStore := NewNetStore(hash, localStore, silentCloud{}, NewStoreParams(datadir))
+	dpa := NewDPA(NewDpaChunkStore(localStore, netStore), NewChunkerParams())
+
+	start := time.Now()
+	if _, err := dpa.GetTimeout(Key(make([]byte, 32)), 100*time.Millisecond); err != notFound {
+		t.Fatalf("expected missing chunk not to be found, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= searchTimeout {
+		t.Errorf("miss took %v, longer than the search timeout", elapsed)
+	}
+
+	chunk := NewChunk(Key(make([]byte, 32)), nil)
+	chunk.Key[0] = 1
+	chunk.SData = []byte("local")
+	localStore.Put(chunk)
+	if found, err := dpa.GetTimeout(chunk.Key, 100*time.Millisecond); err != nil || string(found.SData) != "local" {
+		t.Errorf("expected local chunk, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+)
+
+/*
+Encrypted content is split by the same tree chunker as plain content, but the
+payload of every chunk (everything after the 8 byte size prefix, i.e. the data
+of leaf chunks and the child hashes of intermediate ones) is encrypted before
+the chunk is hashed. Chunks are therefore addressed by the hash of their
+ciphertext and nodes storing them learn nothing but the size of the subtree.
+
+Each chunk is encrypted with AES-256 in CTR mode under its own key, derived
+from a per-upload secret and the position of the chunk in the tree:
+  chunkkey = keccak256(secret || offset || depth)
+so no key stream is ever reused within an upload.
+
+The reference of an encrypted document is the root hash followed by the
+secret. Its length tells the chunker whether to decrypt while joining, so
+encrypted and plain references can be used interchangeably, e.g. as manifest
+entries.
+*/
+
+// newEncryptedKey allocates a reference for encrypted content with a fresh
+// random secret, the root hash is filled in by the chunker
+func newEncryptedKey(hashSize int64) (Key, error) {
+	key := make([]byte, 2*hashSize)
+	if _, err := rand.Read(key[hashSize:]); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// chunkCipher returns the key stream for the chunk at the given absolute
+// offset and (normalised) depth of the tree
+func chunkCipher(secret []byte, offset int64, depth int) cipher.Stream {
+	var pos [9]byte
+	binary.BigEndian.PutUint64(pos[:8], uint64(offset))
+	pos[8] = byte(depth)
+
+	hasher := sha3.NewKeccak256()
+	hasher.Write(secret)
+	hasher.Write(pos[:])
+	block, err := aes.NewCipher(hasher.Sum(nil))
+	if err != nil {
+		panic(err) // cannot happen, the key is always 32 bytes
+	}
+	return cipher.NewCTR(block, make([]byte, aes.BlockSize))
+}
+
+// encryptChunk encrypts the payload of the chunk data in place
+func encryptChunk(secret []byte, offset int64, depth int, data []byte) {
+	stream := chunkCipher(secret, offset, depth)
+	stream.XORKeyStream(data[8:], data[8:])
+}
+
+// decryptChunk returns a copy of the chunk data with the payload decrypted,
+// the original is left untouched as it may be shared with the chunk store
+func decryptChunk(secret []byte, offset int64, depth int, data []byte) []byte {
+	plain := make([]byte, len(data))
+	copy(plain, data[:8])
+	stream := chunkCipher(secret, offset, depth)
+	stream.XORKeyStream(plain[8:], data[8:])
+	return plain
+}
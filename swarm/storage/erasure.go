@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+/*
+Erasure coding lets content survive the loss of chunks. If the chunker is
+configured with p parities (ChunkerParams.Parities), each intermediate chunk of
+n children gets p parity chunks computed with a systematic Reed-Solomon code
+over GF(2^8). Their keys are appended to those of the children:
+  data_{i} := size(subtree_{i}) || key_{j} || ... || key_{j+n-1} || parity_{0} || ... || parity_{p-1}
+Any n of the n+p chunks are enough to reconstruct the missing ones.
+
+The children are padded with zeros to the length of the longest (i.e. the
+first) one. A parity chunk holds that length followed by the parity bytes, so
+it is a well formed chunk itself.
+
+The number of parities is derived from the length of the intermediate chunk,
+so content encoded with any redundancy can be joined by any chunker.
+*/
+
+// maxShards is the limit on the number of children and parities of a node,
+// the size of the field the code works in
+const maxShards = 256
+
+var (
+	gfExp [2 * 255]byte // exponentials, doubled to spare the modulo in gfMul
+	gfLog [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfExp[i+255] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d // x^8 + x^4 + x^3 + x^2 + 1
+		}
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// gfMulAdd adds c*src to dst
+func gfMulAdd(dst, src []byte, c byte) {
+	if c == 0 {
+		return
+	}
+	lc := int(gfLog[c])
+	for i, b := range src {
+		if b != 0 {
+			dst[i] ^= gfExp[int(gfLog[b])+lc]
+		}
+	}
+}
+
+// parityCoefficient is the element of the Cauchy matrix by which data shard j
+// is multiplied in parity shard i. Every square submatrix of a Cauchy matrix
+// is invertible, which is what makes any n shards sufficient.
+func parityCoefficient(i, j, parities int) byte {
+	return gfInv(byte(i) ^ byte(parities+j))
+}
+
+// encodeParities calculates the parity shards of the equally long data shards
+func encodeParities(shards [][]byte, parities int) [][]byte {
+	out := make([][]byte, parities)
+	for i := range out {
+		out[i] = make([]byte, len(shards[0]))
+		for j, shard := range shards {
+			gfMulAdd(out[i], shard, parityCoefficient(i, j, parities))
+		}
+	}
+	return out
+}
+
+// reconstruct fills in the missing (nil) data shards among the first n of
+// shards from the available data and parity shards
+func reconstruct(shards [][]byte, n int) error {
+	parities := len(shards) - n
+	var rows []int
+	var size int
+	for k, shard := range shards {
+		if shard != nil && len(rows) < n {
+			rows = append(rows, k)
+			size = len(shard)
+		}
+	}
+	if len(rows) < n {
+		return fmt.Errorf("%d of %d chunks missing, only %d can be recovered", len(shards)-len(rows), len(shards), parities)
+	}
+	// the rows of the generator matrix of the available shards
+	matrix := make([][]byte, n)
+	for r, k := range rows {
+		matrix[r] = make([]byte, n)
+		if k < n {
+			matrix[r][k] = 1
+			continue
+		}
+		for j := range matrix[r] {
+			matrix[r][j] = parityCoefficient(k-n, j, parities)
+		}
+	}
+	inverse, err := invertMatrix(matrix)
+	if err != nil {
+		return err
+	}
+	for j := 0; j < n; j++ {
+		if shards[j] != nil {
+			continue
+		}
+		shard := make([]byte, size)
+		for r, k := range rows {
+			gfMulAdd(shard, shards[k], inverse[j][r])
+		}
+		shards[j] = shard
+	}
+	return nil
+}
+
+// invertMatrix inverts the square matrix by Gauss-Jordan elimination, the
+// matrix itself is destroyed in the process
+func invertMatrix(matrix [][]byte) ([][]byte, error) {
+	n := len(matrix)
+	inverse := make([][]byte, n)
+	for i := range inverse {
+		inverse[i] = make([]byte, n)
+		inverse[i][i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for pivot < n && matrix[pivot][col] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, fmt.Errorf("singular matrix")
+		}
+		matrix[col], matrix[pivot] = matrix[pivot], matrix[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+
+		c := gfInv(matrix[col][col])
+		for k := 0; k < n; k++ {
+			matrix[col][k] = gfMul(matrix[col][k], c)
+			inverse[col][k] = gfMul(inverse[col][k], c)
+		}
+		for r := 0; r < n; r++ {
+			if f := matrix[r][col]; r != col && f != 0 {
+				gfMulAdd(matrix[r], matrix[col], f)
+				gfMulAdd(inverse[r], inverse[col], f)
+			}
+		}
+	}
+	return inverse, nil
+}
+
+// parityChunks returns the data of the parity chunks for the data of the
+// children of an intermediate chunk
+func parityChunks(children [][]byte, parities int) [][]byte {
+	size := len(children[0])
+	shards := make([][]byte, len(children))
+	for j, child := range children {
+		shards[j] = child
+		if len(child) < size {
+			shards[j] = make([]byte, size)
+			copy(shards[j], child)
+		}
+	}
+	out := encodeParities(shards, parities)
+	for i, parity := range out {
+		out[i] = make([]byte, 8+size)
+		binary.LittleEndian.PutUint64(out[i][0:8], uint64(size))
+		copy(out[i][8:], parity)
+	}
+	return out
+}
+
+// recoverChunks reconstructs the missing (nil) children of an intermediate
+// chunk from the available children and parity chunks. sizes are the lengths
+// of the children data, so that the padding can be removed.
+func recoverChunks(children, parities [][]byte, sizes []int64) error {
+	size := int(sizes[0])
+	shards := make([][]byte, len(children)+len(parities))
+	for j, child := range children {
+		if child != nil {
+			shards[j] = make([]byte, size)
+			copy(shards[j], child)
+		}
+	}
+	for i, parity := range parities {
+		if len(parity) == 8+size {
+			shards[len(children)+i] = parity[8:]
+		}
+	}
+	if err := reconstruct(shards, len(children)); err != nil {
+		return err
+	}
+	for j := range children {
+		if children[j] == nil {
+			children[j] = shards[j][:sizes[j]]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestReconstruct(t *testing.T) {
+	const n, parities = 10, 4
+	shards := make([][]byte, n)
+	for i := range shards {
+		shards[i] = make([]byte, 100)
+		rand.Read(shards[i])
+	}
+	all := append(append([][]byte{}, shards...), encodeParities(shards, parities)...)
+
+	for round := 0; round < 100; round++ {
+		damaged := append([][]byte{}, all...)
+		for _, i := range rand.Perm(n + parities)[:parities] {
+			damaged[i] = nil
+		}
+		if err := reconstruct(damaged, n); err != nil {
+			t.Fatalf("reconstruction failed: %v", err)
+		}
+		for i, shard := range shards {
+			if !bytes.Equal(damaged[i], shard) {
+				t.Fatalf("shard %d mismatch: have %x, want %x", i, damaged[i], shard)
+			}
+		}
+	}
+	damaged := append([][]byte{}, all...)
+	for i := 0; i <= parities; i++ {
+		damaged[i] = nil
+	}
+	if err := reconstruct(damaged, n); err == nil {
+		t.Errorf("expected error with more missing shards than parities")
+	}
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+/*
+Pinning exempts the chunks of content from garbage collection of the DbStore.
+
+Content is pinned by its root key together with the keys of all its chunks
+(as collected by the chunker, see DPA.Keys). The pin record of the root keeps
+the list of chunk keys so that they can be released when the root is unpinned,
+and every chunk has a counter of the pinned roots it belongs to, since chunks
+may be shared among content.
+
+Chunks do not need to be present when pinned, they are kept once stored.
+*/
+
+const (
+	// key prefixes for leveldb storage
+	kpPin     = 7 // pin counter of a chunk
+	kpPinRoot = 8 // pin record of a root key
+)
+
+// PinInfo describes pinned content
+type PinInfo struct {
+	Root   Key       `json:"root"`
+	Chunks int       `json:"chunks"`
+	Time   time.Time `json:"time"`
+}
+
+// GCStats reports the state of the garbage collection of the DbStore
+type GCStats struct {
+	Entries   uint64    `json:"entries"`   // chunks stored
+	Capacity  uint64    `json:"capacity"`  // chunks stored before collection starts
+	Pinned    uint64    `json:"pinned"`    // chunks exempt from collection
+	Runs      uint64    `json:"runs"`      // collection rounds since startup
+	Collected uint64    `json:"collected"` // chunks removed since startup
+	LastRun   time.Time `json:"lastRun"`
+}
+
+type pinRecord struct {
+	Time uint64
+	Keys []Key
+}
+
+func getPinKey(key Key) []byte {
+	return append([]byte{kpPin}, key...)
+}
+
+func getPinRootKey(root Key) []byte {
+	return append([]byte{kpPinRoot}, root...)
+}
+
+// pinned tells if the chunk is pinned by any root
+func (s *DbStore) pinned(key Key) bool {
+	_, err := s.db.Get(getPinKey(key))
+	return err == nil
+}
+
+// updatePins changes the pin counters of the chunks by delta, a chunk listed
+// several times is counted as many times
+func (s *DbStore) updatePins(batch *leveldb.Batch, keys []Key, delta int) {
+	deltas := make(map[string]int)
+	for _, key := range keys {
+		deltas[string(key)] += delta
+	}
+	for key, d := range deltas {
+		pkey := getPinKey(Key(key))
+		data, _ := s.db.Get(pkey)
+		cnt := int(BytesToU64(data))
+		switch {
+		case cnt == 0 && cnt+d > 0:
+			s.pinCnt++
+		case cnt > 0 && cnt+d <= 0:
+			s.pinCnt--
+		}
+		if cnt += d; cnt > 0 {
+			batch.Put(pkey, U64ToBytes(uint64(cnt)))
+		} else {
+			batch.Delete(pkey)
+		}
+	}
+	batch.Put(keyPinCnt, U64ToBytes(s.pinCnt))
+}
+
+func (s *DbStore) getPinRecord(root Key) (*pinRecord, error) {
+	data, err := s.db.Get(getPinRootKey(root))
+	if err != nil {
+		return nil, err
+	}
+	record := new(pinRecord)
+	if err := rlp.DecodeBytes(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Pin exempts the chunks of the content under root from garbage collection.
+// Pinning a root again replaces its chunks.
+func (s *DbStore) Pin(root Key, keys []Key) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	batch := new(leveldb.Batch)
+	if old, err := s.getPinRecord(root); err == nil {
+		s.updatePins(batch, old.Keys, -1)
+		// the counters are read from the db, so apply the decrements first
+		if err := s.db.Write(batch); err != nil {
+			return err
+		}
+		batch = new(leveldb.Batch)
+	}
+	s.updatePins(batch, keys, 1)
+	data, err := rlp.EncodeToBytes(&pinRecord{uint64(time.Now().Unix()), keys})
+	if err != nil {
+		return err
+	}
+	batch.Put(getPinRootKey(root), data)
+	glog.V(logger.Debug).Infof("[BZZ] DbStore: pinned %v (%d chunks)", root.Log(), len(keys))
+	return s.db.Write(batch)
+}
+
+// Unpin releases the chunks of the content under root, unless they are
+// pinned by other roots too
+func (s *DbStore) Unpin(root Key) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	record, err := s.getPinRecord(root)
+	if err != nil {
+		return fmt.Errorf("%v is not pinned", root)
+	}
+	batch := new(leveldb.Batch)
+	s.updatePins(batch, record.Keys, -1)
+	batch.Delete(getPinRootKey(root))
+	glog.V(logger.Debug).Infof("[BZZ] DbStore: unpinned %v", root.Log())
+	return s.db.Write(batch)
+}
+
+// Pins lists the pinned roots
+func (s *DbStore) Pins() []*PinInfo {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	pins := []*PinInfo{}
+	it := s.db.NewIterator()
+	defer it.Release()
+	prefix := []byte{kpPinRoot}
+	for it.Seek(prefix); it.Valid() && bytes.HasPrefix(it.Key(), prefix); it.Next() {
+		var record pinRecord
+		if err := rlp.DecodeBytes(it.Value(), &record); err != nil {
+			continue
+		}
+		pins = append(pins, &PinInfo{
+			Root:   Key(common.CopyBytes(it.Key()[1:])),
+			Chunks: len(record.Keys),
+			Time:   time.Unix(int64(record.Time), 0),
+		})
+	}
+	return pins
+}
+
+// GCStats reports the number of stored and pinned chunks and the garbage
+// collection rounds run since startup
+func (s *DbStore) GCStats() GCStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.gcStats
+	stats.Entries = s.entryCnt
+	stats.Capacity = s.capacity
+	stats.Pinned = s.pinCnt
+	return stats
+}
@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+/*
+PyramidChunker encodes content into the same tree of chunks as TreeChunker,
+so the root keys of the two are interchangeable, but it builds the tree
+bottom-up while reading the content and does not need to know its size in
+advance.
+
+Chunks are sent to storage as soon as they are complete: a leaf once chunksize
+bytes are read, and a branching node once it has all its children. Only the
+keys of the complete subtrees not yet referenced by a parent are kept, at most
+branches-1 on every level, together with the data of the last incomplete leaf.
+When the stream ends, the incomplete nodes along the right edge of the tree
+are encoded the way TreeChunker would.
+
+The same state can be recovered from the root chunk of existing content by
+walking down its right edge, so appending to content only rewrites the last
+leaf and the branching nodes above it, the rest of the tree is kept as is.
+*/
+
+// ErrAppendEncrypted is returned when appending to encrypted content: the
+// rewritten chunks would be encrypted with the same keystream as the ones they
+// replace, revealing the difference of the two plaintexts
+var ErrAppendEncrypted = errors.New("appending to encrypted content is not supported")
+
+type PyramidChunker struct {
+	*TreeChunker
+}
+
+func NewPyramidChunker(params *ChunkerParams) *PyramidChunker {
+	return &PyramidChunker{NewTreeChunker(params)}
+}
+
+// Split implements Chunker by splitting data as a stream, see StreamSplit
+func (self *PyramidChunker) Split(key Key, data SectionReader, chunkC chan *Chunk, swg *sync.WaitGroup) chan error {
+	errC := make(chan error)
+	go func() {
+		if err := self.StreamSplit(key, io.NewSectionReader(data, 0, data.Size()), chunkC, swg); err != nil {
+			errC <- err
+		}
+		close(errC)
+	}()
+	return errC
+}
+
+// StreamSplit reads data until EOF and sends the chunks to store on chunkC.
+// Once it returns the root hash is copied to key, which, as for TreeChunker,
+// may carry the secret to encrypt the chunks with.
+func (self *PyramidChunker) StreamSplit(key Key, data io.Reader, chunkC chan *Chunk, swg *sync.WaitGroup) error {
+	p, err := self.newPyramid(key, chunkC, swg)
+	if err != nil {
+		return err
+	}
+	if err := p.readFrom(data); err != nil {
+		return err
+	}
+	copy(key, p.finish().key)
+	return nil
+}
+
+// Append extends the content under key with data and returns the key of the
+// result, which is the same as that of splitting the whole content at once.
+// Chunks of the existing content are retrieved on retrieveC as when joining,
+// only the last leaf and the branching nodes on the right edge of the tree
+// are needed (all children of these with erasure coding).
+// Encrypted content can't be appended to, see ErrAppendEncrypted.
+func (self *PyramidChunker) Append(key Key, data io.Reader, retrieveC, chunkC chan *Chunk, swg *sync.WaitGroup) (Key, error) {
+	if int64(len(key)) == 2*self.hashSize {
+		return nil, ErrAppendEncrypted
+	}
+	newKey := make(Key, len(key))
+	copy(newKey, key)
+	p, err := self.newPyramid(newKey, chunkC, swg)
+	if err != nil {
+		return nil, err
+	}
+	reader := self.join(key, retrieveC)
+	root, _ := reader.retrieve(reader.key)
+	if len(root.SData) < 8 {
+		return nil, notFound
+	}
+	p.size = int64(binary.LittleEndian.Uint64(root.SData[0:8]))
+	depth, treeSize := self.depth(p.size)
+	if err := p.load(reader, root, depth, treeSize, 0); err != nil {
+		return nil, err
+	}
+	if err := p.readFrom(data); err != nil {
+		return nil, err
+	}
+	copy(newKey, p.finish().key)
+	return newKey, nil
+}
+
+// depth returns the depth of the tree encoding size bytes and the size of the
+// subtrees under its root, as TreeChunker.Split calculates them
+func (self *PyramidChunker) depth(size int64) (int, int64) {
+	depth := 0
+	treeSize := self.chunkSize
+	for ; treeSize < size; treeSize *= self.branches {
+		depth++
+	}
+	return depth, treeSize / self.branches
+}
+
+// span returns the size of the content covered by a complete subtree
+func (self *PyramidChunker) span(depth int) int64 {
+	span := self.chunkSize
+	for ; depth > 0; depth-- {
+		span *= self.branches
+	}
+	return span
+}
+
+func (self *PyramidChunker) newPyramid(key Key, chunkC chan *Chunk, swg *sync.WaitGroup) (*pyramid, error) {
+	if self.chunkSize <= 0 {
+		panic("chunker must be initialised")
+	}
+	p := &pyramid{
+		chunker: self,
+		chunkC:  chunkC,
+		swg:     swg,
+		leaf:    make([]byte, 0, self.chunkSize),
+	}
+	switch int64(len(key)) {
+	case self.hashSize:
+	case 2 * self.hashSize:
+		p.secret = key[self.hashSize:]
+	default:
+		return nil, fmt.Errorf("root key buffer must be allocated byte slice of length %d or %d", self.hashSize, 2*self.hashSize)
+	}
+	return p, nil
+}
+
+// subtree is the root chunk of a subtree, its data is needed for the parities
+// of its parent
+type subtree struct {
+	key   Key
+	sdata []byte
+}
+
+// pyramid is the state of the content being split
+type pyramid struct {
+	chunker *PyramidChunker
+	secret  []byte
+	chunkC  chan *Chunk
+	swg     *sync.WaitGroup
+	size    int64       // size of the content so far
+	levels  [][]subtree // complete subtrees of every depth without a parent yet
+	leaf    []byte      // data of the last incomplete leaf
+}
+
+// readFrom splits data until EOF, the complete chunks are stored right away
+func (self *pyramid) readFrom(data io.Reader) error {
+	chunkSize := self.chunker.chunkSize
+	for {
+		n, err := data.Read(self.leaf[len(self.leaf):chunkSize])
+		self.leaf = self.leaf[:len(self.leaf)+n]
+		self.size += int64(n)
+		if int64(len(self.leaf)) == chunkSize {
+			offset := self.size - chunkSize
+			self.add(0, offset, self.leafChunk(offset, self.leaf))
+			self.leaf = self.leaf[:0]
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// add records a complete subtree at offset, and once there are branches of
+// them on a level, they are encoded in their parent
+func (self *pyramid) add(depth int, offset int64, st subtree) {
+	for len(self.levels) <= depth {
+		self.levels = append(self.levels, nil)
+	}
+	if self.chunker.parities == 0 {
+		st.sdata = nil
+	}
+	self.levels[depth] = append(self.levels[depth], st)
+	if int64(len(self.levels[depth])) < self.chunker.branches {
+		return
+	}
+	children := self.levels[depth]
+	self.levels[depth] = nil
+	span := self.chunker.span(depth)
+	offset -= (self.chunker.branches - 1) * span
+	self.add(depth+1, offset, self.node(depth+1, offset, self.chunker.branches*span, children))
+}
+
+// complete returns the complete subtree of the given depth at offset
+func (self *pyramid) complete(depth int, offset int64) subtree {
+	span := self.chunker.span(depth)
+	// the subtrees on this level follow the ones encoded on higher levels
+	base := self.size / (span * self.chunker.branches) * (span * self.chunker.branches)
+	return self.levels[depth][(offset-base)/span]
+}
+
+// finish encodes the incomplete subtrees on the right edge and returns the
+// root
+func (self *pyramid) finish() subtree {
+	depth, treeSize := self.chunker.depth(self.size)
+	return self.subtree(depth, treeSize, 0, self.size)
+}
+
+// subtree returns the subtree of size at offset as TreeChunker.split encodes
+// it, complete subtrees are taken as they are and the rest is encoded now
+func (self *pyramid) subtree(depth int, treeSize, offset, size int64) subtree {
+	depth, treeSize = self.chunker.normalise(depth, treeSize, size)
+	if depth == 0 {
+		if size == self.chunker.chunkSize {
+			return self.complete(0, offset)
+		}
+		return self.leafChunk(offset, self.leaf)
+	}
+	if size == treeSize*self.chunker.branches {
+		return self.complete(depth, offset)
+	}
+	var children []subtree
+	for pos := int64(0); pos < size; pos += treeSize {
+		secSize := treeSize
+		if size-pos < treeSize {
+			secSize = size - pos
+		}
+		children = append(children, self.subtree(depth-1, treeSize/self.chunker.branches, offset+pos, secSize))
+	}
+	return self.node(depth, offset, size, children)
+}
+
+// leafChunk encodes and stores a content chunk
+func (self *pyramid) leafChunk(offset int64, data []byte) subtree {
+	sdata := make([]byte, 8+len(data))
+	binary.LittleEndian.PutUint64(sdata[0:8], uint64(len(data)))
+	copy(sdata[8:], data)
+	if self.secret != nil {
+		encryptChunk(self.secret, offset, 0, sdata)
+	}
+	return self.store(sdata, int64(len(data)))
+}
+
+// node encodes and stores a branching chunk and its parity chunks
+func (self *pyramid) node(depth int, offset, size int64, children []subtree) subtree {
+	hashSize := self.chunker.hashSize
+	parities := self.chunker.parities
+	sdata := make([]byte, 8+(int64(len(children))+parities)*hashSize)
+	binary.LittleEndian.PutUint64(sdata[0:8], uint64(size))
+	for i, child := range children {
+		copy(sdata[8+int64(i)*hashSize:], child.key)
+	}
+	if parities > 0 {
+		shards := make([][]byte, len(children))
+		for i, child := range children {
+			shards[i] = child.sdata
+		}
+		for j, parity := range parityChunks(shards, int(parities)) {
+			st := self.store(parity, int64(len(parity)-8))
+			copy(sdata[8+(int64(len(children)+j))*hashSize:], st.key)
+		}
+	}
+	if self.secret != nil {
+		encryptChunk(self.secret, offset, depth, sdata)
+	}
+	return self.store(sdata, size)
+}
+
+func (self *pyramid) store(sdata []byte, size int64) subtree {
+	key := Key(self.chunker.Hash(sdata))
+	if self.chunkC != nil {
+		if self.swg != nil {
+			self.swg.Add(1)
+		}
+		self.chunkC <- &Chunk{
+			Key:   key,
+			SData: sdata,
+			Size:  size,
+			wg:    self.swg,
+		}
+	}
+	return subtree{key, sdata}
+}
+
+// load recovers the state of the pyramid from the subtree under chunk at
+// offset: complete subtrees are recorded and the last incomplete one is
+// loaded recursively down to the last leaf
+func (self *pyramid) load(reader *LazyChunkReader, chunk *Chunk, depth int, treeSize, offset int64) error {
+	size := int64(binary.LittleEndian.Uint64(chunk.SData[0:8]))
+	depth, treeSize = self.chunker.normalise(depth, treeSize, size)
+	if depth == 0 && size == self.chunker.chunkSize || depth > 0 && size == treeSize*self.chunker.branches {
+		self.add(depth, offset, subtree{chunk.Key, chunk.SData})
+		return nil
+	}
+	data := chunk.SData
+	if self.secret != nil {
+		data = decryptChunk(self.secret, offset, depth, data)
+	}
+	if depth == 0 {
+		self.leaf = append(self.leaf[:0], data[8:]...)
+		return nil
+	}
+	hashSize := self.chunker.hashSize
+	children := (size + treeSize - 1) / treeSize
+	if int64(len(data)) < 8+children*hashSize {
+		return fmt.Errorf("invalid chunk %v", chunk.Key.Log())
+	}
+	for j := int64(0); j < children; j++ {
+		key := Key(data[8+j*hashSize : 8+(j+1)*hashSize])
+		complete := j < children-1 || size-j*treeSize == treeSize
+		if complete && self.chunker.parities == 0 {
+			// the data is only needed for the parities
+			self.add(depth-1, offset+j*treeSize, subtree{key: key})
+			continue
+		}
+		child, ok := reader.retrieve(key)
+		if !ok || len(child.SData) < 8 {
+			return fmt.Errorf("chunk %v not found", key.Log())
+		}
+		if err := self.load(reader, child, depth-1, treeSize/self.chunker.branches, offset+j*treeSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
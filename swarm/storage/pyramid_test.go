@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+)
+
+// pyramidTester stores the chunks of the pyramid chunker and serves them to
+// retrieval requests
+type pyramidTester struct {
+	lock      sync.Mutex
+	chunks    map[string][]byte
+	stored    int
+	chunkC    chan *Chunk
+	retrieveC chan *Chunk
+}
+
+func newPyramidTester() *pyramidTester {
+	self := &pyramidTester{
+		chunks:    make(map[string][]byte),
+		chunkC:    make(chan *Chunk),
+		retrieveC: make(chan *Chunk),
+	}
+	go func() {
+		for chunk := range self.chunkC {
+			self.lock.Lock()
+			self.chunks[string(chunk.Key)] = chunk.SData
+			self.stored++
+			self.lock.Unlock()
+			if chunk.wg != nil {
+				chunk.wg.Done()
+			}
+		}
+	}()
+	go func() {
+		for chunk := range self.retrieveC {
+			self.lock.Lock()
+			chunk.SData = self.chunks[string(chunk.Key)]
+			self.lock.Unlock()
+			close(chunk.C)
+		}
+	}()
+	return self
+}
+
+func (self *pyramidTester) close() {
+	close(self.chunkC)
+	close(self.retrieveC)
+}
+
+func testChunkerParams(parities int64) *ChunkerParams {
+	return &ChunkerParams{
+		Branches:     4,
+		Hash:         "SHA256",
+		SplitTimeout: 10,
+		JoinTimeout:  10,
+		Parities:     parities,
+	}
+}
+
+// treeKey returns the key TreeChunker splits data to
+func treeKey(t *testing.T, params *ChunkerParams, data []byte, secret []byte) Key {
+	key := append(make(Key, 32), secret...)
+	chunkC := make(chan *Chunk)
+	go func() {
+		for range chunkC {
+		}
+	}()
+	defer close(chunkC)
+	chunker := NewTreeChunker(params)
+	for err := range chunker.Split(key, NewChunkReaderFromBytes(data), chunkC, nil) {
+		t.Fatalf("tree chunker failed: %v", err)
+	}
+	return key
+}
+
+func (self *pyramidTester) split(t *testing.T, chunker *PyramidChunker, data []byte, secret []byte) Key {
+	key := append(make(Key, 32), secret...)
+	wg := &sync.WaitGroup{}
+	if err := chunker.StreamSplit(key, bytes.NewReader(data), self.chunkC, wg); err != nil {
+		t.Fatalf("split failed: %v", err)
+	}
+	wg.Wait()
+	return key
+}
+
+func TestPyramidChunker(t *testing.T) {
+	// with 4 branches a chunk holds 128 bytes, the sizes are around the
+	// boundaries of subtrees of depth 0 to 3
+	sizes := []int{0, 1, 127, 128, 129, 300, 511, 512, 513, 640, 641, 2047, 2048, 2049, 2176, 2560, 5000, 8191, 8192, 8193, 8320, 10240, 12345}
+	for _, parities := range []int64{0, 2} {
+		for _, secret := range [][]byte{nil, bytes.Repeat([]byte{0x42}, 32)} {
+			params := testChunkerParams(parities)
+			chunker := NewPyramidChunker(params)
+			tester := newPyramidTester()
+			for _, size := range sizes {
+				_, data := testDataReader(size)
+				key := tester.split(t, chunker, data, secret)
+				if want := treeKey(t, params, data, secret); !bytes.Equal(key, want) {
+					t.Fatalf("key mismatch for %d bytes (parities %d, encrypted %v): have %x, want %x", size, parities, secret != nil, key, want)
+				}
+				reader := chunker.Join(key, tester.retrieveC)
+				output := make([]byte, size)
+				if n, err := reader.ReadAt(output, 0); n != size || (err != nil && err != io.EOF) {
+					t.Fatalf("read %d of %d bytes: %v", n, size, err)
+				}
+				if !bytes.Equal(output, data) {
+					t.Fatalf("content mismatch for %d bytes", size)
+				}
+			}
+			tester.close()
+		}
+	}
+}
+
+func TestPyramidAppend(t *testing.T) {
+	splits := [][2]int{{0, 10}, {100, 0}, {100, 28}, {128, 1}, {300, 300}, {512, 128}, {640, 1}, {2047, 1}, {2048, 3000}, {5000, 1}, {8192, 128}, {8193, 4000}}
+	for _, parities := range []int64{0, 2} {
+		params := testChunkerParams(parities)
+		chunker := NewPyramidChunker(params)
+		tester := newPyramidTester()
+		for _, s := range splits {
+			_, data := testDataReader(s[0] + s[1])
+			key := tester.split(t, chunker, data[:s[0]], nil)
+
+			tester.stored = 0
+			wg := &sync.WaitGroup{}
+			newKey, err := chunker.Append(key, bytes.NewReader(data[s[0]:]), tester.retrieveC, tester.chunkC, wg)
+			if err != nil {
+				t.Fatalf("appending %d to %d bytes failed: %v", s[1], s[0], err)
+			}
+			wg.Wait()
+			if want := treeKey(t, params, data, nil); !bytes.Equal(newKey, want) {
+				t.Fatalf("key mismatch appending %d to %d bytes (parities %d): have %x, want %x", s[1], s[0], parities, newKey, want)
+			}
+			// without new leaves only the right edge is rewritten
+			if s[1] == 1 && parities == 0 && tester.stored > 4 {
+				t.Errorf("appending a byte to %d bytes stored %d chunks", s[0], tester.stored)
+			}
+		}
+		tester.close()
+	}
+
+	// content must be found to append to
+	chunker := NewPyramidChunker(testChunkerParams(0))
+	tester := newPyramidTester()
+	defer tester.close()
+	if _, err := chunker.Append(make(Key, 32), bytes.NewReader([]byte{1}), tester.retrieveC, tester.chunkC, nil); err == nil {
+		t.Errorf("expected error appending to missing content")
+	}
+}
+
+// appending twice to the same encrypted root would rewrite its last leaf with
+// the same keystream, encrypted content must be refused
+func TestPyramidAppendEncrypted(t *testing.T) {
+	chunker := NewPyramidChunker(testChunkerParams(0))
+	tester := newPyramidTester()
+	defer tester.close()
+
+	_, data := testDataReader(300)
+	key := tester.split(t, chunker, data, bytes.Repeat([]byte{0x42}, 32))
+	for _, suffix := range []string{"first", "second"} {
+		tester.stored = 0
+		if _, err := chunker.Append(key, bytes.NewReader([]byte(suffix)), tester.retrieveC, tester.chunkC, nil); err != ErrAppendEncrypted {
+			t.Fatalf("appending %q: error mismatch: have %v, want %v", suffix, err, ErrAppendEncrypted)
+		}
+		if tester.stored != 0 {
+			t.Errorf("appending %q stored %d chunks", suffix, tester.stored)
+		}
+	}
+}
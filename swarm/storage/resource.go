@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/*
+Mutable resources are a chunk type to publish changing content without writes
+to the blockchain. A resource is identified by its owner (an ethereum address)
+and a topic, and its content is a series of updates signed by the owner.
+
+Updates are numbered: version 1 is the first, every update increments the
+version by one and records the time it was made. The key of an update is
+derived from the resource and the version alone:
+  key = keccak256(topic || owner || version)
+so readers can look for a given version without any index, and the latest
+one can be found by probing. Unlike content chunks, update chunks are
+validated by their signature: the owner recovered from it has to yield the
+key, so only the owner can publish under it.
+
+The chunk data of an update is
+  size || topic || version || time || data || signature
+where size is the length of data (8 bytes little endian like every chunk),
+version and time (unix seconds) are 8 bytes big endian and the 65 byte
+signature is over the keccak256 hash of everything before it.
+
+The data of an update is limited to the size of a chunk, larger content is
+to be uploaded separately and referred to by its swarm hash.
+*/
+
+const (
+	// MaxResourceDataSize is the maximum length of the data of an update
+	MaxResourceDataSize = 4096
+
+	resourceHeaderSize = 8 + 32 + 8 + 8 // size, topic, version and time
+	signatureSize      = 65
+)
+
+// ResourceUpdate is a signed update of a mutable resource
+type ResourceUpdate struct {
+	Owner     common.Address
+	Topic     common.Hash
+	Version   uint64
+	Time      uint64
+	Data      []byte
+	Signature []byte
+}
+
+// ResourceTopic derives the topic of a resource from a human readable name
+func ResourceTopic(name string) common.Hash {
+	return crypto.Sha3Hash([]byte(name))
+}
+
+// ResourceKey returns the key of an update of the resource
+func ResourceKey(owner common.Address, topic common.Hash, version uint64) Key {
+	var v [8]byte
+	binary.BigEndian.PutUint64(v[:], version)
+	return Key(crypto.Sha3(topic[:], owner[:], v[:]))
+}
+
+// NewResourceUpdate creates an update of the resource of the key owner and
+// signs it
+func NewResourceUpdate(prvkey *ecdsa.PrivateKey, topic common.Hash, version, time uint64, data []byte) (*ResourceUpdate, error) {
+	if version == 0 {
+		return nil, fmt.Errorf("resource versions start at 1")
+	}
+	if len(data) > MaxResourceDataSize {
+		return nil, fmt.Errorf("resource data too large (%d > %d bytes)", len(data), MaxResourceDataSize)
+	}
+	update := &ResourceUpdate{
+		Owner:   crypto.PubkeyToAddress(prvkey.PublicKey),
+		Topic:   topic,
+		Version: version,
+		Time:    time,
+		Data:    data,
+	}
+	sig, err := crypto.Sign(crypto.Sha3(update.unsigned()), prvkey)
+	if err != nil {
+		return nil, err
+	}
+	update.Signature = sig
+	return update, nil
+}
+
+// Key returns the key the update is stored under
+func (self *ResourceUpdate) Key() Key {
+	return ResourceKey(self.Owner, self.Topic, self.Version)
+}
+
+// unsigned returns the chunk data without the signature
+func (self *ResourceUpdate) unsigned() []byte {
+	data := make([]byte, resourceHeaderSize, resourceHeaderSize+len(self.Data)+signatureSize)
+	binary.LittleEndian.PutUint64(data[0:8], uint64(len(self.Data)))
+	copy(data[8:40], self.Topic[:])
+	binary.BigEndian.PutUint64(data[40:48], self.Version)
+	binary.BigEndian.PutUint64(data[48:56], self.Time)
+	return append(data, self.Data...)
+}
+
+// SData returns the chunk data of the update
+func (self *ResourceUpdate) SData() []byte {
+	return append(self.unsigned(), self.Signature...)
+}
+
+// Chunk returns the update as a chunk ready to be stored
+func (self *ResourceUpdate) Chunk() *Chunk {
+	chunk := NewChunk(self.Key(), nil)
+	chunk.SData = self.SData()
+	chunk.Size = int64(len(self.Data))
+	return chunk
+}
+
+// ParseResourceUpdate decodes the chunk data of an update and verifies its
+// signature. If key is not nil, the update must be the one stored under it.
+func ParseResourceUpdate(key Key, sdata []byte) (*ResourceUpdate, error) {
+	if len(sdata) < resourceHeaderSize+signatureSize {
+		return nil, fmt.Errorf("resource update too short (%d bytes)", len(sdata))
+	}
+	size := binary.LittleEndian.Uint64(sdata[0:8])
+	if size > MaxResourceDataSize || uint64(len(sdata)) != resourceHeaderSize+size+signatureSize {
+		return nil, fmt.Errorf("resource update length mismatch")
+	}
+	signed := sdata[:len(sdata)-signatureSize]
+	update := &ResourceUpdate{
+		Topic:     common.BytesToHash(sdata[8:40]),
+		Version:   binary.BigEndian.Uint64(sdata[40:48]),
+		Time:      binary.BigEndian.Uint64(sdata[48:56]),
+		Data:      common.CopyBytes(sdata[resourceHeaderSize:len(signed)]),
+		Signature: common.CopyBytes(sdata[len(signed):]),
+	}
+	pubkey, err := crypto.SigToPub(crypto.Sha3(signed), update.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource update signature: %v", err)
+	}
+	update.Owner = crypto.PubkeyToAddress(*pubkey)
+	if key != nil && !bytes.Equal(update.Key(), key) {
+		return nil, fmt.Errorf("resource update does not match key %v", key.Log())
+	}
+	return update, nil
+}
+
+// ValidChunk tells if the data is valid under key: either its hash is the
+// key or it is an update of a mutable resource signed by the owner
+func ValidChunk(hashfunc Hasher, key Key, sdata []byte) bool {
+	hasher := hashfunc()
+	hasher.Write(sdata)
+	if bytes.Equal(hasher.Sum(nil), key) {
+		return true
+	}
+	_, err := ParseResourceUpdate(key, sdata)
+	return err == nil
+}
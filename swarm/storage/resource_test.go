@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestResourceUpdate(t *testing.T) {
+	prvkey, _ := crypto.GenerateKey()
+	topic := ResourceTopic("news")
+	update, err := NewResourceUpdate(prvkey, topic, 3, 1000, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := crypto.PubkeyToAddress(prvkey.PublicKey)
+	key := ResourceKey(owner, topic, 3)
+	if !bytes.Equal(update.Key(), key) {
+		t.Fatalf("key mismatch: have %v, want %v", update.Key(), key)
+	}
+
+	parsed, err := ParseResourceUpdate(key, update.SData())
+	if err != nil {
+		t.Fatalf("parsing failed: %v", err)
+	}
+	if parsed.Owner != owner || parsed.Topic != topic || parsed.Version != 3 || parsed.Time != 1000 || string(parsed.Data) != "hello" {
+		t.Errorf("parsed update mismatch: %+v", parsed)
+	}
+	if !ValidChunk(MakeHashFunc("SHA3"), key, update.SData()) {
+		t.Errorf("update not valid under its key")
+	}
+
+	// another version or owner is another key
+	if _, err := ParseResourceUpdate(ResourceKey(owner, topic, 4), update.SData()); err == nil {
+		t.Errorf("update accepted under the key of another version")
+	}
+	other, _ := crypto.GenerateKey()
+	forged, _ := NewResourceUpdate(other, topic, 3, 1000, []byte("hello"))
+	if ValidChunk(MakeHashFunc("SHA3"), key, forged.SData()) {
+		t.Errorf("update of another owner accepted")
+	}
+	// tampering breaks the signature
+	sdata := update.SData()
+	sdata[resourceHeaderSize] ^= 1
+	if ValidChunk(MakeHashFunc("SHA3"), key, sdata) {
+		t.Errorf("tampered update accepted")
+	}
+	if ValidChunk(MakeHashFunc("SHA3"), key, sdata[:len(sdata)-1]) {
+		t.Errorf("truncated update accepted")
+	}
+
+	if _, err := NewResourceUpdate(prvkey, topic, 0, 1000, nil); err == nil {
+		t.Errorf("version 0 accepted")
+	}
+	if _, err := NewResourceUpdate(prvkey, topic, 1, 1000, make([]byte, MaxResourceDataSize+1)); err == nil {
+		t.Errorf("oversized data accepted")
+	}
+}
@@ -130,6 +130,7 @@ When calling Join with a root key, the caller gets returned a seekable lazy read
 type Chunker interface {
 	/*
 	   When splitting, data is given as a SectionReader, and the key is a hashSize long byte slice (Key), the root hash of the entire content will fill this once processing finishes.
+	   If the key is twice as long, its second half is the secret with which the chunks are encrypted (see encryption.go).
 	   New chunks to store are coming to caller via the chunk storage channel, which the caller provides.
 	   wg is a Waitgroup (can be nil) that can be used to block until the local storage finishes
 	   The caller gets returned an error channel, if an error is encountered during splitting, it is fed to errC error channel.
@@ -138,6 +139,7 @@ type Chunker interface {
 	Split(key Key, data SectionReader, chunkC chan *Chunk, wg *sync.WaitGroup) chan error
 	/*
 	   Join reconstructs original content based on a root key.
+	   Keys carrying a secret after the root hash refer to encrypted content, which is decrypted transparently.
 	   When joining, the caller gets returned a Lazy SectionReader, which is
 	   seekable and implements on-demand fetching of chunks as and where it is read.
 	   New chunks to retrieve are coming to caller via the Chunk channel, which the caller provides.
@@ -148,7 +150,11 @@ type Chunker interface {
 	   The chunks are not meant to be validated by the chunker when joining. This
 	   is because it is left to the DPA to decide which sources are trusted.
 	*/
-	Join(key Key, chunkC chan *Chunk) SectionReader
+	Join(key Key, chunkC chan *Chunk) LazySectionReader
+
+	// Keys returns the keys of all chunks of the content under key, retrieving
+	// them on chunkC as Join does.
+	Keys(key Key, chunkC chan *Chunk) ([]Key, error)
 
 	// returns the key length
 	KeySize() int64
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/chequebook"
+	"github.com/ethereum/go-ethereum/common/ens"
 	"github.com/ethereum/go-ethereum/common/httpclient"
 	"github.com/ethereum/go-ethereum/common/registrar/ethreg"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -19,6 +21,7 @@ import (
 	"github.com/ethereum/go-ethereum/swarm/api"
 	httpapi "github.com/ethereum/go-ethereum/swarm/api/http"
 	"github.com/ethereum/go-ethereum/swarm/network"
+	bzzswap "github.com/ethereum/go-ethereum/swarm/services/swap"
 	"github.com/ethereum/go-ethereum/swarm/storage"
 )
 
@@ -31,7 +34,8 @@ const (
 type Swarm struct {
 	config   *api.Config            // swarm configuration
 	api      *api.Api               // high level api layer (fs/manifest)
-	dns      api.Resolver           // DNS registrar
+	dns      api.Resolver           // DNS registrar or ENS resolver
+	ens      *ens.ENS               // ENS registry, if configured
 	dbAccess *network.DbAccess      // access to local chunk db iterator and storage counter
 	storage  storage.ChunkStore     // internal access to storage, common interface to cloud storage backends
 	dpa      *storage.DPA           // distributed preimage archive, the local API to the storage with document level storage/retrieval support
@@ -43,7 +47,12 @@ type Swarm struct {
 
 // creates a new swarm service instance
 // implements node.Service
-func NewSwarm(stack *node.ServiceContext, config *api.Config, swapEnabled, syncEnabled bool) (self *Swarm, err error) {
+// if the node runs an Ethereum service, it backs the DNS registrar and the
+// chequebook, otherwise the given backend (e.g. a JSON-IPC client to a remote
+// node) is used for the chequebook and name resolution is disabled
+// if an ENS registry is configured, names are resolved through it on either
+// backend
+func NewSwarm(stack *node.ServiceContext, backend chequebook.Backend, config *api.Config, swapEnabled, syncEnabled bool) (self *Swarm, err error) {
 
 	if bytes.Equal(common.FromHex(config.PublicKey), storage.ZeroKey) {
 		return nil, fmt.Errorf("empty public key")
@@ -54,11 +63,18 @@ func NewSwarm(stack *node.ServiceContext, config *api.Config, swapEnabled, syncE
 
 	var ethereum *eth.Ethereum
 	if err := stack.Service(&ethereum); err != nil {
-		return nil, fmt.Errorf("unable to find Ethereum service: %v", err)
+		ethereum = nil
+	}
+	if ethereum == nil && backend == nil && swapEnabled {
+		return nil, fmt.Errorf("SWAP requires the Ethereum service or an Ethereum API backend")
 	}
 	self = &Swarm{
 		config: config,
-		client: ethereum.HTTPClient(),
+	}
+	if ethereum != nil {
+		self.client = ethereum.HTTPClient()
+	} else {
+		self.client = httpclient.New("")
 	}
 	glog.V(logger.Debug).Infof("[BZZ] Setting up Swarm service components")
 
@@ -102,12 +118,34 @@ func NewSwarm(stack *node.ServiceContext, config *api.Config, swapEnabled, syncE
 	glog.V(logger.Debug).Infof("[BZZ] -> Content Store API")
 
 	// set up high level api
-	backend := api.NewEthApi(ethereum)
-	backend.UpdateState()
-	self.dns = api.NewDNS(ethreg.New(backend))
-	glog.V(logger.Debug).Infof("[BZZ] -> Swarm Domain Registrar")
+	if ethereum != nil {
+		ethapi := api.NewEthApi(ethereum)
+		ethapi.UpdateState()
+		self.dns = api.NewDNS(ethreg.New(ethapi))
+		glog.V(logger.Debug).Infof("[BZZ] -> Swarm Domain Registrar")
+		if backend == nil {
+			backend = ethapi
+		}
+	} else {
+		glog.V(logger.Debug).Infof("[BZZ] -> no Ethereum service: Swarm Domain Registrar disabled")
+	}
+	if config.EnsRoot != (common.Address{}) {
+		contractBackend, ok := backend.(bind.ContractBackend)
+		if !ok {
+			return nil, fmt.Errorf("ENS requires the Ethereum service or an Ethereum API backend")
+		}
+		transactOpts := bind.NewKeyedTransactor(config.Swap.PrivateKey())
+		self.ens, err = ens.NewENS(transactOpts, config.EnsRoot, contractBackend)
+		if err != nil {
+			return nil, err
+		}
+		self.dns = api.NewENSResolver(self.ens)
+		glog.V(logger.Debug).Infof("[BZZ] -> Swarm ENS resolver (registry: %v)", config.EnsRoot.Hex())
+	}
 
+	// without a resolver the api accepts content hashes only
 	self.api = api.NewApi(self.dpa, self.dns)
+	self.api.SetDbStore(lstore.DbStore.(*storage.DbStore))
 	// Manifests for Smart Hosting
 	glog.V(logger.Debug).Infof("[BZZ] -> Web3 virtual server API")
 
@@ -186,6 +224,9 @@ func (self *Swarm) Stop() error {
 		ch.Stop()
 		ch.Save()
 	}
+	if ledger := self.config.Swap.Ledger(); ledger != nil {
+		ledger.Save()
+	}
 	return self.config.Save()
 }
 
@@ -201,18 +242,73 @@ func (self *Swarm) Protocols() []p2p.Protocol {
 // implements node.Service
 // Apis returns the RPC Api descriptors the Swarm implementation offers
 func (self *Swarm) APIs() []rpc.API {
-	return []rpc.API{
+	apis := []rpc.API{
 		// public APIs.
-		rpc.API{Namespace, Version, api.NewStorage(self.api), true},
-		rpc.API{Namespace, Version, self.dns, true},
-		rpc.API{Namespace, Version, &Info{self.config, chequebook.ContractParams}, true},
+		{
+			Namespace: Namespace,
+			Version:   Version,
+			Service:   api.NewStorage(self.api),
+			Public:    true,
+		},
+		{
+			Namespace: Namespace,
+			Version:   Version,
+			Service:   &Info{self.config, chequebook.ContractParams},
+			Public:    true,
+		},
 		// admin APIs
-		rpc.API{Namespace, Version, api.NewFileSystem(self.api), false},
-		rpc.API{Namespace, Version, api.NewControl(self.api, self.hive), false},
+		{
+			Namespace: Namespace,
+			Version:   Version,
+			Service:   api.NewFileSystem(self.api),
+		},
+		{
+			Namespace: Namespace,
+			Version:   Version,
+			Service:   api.NewControl(self.api, self.hive),
+		},
+		{
+			Namespace: Namespace,
+			Version:   Version,
+			Service:   api.NewPinning(self.api),
+		},
 		// rpc.API{Namespace, Version, api.NewAdmin(self), false},
 		// TODO: external apis exposed
-		rpc.API{"chequebook", chequebook.Version, chequebook.NewApi(self.config.Swap.Chequebook), true},
+		{
+			Namespace: "chequebook",
+			Version:   chequebook.Version,
+			Service:   chequebook.NewApi(self.config.Swap.Chequebook),
+			Public:    true,
+		},
+		{
+			Namespace: "swap",
+			Version:   Version,
+			Service:   bzzswap.NewApi(self.config.Swap),
+			Public:    true,
+		},
+	}
+	if self.ens != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "ens",
+			Version:   ens.Version,
+			Service:   ens.NewPublicApi(self.ens),
+			Public:    true,
+		}, rpc.API{
+			// transactions are signed with the swarm account
+			Namespace: "ens",
+			Version:   ens.Version,
+			Service:   ens.NewPrivateApi(self.ens),
+		})
+	}
+	if self.dns != nil {
+		apis = append(apis, rpc.API{
+			Namespace: Namespace,
+			Version:   Version,
+			Service:   self.dns,
+			Public:    true,
+		})
 	}
+	return apis
 }
 
 // Backend interface implemented by eth or JSON-IPC client
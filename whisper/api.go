@@ -17,6 +17,7 @@
 package whisper
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -25,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
 )
 
 // PublicWhisperAPI provides the whisper RPC service.
@@ -113,6 +115,53 @@ func (s *PublicWhisperAPI) NewFilter(args NewFilterArgs) (*rpc.HexNumber, error)
 	return rpc.NewHexNumber(id), nil
 }
 
+// Messages creates a subscription that pushes the messages matching the given
+// criteria to the client as they arrive. If a private key is supplied, it is
+// used to decrypt the messages of this subscription only, without it becoming
+// a node identity.
+func (s *PublicWhisperAPI) Messages(ctx context.Context, args SubscribeArgs) (rpc.Subscription, error) {
+	if s.w == nil {
+		return nil, whisperOffLineErr
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+
+	filter := Filter{
+		To:     crypto.ToECDSAPub(common.FromHex(args.To)),
+		From:   crypto.ToECDSAPub(common.FromHex(args.From)),
+		Topics: NewFilterTopics(args.Topics...),
+	}
+	if len(args.Key) > 0 {
+		key := crypto.ToECDSA(common.FromHex(args.Key))
+		if key == nil {
+			return nil, fmt.Errorf("invalid private key: %s", args.Key)
+		}
+		if len(args.To) > 0 && !bytes.Equal(common.FromHex(args.To), crypto.FromECDSAPub(&key.PublicKey)) {
+			return nil, fmt.Errorf("private key doesn't match recipient: %s", args.To)
+		}
+		filter.Key = key
+	}
+
+	// uninstall the whisper filter when the subscription is unsubscribed/cancelled
+	var id int
+	subscription, err := notifier.NewSubscription(func(string) {
+		s.w.Unwatch(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	filter.Fn = func(message *Message) {
+		if err := subscription.Notify(NewWhisperMessage(message)); err != nil {
+			subscription.Cancel()
+		}
+	}
+	id = s.w.Watch(filter)
+
+	return subscription, nil
+}
+
 // GetFilterChanges retrieves all the new messages matched by a filter since the last retrieval.
 func (s *PublicWhisperAPI) GetFilterChanges(filterId rpc.HexNumber) []WhisperMessage {
 	s.messagesMu.RLock()
@@ -207,12 +256,14 @@ func (s *PublicWhisperAPI) Post(args PostArgs) (bool, error) {
 type WhisperMessage struct {
 	ref *Message
 
-	Payload string `json:"payload"`
-	To      string `json:"to"`
-	From    string `json:"from"`
-	Sent    int64  `json:"sent"`
-	TTL     int64  `json:"ttl"`
-	Hash    string `json:"hash"`
+	Payload string   `json:"payload"`
+	To      string   `json:"to"`
+	From    string   `json:"from"`
+	Topics  []string `json:"topics"`
+	Sent    int64    `json:"sent"`
+	TTL     int64    `json:"ttl"`
+	PoW     int      `json:"pow"`
+	Hash    string   `json:"hash"`
 }
 
 func (args *PostArgs) UnmarshalJSON(data []byte) (err error) {
@@ -325,6 +376,34 @@ func (args *NewFilterArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+// SubscribeArgs represents the criteria of a message subscription.
+type SubscribeArgs struct {
+	NewFilterArgs
+	Key string // Private key to decrypt the messages with (optional)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, invoked to convert a
+// JSON message blob into a SubscribeArgs structure.
+func (args *SubscribeArgs) UnmarshalJSON(b []byte) error {
+	if err := args.NewFilterArgs.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	var obj struct {
+		Key interface{} `json:"key"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	if obj.Key != nil {
+		argstr, ok := obj.Key.(string)
+		if !ok {
+			return fmt.Errorf("key is not a string")
+		}
+		args.Key = argstr
+	}
+	return nil
+}
+
 // whisperFilter is the message cache matching a specific filter, accumulating
 // inbound messages until the are requested by the client.
 type whisperFilter struct {
@@ -400,14 +479,20 @@ func newWhisperFilter(id int, ref *Whisper) *whisperFilter {
 
 // NewWhisperMessage converts an internal message into an API version.
 func NewWhisperMessage(message *Message) WhisperMessage {
+	topics := make([]string, len(message.Topics))
+	for i, topic := range message.Topics {
+		topics[i] = common.ToHex(topic[:])
+	}
 	return WhisperMessage{
 		ref: message,
 
 		Payload: common.ToHex(message.Payload),
 		From:    common.ToHex(crypto.FromECDSAPub(message.Recover())),
 		To:      common.ToHex(crypto.FromECDSAPub(message.To)),
+		Topics:  topics,
 		Sent:    message.Sent.Unix(),
 		TTL:     int64(message.TTL / time.Second),
+		PoW:     message.PoW,
 		Hash:    common.ToHex(message.Hash.Bytes()),
 	}
 }
@@ -0,0 +1,168 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisper
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that messages matching a subscription are pushed to the client as they
+// arrive, decrypted with the subscription's own key.
+func TestMessagesSubscription(t *testing.T) {
+	node := startTestCluster(1)[0]
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("shh", NewPublicWhisperAPI(node)); err != nil {
+		t.Fatalf("failed to register whisper API: %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(rpc.NewJSONCodec(serverConn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	// Subscribe to messages sent to a key unknown to the node
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	topic := NewTopicFromString("subscription topic")
+	request := map[string]interface{}{
+		"id":      1,
+		"method":  "shh_subscribe",
+		"version": "2.0",
+		"params": []interface{}{"messages", map[string]interface{}{
+			"key":    common.ToHex(crypto.FromECDSA(key)),
+			"topics": []string{common.ToHex([]byte("subscription topic"))},
+		}},
+	}
+	if err := out.Encode(request); err != nil {
+		t.Fatalf("failed to send subscription request: %v", err)
+	}
+	var response rpc.JSONSuccessResponse
+	if err := in.Decode(&response); err != nil {
+		t.Fatalf("failed to read subscription response: %v", err)
+	}
+	subid, ok := response.Result.(string)
+	if !ok {
+		t.Fatalf("expected subscription id, got %v", response.Result)
+	}
+	// Send a signed message to the subscription key and wait for the notification
+	sender := node.NewIdentity()
+
+	msg := NewMessage([]byte("subscribed whisper"))
+	envelope, err := msg.Wrap(DefaultPoW, Options{
+		From:   sender,
+		To:     &key.PublicKey,
+		TTL:    DefaultTTL,
+		Topics: []Topic{topic},
+	})
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	if err := node.Send(envelope); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+	var notification struct {
+		Method string `json:"method"`
+		Params struct {
+			Subscription string         `json:"subscription"`
+			Result       WhisperMessage `json:"result"`
+		} `json:"params"`
+	}
+	clientConn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := in.Decode(&notification); err != nil {
+		t.Fatalf("failed to read notification: %v", err)
+	}
+	if notification.Method != "shh_subscription" {
+		t.Errorf("notification method mismatch: have %s, want shh_subscription", notification.Method)
+	}
+	if notification.Params.Subscription != subid {
+		t.Errorf("subscription id mismatch: have %s, want %s", notification.Params.Subscription, subid)
+	}
+	result := notification.Params.Result
+	if want := common.ToHex([]byte("subscribed whisper")); result.Payload != want {
+		t.Errorf("payload mismatch: have %s, want %s", result.Payload, want)
+	}
+	if want := common.ToHex(crypto.FromECDSAPub(&sender.PublicKey)); result.From != want {
+		t.Errorf("sender mismatch: have %s, want %s", result.From, want)
+	}
+	if want := common.ToHex(crypto.FromECDSAPub(&key.PublicKey)); result.To != want {
+		t.Errorf("recipient mismatch: have %s, want %s", result.To, want)
+	}
+	if len(result.Topics) != 1 || result.Topics[0] != common.ToHex(topic[:]) {
+		t.Errorf("topics mismatch: have %v, want [%x]", result.Topics, topic)
+	}
+	if result.TTL != int64(DefaultTTL/time.Second) {
+		t.Errorf("TTL mismatch: have %d, want %d", result.TTL, int64(DefaultTTL/time.Second))
+	}
+	if result.PoW != envelope.PoW() {
+		t.Errorf("PoW mismatch: have %d, want %d", result.PoW, envelope.PoW())
+	}
+}
+
+// Tests that subscriptions with a key not matching the requested recipient are
+// rejected.
+func TestMessagesSubscriptionKeyMismatch(t *testing.T) {
+	api := NewPublicWhisperAPI(New())
+
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	var args SubscribeArgs
+	blob := `{"key": "` + common.ToHex(crypto.FromECDSA(key)) + `", "to": "` + common.ToHex(crypto.FromECDSAPub(&other.PublicKey)) + `"}`
+	if err := json.Unmarshal([]byte(blob), &args); err != nil {
+		t.Fatalf("failed to parse subscription args: %v", err)
+	}
+	if args.Key != common.ToHex(crypto.FromECDSA(key)) {
+		t.Fatalf("key mismatch: have %s, want %x", args.Key, crypto.FromECDSA(key))
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("shh", api); err != nil {
+		t.Fatalf("failed to register whisper API: %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(rpc.NewJSONCodec(serverConn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+
+	request := map[string]interface{}{
+		"id":      1,
+		"method":  "shh_subscribe",
+		"version": "2.0",
+		"params":  []interface{}{"messages", json.RawMessage(blob)},
+	}
+	if err := json.NewEncoder(clientConn).Encode(request); err != nil {
+		t.Fatalf("failed to send subscription request: %v", err)
+	}
+	var response rpc.JSONErrResponse
+	if err := json.NewDecoder(clientConn).Decode(&response); err != nil {
+		t.Fatalf("failed to read subscription response: %v", err)
+	}
+	if response.Error.Message == "" {
+		t.Fatalf("subscription with mismatching key accepted")
+	}
+}
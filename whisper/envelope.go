@@ -75,6 +75,16 @@ func (self *Envelope) Seal(pow time.Duration) {
 	}
 }
 
+// PoW returns the proof of work sealed into the envelope, measured as the number
+// of trailing zero bits of the nonce salted hash maximized by Seal.
+func (self *Envelope) PoW() int {
+	d := make([]byte, 64)
+	copy(d[:32], self.rlpWithoutNonce())
+	binary.BigEndian.PutUint32(d[60:], self.Nonce)
+
+	return common.FirstBitSet(common.BigD(crypto.Keccak256(d)))
+}
+
 // rlpWithoutNonce returns the RLP encoded envelope contents, except the nonce.
 func (self *Envelope) rlpWithoutNonce() []byte {
 	enc, _ := rlp.EncodeToBytes([]interface{}{self.Expiry, self.TTL, self.Topics, self.Data})
@@ -91,6 +101,9 @@ func (self *Envelope) Open(key *ecdsa.PrivateKey) (msg *Message, err error) {
 		Sent:  time.Unix(int64(self.Expiry-self.TTL), 0),
 		TTL:   time.Duration(self.TTL) * time.Second,
 		Hash:  self.Hash(),
+
+		Topics: self.Topics,
+		PoW:    self.PoW(),
 	}
 	data = data[1:]
 
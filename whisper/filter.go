@@ -29,6 +29,7 @@ type Filter struct {
 	To     *ecdsa.PublicKey   // Recipient of the message
 	From   *ecdsa.PublicKey   // Sender of the message
 	Topics [][]Topic          // Topics to filter messages with
+	Key    *ecdsa.PrivateKey  // Private key to open messages with, instead of the node identities (overrides To)
 	Fn     func(msg *Message) // Handler in case of a match
 }
 
@@ -0,0 +1,223 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package mailserver implements a Whisper v5 mail server, archiving envelopes
+// and delivering historic ones to the peers requesting them.
+package mailserver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+const (
+	deliveryBatchSize = 64 // Maximum number of envelopes sent to a peer at once
+	maxPeerDeliveries = 2  // Maximum number of requests of a peer answered at once
+)
+
+// envelopePrefix + time (uint32 big endian) + topic + hash -> envelope
+var envelopePrefix = []byte("e")
+
+// MailServer archives all envelopes passing through a Whisper node into a
+// database, and delivers historic ones to the peers asking for them with a
+// request encrypted with the key derived from the mail server password.
+type MailServer struct {
+	w   *whisperv5.Whisper
+	db  *ethdb.LDBDatabase
+	key []byte  // Symmetric key of the requests, derived from the password
+	pow float64 // Minimum PoW of the requests
+
+	deliveries   map[discover.NodeID]int // Number of requests being answered per peer
+	deliveriesMu sync.Mutex
+}
+
+// New creates a mail server archiving the envelopes of a Whisper node into the
+// given database, and registers it with the node. Requests must be encrypted
+// with the key derived from the password and carry at least the given PoW.
+func New(w *whisperv5.Whisper, db *ethdb.LDBDatabase, password string, pow float64) (*MailServer, error) {
+	if password == "" {
+		return nil, errors.New("mail server password required")
+	}
+	id, err := w.AddSymKeyFromPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	key, err := w.GetSymKey(id)
+	w.DeleteSymKey(id)
+	if err != nil {
+		return nil, err
+	}
+	server := &MailServer{w: w, db: db, key: key, pow: pow, deliveries: make(map[discover.NodeID]int)}
+	w.RegisterServer(server)
+	return server, nil
+}
+
+// Protocols implements node.Service, the mail server runs on top of Whisper.
+func (s *MailServer) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, the mail server offers no RPC services.
+func (s *MailServer) APIs() []rpc.API { return nil }
+
+// Start implements node.Service.
+func (s *MailServer) Start(*p2p.Server) error { return nil }
+
+// Stop implements node.Service, closing the archive database.
+func (s *MailServer) Stop() error {
+	s.db.Close()
+	return nil
+}
+
+// envelopeKey returns the database key of an archived envelope.
+func envelopeKey(sent uint32, topic whisperv5.TopicType, hash common.Hash) []byte {
+	key := make([]byte, 0, len(envelopePrefix)+4+whisperv5.TopicLength+common.HashLength)
+	key = append(key, envelopePrefix...)
+	key = append(key, encodeTime(sent)...)
+	key = append(key, topic[:]...)
+	return append(key, hash[:]...)
+}
+
+// encodeTime encodes a timestamp so that keys sort by time.
+func encodeTime(t uint32) []byte {
+	enc := make([]byte, 4)
+	binary.BigEndian.PutUint32(enc, t)
+	return enc
+}
+
+// Archive implements whisperv5.MailServer, storing an envelope in the database.
+func (s *MailServer) Archive(env *whisperv5.Envelope) {
+	key := envelopeKey(env.Expiry-env.TTL, env.Topic, env.Hash())
+
+	// Envelopes may be seen again after a restart, archive them only once
+	if _, err := s.db.Get(key); err == nil {
+		return
+	}
+	blob, err := rlp.EncodeToBytes(env)
+	if err != nil {
+		glog.V(logger.Error).Infof("failed to encode envelope %x: %v", env.Hash(), err)
+		return
+	}
+	if err := s.db.Put(key, blob); err != nil {
+		glog.V(logger.Error).Infof("failed to archive envelope %x: %v", env.Hash(), err)
+	}
+}
+
+// DeliverMail implements whisperv5.MailServer, validating a request of a peer
+// and sending it the matching archived envelopes in the background. Requests
+// beyond maxPeerDeliveries being answered for the same peer are dropped.
+func (s *MailServer) DeliverMail(peer *whisperv5.Peer, request *whisperv5.Envelope) {
+	id := peer.ID()
+	if pow := request.PoW(); pow < s.pow {
+		glog.V(logger.Debug).Infof("%x: mail request with low PoW %f", id[:8], pow)
+		return
+	}
+	req, _, err := whisperv5.OpenMailRequest(request, s.key)
+	if err != nil {
+		glog.V(logger.Debug).Infof("%x: invalid mail request: %v", id[:8], err)
+		return
+	}
+	if !s.startDelivery(id) {
+		glog.V(logger.Debug).Infof("%x: dropping mail request, too many being answered", id[:8])
+		return
+	}
+	go func() {
+		defer s.endDelivery(id)
+
+		err := s.Envelopes(req, func(batch []*whisperv5.Envelope) error {
+			return s.w.SendP2PDirect(peer, batch...)
+		})
+		if err != nil {
+			glog.V(logger.Debug).Infof("%x: failed to deliver mail: %v", id[:8], err)
+		}
+	}()
+}
+
+// startDelivery reserves a delivery slot for a peer, returning false if all of
+// them are taken.
+func (s *MailServer) startDelivery(id discover.NodeID) bool {
+	s.deliveriesMu.Lock()
+	defer s.deliveriesMu.Unlock()
+
+	if s.deliveries[id] >= maxPeerDeliveries {
+		return false
+	}
+	s.deliveries[id]++
+	return true
+}
+
+// endDelivery releases a delivery slot of a peer.
+func (s *MailServer) endDelivery(id discover.NodeID) {
+	s.deliveriesMu.Lock()
+	defer s.deliveriesMu.Unlock()
+
+	if s.deliveries[id]--; s.deliveries[id] <= 0 {
+		delete(s.deliveries, id)
+	}
+}
+
+// Envelopes iterates over the archived envelopes matching a request in the
+// order they were sent, passing them to fn in batches of at most
+// deliveryBatchSize. The iteration stops at the first error returned by fn.
+func (s *MailServer) Envelopes(req *whisperv5.MailRequest, fn func([]*whisperv5.Envelope) error) error {
+	if req.Lower > req.Upper {
+		return nil
+	}
+	it := s.db.NewIterator()
+	defer it.Release()
+
+	var batch []*whisperv5.Envelope
+	for ok := it.Seek(append(append([]byte{}, envelopePrefix...), encodeTime(req.Lower)...)); ok; ok = it.Next() {
+		key := it.Key()
+		if !bytes.HasPrefix(key, envelopePrefix) || len(key) < len(envelopePrefix)+4 {
+			break
+		}
+		if sent := binary.BigEndian.Uint32(key[len(envelopePrefix):]); sent > req.Upper {
+			break
+		}
+		env := new(whisperv5.Envelope)
+		if err := rlp.DecodeBytes(it.Value(), env); err != nil {
+			glog.V(logger.Error).Infof("corrupt archived envelope %x: %v", key, err)
+			continue
+		}
+		if !req.Match(env) {
+			continue
+		}
+		if batch = append(batch, env); len(batch) == deliveryBatchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package mailserver
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+var (
+	testTopic  = whisperv5.TopicType{0x01, 0x02, 0x03, 0x04}
+	otherTopic = whisperv5.TopicType{0x05, 0x06, 0x07, 0x08}
+	testKey    = bytes.Repeat([]byte{0x42}, 32)
+)
+
+// newTestServer creates a mail server backed by a temporary database, and a
+// function to remove the database after the test.
+func newTestServer(t *testing.T) (*whisperv5.Whisper, *MailServer, func()) {
+	dir, err := ioutil.TempDir("", "mailserver-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to open database: %v", err)
+	}
+	w := whisperv5.New(&whisperv5.Config{})
+	server, err := New(w, db, "password", 0)
+	if err != nil {
+		db.Close()
+		os.RemoveAll(dir)
+		t.Fatalf("failed to create mail server: %v", err)
+	}
+	return w, server, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// collect retrieves all archived envelopes matching a request.
+func collect(t *testing.T, server *MailServer, req *whisperv5.MailRequest) []*whisperv5.Envelope {
+	var envelopes []*whisperv5.Envelope
+	err := server.Envelopes(req, func(batch []*whisperv5.Envelope) error {
+		envelopes = append(envelopes, batch...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate envelopes: %v", err)
+	}
+	return envelopes
+}
+
+// newTestEnvelope creates an envelope sent at the given time on a topic.
+func newTestEnvelope(t *testing.T, sent uint32, topic whisperv5.TopicType, payload string) *whisperv5.Envelope {
+	params := &whisperv5.MessageParams{TTL: 10, KeySym: testKey, Topic: topic, Payload: []byte(payload)}
+	envelope, err := whisperv5.NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	envelope.Expiry = sent + envelope.TTL
+	return envelope
+}
+
+// Tests that archived envelopes are retrieved by time range and topic.
+func TestArchive(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	envelopes := []*whisperv5.Envelope{
+		newTestEnvelope(t, 1000, testTopic, "first"),
+		newTestEnvelope(t, 1000, otherTopic, "other"),
+		newTestEnvelope(t, 1005, testTopic, "second"),
+		newTestEnvelope(t, 2000, testTopic, "third"),
+	}
+	for _, env := range envelopes {
+		server.Archive(env)
+	}
+	server.Archive(envelopes[0]) // duplicates must be ignored
+
+	tests := []struct {
+		lower, upper uint32
+		bloom        []byte
+		want         []int
+	}{
+		{0, 5000, nil, []int{0, 1, 2, 3}},
+		{1000, 1000, nil, []int{0, 1}},
+		{1001, 1999, nil, []int{2}},
+		{0, 5000, whisperv5.TopicToBloom(testTopic), []int{0, 2, 3}},
+		{0, 5000, whisperv5.TopicToBloom(otherTopic), []int{1}},
+		{2001, 5000, nil, nil},
+		{2000, 1000, nil, nil},
+	}
+	for i, tt := range tests {
+		have := collect(t, server, &whisperv5.MailRequest{Lower: tt.lower, Upper: tt.upper, Bloom: tt.bloom})
+		if len(have) != len(tt.want) {
+			t.Errorf("test %d: envelope count mismatch: have %d, want %d", i, len(have), len(tt.want))
+			continue
+		}
+		for j, idx := range tt.want {
+			if have[j].Hash() != envelopes[idx].Hash() {
+				t.Errorf("test %d: envelope %d mismatch: have %x, want %x", i, j, have[j].Hash(), envelopes[idx].Hash())
+			}
+		}
+	}
+}
+
+// Tests that the archive survives restarts of the mail server.
+func TestArchivePersistence(t *testing.T) {
+	w, server, cleanup := newTestServer(t)
+	defer cleanup()
+	env := newTestEnvelope(t, 1000, testTopic, "persistent")
+	server.Archive(env)
+
+	restarted, err := New(w, server.db, "password", 0)
+	if err != nil {
+		t.Fatalf("failed to recreate mail server: %v", err)
+	}
+	have := collect(t, restarted, &whisperv5.MailRequest{Lower: 0, Upper: 5000})
+	if len(have) != 1 || have[0].Hash() != env.Hash() {
+		t.Fatalf("archived envelope lost after restart: have %d envelopes", len(have))
+	}
+}
+
+// Tests that matching envelopes are passed on in batches of limited size, and
+// that the iteration stops when the consumer fails.
+func TestEnvelopeBatches(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	total := 2*deliveryBatchSize + 1
+	for i := 0; i < total; i++ {
+		server.Archive(newTestEnvelope(t, uint32(1000+i), testTopic, "batched"))
+	}
+	var sizes []int
+	err := server.Envelopes(&whisperv5.MailRequest{Lower: 0, Upper: 5000}, func(batch []*whisperv5.Envelope) error {
+		sizes = append(sizes, len(batch))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate envelopes: %v", err)
+	}
+	if want := []int{deliveryBatchSize, deliveryBatchSize, 1}; !reflect.DeepEqual(sizes, want) {
+		t.Fatalf("batch sizes mismatch: have %v, want %v", sizes, want)
+	}
+	calls, failure := 0, errors.New("delivery failed")
+	err = server.Envelopes(&whisperv5.MailRequest{Lower: 0, Upper: 5000}, func(batch []*whisperv5.Envelope) error {
+		calls++
+		return failure
+	})
+	if err != failure || calls != 1 {
+		t.Fatalf("iteration not aborted: have error %v after %d calls", err, calls)
+	}
+}
+
+// Tests that a client can request historic envelopes from a connected mail
+// server, and that they only reach the filters accepting direct delivery.
+func TestDeliverMail(t *testing.T) {
+	server, mail, cleanup := newTestServer(t)
+	defer cleanup()
+	client := whisperv5.New(&whisperv5.Config{})
+
+	// Archive an expired envelope no longer found in any pool
+	sent := uint32(time.Now().Add(-time.Hour).Unix())
+	old := newTestEnvelope(t, sent, testTopic, "historic")
+	mail.Archive(old)
+
+	// Connect the client and the server over a simulated link
+	serverID, clientID := discover.NodeID{1}, discover.NodeID{2}
+	rw1, rw2 := p2p.MsgPipe()
+	defer rw1.Close()
+	defer rw2.Close()
+
+	server.Start(nil)
+	defer server.Stop()
+	client.Start(nil)
+	defer client.Stop()
+
+	go server.Protocols()[0].Run(p2p.NewPeer(clientID, "client", nil), rw1)
+	go client.Protocols()[0].Run(p2p.NewPeer(serverID, "server", nil), rw2)
+
+	direct, err := client.Subscribe(&whisperv5.Filter{KeySym: testKey, AllowP2P: true})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	pooled, _ := client.Subscribe(&whisperv5.Filter{KeySym: testKey})
+
+	key, _ := client.AddSymKeyFromPassword("password")
+	params := &whisperv5.MessageParams{TTL: 10, WorkTime: 1}
+	params.KeySym, _ = client.GetSymKey(key)
+	request, err := whisperv5.NewMailRequestEnvelope(&whisperv5.MailRequest{Lower: sent - 10, Upper: sent + 10}, params)
+	if err != nil {
+		t.Fatalf("failed to create mail request: %v", err)
+	}
+	// Wait for the handshake to complete before requesting
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if err = client.RequestHistoricMessages(serverID, request); err == nil {
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("failed to request historic messages: %v", err)
+		}
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if msgs := client.GetFilter(direct).Retrieve(); len(msgs) > 0 {
+			if len(msgs) != 1 || string(msgs[0].Payload) != "historic" {
+				t.Fatalf("delivered message mismatch: have %d messages", len(msgs))
+			}
+			break
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("historic message not delivered")
+		}
+	}
+	if msgs := client.GetFilter(pooled).Retrieve(); len(msgs) != 0 {
+		t.Fatalf("directly delivered message reached filter without p2p permission")
+	}
+}
+
+// Tests that only a limited number of requests of a peer are answered at once.
+func TestDeliveryLimit(t *testing.T) {
+	_, server, cleanup := newTestServer(t)
+	defer cleanup()
+
+	peer, other := discover.NodeID{1}, discover.NodeID{2}
+	for i := 0; i < maxPeerDeliveries; i++ {
+		if !server.startDelivery(peer) {
+			t.Fatalf("delivery %d refused", i)
+		}
+	}
+	if server.startDelivery(peer) {
+		t.Fatalf("delivery beyond the limit accepted")
+	}
+	if !server.startDelivery(other) {
+		t.Fatalf("delivery to another peer refused")
+	}
+	server.endDelivery(peer)
+	if !server.startDelivery(peer) {
+		t.Fatalf("delivery refused after one finished")
+	}
+	for i := 0; i < maxPeerDeliveries; i++ {
+		server.endDelivery(peer)
+	}
+	server.endDelivery(other)
+	if len(server.deliveries) != 0 {
+		t.Fatalf("delivery slots leaked: %v", server.deliveries)
+	}
+}
@@ -43,6 +43,9 @@ type Message struct {
 
 	To   *ecdsa.PublicKey // Message recipient (identity used to decode the message)
 	Hash common.Hash      // Message envelope hash to act as a unique id
+
+	Topics []Topic // Topics of the envelope the message arrived in
+	PoW    int     // Proof of work sealed into the envelope
 }
 
 // Options specifies the exact way a message should be wrapped into an Envelope.
@@ -66,6 +66,9 @@ type Whisper struct {
 
 	keys map[string]*ecdsa.PrivateKey
 
+	filterKeys map[int]*ecdsa.PrivateKey // Private keys of the filters carrying their own key material
+	filterMu   sync.RWMutex              // Mutex to sync the filter key set
+
 	messages    map[common.Hash]*Envelope // Pool of messages currently tracked by this node
 	expirations map[uint32]*set.SetNonTS  // Message expiration pool (TODO: something lighter)
 	poolMu      sync.RWMutex              // Mutex to sync the message and expiration pools
@@ -82,6 +85,7 @@ func New() *Whisper {
 	whisper := &Whisper{
 		filters:     filter.New(),
 		keys:        make(map[string]*ecdsa.PrivateKey),
+		filterKeys:  make(map[int]*ecdsa.PrivateKey),
 		messages:    make(map[common.Hash]*Envelope),
 		expirations: make(map[uint32]*set.SetNonTS),
 		peers:       make(map[*peer]struct{}),
@@ -146,8 +150,12 @@ func (self *Whisper) GetIdentity(key *ecdsa.PublicKey) *ecdsa.PrivateKey {
 }
 
 // Watch installs a new message handler to run in case a matching packet arrives
-// from the whisper network.
+// from the whisper network. If the filter carries its own private key, messages
+// are opened with it too, without the key becoming a node identity.
 func (self *Whisper) Watch(options Filter) int {
+	if options.Key != nil {
+		options.To = &options.Key.PublicKey
+	}
 	filter := filterer{
 		to:      string(crypto.FromECDSAPub(options.To)),
 		from:    string(crypto.FromECDSAPub(options.From)),
@@ -156,12 +164,23 @@ func (self *Whisper) Watch(options Filter) int {
 			options.Fn(data.(*Message))
 		},
 	}
-	return self.filters.Install(filter)
+	id := self.filters.Install(filter)
+
+	if options.Key != nil {
+		self.filterMu.Lock()
+		self.filterKeys[id] = options.Key
+		self.filterMu.Unlock()
+	}
+	return id
 }
 
 // Unwatch removes an installed message handler.
 func (self *Whisper) Unwatch(id int) {
 	self.filters.Uninstall(id)
+
+	self.filterMu.Lock()
+	delete(self.filterKeys, id)
+	self.filterMu.Unlock()
 }
 
 // Send injects a message into the whisper send queue, to be distributed in the
@@ -287,6 +306,35 @@ func (self *Whisper) postEvent(envelope *Envelope) {
 	if message := self.open(envelope); message != nil {
 		self.filters.Notify(createFilter(message, envelope.Topics), message)
 	}
+	// Try the keys of filters carrying their own key material too
+	for _, key := range self.filterOnlyKeys() {
+		if message, err := envelope.Open(key); err == nil {
+			message.To = &key.PublicKey
+			self.filters.Notify(createFilter(message, envelope.Topics), message)
+		}
+	}
+}
+
+// filterOnlyKeys returns the distinct private keys installed by filters that are
+// not node identities too (those are already tried when opening an envelope).
+func (self *Whisper) filterOnlyKeys() []*ecdsa.PrivateKey {
+	self.filterMu.RLock()
+	defer self.filterMu.RUnlock()
+
+	seen := make(map[string]struct{})
+	keys := make([]*ecdsa.PrivateKey, 0, len(self.filterKeys))
+	for _, key := range self.filterKeys {
+		id := string(crypto.FromECDSAPub(&key.PublicKey))
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if self.keys[id] == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
 // open tries to decrypt a whisper envelope with all the configured identities,
@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 )
@@ -119,6 +120,59 @@ func TestDirectMessage(t *testing.T) {
 	}
 }
 
+func TestFilterKeyMessage(t *testing.T) {
+	// Start the single node cluster and create a key unknown to the node
+	client := startTestCluster(1)[0]
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	// Watch for messages with the filter's own key, signal any arrivals
+	arrived := make(chan *Message, 1)
+	id := client.Watch(Filter{
+		Key: key,
+		Fn: func(msg *Message) {
+			arrived <- msg
+		},
+	})
+	if client.HasIdentity(&key.PublicKey) {
+		t.Fatalf("filter key became a node identity")
+	}
+	// Send a message encrypted to the filter's key
+	msg := NewMessage([]byte("filter key whisper"))
+	envelope, err := msg.Wrap(DefaultPoW, Options{
+		To:     &key.PublicKey,
+		TTL:    DefaultTTL,
+		Topics: NewTopicsFromStrings("filter topic"),
+	})
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	if err := client.Send(envelope); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+	select {
+	case msg := <-arrived:
+		if string(msg.Payload) != "filter key whisper" {
+			t.Fatalf("payload mismatch: have %q, want %q", msg.Payload, "filter key whisper")
+		}
+		if len(msg.Topics) != 1 || msg.Topics[0] != NewTopicFromString("filter topic") {
+			t.Fatalf("topics mismatch: have %v", msg.Topics)
+		}
+		if msg.PoW != envelope.PoW() {
+			t.Fatalf("PoW mismatch: have %d, want %d", msg.PoW, envelope.PoW())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("filter key message receive timeout")
+	}
+	// Uninstall the filter and make sure the key is dropped too
+	client.Unwatch(id)
+	if keys := client.filterOnlyKeys(); len(keys) != 0 {
+		t.Fatalf("filter key retained after unwatch: %d keys", len(keys))
+	}
+}
+
 func TestAnonymousBroadcast(t *testing.T) {
 	testBroadcast(true, t)
 }
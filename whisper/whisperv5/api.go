@@ -0,0 +1,368 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+var ErrFilterNotFound = errors.New("whisper: filter not found")
+
+// PublicWhisperAPI provides the whisper RPC service that can be used publicly
+// without security implications.
+type PublicWhisperAPI struct {
+	w *Whisper
+}
+
+// NewPublicWhisperAPI create a new RPC whisper service.
+func NewPublicWhisperAPI(w *Whisper) *PublicWhisperAPI {
+	return &PublicWhisperAPI{w: w}
+}
+
+// Version returns the Whisper version this node offers.
+func (api *PublicWhisperAPI) Version() string {
+	return ProtocolVersionStr
+}
+
+// Info contains diagnostic information about the whisper node.
+type Info struct {
+	MinPoW         float64 `json:"minPow"`         // Minimum PoW accepted from peers
+	MaxMessageSize uint32  `json:"maxMessageSize"` // Maximum accepted envelope size
+	Messages       int     `json:"messages"`       // Number of pooled envelopes
+	BloomFilter    string  `json:"bloomFilter"`    // Advertised topic bloom filter
+}
+
+// Info returns diagnostic information about the whisper node.
+func (api *PublicWhisperAPI) Info() Info {
+	bloom := api.w.BloomFilter()
+	if bloom == nil {
+		bloom = MakeFullNodeBloom()
+	}
+	return Info{
+		MinPoW:         api.w.MinPoW(),
+		MaxMessageSize: api.w.maxMessageSize,
+		Messages:       len(api.w.Envelopes()),
+		BloomFilter:    common.ToHex(bloom),
+	}
+}
+
+// SetMinPoW sets the minimum PoW of the envelopes accepted from peers.
+func (api *PublicWhisperAPI) SetMinPoW(pow float64) (bool, error) {
+	if err := api.w.SetMinimumPoW(pow); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// NewKeyPair generates a new key pair for asymmetric encryption and signing,
+// returning its id.
+func (api *PublicWhisperAPI) NewKeyPair() (string, error) {
+	return api.w.NewKeyPair()
+}
+
+// AddPrivateKey imports a hex encoded private key, returning the id of the
+// key pair.
+func (api *PublicWhisperAPI) AddPrivateKey(privateKey string) (string, error) {
+	key, err := crypto.HexToECDSA(common.Bytes2Hex(common.FromHex(privateKey)))
+	if err != nil {
+		return "", err
+	}
+	return api.w.AddKeyPair(key)
+}
+
+// HasKeyPair checks if the node holds the key pair with the given id.
+func (api *PublicWhisperAPI) HasKeyPair(id string) bool {
+	return api.w.HasKeyPair(id)
+}
+
+// DeleteKeyPair deletes the key pair with the given id.
+func (api *PublicWhisperAPI) DeleteKeyPair(id string) bool {
+	return api.w.DeleteKeyPair(id)
+}
+
+// GetPublicKey returns the hex encoded public key of the key pair with the
+// given id.
+func (api *PublicWhisperAPI) GetPublicKey(id string) (string, error) {
+	key, err := api.w.GetPrivateKey(id)
+	if err != nil {
+		return "", err
+	}
+	return common.ToHex(crypto.FromECDSAPub(&key.PublicKey)), nil
+}
+
+// NewSymKey generates a random symmetric key, returning its id.
+func (api *PublicWhisperAPI) NewSymKey() (string, error) {
+	return api.w.GenerateSymKey()
+}
+
+// AddSymKey imports a hex encoded symmetric key, returning its id.
+func (api *PublicWhisperAPI) AddSymKey(key string) (string, error) {
+	return api.w.AddSymKeyDirect(common.FromHex(key))
+}
+
+// GenerateSymKeyFromPassword derives a symmetric key from a password shared by
+// the participants of a topic, returning its id.
+func (api *PublicWhisperAPI) GenerateSymKeyFromPassword(password string) (string, error) {
+	return api.w.AddSymKeyFromPassword(password)
+}
+
+// HasSymKey checks if the node holds the symmetric key with the given id.
+func (api *PublicWhisperAPI) HasSymKey(id string) bool {
+	return api.w.HasSymKey(id)
+}
+
+// GetSymKey returns the hex encoded symmetric key with the given id.
+func (api *PublicWhisperAPI) GetSymKey(id string) (string, error) {
+	key, err := api.w.GetSymKey(id)
+	if err != nil {
+		return "", err
+	}
+	return common.ToHex(key), nil
+}
+
+// DeleteSymKey deletes the symmetric key with the given id.
+func (api *PublicWhisperAPI) DeleteSymKey(id string) bool {
+	return api.w.DeleteSymKey(id)
+}
+
+// NewMessage represents a message to be posted into the whisper network.
+// Exactly one of SymKeyID and PublicKey must be set.
+type NewMessage struct {
+	SymKeyID  string  `json:"symKeyID"`  // Id of the symmetric key to encrypt with
+	PublicKey string  `json:"pubKey"`    // Hex encoded public key of the recipient
+	Sig       string  `json:"sig"`       // Id of the key pair to sign with, if any
+	TTL       uint32  `json:"ttl"`       // Time to live in seconds
+	Topic     string  `json:"topic"`     // Hex encoded 4 byte topic
+	Payload   string  `json:"payload"`   // Hex encoded payload
+	PowTime   uint32  `json:"powTime"`   // Maximum time in seconds to spend on the PoW
+	PowTarget float64 `json:"powTarget"` // PoW target of the envelope
+}
+
+// Post signs and encrypts a message, seals it with the requested proof of work
+// and injects it into the whisper network for distribution.
+func (api *PublicWhisperAPI) Post(args NewMessage) (bool, error) {
+	params := &MessageParams{
+		TTL:      args.TTL,
+		Topic:    BytesToTopic(common.FromHex(args.Topic)),
+		Payload:  common.FromHex(args.Payload),
+		WorkTime: args.PowTime,
+		PoW:      args.PowTarget,
+	}
+	if args.Sig != "" {
+		key, err := api.w.GetPrivateKey(args.Sig)
+		if err != nil {
+			return false, fmt.Errorf("unknown signing key pair %s: %v", args.Sig, err)
+		}
+		params.Src = key
+	}
+	switch {
+	case args.SymKeyID != "" && args.PublicKey != "":
+		return false, errors.New("either symKeyID or pubKey must be set, not both")
+	case args.SymKeyID != "":
+		key, err := api.w.GetSymKey(args.SymKeyID)
+		if err != nil {
+			return false, fmt.Errorf("unknown symmetric key %s: %v", args.SymKeyID, err)
+		}
+		params.KeySym = key
+	case args.PublicKey != "":
+		if params.Dst = crypto.ToECDSAPub(common.FromHex(args.PublicKey)); params.Dst == nil {
+			return false, ErrInvalidKey
+		}
+	default:
+		return false, errors.New("either symKeyID or pubKey must be set")
+	}
+	envelope, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		return false, err
+	}
+	if err := api.w.Send(envelope); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Criteria holds the options of a new message filter. Exactly one of SymKeyID
+// and PrivateKeyID must be set.
+type Criteria struct {
+	SymKeyID     string   `json:"symKeyID"`     // Id of the symmetric key to decrypt with
+	PrivateKeyID string   `json:"privateKeyID"` // Id of the key pair to decrypt with
+	Sig          string   `json:"sig"`          // Hex encoded public key of the sender, if any
+	MinPow       float64  `json:"minPow"`       // Minimum PoW of the messages
+	Topics       []string `json:"topics"`       // Hex encoded topics, empty for any
+	AllowP2P     bool     `json:"allowP2P"`     // Whether to accept envelopes delivered by mail servers
+}
+
+// NewMessageFilter creates a filter collecting the inbound messages matching
+// the criteria, returning its id.
+func (api *PublicWhisperAPI) NewMessageFilter(args Criteria) (string, error) {
+	filter := &Filter{PoW: args.MinPow, AllowP2P: args.AllowP2P}
+
+	switch {
+	case args.SymKeyID != "" && args.PrivateKeyID != "":
+		return "", errors.New("either symKeyID or privateKeyID must be set, not both")
+	case args.SymKeyID != "":
+		key, err := api.w.GetSymKey(args.SymKeyID)
+		if err != nil {
+			return "", fmt.Errorf("unknown symmetric key %s: %v", args.SymKeyID, err)
+		}
+		filter.KeySym = key
+	case args.PrivateKeyID != "":
+		key, err := api.w.GetPrivateKey(args.PrivateKeyID)
+		if err != nil {
+			return "", fmt.Errorf("unknown key pair %s: %v", args.PrivateKeyID, err)
+		}
+		filter.KeyAsym = key
+	default:
+		return "", errors.New("either symKeyID or privateKeyID must be set")
+	}
+	if args.Sig != "" {
+		if filter.Src = crypto.ToECDSAPub(common.FromHex(args.Sig)); filter.Src == nil {
+			return "", ErrInvalidKey
+		}
+	}
+	for _, topic := range args.Topics {
+		filter.Topics = append(filter.Topics, BytesToTopic(common.FromHex(topic)))
+	}
+	return api.w.Subscribe(filter)
+}
+
+// GetFilterMessages returns the messages matched by a filter since the last
+// retrieval.
+func (api *PublicWhisperAPI) GetFilterMessages(id string) ([]*WhisperMessage, error) {
+	filter := api.w.GetFilter(id)
+	if filter == nil {
+		return nil, ErrFilterNotFound
+	}
+	return toWhisperMessages(filter.Retrieve()), nil
+}
+
+// GetMessages returns all the pooled messages matching a filter.
+func (api *PublicWhisperAPI) GetMessages(id string) ([]*WhisperMessage, error) {
+	if api.w.GetFilter(id) == nil {
+		return nil, ErrFilterNotFound
+	}
+	return toWhisperMessages(api.w.Messages(id)), nil
+}
+
+// DeleteMessageFilter removes a message filter.
+func (api *PublicWhisperAPI) DeleteMessageFilter(id string) bool {
+	return api.w.Unsubscribe(id)
+}
+
+// MarkTrustedPeer marks a connected peer, given by its enode URL, trusted to
+// deliver envelopes directly.
+func (api *PublicWhisperAPI) MarkTrustedPeer(enode string) (bool, error) {
+	node, err := discover.ParseNode(enode)
+	if err != nil {
+		return false, err
+	}
+	if err := api.w.AllowP2PMessagesFromPeer(node.ID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// HistoricMessagesRequest represents a request for historic envelopes to a mail
+// server peer.
+type HistoricMessagesRequest struct {
+	Peer      string   `json:"peer"`      // Enode URL of the mail server
+	SymKeyID  string   `json:"symKeyID"`  // Id of the symmetric key of the mail server
+	Lower     uint32   `json:"lower"`     // Start of the time range, in seconds since the epoch
+	Upper     uint32   `json:"upper"`     // End of the time range, in seconds since the epoch
+	Topics    []string `json:"topics"`    // Hex encoded topics, empty for all
+	PowTime   uint32   `json:"powTime"`   // Maximum time in seconds to spend on the PoW
+	PowTarget float64  `json:"powTarget"` // PoW target of the request envelope
+}
+
+// RequestHistoricMessages asks a mail server peer for the envelopes sent in a
+// time range on some topics. The envelopes are delivered to the filters allowing
+// direct delivery.
+func (api *PublicWhisperAPI) RequestHistoricMessages(args HistoricMessagesRequest) (bool, error) {
+	node, err := discover.ParseNode(args.Peer)
+	if err != nil {
+		return false, err
+	}
+	key, err := api.w.GetSymKey(args.SymKeyID)
+	if err != nil {
+		return false, fmt.Errorf("unknown symmetric key %s: %v", args.SymKeyID, err)
+	}
+	request := &MailRequest{Lower: args.Lower, Upper: args.Upper}
+	if len(args.Topics) > 0 {
+		topics := make([]TopicType, len(args.Topics))
+		for i, topic := range args.Topics {
+			topics[i] = BytesToTopic(common.FromHex(topic))
+		}
+		request.Bloom = TopicsToBloom(topics)
+	}
+	envelope, err := NewMailRequestEnvelope(request, &MessageParams{
+		KeySym:   key,
+		WorkTime: args.PowTime,
+		PoW:      args.PowTarget,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := api.w.RequestHistoricMessages(node.ID, envelope); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WhisperMessage is the RPC representation of a whisper message.
+type WhisperMessage struct {
+	Sig       string  `json:"sig,omitempty"`
+	TTL       uint32  `json:"ttl"`
+	Timestamp uint32  `json:"timestamp"`
+	Topic     string  `json:"topic"`
+	Payload   string  `json:"payload"`
+	PoW       float64 `json:"pow"`
+	Hash      string  `json:"hash"`
+	Dst       string  `json:"recipientPublicKey,omitempty"`
+}
+
+// NewWhisperMessage converts an internal message into an API version.
+func NewWhisperMessage(message *ReceivedMessage) *WhisperMessage {
+	msg := &WhisperMessage{
+		TTL:       message.TTL,
+		Timestamp: message.Sent,
+		Topic:     common.ToHex(message.Topic[:]),
+		Payload:   common.ToHex(message.Payload),
+		PoW:       message.PoW,
+		Hash:      message.EnvelopeHash.Hex(),
+	}
+	if message.Src != nil {
+		msg.Sig = common.ToHex(crypto.FromECDSAPub(message.Src))
+	}
+	if message.Dst != nil {
+		msg.Dst = common.ToHex(crypto.FromECDSAPub(message.Dst))
+	}
+	return msg
+}
+
+// toWhisperMessages converts a batch of internal messages into API versions.
+func toWhisperMessages(messages []*ReceivedMessage) []*WhisperMessage {
+	msgs := make([]*WhisperMessage, len(messages))
+	for i, msg := range messages {
+		msgs[i] = NewWhisperMessage(msg)
+	}
+	return msgs
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+Package whisperv5 implements version 5 of the Whisper protocol.
+
+Compared to the PoC-1 protocol of the whisper package, version 5 changes three
+aspects of the messaging system:
+
+Messages are either encrypted asymmetrically to the public key of a recipient,
+or symmetrically with a key shared by all the participants of a topic. The
+latter allows groups to communicate without knowing each other's identities.
+
+Every node enforces a minimum proof of work on the envelopes it accepts and
+advertises it to its peers, which in turn don't forward it cheaper envelopes.
+The work needed to reach a target is proportional to the size and lifetime of
+an envelope, making spam expensive without penalizing short messages.
+
+Every node also advertises a bloom filter of the topics it is interested in,
+so that envelopes are only forwarded to the peers that might want them. Nodes
+relaying all traffic advertise a full filter.
+
+Envelopes are only kept in memory until they expire. Nodes may opt in to act as
+mail servers, archiving all envelopes and delivering historic ones directly to
+the peers requesting them, so that nodes which were offline can catch up.
+*/
+package whisperv5
+
+import "time"
+
+const (
+	ProtocolVersion    = uint64(5) // Protocol version number
+	ProtocolVersionStr = "5.0"     // The same, as a string
+	ProtocolName       = "shh"     // Nickname of the protocol in geth
+
+	statusCode           = 0 // Handshake carrying version, PoW requirement and bloom filter
+	messagesCode         = 1 // Batch of envelopes
+	powRequirementCode   = 2 // Update of the minimum PoW accepted by the sender
+	bloomFilterCode      = 3 // Update of the topic bloom filter of the sender
+	p2pRequestCode       = 4 // Request of historic envelopes from a mail server
+	p2pMessageCode       = 5 // Envelopes delivered directly by a trusted peer
+	NumberOfMessageCodes = 6 // Number of message codes reserved by the protocol
+
+	signatureFlag   = byte(1 << 7)
+	signatureLength = 65 // Length of a secp256k1 signature in bytes
+
+	aesKeyLength   = 32 // Length of the symmetric keys in bytes (AES-256)
+	aesNonceLength = 12 // Length of the AES-GCM nonces in bytes
+
+	TopicLength     = 4  // Length of an envelope topic in bytes
+	BloomFilterSize = 64 // Size of a topic bloom filter in bytes
+
+	DefaultMaxMessageSize = uint32(1024 * 1024) // Maximum accepted size of an encoded envelope
+	DefaultMinimumPoW     = 0.2                 // Minimum PoW accepted by default
+	DefaultTTL            = 50                  // Default time to live of messages in seconds
+	DefaultSyncAllowance  = 10                  // Seconds of clock drift tolerated between peers
+
+	expirationCycle   = 800 * time.Millisecond
+	transmissionCycle = 300 * time.Millisecond
+)
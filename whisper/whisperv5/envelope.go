@@ -0,0 +1,212 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the Whisper protocol Envelope element.
+
+package whisperv5
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Envelope represents a clear-text data packet to transmit through the Whisper
+// network. Its contents may or may not be encrypted and signed.
+type Envelope struct {
+	Expiry   uint32
+	TTL      uint32
+	Topic    TopicType
+	AESNonce []byte // Nonce of symmetrically encrypted messages, empty otherwise
+	Data     []byte
+	EnvNonce uint64
+
+	pow  float64     // Cached proof of work of the envelope, zero if not yet calculated
+	hash common.Hash // Cached hash of the envelope to avoid rehashing every time
+}
+
+// NewEnvelope wraps a Whisper message with expiration and destination data
+// included into an envelope for network forwarding.
+func NewEnvelope(ttl uint32, topic TopicType, aesNonce []byte, msg *sentMessage) *Envelope {
+	return &Envelope{
+		Expiry:   uint32(time.Now().Add(time.Second * time.Duration(ttl)).Unix()),
+		TTL:      ttl,
+		Topic:    topic,
+		AESNonce: aesNonce,
+		Data:     msg.Raw,
+	}
+}
+
+// IsSymmetric reports whether the envelope carries a symmetrically encrypted
+// message.
+func (e *Envelope) IsSymmetric() bool {
+	return len(e.AESNonce) > 0
+}
+
+// Seal searches for a nonce reaching the PoW target of the options, giving up
+// after the specified work time. Without a target the envelope is left as is.
+func (e *Envelope) Seal(options *MessageParams) error {
+	if options.PoW <= 0 {
+		return nil
+	}
+	enc := e.rlpWithoutNonce()
+	target := int(math.Ceil(math.Log2(options.PoW * float64(len(enc)) * float64(e.TTL))))
+
+	buf := make([]byte, 64)
+	copy(buf[:32], crypto.Keccak256(enc))
+
+	finish, bestBit := time.Now().Add(time.Duration(options.WorkTime)*time.Second).UnixNano(), -1
+	for nonce := uint64(0); time.Now().UnixNano() < finish; {
+		for i := 0; i < 1024; i++ {
+			binary.BigEndian.PutUint64(buf[56:], nonce)
+			if firstBit := leadingZeroBits(crypto.Keccak256(buf)); firstBit > bestBit {
+				e.EnvNonce, bestBit = nonce, firstBit
+				if bestBit >= target {
+					e.pow, e.hash = 0, common.Hash{}
+					return nil
+				}
+			}
+			nonce++
+		}
+	}
+	return fmt.Errorf("failed to reach the PoW target %f in %d seconds", options.PoW, options.WorkTime)
+}
+
+// PoW calculates the proof of work of the envelope, defined as two to the power
+// of the leading zero bits of the nonce hash, divided by the size of the sealed
+// data and the time to live. Big or long lived envelopes thus need more work to
+// reach the same PoW.
+func (e *Envelope) PoW() float64 {
+	if e.pow == 0 {
+		enc := e.rlpWithoutNonce()
+
+		buf := make([]byte, 64)
+		copy(buf[:32], crypto.Keccak256(enc))
+		binary.BigEndian.PutUint64(buf[56:], e.EnvNonce)
+
+		ttl := e.TTL
+		if ttl == 0 {
+			ttl = 1
+		}
+		e.pow = math.Pow(2, float64(leadingZeroBits(crypto.Keccak256(buf)))) / float64(len(enc)) / float64(ttl)
+	}
+	return e.pow
+}
+
+// leadingZeroBits counts the leading zero bits of a hash.
+func leadingZeroBits(hash []byte) int {
+	for i, b := range hash {
+		if b != 0 {
+			for j := 7; j >= 0; j-- {
+				if b&(1<<uint(j)) != 0 {
+					return i*8 + 7 - j
+				}
+			}
+		}
+	}
+	return len(hash) * 8
+}
+
+// rlpWithoutNonce returns the RLP encoded envelope contents, except the nonce.
+func (e *Envelope) rlpWithoutNonce() []byte {
+	enc, _ := rlp.EncodeToBytes([]interface{}{e.Expiry, e.TTL, e.Topic, e.AESNonce, e.Data})
+	return enc
+}
+
+// Bloom returns the bloom filter of the topic of the envelope.
+func (e *Envelope) Bloom() []byte {
+	return TopicToBloom(e.Topic)
+}
+
+// Hash returns the Keccak256 hash of the envelope, calculating it if not yet
+// done.
+func (e *Envelope) Hash() common.Hash {
+	if (e.hash == common.Hash{}) {
+		enc, _ := rlp.EncodeToBytes(e)
+		e.hash = crypto.Keccak256Hash(enc)
+	}
+	return e.hash
+}
+
+// DecodeRLP decodes an Envelope from an RLP data stream.
+func (e *Envelope) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	// The decoding of Envelope uses the struct fields but also needs
+	// to compute the hash of the whole RLP-encoded envelope. This
+	// type has the same structure as Envelope but is not an
+	// rlp.Decoder so we can reuse the Envelope struct definition.
+	type rlpenv Envelope
+	if err := rlp.DecodeBytes(raw, (*rlpenv)(e)); err != nil {
+		return err
+	}
+	e.hash = crypto.Keccak256Hash(raw)
+	return nil
+}
+
+// OpenAsymmetric tries to decrypt an envelope with the given private key.
+func (e *Envelope) OpenAsymmetric(key *ecdsa.PrivateKey) (*ReceivedMessage, error) {
+	msg := &ReceivedMessage{Raw: e.Data}
+	if err := msg.decryptAsymmetric(key); err != nil {
+		return nil, err
+	}
+	msg.Dst = &key.PublicKey
+	return msg, nil
+}
+
+// OpenSymmetric tries to decrypt an envelope with the given topic key.
+func (e *Envelope) OpenSymmetric(key []byte) (*ReceivedMessage, error) {
+	msg := &ReceivedMessage{Raw: e.Data}
+	if err := msg.decryptSymmetric(key, e.AESNonce); err != nil {
+		return nil, err
+	}
+	msg.SymKeyHash = crypto.Keccak256Hash(key)
+	return msg, nil
+}
+
+// Open tries to decrypt an envelope with the key material of a filter and
+// validates the resulting message, returning nil on failure.
+func (e *Envelope) Open(watcher *Filter) *ReceivedMessage {
+	var (
+		msg *ReceivedMessage
+		err error
+	)
+	switch {
+	case e.IsSymmetric() && watcher.KeySym != nil:
+		msg, err = e.OpenSymmetric(watcher.KeySym)
+	case !e.IsSymmetric() && watcher.KeyAsym != nil:
+		msg, err = e.OpenAsymmetric(watcher.KeyAsym)
+	default:
+		return nil
+	}
+	if err != nil || !msg.Validate() {
+		return nil
+	}
+	msg.Topic = e.Topic
+	msg.PoW = e.PoW()
+	msg.TTL = e.TTL
+	msg.Sent = e.Expiry - e.TTL
+	msg.EnvelopeHash = e.Hash()
+	return msg
+}
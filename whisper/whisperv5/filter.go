@@ -0,0 +1,217 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Filter selects the messages of interest to a client, holding the key material
+// to decrypt them and the messages received so far.
+type Filter struct {
+	Src     *ecdsa.PublicKey  // Sender of the message, nil for any
+	KeyAsym *ecdsa.PrivateKey // Private key of the recipient of asymmetric messages
+	KeySym  []byte            // Key of the topics of symmetric messages
+	Topics  []TopicType       // Topics to filter messages with, empty for any
+	PoW     float64           // Minimum proof of work of the messages
+
+	AllowP2P bool // Whether to accept envelopes delivered directly by trusted peers
+
+	SymKeyHash common.Hash // Keccak256 hash of the symmetric key, to avoid rehashing
+
+	messages map[common.Hash]*ReceivedMessage
+	mutex    sync.RWMutex
+}
+
+// Filters is the set of filters installed in a Whisper node.
+type Filters struct {
+	watchers map[string]*Filter
+	mutex    sync.RWMutex
+}
+
+// NewFilters creates an empty filter set.
+func NewFilters() *Filters {
+	return &Filters{watchers: make(map[string]*Filter)}
+}
+
+// Install adds a filter to the set, returning the id it was registered under.
+// Filters must hold exactly one of a symmetric and an asymmetric key.
+func (fs *Filters) Install(watcher *Filter) (string, error) {
+	if (watcher.KeySym == nil) == (watcher.KeyAsym == nil) {
+		return "", errors.New("filters must have exactly one of a symmetric and an asymmetric key")
+	}
+	if watcher.KeySym != nil {
+		if !validateSymmetricKey(watcher.KeySym) {
+			return "", errors.New("invalid symmetric key")
+		}
+		watcher.SymKeyHash = crypto.Keccak256Hash(watcher.KeySym)
+	}
+	if watcher.messages == nil {
+		watcher.messages = make(map[common.Hash]*ReceivedMessage)
+	}
+	id, err := generateRandomID()
+	if err != nil {
+		return "", err
+	}
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.watchers[id] = watcher
+	return id, nil
+}
+
+// Uninstall removes a filter from the set, reporting whether it was installed.
+func (fs *Filters) Uninstall(id string) bool {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	if fs.watchers[id] != nil {
+		delete(fs.watchers, id)
+		return true
+	}
+	return false
+}
+
+// Get returns the filter installed with the given id, or nil.
+func (fs *Filters) Get(id string) *Filter {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	return fs.watchers[id]
+}
+
+// NotifyWatchers delivers an envelope to all the filters it matches, decrypting
+// it at most once per key. Envelopes delivered directly by trusted peers only
+// reach the filters allowing them.
+func (fs *Filters) NotifyWatchers(env *Envelope, p2pMessage bool) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	var msg *ReceivedMessage
+	for _, watcher := range fs.watchers {
+		if p2pMessage && !watcher.AllowP2P {
+			continue
+		}
+		if !watcher.MatchEnvelope(env) {
+			continue
+		}
+		if msg == nil || !watcher.matchKey(msg) {
+			if msg = env.Open(watcher); msg == nil {
+				continue
+			}
+		}
+		if watcher.MatchMessage(msg) {
+			watcher.Trigger(msg)
+		}
+	}
+}
+
+// bloom returns the aggregated bloom filter of the topics of all installed
+// filters. A filter accepting any topic yields a full node bloom filter.
+func (fs *Filters) bloom() []byte {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+
+	bloom := make([]byte, BloomFilterSize)
+	for _, watcher := range fs.watchers {
+		if len(watcher.Topics) == 0 {
+			return MakeFullNodeBloom()
+		}
+		bloom = addBloom(bloom, TopicsToBloom(watcher.Topics))
+	}
+	return bloom
+}
+
+// Trigger stores a matching message in the filter for later retrieval.
+func (f *Filter) Trigger(msg *ReceivedMessage) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if _, exist := f.messages[msg.EnvelopeHash]; !exist {
+		f.messages[msg.EnvelopeHash] = msg
+	}
+}
+
+// Retrieve returns the messages received since the last retrieval.
+func (f *Filter) Retrieve() []*ReceivedMessage {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	all := make([]*ReceivedMessage, 0, len(f.messages))
+	for _, msg := range f.messages {
+		all = append(all, msg)
+	}
+	f.messages = make(map[common.Hash]*ReceivedMessage)
+	return all
+}
+
+// MatchEnvelope checks the clear-text properties of an envelope against the
+// filter, telling whether it's worth decrypting it.
+func (f *Filter) MatchEnvelope(envelope *Envelope) bool {
+	if f.PoW > 0 && envelope.PoW() < f.PoW {
+		return false
+	}
+	if envelope.IsSymmetric() != (f.KeySym != nil) {
+		return false
+	}
+	return f.MatchTopic(envelope.Topic)
+}
+
+// MatchTopic reports whether a topic is of interest to the filter.
+func (f *Filter) MatchTopic(topic TopicType) bool {
+	if len(f.Topics) == 0 {
+		return true
+	}
+	for _, t := range f.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchMessage checks a decrypted message against the filter.
+func (f *Filter) MatchMessage(msg *ReceivedMessage) bool {
+	if f.PoW > 0 && msg.PoW < f.PoW {
+		return false
+	}
+	if f.Src != nil && !IsPubKeyEqual(msg.Src, f.Src) {
+		return false
+	}
+	return f.matchKey(msg) && f.MatchTopic(msg.Topic)
+}
+
+// matchKey reports whether a message was decrypted with the key of the filter.
+func (f *Filter) matchKey(msg *ReceivedMessage) bool {
+	if f.KeySym != nil {
+		return f.SymKeyHash == msg.SymKeyHash
+	}
+	return IsPubKeyEqual(&f.KeyAsym.PublicKey, msg.Dst)
+}
+
+// IsPubKeyEqual reports whether two public keys are equal.
+func IsPubKeyEqual(a, b *ecdsa.PublicKey) bool {
+	if a == nil || b == nil || a.X == nil || b.X == nil {
+		return false
+	}
+	return a.X.Cmp(b.X) == 0 && a.Y.Cmp(b.Y) == 0
+}
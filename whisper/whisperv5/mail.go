@@ -0,0 +1,99 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// MailServer represents a node archiving envelopes and delivering historic ones
+// to the peers requesting them.
+type MailServer interface {
+	// Archive stores an envelope newly added to the pool.
+	Archive(env *Envelope)
+
+	// DeliverMail answers a request of a peer, sending it the requested
+	// envelopes with SendP2PDirect. It is called on the message loop of the
+	// peer, so it should validate the request right away and deliver the
+	// envelopes in the background.
+	DeliverMail(whisperPeer *Peer, request *Envelope)
+}
+
+// MailRequest is the payload of a request for the historic envelopes sent in a
+// time range and matching a bloom filter.
+type MailRequest struct {
+	Lower uint32 // Start of the time range (inclusive), in seconds since the epoch
+	Upper uint32 // End of the time range (inclusive), in seconds since the epoch
+	Bloom []byte // Bloom filter of the requested topics, empty for all
+}
+
+// NewMailRequestEnvelope wraps a mail request into an envelope as specified by
+// the message parameters, usually encrypted with the key of the mail server.
+func NewMailRequestEnvelope(request *MailRequest, params *MessageParams) (*Envelope, error) {
+	if err := request.validate(); err != nil {
+		return nil, err
+	}
+	payload, err := rlp.EncodeToBytes(request)
+	if err != nil {
+		return nil, err
+	}
+	params.Payload = payload
+	return NewSentMessage(params).Wrap(params)
+}
+
+// OpenMailRequest decrypts a mail request envelope with the given symmetric key,
+// returning the request and the message carrying it.
+func OpenMailRequest(envelope *Envelope, key []byte) (*MailRequest, *ReceivedMessage, error) {
+	if !envelope.IsSymmetric() {
+		return nil, nil, errors.New("mail request not symmetrically encrypted")
+	}
+	msg, err := envelope.OpenSymmetric(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !msg.Validate() {
+		return nil, nil, errors.New("malformed mail request")
+	}
+	request := new(MailRequest)
+	if err := rlp.DecodeBytes(msg.Payload, request); err != nil {
+		return nil, nil, err
+	}
+	if err := request.validate(); err != nil {
+		return nil, nil, err
+	}
+	return request, msg, nil
+}
+
+// validate checks the sanity of the request fields.
+func (r *MailRequest) validate() error {
+	if r.Lower > r.Upper {
+		return fmt.Errorf("invalid time range [%d, %d]", r.Lower, r.Upper)
+	}
+	if len(r.Bloom) != 0 && len(r.Bloom) != BloomFilterSize {
+		return fmt.Errorf("invalid bloom filter size %d", len(r.Bloom))
+	}
+	return nil
+}
+
+// Match reports whether an envelope falls into the requested range and topics.
+func (r *MailRequest) Match(env *Envelope) bool {
+	sent := env.Expiry - env.TTL
+	return r.Lower <= sent && sent <= r.Upper && bloomFilterMatch(r.Bloom, env.Bloom())
+}
@@ -0,0 +1,265 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the Whisper protocol Message element.
+
+package whisperv5
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	mrand "math/rand"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// MessageParams specifies how a message should be wrapped into an envelope.
+// Exactly one of Dst and KeySym must be set.
+type MessageParams struct {
+	TTL      uint32            // Time to live of the message in seconds
+	Src      *ecdsa.PrivateKey // Key to sign the message with, if any
+	Dst      *ecdsa.PublicKey  // Recipient for asymmetric encryption
+	KeySym   []byte            // Key of the topic for symmetric encryption
+	Topic    TopicType         // Topic of the message
+	WorkTime uint32            // Maximum time in seconds to spend on reaching the PoW target
+	PoW      float64           // PoW target of the envelope
+	Payload  []byte            // Application data of the message
+}
+
+// sentMessage represents an end-user data packet to transmit through the
+// Whisper protocol. It is wrapped into an envelope that need not be understood
+// by intermediate nodes, just forwarded.
+type sentMessage struct {
+	Raw []byte
+}
+
+// ReceivedMessage represents a data packet received through the Whisper
+// protocol and successfully decrypted.
+type ReceivedMessage struct {
+	Raw []byte
+
+	Payload   []byte
+	Signature []byte
+
+	PoW   float64          // Proof of work of the envelope, as described in the Whisper spec
+	Sent  uint32           // Time when the message was posted into the network
+	TTL   uint32           // Maximum time to live allowed for the message
+	Src   *ecdsa.PublicKey // Message sender, recovered from the signature if any
+	Dst   *ecdsa.PublicKey // Message recipient, for asymmetrically encrypted messages
+	Topic TopicType
+
+	SymKeyHash   common.Hash // Keccak256 hash of the symmetric key, for symmetrically encrypted messages
+	EnvelopeHash common.Hash // Message envelope hash to act as a unique id
+}
+
+// isMessageSigned reports whether the signature flag is set.
+func isMessageSigned(flags byte) bool {
+	return flags&signatureFlag != 0
+}
+
+// validateSymmetricKey reports whether a key is suitable for AES-256, i.e. of
+// the right length and not all zeroes.
+func validateSymmetricKey(key []byte) bool {
+	if len(key) != aesKeyLength {
+		return false
+	}
+	for _, b := range key {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSentMessage creates and initializes a non-signed, non-encrypted Whisper
+// message carrying the payload of the given parameters.
+func NewSentMessage(params *MessageParams) *sentMessage {
+	// Construct an initial flag set: no signature, rest random
+	flags := byte(mrand.Intn(256)) &^ signatureFlag
+
+	msg := &sentMessage{Raw: make([]byte, 1, 1+len(params.Payload)+signatureLength)}
+	msg.Raw[0] = flags
+	msg.Raw = append(msg.Raw, params.Payload...)
+	return msg
+}
+
+// Wrap signs and encrypts the message as requested by the options, bundles it
+// into an envelope and seals it with the requested proof of work.
+func (msg *sentMessage) Wrap(options *MessageParams) (*Envelope, error) {
+	if options.TTL == 0 {
+		options.TTL = DefaultTTL
+	}
+	if options.Src != nil {
+		if err := msg.sign(options.Src); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		nonce []byte
+		err   error
+	)
+	switch {
+	case options.Dst != nil && options.KeySym != nil:
+		err = errors.New("unable to encrypt the message: both symmetric and asymmetric keys provided")
+	case options.Dst != nil:
+		err = msg.encryptAsymmetric(options.Dst)
+	case options.KeySym != nil:
+		nonce, err = msg.encryptSymmetric(options.KeySym)
+	default:
+		err = errors.New("unable to encrypt the message: neither symmetric nor asymmetric key provided")
+	}
+	if err != nil {
+		return nil, err
+	}
+	envelope := NewEnvelope(options.TTL, options.Topic, nonce, msg)
+	if err := envelope.Seal(options); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+// sign sets the signature flag and appends the signature of the flags and the
+// payload to the message.
+func (msg *sentMessage) sign(key *ecdsa.PrivateKey) error {
+	if isMessageSigned(msg.Raw[0]) {
+		return errors.New("message already signed")
+	}
+	msg.Raw[0] |= signatureFlag
+	signature, err := crypto.Sign(crypto.Keccak256(msg.Raw), key)
+	if err != nil {
+		msg.Raw[0] &^= signatureFlag
+		return err
+	}
+	msg.Raw = append(msg.Raw, signature...)
+	return nil
+}
+
+// encryptAsymmetric encrypts the message with the public key of the recipient.
+func (msg *sentMessage) encryptAsymmetric(key *ecdsa.PublicKey) error {
+	if key.X == nil || key.Y == nil || !secp256k1.S256().IsOnCurve(key.X, key.Y) {
+		return errors.New("invalid public key provided for asymmetric encryption")
+	}
+	encrypted, err := crypto.Encrypt(key, msg.Raw)
+	if err == nil {
+		msg.Raw = encrypted
+	}
+	return err
+}
+
+// encryptSymmetric encrypts the message with AES-GCM using the given key and a
+// random nonce, which is returned to be included in the envelope.
+func (msg *sentMessage) encryptSymmetric(key []byte) ([]byte, error) {
+	if !validateSymmetricKey(key) {
+		return nil, errors.New("invalid key provided for symmetric encryption")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := crand.Read(nonce); err != nil {
+		return nil, err
+	}
+	msg.Raw = aesgcm.Seal(nil, nonce, msg.Raw, nil)
+	return nonce, nil
+}
+
+// decryptSymmetric decrypts a message with the given topic key and nonce.
+func (msg *ReceivedMessage) decryptSymmetric(key []byte, nonce []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	if len(nonce) != aesgcm.NonceSize() {
+		return fmt.Errorf("wrong AES nonce size: have %d, want %d", len(nonce), aesgcm.NonceSize())
+	}
+	decrypted, err := aesgcm.Open(nil, nonce, msg.Raw, nil)
+	if err != nil {
+		return err
+	}
+	msg.Raw = decrypted
+	return nil
+}
+
+// decryptAsymmetric decrypts a message with the private key of the recipient.
+func (msg *ReceivedMessage) decryptAsymmetric(key *ecdsa.PrivateKey) error {
+	decrypted, err := crypto.Decrypt(key, msg.Raw)
+	if err == nil {
+		msg.Raw = decrypted
+	}
+	return err
+}
+
+// Validate splits the decrypted raw data into payload and signature, recovering
+// the sender of signed messages. It reports whether the message is well formed.
+func (msg *ReceivedMessage) Validate() bool {
+	end := len(msg.Raw)
+	if end < 1 {
+		return false
+	}
+	if isMessageSigned(msg.Raw[0]) {
+		end -= signatureLength
+		if end < 1 {
+			return false
+		}
+		msg.Signature = msg.Raw[end:]
+		if msg.Src = msg.SigToPubKey(); msg.Src == nil {
+			return false
+		}
+	}
+	msg.Payload = msg.Raw[1:end]
+	return true
+}
+
+// SigToPubKey retrieves the public key of the message signer, or nil if the
+// message is unsigned or the signature is invalid.
+func (msg *ReceivedMessage) SigToPubKey() *ecdsa.PublicKey {
+	defer func() { recover() }() // in case of invalid signature
+
+	if len(msg.Signature) != signatureLength {
+		return nil
+	}
+	pub, err := crypto.SigToPub(msg.hash(), msg.Signature)
+	if err != nil {
+		glog.V(logger.Detail).Infof("could not get public key from signature: %v", err)
+		return nil
+	}
+	return pub
+}
+
+// hash calculates the hash of the flags and payload signed by the sender.
+func (msg *ReceivedMessage) hash() []byte {
+	if isMessageSigned(msg.Raw[0]) {
+		return crypto.Keccak256(msg.Raw[:len(msg.Raw)-signatureLength])
+	}
+	return crypto.Keccak256(msg.Raw)
+}
@@ -0,0 +1,142 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testSymKey is a valid symmetric key used throughout the tests.
+var testSymKey = bytes.Repeat([]byte{0x42}, aesKeyLength)
+
+// Tests that symmetrically encrypted messages can be opened with the topic key
+// only, and that signatures are verified.
+func TestMessageSymmetric(t *testing.T) {
+	signer, _ := crypto.GenerateKey()
+	params := &MessageParams{
+		TTL:     10,
+		Src:     signer,
+		KeySym:  testSymKey,
+		Topic:   TopicType{0x01, 0x02, 0x03, 0x04},
+		Payload: []byte("hello whisper"),
+	}
+	envelope, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	if !envelope.IsSymmetric() || len(envelope.AESNonce) != aesNonceLength {
+		t.Fatalf("envelope not symmetric: nonce %x", envelope.AESNonce)
+	}
+	if bytes.Contains(envelope.Data, params.Payload) {
+		t.Fatalf("payload not encrypted")
+	}
+	if _, err := envelope.OpenSymmetric(bytes.Repeat([]byte{0x24}, aesKeyLength)); err == nil {
+		t.Fatalf("envelope opened with wrong key")
+	}
+	msg := envelope.Open(&Filter{KeySym: testSymKey})
+	if msg == nil {
+		t.Fatalf("failed to open envelope")
+	}
+	if !bytes.Equal(msg.Payload, params.Payload) {
+		t.Errorf("payload mismatch: have %x, want %x", msg.Payload, params.Payload)
+	}
+	if !IsPubKeyEqual(msg.Src, &signer.PublicKey) {
+		t.Errorf("recovered signer mismatch")
+	}
+	if msg.Topic != params.Topic || msg.TTL != params.TTL {
+		t.Errorf("envelope metadata mismatch: have topic %x ttl %d", msg.Topic, msg.TTL)
+	}
+	if msg.SymKeyHash != crypto.Keccak256Hash(testSymKey) {
+		t.Errorf("symmetric key hash mismatch")
+	}
+}
+
+// Tests that asymmetrically encrypted messages can be opened by the recipient.
+func TestMessageAsymmetric(t *testing.T) {
+	recipient, _ := crypto.GenerateKey()
+	params := &MessageParams{
+		Dst:     &recipient.PublicKey,
+		Payload: []byte("hello whisper"),
+	}
+	envelope, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	if envelope.IsSymmetric() {
+		t.Fatalf("asymmetric envelope marked symmetric")
+	}
+	if envelope.TTL != DefaultTTL {
+		t.Errorf("default TTL mismatch: have %d, want %d", envelope.TTL, DefaultTTL)
+	}
+	other, _ := crypto.GenerateKey()
+	if msg := envelope.Open(&Filter{KeyAsym: other}); msg != nil {
+		t.Fatalf("envelope opened by wrong recipient")
+	}
+	msg := envelope.Open(&Filter{KeyAsym: recipient})
+	if msg == nil {
+		t.Fatalf("failed to open envelope")
+	}
+	if !bytes.Equal(msg.Payload, params.Payload) {
+		t.Errorf("payload mismatch: have %x, want %x", msg.Payload, params.Payload)
+	}
+	if msg.Src != nil || msg.Signature != nil {
+		t.Errorf("unsigned message has sender")
+	}
+	if !IsPubKeyEqual(msg.Dst, &recipient.PublicKey) {
+		t.Errorf("recipient mismatch")
+	}
+}
+
+// Tests that messages without exactly one encryption key are rejected.
+func TestMessageKeyRequired(t *testing.T) {
+	recipient, _ := crypto.GenerateKey()
+	for i, params := range []*MessageParams{
+		{Payload: []byte("plain")},
+		{Dst: &recipient.PublicKey, KeySym: testSymKey},
+		{KeySym: make([]byte, aesKeyLength)},
+	} {
+		if _, err := NewSentMessage(params).Wrap(params); err == nil {
+			t.Errorf("test %d: invalid encryption parameters accepted", i)
+		}
+	}
+}
+
+// Tests that sealing reaches the requested PoW target and survives encoding.
+func TestEnvelopeSeal(t *testing.T) {
+	params := &MessageParams{
+		TTL:      5,
+		KeySym:   testSymKey,
+		Payload:  []byte("proof of work"),
+		WorkTime: 2,
+		PoW:      1,
+	}
+	envelope, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	if pow := envelope.PoW(); pow < params.PoW {
+		t.Fatalf("PoW target missed: have %f, want %f", pow, params.PoW)
+	}
+	// Unreachable targets must fail
+	params.PoW, params.WorkTime = 1e50, 1
+	if _, err := NewSentMessage(params).Wrap(params); err == nil {
+		t.Fatalf("unreachable PoW target reached")
+	}
+}
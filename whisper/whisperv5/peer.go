@@ -0,0 +1,257 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+	"gopkg.in/fatih/set.v0"
+)
+
+// Peer represents a whisper protocol peer connection.
+type Peer struct {
+	host *Whisper
+	peer *p2p.Peer
+	ws   p2p.MsgReadWriter
+
+	powRequirement float64 // Minimum PoW of the envelopes accepted by the peer
+	bloomFilter    []byte  // Topics the peer is interested in, empty for all
+	trusted        bool    // Whether the peer may deliver envelopes directly, e.g. as a mail server
+	settingsMu     sync.RWMutex
+
+	known *set.Set // Messages already known by the peer to avoid wasting bandwidth
+
+	quit chan struct{}
+}
+
+// newPeer creates a new whisper peer object, but does not run the handshake itself.
+func newPeer(host *Whisper, remote *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
+	return &Peer{
+		host:  host,
+		peer:  remote,
+		ws:    rw,
+		known: set.New(),
+		quit:  make(chan struct{}),
+	}
+}
+
+// ID returns the node id of the remote peer.
+func (p *Peer) ID() discover.NodeID {
+	return p.peer.ID()
+}
+
+// setTrusted marks the peer trusted to deliver envelopes directly.
+func (p *Peer) setTrusted() {
+	p.settingsMu.Lock()
+	p.trusted = true
+	p.settingsMu.Unlock()
+}
+
+// isTrusted reports whether the peer may deliver envelopes directly.
+func (p *Peer) isTrusted() bool {
+	p.settingsMu.RLock()
+	defer p.settingsMu.RUnlock()
+
+	return p.trusted
+}
+
+// start initiates the peer updater, periodically broadcasting the whisper packets
+// into the network.
+func (p *Peer) start() {
+	go p.update()
+	glog.V(logger.Debug).Infof("%v: whisper started", p.peer)
+}
+
+// stop terminates the peer updater, stopping message forwarding to it.
+func (p *Peer) stop() {
+	close(p.quit)
+	glog.V(logger.Debug).Infof("%v: whisper stopped", p.peer)
+}
+
+// handshake sends the protocol initiation status message to the remote peer,
+// carrying the local PoW requirement and bloom filter, and verifies the remote
+// status too.
+func (p *Peer) handshake() error {
+	// Send the handshake status message asynchronously
+	errc := make(chan error, 1)
+	go func() {
+		pow, bloom := p.host.MinPoW(), p.host.BloomFilter()
+		errc <- p2p.SendItems(p.ws, statusCode, ProtocolVersion, math.Float64bits(pow), bloom)
+	}()
+	// Fetch the remote status packet and verify protocol match
+	packet, err := p.ws.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if packet.Code != statusCode {
+		return fmt.Errorf("peer sent %x before status packet", packet.Code)
+	}
+	s := rlp.NewStream(packet.Payload, uint64(packet.Size))
+	if _, err := s.List(); err != nil {
+		return fmt.Errorf("bad status message: %v", err)
+	}
+	peerVersion, err := s.Uint()
+	if err != nil {
+		return fmt.Errorf("bad status message: %v", err)
+	}
+	if peerVersion != ProtocolVersion {
+		return fmt.Errorf("protocol version mismatch %d != %d", peerVersion, ProtocolVersion)
+	}
+	powRaw, err := s.Uint()
+	if err != nil {
+		return fmt.Errorf("bad status message: %v", err)
+	}
+	if err := p.setPoWRequirement(powRaw); err != nil {
+		return err
+	}
+	bloom, err := s.Bytes()
+	if err != nil {
+		return fmt.Errorf("bad status message: %v", err)
+	}
+	if err := p.setBloomFilter(bloom); err != nil {
+		return err
+	}
+	// Wait until out own status is consumed too
+	if err := <-errc; err != nil {
+		return fmt.Errorf("failed to send status packet: %v", err)
+	}
+	return nil
+}
+
+// setPoWRequirement updates the minimum PoW accepted by the peer from its
+// wire representation.
+func (p *Peer) setPoWRequirement(raw uint64) error {
+	pow := math.Float64frombits(raw)
+	if math.IsInf(pow, 0) || math.IsNaN(pow) || pow < 0 {
+		return fmt.Errorf("invalid PoW requirement %v", pow)
+	}
+	p.settingsMu.Lock()
+	p.powRequirement = pow
+	p.settingsMu.Unlock()
+	return nil
+}
+
+// setBloomFilter updates the topic bloom filter of the peer.
+func (p *Peer) setBloomFilter(bloom []byte) error {
+	if len(bloom) != 0 && len(bloom) != BloomFilterSize {
+		return fmt.Errorf("invalid bloom filter size %d", len(bloom))
+	}
+	if isFullNode(bloom) {
+		bloom = nil
+	}
+	p.settingsMu.Lock()
+	p.bloomFilter = bloom
+	p.settingsMu.Unlock()
+	return nil
+}
+
+// accepts reports whether the peer is interested in an envelope, i.e. whether
+// it has sufficient PoW and matches the peer's bloom filter.
+func (p *Peer) accepts(envelope *Envelope) bool {
+	p.settingsMu.RLock()
+	defer p.settingsMu.RUnlock()
+
+	return envelope.PoW() >= p.powRequirement && bloomFilterMatch(p.bloomFilter, envelope.Bloom())
+}
+
+// update executes periodic operations on the peer, including message transmission
+// and expiration.
+func (p *Peer) update() {
+	// Start the tickers for the updates
+	expire := time.NewTicker(expirationCycle)
+	transmit := time.NewTicker(transmissionCycle)
+
+	// Loop and transmit until termination is requested
+	for {
+		select {
+		case <-expire.C:
+			p.expire()
+
+		case <-transmit.C:
+			if err := p.broadcast(); err != nil {
+				glog.V(logger.Info).Infof("%v: broadcast failed: %v", p.peer, err)
+				return
+			}
+
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// mark marks an envelope known to the peer so that it won't be sent back.
+func (p *Peer) mark(envelope *Envelope) {
+	p.known.Add(envelope.Hash())
+}
+
+// marked checks if an envelope is already known to the remote peer.
+func (p *Peer) marked(envelope *Envelope) bool {
+	return p.known.Has(envelope.Hash())
+}
+
+// expire iterates over all the known envelopes in the host and removes all
+// expired (unknown) ones from the known list.
+func (p *Peer) expire() {
+	// Assemble the list of available envelopes
+	available := set.NewNonTS()
+	for _, envelope := range p.host.Envelopes() {
+		available.Add(envelope.Hash())
+	}
+	// Cross reference availability with known status
+	unmark := make(map[common.Hash]struct{})
+	p.known.Each(func(v interface{}) bool {
+		if !available.Has(v.(common.Hash)) {
+			unmark[v.(common.Hash)] = struct{}{}
+		}
+		return true
+	})
+	// Dump all known but unavailable
+	for hash := range unmark {
+		p.known.Remove(hash)
+	}
+}
+
+// broadcast iterates over the collection of envelopes and transmits yet unknown
+// ones the peer is interested in over the network.
+func (p *Peer) broadcast() error {
+	// Fetch the envelopes and collect the unknown, interesting ones
+	envelopes := p.host.Envelopes()
+	transmit := make([]*Envelope, 0, len(envelopes))
+	for _, envelope := range envelopes {
+		if !p.marked(envelope) && p.accepts(envelope) {
+			transmit = append(transmit, envelope)
+			p.mark(envelope)
+		}
+	}
+	if len(transmit) == 0 {
+		return nil
+	}
+	if err := p2p.Send(p.ws, messagesCode, transmit); err != nil {
+		return err
+	}
+	glog.V(logger.Detail).Infoln(p.peer, "broadcasted", len(transmit), "message(s)")
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+type testPeer struct {
+	client *Whisper
+	stream *p2p.MsgPipeRW
+	termed chan struct{}
+}
+
+func startTestPeer(config *Config) *testPeer {
+	// Create a simulated P2P remote peer and data streams to it
+	remote := p2p.NewPeer(discover.NodeID{}, "", nil)
+	tester, tested := p2p.MsgPipe()
+
+	// Create a whisper client and connect with it to the tester peer
+	client := New(config)
+	client.Start(nil)
+
+	termed := make(chan struct{})
+	go func() {
+		defer client.Stop()
+		defer close(termed)
+		defer tested.Close()
+
+		client.handlePeer(remote, tested)
+	}()
+
+	return &testPeer{
+		client: client,
+		stream: tester,
+		termed: termed,
+	}
+}
+
+// handshake runs the handshake of the tester, advertising the given settings.
+func (tp *testPeer) handshake(t *testing.T, pow float64, bloom []byte) {
+	if err := p2p.ExpectMsg(tp.stream, statusCode, []interface{}{ProtocolVersion, math.Float64bits(tp.client.MinPoW()), tp.client.BloomFilter()}); err != nil {
+		t.Fatalf("status message mismatch: %v", err)
+	}
+	if err := p2p.SendItems(tp.stream, statusCode, ProtocolVersion, math.Float64bits(pow), bloom); err != nil {
+		t.Fatalf("failed to send status: %v", err)
+	}
+}
+
+func TestPeerHandshake(t *testing.T) {
+	tester := startTestPeer(nil)
+	defer tester.stream.Close()
+
+	tester.handshake(t, 0, nil)
+
+	select {
+	case <-tester.termed:
+		t.Fatalf("valid handshake disconnected")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPeerHandshakeFail(t *testing.T) {
+	tests := []struct {
+		pow   uint64
+		bloom []byte
+	}{
+		{math.Float64bits(math.NaN()), nil},
+		{math.Float64bits(-1), nil},
+		{0, make([]byte, BloomFilterSize-1)},
+	}
+	for i, tt := range tests {
+		tester := startTestPeer(nil)
+		if err := p2p.ExpectMsg(tester.stream, statusCode, []interface{}{ProtocolVersion, math.Float64bits(DefaultMinimumPoW), []byte{}}); err != nil {
+			t.Fatalf("test %d: status message mismatch: %v", i, err)
+		}
+		if err := p2p.SendItems(tester.stream, statusCode, ProtocolVersion, tt.pow, tt.bloom); err != nil {
+			t.Fatalf("test %d: failed to send status: %v", i, err)
+		}
+		select {
+		case <-tester.termed:
+		case <-time.After(time.Second):
+			t.Fatalf("test %d: invalid handshake not disconnected", i)
+		}
+		tester.stream.Close()
+	}
+}
+
+// Tests that envelopes are only forwarded to peers requiring at most their PoW
+// and interested in their topic.
+func TestPeerForwarding(t *testing.T) {
+	tests := []struct {
+		pow   float64
+		bloom []byte
+		sent  bool
+	}{
+		{0, nil, true},
+		{1e10, nil, false},
+		{0, TopicToBloom(TopicType{0x01}), true},
+		{0, TopicToBloom(TopicType{0x02}), false},
+	}
+	for i, tt := range tests {
+		tester := startTestPeer(nil)
+		tester.handshake(t, tt.pow, tt.bloom)
+
+		params := &MessageParams{TTL: 10, KeySym: testSymKey, Topic: TopicType{0x01}, Payload: []byte("forward")}
+		envelope, err := NewSentMessage(params).Wrap(params)
+		if err != nil {
+			t.Fatalf("test %d: failed to wrap message: %v", i, err)
+		}
+		if err := tester.client.Send(envelope); err != nil {
+			t.Fatalf("test %d: failed to send envelope: %v", i, err)
+		}
+		received := make(chan error, 1)
+		go func() {
+			received <- p2p.ExpectMsg(tester.stream, messagesCode, []*Envelope{envelope})
+		}()
+		select {
+		case err := <-received:
+			if !tt.sent {
+				t.Errorf("test %d: envelope forwarded to uninterested peer", i)
+			} else if err != nil {
+				t.Errorf("test %d: forwarded envelope mismatch: %v", i, err)
+			}
+		case <-time.After(3 * transmissionCycle):
+			if tt.sent {
+				t.Errorf("test %d: envelope not forwarded", i)
+			}
+		}
+		tester.stream.Close()
+	}
+}
+
+// Tests that PoW requirement updates of peers are honored.
+func TestPeerPoWRequirementUpdate(t *testing.T) {
+	tester := startTestPeer(nil)
+	defer tester.stream.Close()
+
+	tester.handshake(t, 1e10, nil)
+	if err := p2p.Send(tester.stream, powRequirementCode, math.Float64bits(0)); err != nil {
+		t.Fatalf("failed to send PoW requirement: %v", err)
+	}
+	params := &MessageParams{TTL: 10, KeySym: testSymKey, Payload: []byte("update")}
+	envelope, _ := NewSentMessage(params).Wrap(params)
+	if err := tester.client.Send(envelope); err != nil {
+		t.Fatalf("failed to send envelope: %v", err)
+	}
+	if err := p2p.ExpectMsg(tester.stream, messagesCode, []*Envelope{envelope}); err != nil {
+		t.Fatalf("envelope not forwarded after requirement update: %v", err)
+	}
+	// Local setting changes must be advertised (the pipe blocks until read)
+	errc := make(chan error, 1)
+	go func() { errc <- tester.client.SetMinimumPoW(2) }()
+
+	if err := p2p.ExpectMsg(tester.stream, powRequirementCode, math.Float64bits(2)); err != nil {
+		t.Fatalf("PoW requirement not advertised: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to set minimum PoW: %v", err)
+	}
+}
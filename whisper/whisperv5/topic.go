@@ -0,0 +1,106 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import "github.com/ethereum/go-ethereum/common"
+
+// TopicType represents a cryptographically secure, probabilistic partial
+// classification of a message, determined as the first (left) 4 bytes of the
+// Keccak256 hash of some arbitrary data given by the original author.
+type TopicType [TopicLength]byte
+
+// BytesToTopic converts the first 4 bytes of a byte slice into a topic,
+// zero padding shorter slices on the right.
+func BytesToTopic(b []byte) (t TopicType) {
+	copy(t[:], b)
+	return t
+}
+
+// String converts a topic byte array to a hex string representation.
+func (t *TopicType) String() string {
+	return common.ToHex(t[:])
+}
+
+// TopicToBloom converts a topic into a bloom filter with three of its 512 bits
+// set. The first three bytes of the topic select the bits, and the low bits of
+// the last byte move them into the upper half of the filter.
+func TopicToBloom(topic TopicType) []byte {
+	bloom := make([]byte, BloomFilterSize)
+	for j := 0; j < 3; j++ {
+		index := int(topic[j])
+		if topic[3]&(1<<uint(j)) != 0 {
+			index += 256
+		}
+		bloom[index/8] |= 1 << uint(index%8)
+	}
+	return bloom
+}
+
+// TopicsToBloom aggregates the bloom filters of a set of topics.
+func TopicsToBloom(topics []TopicType) []byte {
+	bloom := make([]byte, BloomFilterSize)
+	for _, topic := range topics {
+		bloom = addBloom(bloom, TopicToBloom(topic))
+	}
+	return bloom
+}
+
+// MakeFullNodeBloom returns a bloom filter matching all topics.
+func MakeFullNodeBloom() []byte {
+	bloom := make([]byte, BloomFilterSize)
+	for i := range bloom {
+		bloom[i] = 0xff
+	}
+	return bloom
+}
+
+// isFullNode reports whether a bloom filter matches all topics. An empty filter
+// is the wire representation of a node interested in everything.
+func isFullNode(bloom []byte) bool {
+	if len(bloom) == 0 {
+		return true
+	}
+	for _, b := range bloom {
+		if b != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomFilterMatch reports whether all the bits set in sample are also set in
+// filter, i.e. whether the topics of sample may be of interest to filter.
+func bloomFilterMatch(filter, sample []byte) bool {
+	if isFullNode(filter) {
+		return true
+	}
+	for i := 0; i < BloomFilterSize; i++ {
+		if filter[i]|sample[i] != filter[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// addBloom returns the union of two bloom filters.
+func addBloom(a, b []byte) []byte {
+	c := make([]byte, BloomFilterSize)
+	for i := 0; i < BloomFilterSize; i++ {
+		c[i] = a[i] | b[i]
+	}
+	return c
+}
@@ -0,0 +1,53 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import "testing"
+
+// Tests that topic bloom filters set exactly three bits and match properly.
+func TestTopicBloom(t *testing.T) {
+	topics := []TopicType{{0x00, 0x00, 0x00, 0x00}, {0xff, 0xfe, 0xfd, 0x07}, {0x8f, 0x9a, 0x2b, 0x7c}}
+	for i, topic := range topics {
+		bloom := TopicToBloom(topic)
+		if len(bloom) != BloomFilterSize {
+			t.Fatalf("test %d: bloom size mismatch: have %d, want %d", i, len(bloom), BloomFilterSize)
+		}
+		bits := 0
+		for _, b := range bloom {
+			for ; b != 0; b &= b - 1 {
+				bits++
+			}
+		}
+		// Topics selecting the same bit twice set fewer bits
+		if bits == 0 || bits > 3 {
+			t.Errorf("test %d: bloom bit count mismatch: have %d", i, bits)
+		}
+		if !bloomFilterMatch(bloom, bloom) {
+			t.Errorf("test %d: bloom doesn't match itself", i)
+		}
+	}
+	filter := TopicsToBloom(topics[1:])
+	if !bloomFilterMatch(filter, TopicToBloom(topics[1])) || !bloomFilterMatch(filter, TopicToBloom(topics[2])) {
+		t.Errorf("aggregated bloom misses its topics")
+	}
+	if bloomFilterMatch(filter, TopicToBloom(topics[0])) {
+		t.Errorf("aggregated bloom matches foreign topic")
+	}
+	if !bloomFilterMatch(nil, TopicToBloom(topics[0])) || !bloomFilterMatch(MakeFullNodeBloom(), TopicToBloom(topics[0])) {
+		t.Errorf("full node bloom doesn't match everything")
+	}
+}
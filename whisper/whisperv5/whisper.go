@@ -0,0 +1,676 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"crypto/ecdsa"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/crypto/pbkdf2"
+	"gopkg.in/fatih/set.v0"
+)
+
+// Config holds the configurable parameters of a Whisper node.
+type Config struct {
+	MaxMessageSize uint32  // Maximum accepted size of an encoded envelope
+	MinimumPoW     float64 // Minimum PoW of the envelopes accepted from peers
+
+	// TopicFiltering restricts the envelopes requested from peers to the topics
+	// of the installed filters, instead of relaying all traffic.
+	TopicFiltering bool
+}
+
+// DefaultConfig is the configuration of a relaying Whisper node.
+var DefaultConfig = Config{
+	MaxMessageSize: DefaultMaxMessageSize,
+	MinimumPoW:     DefaultMinimumPoW,
+}
+
+var (
+	ErrKeyNotFound = errors.New("whisper: key not found")
+	ErrInvalidKey  = errors.New("whisper: invalid key")
+)
+
+// Whisper represents a dark communication interface through the Ethereum
+// network, using its very own P2P communication layer.
+type Whisper struct {
+	protocol p2p.Protocol
+	filters  *Filters
+
+	privateKeys map[string]*ecdsa.PrivateKey // Key pairs for asymmetric decryption and signing
+	symKeys     map[string][]byte            // Keys for symmetric encryption
+	keyMu       sync.RWMutex                 // Mutex to sync the key stores
+
+	envelopes   map[common.Hash]*Envelope // Pool of envelopes currently tracked by this node
+	expirations map[uint32]*set.SetNonTS  // Message expiration pool
+	poolMu      sync.RWMutex              // Mutex to sync the message and expiration pools
+
+	peers  map[*Peer]struct{} // Set of currently active peers
+	peerMu sync.RWMutex       // Mutex to sync the active peer set
+
+	mailServer MailServer // Archive of the envelopes, if acting as a mail server
+
+	maxMessageSize uint32       // Maximum accepted size of an encoded envelope
+	minPoW         float64      // Minimum PoW of the envelopes accepted from peers
+	topicFiltering bool         // Whether the bloom filter follows the installed filters
+	bloom          []byte       // Topics this node is interested in, nil for all
+	settingsMu     sync.RWMutex // Mutex to sync the settings above
+
+	quit chan struct{}
+}
+
+// New creates a Whisper client ready to communicate through the Ethereum P2P
+// network, using the default configuration if none is given.
+func New(config *Config) *Whisper {
+	if config == nil {
+		config = &DefaultConfig
+	}
+	whisper := &Whisper{
+		filters:        NewFilters(),
+		privateKeys:    make(map[string]*ecdsa.PrivateKey),
+		symKeys:        make(map[string][]byte),
+		envelopes:      make(map[common.Hash]*Envelope),
+		expirations:    make(map[uint32]*set.SetNonTS),
+		peers:          make(map[*Peer]struct{}),
+		maxMessageSize: config.MaxMessageSize,
+		minPoW:         config.MinimumPoW,
+		topicFiltering: config.TopicFiltering,
+		quit:           make(chan struct{}),
+	}
+	if whisper.maxMessageSize == 0 {
+		whisper.maxMessageSize = DefaultMaxMessageSize
+	}
+	if whisper.topicFiltering {
+		whisper.bloom = whisper.filters.bloom()
+	}
+	// p2p whisper sub protocol handler
+	whisper.protocol = p2p.Protocol{
+		Name:    ProtocolName,
+		Version: uint(ProtocolVersion),
+		Length:  NumberOfMessageCodes,
+		Run:     whisper.handlePeer,
+	}
+	return whisper
+}
+
+// APIs returns the RPC descriptors the Whisper implementation offers.
+func (w *Whisper) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: ProtocolName,
+			Version:   ProtocolVersionStr,
+			Service:   NewPublicWhisperAPI(w),
+			Public:    true,
+		},
+	}
+}
+
+// Protocols returns the whisper sub-protocols ran by this particular client.
+func (w *Whisper) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{w.protocol}
+}
+
+// Version returns the whisper sub-protocols version number.
+func (w *Whisper) Version() uint {
+	return w.protocol.Version
+}
+
+// MinPoW returns the minimum PoW of the envelopes accepted from peers.
+func (w *Whisper) MinPoW() float64 {
+	w.settingsMu.RLock()
+	defer w.settingsMu.RUnlock()
+
+	return w.minPoW
+}
+
+// SetMinimumPoW sets the minimum PoW of the envelopes accepted from peers and
+// advertises it to all of them.
+func (w *Whisper) SetMinimumPoW(pow float64) error {
+	if math.IsInf(pow, 0) || math.IsNaN(pow) || pow < 0 {
+		return fmt.Errorf("invalid PoW: %f", pow)
+	}
+	w.settingsMu.Lock()
+	w.minPoW = pow
+	w.settingsMu.Unlock()
+
+	w.notifyPeers(powRequirementCode, math.Float64bits(pow))
+	return nil
+}
+
+// BloomFilter returns the bloom filter of the topics this node is interested
+// in, or nil if it accepts all topics.
+func (w *Whisper) BloomFilter() []byte {
+	w.settingsMu.RLock()
+	defer w.settingsMu.RUnlock()
+
+	return w.bloom
+}
+
+// updateBloomFilter recalculates the bloom filter from the installed filters
+// if topic filtering is enabled, advertising any change to all peers.
+func (w *Whisper) updateBloomFilter() {
+	if !w.topicFiltering {
+		return
+	}
+	bloom := w.filters.bloom()
+	if isFullNode(bloom) {
+		bloom = nil
+	}
+	w.settingsMu.Lock()
+	changed := string(bloom) != string(w.bloom)
+	w.bloom = bloom
+	w.settingsMu.Unlock()
+
+	if changed {
+		w.notifyPeers(bloomFilterCode, bloom)
+	}
+}
+
+// notifyPeers sends a settings update message to all connected peers.
+func (w *Whisper) notifyPeers(code uint64, data interface{}) {
+	w.peerMu.RLock()
+	defer w.peerMu.RUnlock()
+
+	for p := range w.peers {
+		if err := p2p.Send(p.ws, code, data); err != nil {
+			glog.V(logger.Debug).Infof("%v: failed to send settings update: %v", p.peer, err)
+		}
+	}
+}
+
+// generateRandomID generates a random string to identify keys and filters.
+func generateRandomID() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := crand.Read(buf); err != nil {
+		return "", err
+	}
+	return common.Bytes2Hex(buf), nil
+}
+
+// NewKeyPair generates a new cryptographic identity for the client, and injects
+// it into the known identities for message decryption. It returns the id of the
+// key pair.
+func (w *Whisper) NewKeyPair() (string, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return "", err
+	}
+	return w.AddKeyPair(key)
+}
+
+// AddKeyPair imports an existing private key, returning the id of the key pair.
+func (w *Whisper) AddKeyPair(key *ecdsa.PrivateKey) (string, error) {
+	id, err := generateRandomID()
+	if err != nil {
+		return "", err
+	}
+	w.keyMu.Lock()
+	defer w.keyMu.Unlock()
+
+	w.privateKeys[id] = key
+	return id, nil
+}
+
+// DeleteKeyPair deletes the key pair with the given id, reporting whether it
+// existed.
+func (w *Whisper) DeleteKeyPair(id string) bool {
+	w.keyMu.Lock()
+	defer w.keyMu.Unlock()
+
+	if w.privateKeys[id] != nil {
+		delete(w.privateKeys, id)
+		return true
+	}
+	return false
+}
+
+// HasKeyPair checks if the node holds the key pair with the given id.
+func (w *Whisper) HasKeyPair(id string) bool {
+	w.keyMu.RLock()
+	defer w.keyMu.RUnlock()
+
+	return w.privateKeys[id] != nil
+}
+
+// GetPrivateKey retrieves the private key of the key pair with the given id.
+func (w *Whisper) GetPrivateKey(id string) (*ecdsa.PrivateKey, error) {
+	w.keyMu.RLock()
+	defer w.keyMu.RUnlock()
+
+	key := w.privateKeys[id]
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// GenerateSymKey generates a random symmetric key, returning its id.
+func (w *Whisper) GenerateSymKey() (string, error) {
+	key := make([]byte, aesKeyLength)
+	if _, err := crand.Read(key); err != nil {
+		return "", err
+	}
+	return w.AddSymKeyDirect(key)
+}
+
+// AddSymKeyDirect stores a symmetric key, returning its id.
+func (w *Whisper) AddSymKeyDirect(key []byte) (string, error) {
+	if !validateSymmetricKey(key) {
+		return "", ErrInvalidKey
+	}
+	id, err := generateRandomID()
+	if err != nil {
+		return "", err
+	}
+	w.keyMu.Lock()
+	defer w.keyMu.Unlock()
+
+	w.symKeys[id] = common.CopyBytes(key)
+	return id, nil
+}
+
+// AddSymKeyFromPassword derives a symmetric key from a password shared by the
+// participants of a topic and stores it, returning its id.
+func (w *Whisper) AddSymKeyFromPassword(password string) (string, error) {
+	// The salt must be known to all participants, so a fixed one is used. This
+	// only costs the protection against rainbow tables of the low entropy input.
+	key := pbkdf2.Key([]byte(password), nil, 65356, aesKeyLength, sha256.New)
+	return w.AddSymKeyDirect(key)
+}
+
+// HasSymKey checks if the node holds the symmetric key with the given id.
+func (w *Whisper) HasSymKey(id string) bool {
+	w.keyMu.RLock()
+	defer w.keyMu.RUnlock()
+
+	return w.symKeys[id] != nil
+}
+
+// DeleteSymKey deletes the symmetric key with the given id, reporting whether
+// it existed.
+func (w *Whisper) DeleteSymKey(id string) bool {
+	w.keyMu.Lock()
+	defer w.keyMu.Unlock()
+
+	if w.symKeys[id] != nil {
+		delete(w.symKeys, id)
+		return true
+	}
+	return false
+}
+
+// GetSymKey retrieves the symmetric key with the given id.
+func (w *Whisper) GetSymKey(id string) ([]byte, error) {
+	w.keyMu.RLock()
+	defer w.keyMu.RUnlock()
+
+	key := w.symKeys[id]
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// Subscribe installs a new message filter, returning its id.
+func (w *Whisper) Subscribe(f *Filter) (string, error) {
+	id, err := w.filters.Install(f)
+	if err != nil {
+		return "", err
+	}
+	w.updateBloomFilter()
+	return id, nil
+}
+
+// GetFilter returns the filter installed with the given id, or nil.
+func (w *Whisper) GetFilter(id string) *Filter {
+	return w.filters.Get(id)
+}
+
+// Unsubscribe removes an installed message filter, reporting whether it existed.
+func (w *Whisper) Unsubscribe(id string) bool {
+	if !w.filters.Uninstall(id) {
+		return false
+	}
+	w.updateBloomFilter()
+	return true
+}
+
+// RegisterServer makes the node act as a mail server, archiving all envelopes
+// added to the pool and answering the requests of peers for historic ones. It
+// must be called before the node is started.
+func (w *Whisper) RegisterServer(server MailServer) {
+	w.mailServer = server
+}
+
+// getPeer retrieves the connected peer with the given node id.
+func (w *Whisper) getPeer(id discover.NodeID) (*Peer, error) {
+	w.peerMu.RLock()
+	defer w.peerMu.RUnlock()
+
+	for p := range w.peers {
+		if p.ID() == id {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("whisper: peer %x not connected", id[:8])
+}
+
+// AllowP2PMessagesFromPeer marks a connected peer trusted to deliver envelopes
+// directly, bypassing the expiration, PoW and bloom filter checks of the pool.
+func (w *Whisper) AllowP2PMessagesFromPeer(id discover.NodeID) error {
+	p, err := w.getPeer(id)
+	if err != nil {
+		return err
+	}
+	p.setTrusted()
+	return nil
+}
+
+// RequestHistoricMessages sends a mail request envelope to a mail server peer,
+// trusting it to deliver the requested envelopes.
+func (w *Whisper) RequestHistoricMessages(id discover.NodeID, request *Envelope) error {
+	p, err := w.getPeer(id)
+	if err != nil {
+		return err
+	}
+	p.setTrusted()
+	return p2p.Send(p.ws, p2pRequestCode, request)
+}
+
+// SendP2PDirect sends envelopes directly to a peer, outside of the pool. It is
+// used by mail servers to deliver historic envelopes.
+func (w *Whisper) SendP2PDirect(p *Peer, envelopes ...*Envelope) error {
+	return p2p.Send(p.ws, p2pMessageCode, envelopes)
+}
+
+// Send injects a locally created envelope into the pool, to be distributed to
+// the peers interested in it.
+func (w *Whisper) Send(envelope *Envelope) error {
+	return w.add(envelope, false)
+}
+
+// Start implements node.Service, starting the background data propagation
+// thread of the Whisper protocol.
+func (w *Whisper) Start(*p2p.Server) error {
+	glog.V(logger.Info).Infoln("Whisper started")
+	go w.update()
+	return nil
+}
+
+// Stop implements node.Service, stopping the background data propagation
+// thread of the Whisper protocol.
+func (w *Whisper) Stop() error {
+	close(w.quit)
+	glog.V(logger.Info).Infoln("Whisper stopped")
+	return nil
+}
+
+// Messages retrieves all the currently pooled messages matching a filter.
+func (w *Whisper) Messages(id string) []*ReceivedMessage {
+	var messages []*ReceivedMessage
+	if filter := w.filters.Get(id); filter != nil {
+		for _, envelope := range w.Envelopes() {
+			if !filter.MatchEnvelope(envelope) {
+				continue
+			}
+			if msg := envelope.Open(filter); msg != nil && filter.MatchMessage(msg) {
+				messages = append(messages, msg)
+			}
+		}
+	}
+	return messages
+}
+
+// handlePeer is called by the underlying P2P layer when the whisper sub-protocol
+// connection is negotiated.
+func (w *Whisper) handlePeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+	// Create the new peer and start tracking it
+	whisperPeer := newPeer(w, peer, rw)
+
+	// Run the peer handshake before exposing the peer to settings updates
+	if err := whisperPeer.handshake(); err != nil {
+		return err
+	}
+	w.peerMu.Lock()
+	w.peers[whisperPeer] = struct{}{}
+	w.peerMu.Unlock()
+
+	defer func() {
+		w.peerMu.Lock()
+		delete(w.peers, whisperPeer)
+		w.peerMu.Unlock()
+	}()
+
+	whisperPeer.start()
+	defer whisperPeer.stop()
+
+	return w.runMessageLoop(whisperPeer, rw)
+}
+
+// runMessageLoop reads and processes inbound messages directly to merge into
+// client-global state.
+func (w *Whisper) runMessageLoop(p *Peer, rw p2p.MsgReadWriter) error {
+	for {
+		// Fetch the next packet
+		packet, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if packet.Size > w.maxMessageSize*2 {
+			return fmt.Errorf("oversized message received: %d bytes", packet.Size)
+		}
+		switch packet.Code {
+		case messagesCode:
+			var envelopes []*Envelope
+			if err := packet.Decode(&envelopes); err != nil {
+				glog.V(logger.Info).Infof("%v: failed to decode envelope: %v", p.peer, err)
+				continue
+			}
+			// Inject all envelopes into the internal pool
+			for _, envelope := range envelopes {
+				if err := w.add(envelope, true); err != nil {
+					// TODO Punish peer here. Invalid envelope.
+					glog.V(logger.Debug).Infof("%v: failed to pool envelope: %v", p.peer, err)
+				}
+				p.mark(envelope)
+			}
+
+		case powRequirementCode:
+			var raw uint64
+			if err := packet.Decode(&raw); err != nil {
+				return fmt.Errorf("invalid PoW requirement message: %v", err)
+			}
+			if err := p.setPoWRequirement(raw); err != nil {
+				return err
+			}
+
+		case bloomFilterCode:
+			var bloom []byte
+			if err := packet.Decode(&bloom); err != nil {
+				return fmt.Errorf("invalid bloom filter message: %v", err)
+			}
+			if err := p.setBloomFilter(bloom); err != nil {
+				return err
+			}
+
+		case p2pRequestCode:
+			// Only mail servers answer requests for historic envelopes
+			if w.mailServer == nil {
+				packet.Discard()
+				continue
+			}
+			var request Envelope
+			if err := packet.Decode(&request); err != nil {
+				return fmt.Errorf("invalid mail request: %v", err)
+			}
+			w.mailServer.DeliverMail(p, &request)
+
+		case p2pMessageCode:
+			// Directly delivered envelopes are only accepted from trusted peers
+			if !p.isTrusted() {
+				glog.V(logger.Debug).Infof("%v: dropping direct envelopes from untrusted peer", p.peer)
+				packet.Discard()
+				continue
+			}
+			var envelopes []*Envelope
+			if err := packet.Decode(&envelopes); err != nil {
+				return fmt.Errorf("invalid direct envelopes: %v", err)
+			}
+			for _, envelope := range envelopes {
+				w.filters.NotifyWatchers(envelope, true)
+			}
+
+		default:
+			// New message types might be implemented in the future versions of
+			// Whisper. For forward compatibility, just ignore.
+			packet.Discard()
+		}
+	}
+}
+
+// add inserts a new envelope into the message pool to be distributed within the
+// whisper network. It also inserts the envelope into the expiration pool at the
+// appropriate time-stamp. Envelopes received from peers are subject to the PoW
+// requirement and bloom filter of the node.
+func (w *Whisper) add(envelope *Envelope, remote bool) error {
+	now := uint32(time.Now().Unix())
+	sent := envelope.Expiry - envelope.TTL
+
+	if sent > now+DefaultSyncAllowance {
+		return fmt.Errorf("envelope created in the future [%x]", envelope.Hash())
+	}
+	if envelope.Expiry < now {
+		if envelope.Expiry+DefaultSyncAllowance*2 < now {
+			return fmt.Errorf("very old envelope [%x]", envelope.Hash())
+		}
+		return nil // drop envelope without error, it may be caused by clock drift
+	}
+	if envelope.TTL == 0 {
+		return fmt.Errorf("envelope without time to live [%x]", envelope.Hash())
+	}
+	if size := len(envelope.Data); uint32(size) > w.maxMessageSize {
+		return fmt.Errorf("huge envelope of %d bytes [%x]", size, envelope.Hash())
+	}
+	if envelope.IsSymmetric() && len(envelope.AESNonce) != aesNonceLength {
+		return fmt.Errorf("wrong AES nonce size %d [%x]", len(envelope.AESNonce), envelope.Hash())
+	}
+	if remote {
+		if pow := envelope.PoW(); pow < w.MinPoW() {
+			return fmt.Errorf("envelope with low PoW %f [%x]", pow, envelope.Hash())
+		}
+		if !bloomFilterMatch(w.BloomFilter(), envelope.Bloom()) {
+			return fmt.Errorf("envelope does not match the bloom filter [%x]", envelope.Hash())
+		}
+	}
+	if !w.pool(envelope) {
+		return nil
+	}
+	// Notify the local node of a message arrival and archive it if requested
+	go w.filters.NotifyWatchers(envelope, false)
+	if w.mailServer != nil {
+		w.mailServer.Archive(envelope)
+	}
+	return nil
+}
+
+// pool inserts an envelope into the tracked pool and the expiration pool,
+// reporting whether it was new.
+func (w *Whisper) pool(envelope *Envelope) bool {
+	hash := envelope.Hash()
+
+	w.poolMu.Lock()
+	defer w.poolMu.Unlock()
+
+	if _, ok := w.envelopes[hash]; ok {
+		glog.V(logger.Detail).Infof("whisper envelope already cached [%x]", hash)
+		return false
+	}
+	w.envelopes[hash] = envelope
+
+	// Insert the message into the expiration pool for later removal
+	if w.expirations[envelope.Expiry] == nil {
+		w.expirations[envelope.Expiry] = set.NewNonTS()
+	}
+	w.expirations[envelope.Expiry].Add(hash)
+
+	glog.V(logger.Detail).Infof("cached whisper envelope [%x]", hash)
+	return true
+}
+
+// update loops until the lifetime of the whisper node, updating its internal
+// state by expiring stale messages from the pool.
+func (w *Whisper) update() {
+	// Start a ticker to check for expirations
+	expire := time.NewTicker(expirationCycle)
+
+	// Repeat updates until termination is requested
+	for {
+		select {
+		case <-expire.C:
+			w.expire()
+
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// expire iterates over all the expiration timestamps, removing all stale
+// messages from the pools.
+func (w *Whisper) expire() {
+	w.poolMu.Lock()
+	defer w.poolMu.Unlock()
+
+	now := uint32(time.Now().Unix())
+	for then, hashSet := range w.expirations {
+		// Short circuit if a future time
+		if then > now {
+			continue
+		}
+		// Dump all expired messages and remove timestamp
+		hashSet.Each(func(v interface{}) bool {
+			delete(w.envelopes, v.(common.Hash))
+			return true
+		})
+		delete(w.expirations, then)
+	}
+}
+
+// Envelopes retrieves all the messages currently pooled by the node.
+func (w *Whisper) Envelopes() []*Envelope {
+	w.poolMu.RLock()
+	defer w.poolMu.RUnlock()
+
+	all := make([]*Envelope, 0, len(w.envelopes))
+	for _, envelope := range w.envelopes {
+		all = append(all, envelope)
+	}
+	return all
+}
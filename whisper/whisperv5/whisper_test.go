@@ -0,0 +1,192 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package whisperv5
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that filters only deliver the messages matching their criteria.
+func TestFilterMatching(t *testing.T) {
+	fs := NewFilters()
+
+	signer, _ := crypto.GenerateKey()
+	recipient, _ := crypto.GenerateKey()
+
+	symAll := &Filter{KeySym: testSymKey}
+	symTopic := &Filter{KeySym: testSymKey, Topics: []TopicType{{0x01}}}
+	symSigned := &Filter{KeySym: testSymKey, Src: &signer.PublicKey}
+	asym := &Filter{KeyAsym: recipient}
+	for _, f := range []*Filter{symAll, symTopic, symSigned, asym} {
+		if _, err := fs.Install(f); err != nil {
+			t.Fatalf("failed to install filter: %v", err)
+		}
+	}
+	if _, err := fs.Install(&Filter{}); err == nil {
+		t.Fatalf("filter without key installed")
+	}
+	post := func(params *MessageParams) {
+		envelope, err := NewSentMessage(params).Wrap(params)
+		if err != nil {
+			t.Fatalf("failed to wrap message: %v", err)
+		}
+		fs.NotifyWatchers(envelope, false)
+	}
+	post(&MessageParams{KeySym: testSymKey, Topic: TopicType{0x02}, Payload: []byte{1}})
+	post(&MessageParams{KeySym: testSymKey, Topic: TopicType{0x01}, Src: signer, Payload: []byte{2}})
+	post(&MessageParams{Dst: &recipient.PublicKey, Payload: []byte{3}})
+
+	tests := []struct {
+		filter   *Filter
+		payloads []byte
+	}{
+		{symAll, []byte{1, 2}},
+		{symTopic, []byte{2}},
+		{symSigned, []byte{2}},
+		{asym, []byte{3}},
+	}
+	for i, tt := range tests {
+		var payloads []byte
+		for _, msg := range tt.filter.Retrieve() {
+			payloads = append(payloads, msg.Payload...)
+		}
+		if len(payloads) != len(tt.payloads) {
+			t.Errorf("test %d: message count mismatch: have %x, want %x", i, payloads, tt.payloads)
+			continue
+		}
+		for _, p := range tt.payloads {
+			if !bytes.Contains(payloads, []byte{p}) {
+				t.Errorf("test %d: message %d missing: have %x", i, p, payloads)
+			}
+		}
+		if len(tt.filter.Retrieve()) != 0 {
+			t.Errorf("test %d: messages retrieved twice", i)
+		}
+	}
+}
+
+// Tests the management of key pairs and symmetric keys.
+func TestKeyManagement(t *testing.T) {
+	w := New(nil)
+
+	id, err := w.NewKeyPair()
+	if err != nil {
+		t.Fatalf("failed to generate key pair: %v", err)
+	}
+	if !w.HasKeyPair(id) {
+		t.Fatalf("generated key pair missing")
+	}
+	if _, err := w.GetPrivateKey(id); err != nil {
+		t.Fatalf("failed to retrieve key pair: %v", err)
+	}
+	if !w.DeleteKeyPair(id) || w.HasKeyPair(id) || w.DeleteKeyPair(id) {
+		t.Fatalf("key pair deletion failed")
+	}
+	if _, err := w.AddSymKeyDirect(make([]byte, 16)); err != ErrInvalidKey {
+		t.Fatalf("short symmetric key error mismatch: have %v, want %v", err, ErrInvalidKey)
+	}
+	// Keys derived from the same password must be equal
+	id1, err := w.AddSymKeyFromPassword("secret")
+	if err != nil {
+		t.Fatalf("failed to derive symmetric key: %v", err)
+	}
+	id2, _ := w.AddSymKeyFromPassword("secret")
+	key1, _ := w.GetSymKey(id1)
+	key2, _ := w.GetSymKey(id2)
+	if id1 == id2 || !bytes.Equal(key1, key2) || len(key1) != aesKeyLength {
+		t.Fatalf("password derived keys mismatch: %x != %x", key1, key2)
+	}
+	if !w.DeleteSymKey(id1) || w.HasSymKey(id1) || !w.HasSymKey(id2) {
+		t.Fatalf("symmetric key deletion failed")
+	}
+	if _, err := w.GetSymKey(id1); err != ErrKeyNotFound {
+		t.Fatalf("deleted key error mismatch: have %v, want %v", err, ErrKeyNotFound)
+	}
+}
+
+// Tests that envelopes from peers are validated against the node settings.
+func TestEnvelopeValidation(t *testing.T) {
+	w := New(&Config{MinimumPoW: 1e10})
+
+	params := &MessageParams{TTL: 10, KeySym: testSymKey, Payload: []byte("cheap")}
+	envelope, err := NewSentMessage(params).Wrap(params)
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	// Local envelopes bypass the PoW requirement, remote ones don't
+	if err := w.add(envelope, true); err == nil {
+		t.Fatalf("envelope with insufficient PoW accepted from peer")
+	}
+	if err := w.Send(envelope); err != nil {
+		t.Fatalf("failed to send local envelope: %v", err)
+	}
+	if len(w.Envelopes()) != 1 {
+		t.Fatalf("local envelope not pooled")
+	}
+	// Envelopes from the future and the far past must be rejected
+	future := *envelope
+	future.Expiry += 2 * DefaultSyncAllowance
+	if err := w.Send(&future); err == nil {
+		t.Errorf("envelope from the future accepted")
+	}
+	old := *envelope
+	old.Expiry = uint32(time.Now().Unix()) - 3*DefaultSyncAllowance
+	if err := w.Send(&old); err == nil {
+		t.Errorf("very old envelope accepted")
+	}
+}
+
+// Tests that the bloom filter follows the installed filters if topic filtering
+// is enabled, and that envelopes of other topics are refused from peers.
+func TestTopicFiltering(t *testing.T) {
+	w := New(&Config{TopicFiltering: true})
+	if bloom := w.BloomFilter(); isFullNode(bloom) {
+		t.Fatalf("filtering node without filters interested in everything")
+	}
+	topic := TopicType{0xde, 0xad, 0xbe, 0xef}
+	id, err := w.Subscribe(&Filter{KeySym: testSymKey, Topics: []TopicType{topic}})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if !bytes.Equal(w.BloomFilter(), TopicToBloom(topic)) {
+		t.Fatalf("bloom filter mismatch: have %x, want %x", w.BloomFilter(), TopicToBloom(topic))
+	}
+	for _, tt := range []struct {
+		topic TopicType
+		ok    bool
+	}{{topic, true}, {TopicType{0x01}, false}} {
+		params := &MessageParams{TTL: 10, KeySym: testSymKey, Topic: tt.topic, Payload: []byte("bloom")}
+		envelope, _ := NewSentMessage(params).Wrap(params)
+		if err := w.add(envelope, true); (err == nil) != tt.ok {
+			t.Errorf("topic %x: acceptance mismatch: have %v, want %v", tt.topic, err, tt.ok)
+		}
+	}
+	// Filters without topics make the node interested in everything
+	all, _ := w.Subscribe(&Filter{KeySym: testSymKey})
+	if w.BloomFilter() != nil {
+		t.Fatalf("bloom filter not full with catch-all filter")
+	}
+	w.Unsubscribe(all)
+	w.Unsubscribe(id)
+	if bloom := w.BloomFilter(); isFullNode(bloom) {
+		t.Fatalf("bloom filter not reset after unsubscribing")
+	}
+}